@@ -23,6 +23,7 @@ import (
 	"unsafe"
 
 	"github.com/apache/trafficcontrol/grove/cachedata"
+	"github.com/apache/trafficcontrol/grove/errpage"
 	"github.com/apache/trafficcontrol/grove/plugin"
 
 	"github.com/apache/trafficcontrol/grove/remap"
@@ -70,6 +71,8 @@ type Handler struct {
 	httpConns       *web.ConnMap
 	httpsConns      *web.ConnMap
 	interfaceName   string
+	errorPages      errpage.Pages
+	warm            bool   // whether this handler serves internal cache warming requests, which have no client connection
 	requestID       uint64 // Atomic - DO NOT access or modify without atomic operations
 	// keyThrottlers     Throttlers
 	// nocacheThrottlers Throttlers
@@ -113,6 +116,7 @@ func NewHandler(
 	httpConns *web.ConnMap,
 	httpsConns *web.ConnMap,
 	interfaceName string,
+	errorPages errpage.Pages,
 ) *Handler {
 	hostname, err := os.Hostname()
 	if err != nil {
@@ -135,6 +139,7 @@ func NewHandler(
 		httpConns:       httpConns,
 		httpsConns:      httpsConns,
 		interfaceName:   interfaceName,
+		errorPages:      errorPages,
 		// keyThrottlers:     NewThrottlers(keyLimit),
 		// nocacheThrottlers: NewThrottlers(nocacheLimit),
 	}
@@ -167,7 +172,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	reqID := atomic.AddUint64(&h.requestID, 1)
 	pluginContext := copyPluginContext(h.pluginContext) // must give each request a copy, because they can modify in parallel
 	srvrData := cachedata.SrvrData{h.hostname, h.port, h.scheme}
-	onReqData := plugin.OnRequestData{W: w, R: r, Stats: h.stats, StatRules: h.remapper.StatRules(), HTTPConns: h.httpConns, HTTPSConns: h.httpsConns, InterfaceName: h.interfaceName, SrvrData: srvrData, RequestID: reqID}
+	clientSubject := web.ClientCertSubject(r)
+	onReqData := plugin.OnRequestData{W: w, R: r, Stats: h.stats, StatRules: h.remapper.StatRules(), HTTPConns: h.httpConns, HTTPSConns: h.httpsConns, InterfaceName: h.interfaceName, SrvrData: srvrData, RequestID: reqID, Warmer: h, ClientSubject: clientSubject}
 	stop := h.plugins.OnRequest(h.remapper.PluginCfg(), pluginContext, onReqData)
 	if stop {
 		return
@@ -175,7 +181,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	conn := (*web.InterceptConn)(nil)
 	if realConn, ok := h.conns.Get(r.RemoteAddr); !ok {
-		log.Infof("RemoteAddr '%v' not in Conns (reqid %v)\n", r.RemoteAddr, reqID)
+		if !h.warm {
+			log.Infof("RemoteAddr '%v' not in Conns (reqid %v)\n", r.RemoteAddr, reqID)
+		}
 	} else {
 		if conn, ok = realConn.(*web.InterceptConn); !ok {
 			log.Infof("Could not get Conn info: Conn is not an InterceptConn: %T (reqid %v)\n", realConn, reqID)
@@ -198,10 +206,18 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if remappingProducer != nil {
 		toFQDN = remappingProducer.FirstFQDN()
 		pluginCfg = remappingProducer.PluginCfg()
+		if ruleSubject := remappingProducer.ClientSubject(); ruleSubject != "" {
+			clientSubject = ruleSubject
+		}
 	}
 
-	reqData := cachedata.ReqData{r, conn, clientIP, reqTime, toFQDN}
+	reqData := cachedata.ReqData{r, conn, clientIP, reqTime, toFQDN, clientSubject}
 	responder := NewResponder(w, pluginCfg, pluginContext, srvrData, reqData, h.plugins, h.stats, reqID)
+	responder.GlobalErrorPages = h.errorPages
+	if remappingProducer != nil {
+		responder.RemapRule = remappingProducer.Name()
+		responder.RuleErrorPages = remappingProducer.ErrorPages()
+	}
 
 	if err != nil {
 		switch err {
@@ -211,6 +227,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		case remap.ErrIPNotAllowed:
 			log.Debugf("IP %v not allowed (reqid %v)\n", r.RemoteAddr, reqID)
 			*responder.ResponseCode = http.StatusForbidden
+		case remap.ErrClientCertNotAllowed:
+			log.Debugf("client %v certificate '%v' not allowed (reqid %v)\n", r.RemoteAddr, web.ClientCertSubject(r), reqID)
+			*responder.ResponseCode = http.StatusForbidden
 		default:
 			log.Debugf("request error: %v (reqid %v)\n", err, reqID)
 		}
@@ -253,6 +272,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		responder.OriginCode = cacheObj.OriginCode
 		// create new pointers, so plugins don't modify the cacheObj
 		codePtr, hdrsPtr, bodyPtr := cacheObj.Code, cacheObj.RespHeaders, cacheObj.Body
+		if isConnectFailure(cacheObj) {
+			responder.SetErrorPage(codePtr, &hdrsPtr, &bodyPtr)
+		}
 		responder.SetResponse(&codePtr, &hdrsPtr, &bodyPtr, connectionClose)
 		responder.OriginReqSuccess = true
 		responder.ProxyStr = cacheObj.ProxyURL
@@ -305,6 +327,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// create new pointers, so plugins don't modify the cacheObj
 	codePtr, hdrsPtr, bodyPtr := cacheObj.Code, cacheObj.RespHeaders, cacheObj.Body
+	if isConnectFailure(cacheObj) {
+		responder.SetErrorPage(codePtr, &hdrsPtr, &bodyPtr)
+	}
 	responder.SetResponse(&codePtr, &hdrsPtr, &bodyPtr, connectionClose)
 	responder.OriginReqSuccess = true
 	responder.Reuse = canReuseStored
@@ -16,8 +16,10 @@ package cache
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/apache/trafficcontrol/grove/cachedata"
+	"github.com/apache/trafficcontrol/grove/errpage"
 	"github.com/apache/trafficcontrol/grove/plugin"
 	"github.com/apache/trafficcontrol/grove/remapdata"
 	"github.com/apache/trafficcontrol/grove/stat"
@@ -36,6 +38,11 @@ type Responder struct {
 	Stats         stat.Stats
 	F             RespondFunc
 	ResponseCode  *int
+	// RemapRule is the name of the remap rule of the request, if any.
+	RemapRule string
+	// RuleErrorPages are the error pages of the remap rule, which take precedence over GlobalErrorPages. Either may be nil.
+	RuleErrorPages   errpage.Pages
+	GlobalErrorPages errpage.Pages
 	cachedata.ParentRespData
 	cachedata.SrvrData
 	cachedata.ReqData
@@ -73,10 +80,57 @@ func NewResponder(w http.ResponseWriter, pluginCfg map[string]interface{}, plugi
 		SrvrData:       srvrData,
 		ReqData:        reqData,
 	}
-	responder.F = func() (uint64, error) { return web.ServeErr(w, *responder.ResponseCode) }
+	responder.F = func() (uint64, error) { return responder.serveErr(*responder.ResponseCode) }
 	return responder
 }
 
+// serveErr writes the error page for the given code, if one exists, or else the generic error response.
+func (r *Responder) serveErr(code int) (uint64, error) {
+	body, contentType, ok := r.ErrorPage(code)
+	if !ok {
+		return web.ServeErr(r.W, code)
+	}
+	return web.ServeErrBody(r.W, code, contentType, body)
+}
+
+// ErrorPage returns the body and content type of the error page for the given code, from the remap rule's error pages, or else the global error pages. Returns false if no page exists for the code, or the page template failed.
+func (r *Responder) ErrorPage(code int) ([]byte, string, bool) {
+	page, ok := r.RuleErrorPages.Get(code)
+	if !ok {
+		if page, ok = r.GlobalErrorPages.Get(code); !ok {
+			return nil, "", false
+		}
+	}
+	data := errpage.Data{
+		Code:       code,
+		StatusText: http.StatusText(code),
+		RequestID:  r.RequestID,
+		RemapRule:  r.RemapRule,
+		ClientIP:   r.ClientIP,
+		Time:       time.Now(),
+	}
+	if r.Req != nil {
+		data.Host = r.Req.Host
+		data.Path = r.Req.URL.Path
+	}
+	body, err := page.Body(data)
+	if err != nil {
+		log.Errorf("rendering error page for code %v: %v (reqid %v)\n", code, err, r.RequestID)
+		return nil, "", false
+	}
+	return body, page.ContentType, true
+}
+
+// SetErrorPage replaces the given headers and body with the error page for code, if one exists. This is used for error responses generated by the cache itself, such as parent connection failures, which would otherwise have a generic body.
+func (r *Responder) SetErrorPage(code int, hdrs *http.Header, body *[]byte) {
+	pageBody, contentType, ok := r.ErrorPage(code)
+	if !ok {
+		return
+	}
+	*hdrs = http.Header{"Content-Type": []string{contentType}}
+	*body = pageBody
+}
+
 // SetResponse is a helper which sets the RespondFunc of r to `web.Respond` with the given code, headers, body, and connectionClose. Note it takes a pointer to the headers and body, which may be modified after calling this but before the Do() sends the response.
 func (r *Responder) SetResponse(code *int, hdrs *http.Header, body *[]byte, connectionClose bool) {
 	r.ResponseCode = code
@@ -100,6 +100,11 @@ func isFailure(o *cacheobj.CacheObj, retryCodes map[int]struct{}) bool {
 	return failureCode || o.Code == CodeConnectFailure
 }
 
+// isConnectFailure returns whether the object is the result of failing to connect to the parent, rather than a response from the parent.
+func isConnectFailure(o *cacheobj.CacheObj) bool {
+	return o.Code == CodeConnectFailure && o.RespHeaders == nil
+}
+
 const ModifiedSinceHdr = "If-Modified-Since"
 
 // GetAndCache makes a client request for the given `http.Request` and caches it if `CanCache`.
@@ -0,0 +1,182 @@
+package cache
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/trafficcontrol/grove/cachedata"
+	"github.com/apache/trafficcontrol/grove/plugin"
+	"github.com/apache/trafficcontrol/grove/stat"
+	"github.com/apache/trafficcontrol/grove/web"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+)
+
+// WarmRemoteAddr is the client address used for cache warming requests. Remap rules whose allow and deny lists forbid this address cannot be warmed.
+const WarmRemoteAddr = "127.0.0.1:0"
+
+// DefaultWarmConcurrency is the number of simultaneous warming requests made, if no concurrency is given.
+const DefaultWarmConcurrency = 8
+
+// Warm implements plugin.Warmer for the handler currently pointed to.
+func (h *HandlerPointer) Warm(urls []string, concurrency int) []cachedata.WarmResult {
+	realHandler := (*Handler)(atomic.LoadPointer(h.realHandler))
+	return realHandler.Warm(urls, concurrency)
+}
+
+// Warm requests each of the given URLs through the remap rules and cache of h, as if they had been requested by a client, with at most concurrency simultaneous requests. It returns the result of each URL, in the same order as urls.
+//
+// Warming requests skip the onRequest and afterRespond plugin hooks, so they can never be served by plugin endpoints, and are not counted in the request stats or written to the access log. They do share the origin throttlers and request collapsing of h, so warming cannot exceed the configured origin limits.
+func (h *Handler) Warm(urls []string, concurrency int) []cachedata.WarmResult {
+	if concurrency < 1 {
+		concurrency = DefaultWarmConcurrency
+	}
+	warmStats := stat.New(h.remapper.Rules(), nil, 0, nil, nil, "")
+	handlers := map[string]*Handler{
+		"http":  h.newWarmHandler("http", warmStats),
+		"https": h.newWarmHandler("https", warmStats),
+	}
+
+	results := make([]cachedata.WarmResult, len(urls))
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = warmURL(handlers, urls[i])
+			}
+		}()
+	}
+	for i := range urls {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// newWarmHandler returns a new Handler for warming requests with the given scheme. It shares the remapper, getter, and throttlers of h, but not its stats, connections, or request-level plugins.
+func (h *Handler) newWarmHandler(scheme string, warmStats stat.Stats) *Handler {
+	return &Handler{
+		remapper:        h.remapper,
+		getter:          h.getter,
+		ruleThrottlers:  h.ruleThrottlers,
+		scheme:          scheme,
+		port:            h.port,
+		hostname:        h.hostname,
+		strictRFC:       h.strictRFC,
+		stats:           warmStats,
+		conns:           web.NewConnMap(),
+		connectionClose: h.connectionClose,
+		plugins:         warmPlugins{Plugins: h.plugins},
+		pluginContext:   h.pluginContext,
+		httpConns:       h.httpConns,
+		httpsConns:      h.httpsConns,
+		interfaceName:   h.interfaceName,
+		errorPages:      h.errorPages,
+		warm:            true,
+	}
+}
+
+// warmURL requests the given URL with the handler for its scheme, and returns the result.
+func warmURL(handlers map[string]*Handler, urlStr string) cachedata.WarmResult {
+	result := cachedata.WarmResult{URL: urlStr, CacheStatus: cachedata.WarmCacheError}
+	start := time.Now()
+	defer func() { result.TimeMS = int64(time.Since(start) / time.Millisecond) }()
+
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		result.Error = "parsing URL: " + err.Error()
+		return result
+	}
+	h, ok := handlers[u.Scheme]
+	if !ok {
+		result.Error = "unsupported URL scheme '" + u.Scheme + "'"
+		return result
+	}
+	req, err := http.NewRequest(http.MethodGet, urlStr, nil)
+	if err != nil {
+		result.Error = "creating request: " + err.Error()
+		return result
+	}
+	req.RequestURI = req.URL.RequestURI()
+	req.RemoteAddr = WarmRemoteAddr
+
+	w := newWarmResponseWriter()
+	h.ServeHTTP(w, req)
+	if !w.responded {
+		result.Error = "no response"
+		return result
+	}
+
+	result.Code = w.code
+	result.Bytes = w.bytesWritten
+	result.CacheStatus = cachedata.WarmCacheMiss
+	if w.cacheHit {
+		result.CacheStatus = cachedata.WarmCacheHit
+	}
+	result.Success = w.code >= 200 && w.code < 400
+	if !result.Success {
+		result.Error = "responded with code " + strconv.Itoa(w.code)
+	}
+	log.Debugf("cache warm %v code %v cache %v bytes %v\n", urlStr, result.Code, result.CacheStatus, result.Bytes)
+	return result
+}
+
+// warmPlugins wraps the plugins of a Handler for warming requests. It skips the onRequest and afterRespond hooks, and records the final response data in the warmResponseWriter.
+type warmPlugins struct {
+	plugin.Plugins
+}
+
+func (p warmPlugins) OnRequest(cfgs map[string]interface{}, context map[string]*interface{}, d plugin.OnRequestData) bool {
+	return false
+}
+
+func (p warmPlugins) OnAfterRespond(cfgs map[string]interface{}, context map[string]*interface{}, d plugin.AfterRespondData) {
+	if w, ok := d.W.(*warmResponseWriter); ok {
+		w.responded = true
+		w.code = d.RespCode
+		w.cacheHit = d.CacheHit
+	}
+}
+
+// warmResponseWriter is an http.ResponseWriter which discards the response body, recording only its size.
+type warmResponseWriter struct {
+	header       http.Header
+	code         int
+	bytesWritten uint64
+	cacheHit     bool
+	responded    bool
+}
+
+func newWarmResponseWriter() *warmResponseWriter {
+	return &warmResponseWriter{header: http.Header{}}
+}
+
+func (w *warmResponseWriter) Header() http.Header  { return w.header }
+func (w *warmResponseWriter) WriteHeader(code int) { w.code = code }
+func (w *warmResponseWriter) Flush()               {}
+func (w *warmResponseWriter) Write(b []byte) (int, error) {
+	w.bytesWritten += uint64(len(b))
+	return len(b), nil
+}
@@ -0,0 +1,193 @@
+package cache
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/apache/trafficcontrol/grove/cachedata"
+	"github.com/apache/trafficcontrol/grove/icache"
+	"github.com/apache/trafficcontrol/grove/memcache"
+	"github.com/apache/trafficcontrol/grove/plugin"
+	"github.com/apache/trafficcontrol/grove/remap"
+	"github.com/apache/trafficcontrol/grove/stat"
+	"github.com/apache/trafficcontrol/grove/web"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+)
+
+const testWarmFrom = "http://warm.test"
+const testWarmBody = "warm body"
+
+// newTestWarmHandler returns a Handler with a single remap rule from testWarmFrom to the given origin, with the access log and stats plugins enabled, and the cache of the rule.
+func newTestWarmHandler(t *testing.T, originURL string) (*Handler, icache.Cache) {
+	dir, err := ioutil.TempDir("", "grove-warm-test")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	remapFile := filepath.Join(dir, "remap.json")
+	remapJSON := `{
+  "retry_num": 1,
+  "timeout_ms": 5000,
+  "retry_codes": [],
+  "parent_selection": "consistent-hash",
+  "rules": [{"name": "warm-test", "from": "` + testWarmFrom + `", "to": [{"url": "` + originURL + `"}]}]
+}`
+	if err := ioutil.WriteFile(remapFile, []byte(remapJSON), 0600); err != nil {
+		t.Fatalf("writing remap file: %v", err)
+	}
+
+	caches := map[string]icache.Cache{"": memcache.New(1024 * 1024)}
+	plugins := plugin.Get([]string{"ats_log", "record_stats"})
+	remapper, err := remap.LoadRemapper(remapFile, plugins.LoadFuncs(), caches, remap.NewRemappingTransport(0, 0, 0, 0))
+	if err != nil {
+		t.Fatalf("loading remapper: %v", err)
+	}
+	pluginContext := map[string]*interface{}{}
+	plugins.OnStartup(remapper.PluginCfg(), pluginContext, plugin.StartupData{})
+
+	httpConns := web.NewConnMap()
+	stats := stat.New(remapper.Rules(), caches, 1024*1024, httpConns, web.NewConnMap(), "")
+	h := NewHandler(remapper, 0, stats, "http", "80", httpConns, false, false, plugins, pluginContext, httpConns, web.NewConnMap(), "", nil)
+	return h, caches[""]
+}
+
+func TestWarm(t *testing.T) {
+	originReqs := uint64(0)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint64(&originReqs, 1)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(testWarmBody))
+	}))
+	defer origin.Close()
+
+	eventLog := &bytes.Buffer{}
+	infoLog := &bytes.Buffer{}
+	log.Init(log.NopCloser(eventLog), nil, nil, log.NopCloser(infoLog), nil)
+	defer log.Init(nil, nil, nil, nil, nil)
+
+	h, cache := newTestWarmHandler(t, origin.URL)
+
+	urls := []string{
+		testWarmFrom + "/a",
+		testWarmFrom + "/b",
+		testWarmFrom + "/missing",
+		"ftp://warm.test/a",
+		"http://not-a-rule.test/a",
+	}
+	results := h.Warm(urls, 2)
+	if len(results) != len(urls) {
+		t.Fatalf("Warm expected %v results, actual %v", len(urls), len(results))
+	}
+
+	expected := []struct {
+		success bool
+		code    int
+		status  cachedata.WarmCacheStatus
+		bytes   uint64
+	}{
+		{true, http.StatusOK, cachedata.WarmCacheMiss, uint64(len(testWarmBody))},
+		{true, http.StatusOK, cachedata.WarmCacheMiss, uint64(len(testWarmBody))},
+		{false, http.StatusNotFound, cachedata.WarmCacheMiss, 0},
+		{false, 0, cachedata.WarmCacheError, 0},
+		{false, http.StatusNotFound, cachedata.WarmCacheMiss, 0},
+	}
+	for i, result := range results {
+		if result.URL != urls[i] {
+			t.Errorf("Warm result %v expected URL '%v', actual '%v'", i, urls[i], result.URL)
+		}
+		if result.Success != expected[i].success || result.Code != expected[i].code || result.CacheStatus != expected[i].status {
+			t.Errorf("Warm '%v' expected success %v code %v cache %v, actual %+v", urls[i], expected[i].success, expected[i].code, expected[i].status, result)
+		}
+		if expected[i].bytes != 0 && result.Bytes != expected[i].bytes {
+			t.Errorf("Warm '%v' expected %v bytes, actual %v", urls[i], expected[i].bytes, result.Bytes)
+		}
+		if !result.Success && result.Error == "" {
+			t.Errorf("Warm '%v' failed with no error", urls[i])
+		}
+	}
+
+	if len(cache.Keys()) != 3 { // the 404 is also cacheable
+		t.Errorf("Warm expected 3 cached objects, actual %v", cache.Keys())
+	}
+	reqsAfterWarm := atomic.LoadUint64(&originReqs)
+
+	results = h.Warm(urls[:2], 0)
+	for _, result := range results {
+		if !result.Success || result.CacheStatus != cachedata.WarmCacheHit {
+			t.Errorf("Warm of warmed URL expected success cache hit, actual %+v", result)
+		}
+	}
+	if reqs := atomic.LoadUint64(&originReqs); reqs != reqsAfterWarm {
+		t.Errorf("Warm of warmed URLs expected no origin requests, actual %v", reqs-reqsAfterWarm)
+	}
+
+	if hits, misses := h.stats.CacheHits(), h.stats.CacheMisses(); hits != 0 || misses != 0 {
+		t.Errorf("Warm expected no live stats, actual cache hits %v misses %v", hits, misses)
+	}
+	for _, rule := range h.stats.Remap().Rules() {
+		ruleStats, _ := h.stats.Remap().Stats(rule)
+		if ruleStats.Status2xx() != 0 || ruleStats.Status4xx() != 0 || ruleStats.OutBytes() != 0 {
+			t.Errorf("Warm expected no live stats for rule '%v', actual 2xx %v 4xx %v out bytes %v", rule, ruleStats.Status2xx(), ruleStats.Status4xx(), ruleStats.OutBytes())
+		}
+	}
+	if eventLog.Len() != 0 {
+		t.Errorf("Warm expected no access log, actual '%v'", eventLog.String())
+	}
+	if strings.Contains(infoLog.String(), "not in Conns") {
+		t.Errorf("Warm expected no missing connection log, actual '%v'", infoLog.String())
+	}
+}
+
+func TestWarmURL(t *testing.T) {
+	log.Init(nil, nil, nil, nil, nil)
+	h, cache := newTestWarmHandler(t, "http://127.0.0.1:1")
+	handlers := map[string]*Handler{"http": h.newWarmHandler("http", h.stats)}
+
+	tests := []struct {
+		url      string
+		errorStr string
+	}{
+		{"://bad-url", "parsing URL"},
+		{"https://warm.test/a", "unsupported URL scheme"},
+		{testWarmFrom + "/a", "responded with code"},
+	}
+	for _, test := range tests {
+		result := warmURL(handlers, test.url)
+		if result.Success {
+			t.Errorf("warmURL '%v' expected failure, actual success", test.url)
+		}
+		if !strings.Contains(result.Error, test.errorStr) {
+			t.Errorf("warmURL '%v' expected error containing '%v', actual '%v'", test.url, test.errorStr, result.Error)
+		}
+	}
+	if keys := cache.Keys(); len(keys) != 0 {
+		t.Errorf("warmURL of unreachable origin expected nothing cached, actual %v", keys)
+	}
+}
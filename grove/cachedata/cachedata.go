@@ -48,6 +48,8 @@ type ReqData struct {
 	ClientIP string
 	ReqTime  time.Time
 	ToFQDN   string
+	// ClientSubject is the subject of the client certificate verified in the TLS handshake, or empty if the client sent no verified certificate.
+	ClientSubject string
 }
 
 type RespData struct {
@@ -56,3 +58,26 @@ type RespData struct {
 	RespSuccess  bool
 	CacheHit     bool
 }
+
+// WarmCacheStatus is the cache status of a cache warming request.
+type WarmCacheStatus string
+
+const (
+	// WarmCacheHit indicates the object was already in the cache, and the parent was not requested.
+	WarmCacheHit = WarmCacheStatus("hit")
+	// WarmCacheMiss indicates the object was not in the cache, and was requested from the parent.
+	WarmCacheMiss = WarmCacheStatus("miss")
+	// WarmCacheError indicates the request was never responded to, e.g. because the URL was malformed.
+	WarmCacheError = WarmCacheStatus("error")
+)
+
+// WarmResult is the result of fetching a single URL through the cache, in order to pre-populate it.
+type WarmResult struct {
+	URL         string          `json:"url"`
+	Success     bool            `json:"success"`
+	Code        int             `json:"code"`
+	CacheStatus WarmCacheStatus `json:"cache_status"`
+	Bytes       uint64          `json:"bytes"`
+	TimeMS      int64           `json:"time_ms"`
+	Error       string          `json:"error,omitempty"`
+}
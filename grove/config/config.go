@@ -59,6 +59,8 @@ type Config struct {
 	ServerWriteTimeoutMS int                    `json:"server_write_timeout_ms"`
 	ServerReadTimeoutMS  int                    `json:"server_read_timeout_ms"`
 	CacheFiles           map[string][]CacheFile `json:"cache_files"`
+	// ErrorPages is a map of status codes or code classes (e.g. "404" or "5xx") to error page files, which are used for error responses of remap rules without their own error page for the code. See the errpage package.
+	ErrorPages map[string]string `json:"error_pages"`
 	// FileMemBytes is the amount of memory to use as an LRU in front of each name in CacheFiles, that is, each named group of files. E.g. if there are 10 files, the amount of memory used will be 10*FileMemBytes+CacheSizeBytes.
 	FileMemBytes int `json:"file_mem_bytes"`
 }
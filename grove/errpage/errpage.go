@@ -0,0 +1,163 @@
+// Package errpage provides custom error response bodies, loaded from static files or Go templates, keyed by HTTP status code.
+package errpage
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// TemplateExt is the file extension which indicates an error page is a Go template, rather than a static file. Templates whose name ends in `.html.tmpl` or `.htm.tmpl` are HTML templates, and have their data escaped accordingly; all others are text templates.
+const TemplateExt = ".tmpl"
+
+const DefaultContentType = "text/plain; charset=utf-8"
+
+// Data is the data available to error page templates.
+type Data struct {
+	Code       int
+	StatusText string
+	RequestID  uint64
+	RemapRule  string
+	Host       string
+	Path       string
+	ClientIP   string
+	Time       time.Time
+}
+
+// Timestamp returns the Time of the error in RFC3339 format, for convenience in templates.
+func (d Data) Timestamp() string { return d.Time.Format(time.RFC3339) }
+
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// Page is a single error page, which is either a static body, or a template.
+type Page struct {
+	Name        string
+	ContentType string
+	body        []byte
+	tmpl        executor
+}
+
+// Body returns the body of the page, executing the page template with the given data if the page is a template.
+func (p *Page) Body(d Data) ([]byte, error) {
+	if p.tmpl == nil {
+		return p.body, nil
+	}
+	buf := bytes.Buffer{}
+	if err := p.tmpl.Execute(&buf, d); err != nil {
+		return nil, errors.New("executing error page template '" + p.Name + "': " + err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+// Pages is a set of error pages, keyed by status code. The keys 1 through 5 are the pages for each class of status codes, e.g. 5 is the page for all 5xx codes without a specific page.
+type Pages map[int]*Page
+
+// Get returns the page for the given code, or the page for its class if the code has no specific page. Returns false if no page exists. It is safe to call on a nil Pages.
+func (p Pages) Get(code int) (*Page, bool) {
+	if page, ok := p[code]; ok {
+		return page, true
+	}
+	page, ok := p[code/100]
+	return page, ok
+}
+
+// Load loads the given error pages. The keys of files are either status codes such as `404`, or status code classes such as `5xx`. The values are file paths. Files ending in TemplateExt are parsed as templates, other files are served verbatim.
+func Load(files map[string]string) (Pages, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	pages := make(Pages, len(files))
+	for codeStr, path := range files {
+		code, err := parseCodeKey(codeStr)
+		if err != nil {
+			return nil, err
+		}
+		page, err := loadPage(path)
+		if err != nil {
+			return nil, errors.New("loading error page for '" + codeStr + "': " + err.Error())
+		}
+		pages[code] = page
+	}
+	return pages, nil
+}
+
+// parseCodeKey parses an error page key, which is either a status code such as `404`, or a status code class such as `4xx`. Classes are returned as their first digit.
+func parseCodeKey(key string) (int, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if len(key) == 3 && strings.HasSuffix(key, "xx") {
+		class, err := strconv.Atoi(key[:1])
+		if err != nil || class < 1 || class > 5 {
+			return 0, errors.New("invalid error page status code class '" + key + "'")
+		}
+		return class, nil
+	}
+	code, err := strconv.Atoi(key)
+	if err != nil || code < 100 || code > 599 {
+		return 0, errors.New("invalid error page status code '" + key + "'")
+	}
+	return code, nil
+}
+
+func loadPage(path string) (*Page, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("reading file: " + err.Error())
+	}
+	name := filepath.Base(path)
+	if !strings.HasSuffix(name, TemplateExt) {
+		contentType := contentTypeByName(name)
+		if contentType == "" {
+			contentType = http.DetectContentType(b)
+		}
+		return &Page{Name: name, ContentType: contentType, body: b}, nil
+	}
+
+	baseName := strings.TrimSuffix(name, TemplateExt)
+	contentType := contentTypeByName(baseName)
+	if contentType == "" {
+		contentType = DefaultContentType
+	}
+	tmpl := executor(nil)
+	if ext := filepath.Ext(baseName); ext == ".html" || ext == ".htm" {
+		tmpl, err = htmltemplate.New(name).Parse(string(b))
+	} else {
+		tmpl, err = texttemplate.New(name).Parse(string(b))
+	}
+	if err != nil {
+		return nil, errors.New("parsing template: " + err.Error())
+	}
+	return &Page{Name: name, ContentType: contentType, tmpl: tmpl}, nil
+}
+
+func contentTypeByName(name string) string {
+	ext := filepath.Ext(name)
+	if ext == "" {
+		return ""
+	}
+	return mime.TypeByExtension(ext)
+}
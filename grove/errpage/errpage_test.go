@@ -0,0 +1,90 @@
+package errpage
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grove-errpage-test")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"404.html":      `<html>not here</html>`,
+		"5xx.html.tmpl": `<p>{{.Code}} {{.RemapRule}} {{.RequestID}} {{.Timestamp}} {{.Path}}</p>`,
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("writing %v: %v", name, err)
+		}
+	}
+
+	pages, err := Load(map[string]string{
+		"404": filepath.Join(dir, "404.html"),
+		"5xx": filepath.Join(dir, "5xx.html.tmpl"),
+	})
+	if err != nil {
+		t.Fatalf("Load expected nil error, actual %v", err)
+	}
+
+	page, ok := pages.Get(404)
+	if !ok {
+		t.Fatalf("Get 404 expected page, actual none")
+	}
+	if !strings.HasPrefix(page.ContentType, "text/html") {
+		t.Errorf("Get 404 expected content type text/html, actual %v", page.ContentType)
+	}
+	if body, err := page.Body(Data{}); err != nil || string(body) != files["404.html"] {
+		t.Errorf("Get 404 body expected %v, actual %v %v", files["404.html"], string(body), err)
+	}
+
+	if _, ok := pages.Get(403); ok {
+		t.Errorf("Get 403 expected no page, actual page")
+	}
+
+	page, ok = pages.Get(502)
+	if !ok {
+		t.Fatalf("Get 502 expected 5xx page, actual none")
+	}
+	tm := time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC)
+	body, err := page.Body(Data{Code: 502, RemapRule: "foo", RequestID: 42, Time: tm, Path: "/<script>"})
+	if err != nil {
+		t.Fatalf("Get 502 body expected nil error, actual %v", err)
+	}
+	expected := `<p>502 foo 42 2018-04-01T12:00:00Z /&lt;script&gt;</p>`
+	if string(body) != expected {
+		t.Errorf("Get 502 body expected %v, actual %v", expected, string(body))
+	}
+
+	var nilPages Pages
+	if _, ok := nilPages.Get(500); ok {
+		t.Errorf("nil Pages Get expected no page, actual page")
+	}
+
+	for _, key := range []string{"abc", "6xx", "99", "600"} {
+		if _, err := Load(map[string]string{key: filepath.Join(dir, "404.html")}); err == nil {
+			t.Errorf("Load key '%v' expected error, actual nil", key)
+		}
+	}
+}
@@ -17,11 +17,13 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"reflect"
@@ -39,6 +41,7 @@ import (
 	"github.com/apache/trafficcontrol/grove/cache"
 	"github.com/apache/trafficcontrol/grove/config"
 	"github.com/apache/trafficcontrol/grove/diskcache"
+	"github.com/apache/trafficcontrol/grove/errpage"
 	"github.com/apache/trafficcontrol/grove/icache"
 	"github.com/apache/trafficcontrol/grove/memcache"
 	"github.com/apache/trafficcontrol/grove/plugin"
@@ -56,6 +59,8 @@ func main() {
 	configFileName := flag.String("cfg", "", "The config file path")
 	pprof := flag.Bool("pprof", false, "Whether to profile")
 	showVersion := flag.Bool("version", false, "Print the application version")
+	prewarmManifest := flag.String("prewarm", "", "A file or URL with a list of URLs to fetch through the cache on startup, to pre-populate it")
+	prewarmConcurrency := flag.Int("prewarm-concurrency", cache.DefaultWarmConcurrency, "The number of simultaneous requests to make when pre-warming")
 	flag.Parse()
 
 	if *showVersion {
@@ -100,6 +105,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	errorPages, err := errpage.Load(cfg.ErrorPages)
+	if err != nil {
+		log.Errorf("starting service: loading error pages: %v\n", err)
+		os.Exit(1)
+	}
+
 	certs, err := loadCerts(remapper.Rules())
 	if err != nil {
 		log.Errorf("starting service: loading certificates: %v\n", err)
@@ -112,6 +123,13 @@ func main() {
 	}
 	certs = append(certs, defaultCert)
 
+	initialClientAuths, err := loadClientAuths(remapper.Rules())
+	if err != nil {
+		log.Errorf("starting service: loading client certificate authorities: %v\n", err)
+		os.Exit(1)
+	}
+	clientAuths := web.NewTLSClientAuthsPtr(initialClientAuths)
+
 	httpListener, httpConns, httpConnStateCallback, err := web.InterceptListen("tcp", fmt.Sprintf(":%d", cfg.Port))
 	if err != nil {
 		log.Errorf("creating HTTP listener %v: %v\n", cfg.Port, err)
@@ -124,7 +142,7 @@ func main() {
 	httpsConnStateCallback := (func(net.Conn, http.ConnState))(nil)
 	tlsConfig := (*tls.Config)(nil)
 	if cfg.CertFile != "" && cfg.KeyFile != "" {
-		if httpsListener, httpsConns, httpsConnStateCallback, tlsConfig, err = web.InterceptListenTLS("tcp", fmt.Sprintf(":%d", cfg.HTTPSPort), certs, cfg.DisableHTTP2); err != nil {
+		if httpsListener, httpsConns, httpsConnStateCallback, tlsConfig, err = web.InterceptListenTLS("tcp", fmt.Sprintf(":%d", cfg.HTTPSPort), certs, cfg.DisableHTTP2, clientAuths); err != nil {
 			log.Errorf("creating HTTPS listener %v: %v\n", cfg.HTTPSPort, err)
 			return
 		}
@@ -148,6 +166,7 @@ func main() {
 			httpConns,
 			httpsConns,
 			cfg.InterfaceName,
+			errorPages,
 		))
 	}
 
@@ -202,6 +221,21 @@ func main() {
 			return
 		}
 
+		newClientAuths, err := loadClientAuths(remapper.Rules())
+		if err != nil {
+			log.Errorln("reloading config: failed to load client certificate authorities, keeping existing rules: " + err.Error())
+			remapper = oldRemapper
+			return
+		}
+		clientAuths.Set(newClientAuths)
+
+		newErrorPages, err := errpage.Load(cfg.ErrorPages)
+		if err != nil {
+			log.Errorln("reloading config: failed to load error pages, keeping existing error pages: " + err.Error())
+		} else {
+			errorPages = newErrorPages
+		}
+
 		if cfg.Port != oldCfg.Port {
 			if httpListener, httpConns, httpConnStateCallback, err = web.InterceptListen("tcp", fmt.Sprintf(":%d", cfg.Port)); err != nil {
 				log.Errorf("reloading config: creating HTTP listener %v: %v\n", cfg.Port, err)
@@ -214,7 +248,7 @@ func main() {
 		}
 
 		if cfg.HTTPSPort != oldCfg.HTTPSPort {
-			if httpsListener, httpsConns, httpsConnStateCallback, tlsConfig, err = web.InterceptListenTLS("tcp", fmt.Sprintf(":%d", cfg.HTTPSPort), certs, cfg.DisableHTTP2); err != nil {
+			if httpsListener, httpsConns, httpsConnStateCallback, tlsConfig, err = web.InterceptListenTLS("tcp", fmt.Sprintf(":%d", cfg.HTTPSPort), certs, cfg.DisableHTTP2, clientAuths); err != nil {
 				log.Errorf("creating HTTPS listener %v: %v\n", cfg.HTTPSPort, err)
 			}
 		}
@@ -235,6 +269,7 @@ func main() {
 			httpConns,
 			httpsConns,
 			cfg.InterfaceName,
+			errorPages,
 		)
 		httpHandler.Set(httpCacheHandler)
 
@@ -252,6 +287,7 @@ func main() {
 			httpConns,
 			httpsConns,
 			cfg.InterfaceName,
+			errorPages,
 		)
 		httpsHandler.Set(httpsCacheHandler)
 
@@ -293,6 +329,9 @@ func main() {
 	if *pprof {
 		profile()
 	}
+	if *prewarmManifest != "" {
+		go prewarm(httpHandler, *prewarmManifest, *prewarmConcurrency, time.Duration(cfg.ReqTimeoutMS)*time.Millisecond)
+	}
 	signalReloader(unix.SIGHUP, reloadConfig)
 }
 
@@ -315,6 +354,33 @@ func profile() {
 	}()
 }
 
+// prewarm fetches every URL in the given manifest through the cache, and prints the result of each to stdout as a line of JSON.
+func prewarm(warmer plugin.Warmer, manifest string, concurrency int, manifestTimeout time.Duration) {
+	urls, err := web.LoadURLManifest(manifest, manifestTimeout)
+	if err != nil {
+		log.Errorln("prewarm: loading manifest '" + manifest + "': " + err.Error())
+		return
+	}
+	log.Infof("prewarm: warming %v urls with concurrency %v\n", len(urls), concurrency)
+
+	results := warmer.Warm(urls, concurrency)
+	failed := 0
+	enc := json.NewEncoder(os.Stdout)
+	for _, result := range results {
+		if !result.Success {
+			failed++
+		}
+		if err := enc.Encode(result); err != nil {
+			log.Errorln("prewarm: writing result for '" + result.URL + "': " + err.Error())
+		}
+	}
+	if failed > 0 {
+		log.Warnf("prewarm: %v of %v urls failed\n", failed, len(results))
+		return
+	}
+	log.Infof("prewarm: warmed %v urls\n", len(results))
+}
+
 func signalReloader(sig os.Signal, f func()) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, sig)
@@ -380,6 +446,49 @@ func loadCerts(rules []remapdata.RemapRule) ([]tls.Certificate, error) {
 	return certs, nil
 }
 
+// loadClientAuths returns the client certificate verification for the TLS handshake of each HTTPS rule host. Hosts whose rules all require client certificates require them in the handshake; hosts with any rule verifying client certificates verify them if given. The CAs are all the rule CAs of the host. Each rule further verifies the client certificate against its own CAs, see remapdata.RemapRule.ClientCertAllowed.
+func loadClientAuths(rules []remapdata.RemapRule) (map[string]web.TLSClientAuth, error) {
+	hostRules := map[string][]remapdata.RemapRule{}
+	for _, rule := range rules {
+		if !strings.HasPrefix(rule.From, "https://") {
+			continue
+		}
+		fromURL, err := url.Parse(rule.From)
+		if err != nil {
+			return nil, errors.New("parsing rule " + rule.Name + " from: " + err.Error())
+		}
+		host := strings.ToLower(fromURL.Hostname())
+		hostRules[host] = append(hostRules[host], rule)
+	}
+
+	clientAuths := map[string]web.TLSClientAuth{}
+	for host, rules := range hostRules {
+		caFiles := []string{}
+		allRequired := true
+		for _, rule := range rules {
+			if rule.ClientCertVerifyMode != remapdata.ClientCertVerifyRequired {
+				allRequired = false
+			}
+			if rule.ClientCertVerifyMode == remapdata.ClientCertVerifyOptional || rule.ClientCertVerifyMode == remapdata.ClientCertVerifyRequired {
+				caFiles = append(caFiles, rule.ClientCAFile)
+			}
+		}
+		if len(caFiles) == 0 {
+			continue
+		}
+		clientCAs, err := web.LoadCertPool(caFiles)
+		if err != nil {
+			return nil, errors.New("loading host " + host + " client CAs: " + err.Error())
+		}
+		clientAuth := web.TLSClientAuth{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: clientCAs}
+		if allRequired {
+			clientAuth.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		clientAuths[host] = clientAuth
+	}
+	return clientAuths, nil
+}
+
 // createCaches creates the caches specified in the config. The nameFiles is the map of names to groups of files, nameMemBytes is the amount of memory to use for each named group, and memCacheBytes is the amount of memory to use for the default memory cache.
 func createCaches(nameFiles map[string][]config.CacheFile, nameMemBytes uint64, memCacheBytes uint64) (map[string]icache.Cache, error) {
 	caches := map[string]icache.Cache{}
@@ -0,0 +1,97 @@
+package main
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apache/trafficcontrol/grove/remapdata"
+	"github.com/apache/trafficcontrol/grove/testcert"
+)
+
+func makeTestRule(name string, from string, mode remapdata.ClientCertVerify, caFile string) remapdata.RemapRule {
+	return remapdata.RemapRule{
+		RemapRuleBase:        remapdata.RemapRuleBase{Name: name, From: from, ClientCAFile: caFile},
+		ClientCertVerifyMode: mode,
+	}
+}
+
+func TestLoadClientAuths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grove-test")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	caA, err := testcert.WriteCAFile(dir, "ca-a")
+	if err != nil {
+		t.Fatalf("writing CA file: %v", err)
+	}
+	caB, err := testcert.WriteCAFile(dir, "ca-b")
+	if err != nil {
+		t.Fatalf("writing CA file: %v", err)
+	}
+
+	rules := []remapdata.RemapRule{
+		makeTestRule("plain-http", "http://required.example.net/", remapdata.ClientCertVerifyRequired, caA),
+		makeTestRule("no-verify", "https://none.example.net/", remapdata.ClientCertVerifyNone, ""),
+		makeTestRule("required-1", "https://Required.example.net/a", remapdata.ClientCertVerifyRequired, caA),
+		makeTestRule("required-2", "https://required.example.net/b", remapdata.ClientCertVerifyRequired, caB),
+		makeTestRule("mixed-required", "https://mixed.example.net/a", remapdata.ClientCertVerifyRequired, caA),
+		makeTestRule("mixed-none", "https://mixed.example.net/b", remapdata.ClientCertVerifyNone, ""),
+		makeTestRule("optional", "https://optional.example.net:8443/", remapdata.ClientCertVerifyOptional, caB),
+	}
+
+	clientAuths, err := loadClientAuths(rules)
+	if err != nil {
+		t.Fatalf("expected no error, actual: %v", err)
+	}
+	if len(clientAuths) != 3 {
+		t.Errorf("expected 3 client auth hosts, actual: %v", clientAuths)
+	}
+	if _, ok := clientAuths["none.example.net"]; ok {
+		t.Errorf("expected host without client cert verification to have no client auth, actual: %v", clientAuths["none.example.net"])
+	}
+
+	expected := map[string]struct {
+		clientAuth tls.ClientAuthType
+		numCAs     int
+	}{
+		"required.example.net": {tls.RequireAndVerifyClientCert, 2},
+		"mixed.example.net":    {tls.VerifyClientCertIfGiven, 1},
+		"optional.example.net": {tls.VerifyClientCertIfGiven, 1},
+	}
+	for host, exp := range expected {
+		clientAuth, ok := clientAuths[host]
+		if !ok {
+			t.Errorf("expected client auth for host %v, actual: none", host)
+			continue
+		}
+		if clientAuth.ClientAuth != exp.clientAuth {
+			t.Errorf("host %v expected client auth %v, actual: %v", host, exp.clientAuth, clientAuth.ClientAuth)
+		}
+		if clientAuth.ClientCAs == nil || len(clientAuth.ClientCAs.Subjects()) != exp.numCAs {
+			t.Errorf("host %v expected %v client CAs, actual: %v", host, exp.numCAs, clientAuth.ClientCAs)
+		}
+	}
+
+	rules = append(rules, makeTestRule("missing-ca", "https://missing.example.net/", remapdata.ClientCertVerifyRequired, filepath.Join(dir, "nonexistent.pem")))
+	if _, err := loadClientAuths(rules); err == nil {
+		t.Errorf("expected error for missing CA file, actual: nil")
+	}
+}
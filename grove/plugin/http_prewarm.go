@@ -0,0 +1,201 @@
+package plugin
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apache/trafficcontrol/grove/cachedata"
+	"github.com/apache/trafficcontrol/grove/web"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+)
+
+func init() {
+	AddPlugin(10000, Funcs{load: prewarmLoad, onRequest: prewarm})
+}
+
+// PrewarmEndpoint is the path of the cache pre-warming endpoint.
+const PrewarmEndpoint = "/_prewarm"
+
+const DefaultPrewarmMaxConcurrency = 32
+const DefaultPrewarmManifestTimeout = 30 * time.Second
+
+// PrewarmCfg is the configuration of the http_prewarm plugin, loaded from the global remap "plugins" object.
+type PrewarmCfg struct {
+	// Token is the secret which must be sent in an `Authorization: Bearer` header. Requests are forbidden if no token is configured.
+	Token string `json:"token"`
+	// MaxConcurrency is the maximum number of simultaneous warming requests a client may ask for.
+	MaxConcurrency int `json:"max_concurrency"`
+	// ManifestTimeoutMS is the timeout for requesting manifest URLs.
+	ManifestTimeoutMS int `json:"manifest_timeout_ms"`
+}
+
+// PrewarmReq is the request body of the pre-warming endpoint. Either or both of URLs and Manifest may be given.
+type PrewarmReq struct {
+	URLs []string `json:"urls"`
+	// Manifest is a URL, which is requested directly and expanded into a list of URLs to warm. See web.ParseURLManifest.
+	Manifest    string `json:"manifest"`
+	Concurrency int    `json:"concurrency"`
+}
+
+// PrewarmResp is the response body of the pre-warming endpoint.
+type PrewarmResp struct {
+	Succeeded int                    `json:"succeeded"`
+	Failed    int                    `json:"failed"`
+	Bytes     uint64                 `json:"bytes"`
+	Results   []cachedata.WarmResult `json:"results"`
+}
+
+func prewarmLoad(b json.RawMessage) interface{} {
+	cfg := PrewarmCfg{}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		log.Errorln("http_prewarm loading config, unmarshalling JSON: " + err.Error())
+		return nil
+	}
+	if cfg.Token == "" {
+		log.Warnln("http_prewarm loaded with no token, all pre-warm requests will be forbidden")
+	}
+	if cfg.MaxConcurrency < 1 {
+		cfg.MaxConcurrency = DefaultPrewarmMaxConcurrency
+	}
+	log.Debugf("http_prewarm load success\n")
+	return &cfg
+}
+
+func prewarm(icfg interface{}, d OnRequestData) bool {
+	if !strings.HasPrefix(d.R.URL.Path, PrewarmEndpoint) {
+		return false
+	}
+	reqTime := time.Now()
+
+	log.Debugf("plugin onrequest http_prewarm calling\n")
+
+	w := d.W
+	req := d.R
+
+	respCode, bytesWritten := servePrewarm(icfg, d)
+
+	clientIP, _ := web.GetClientIPPort(req)
+	now := time.Now()
+	// log, so we know who is warming the cache. Warming may make many parent requests.
+	log.EventRaw(atsEventLogStr(now, clientIP, d.Hostname, req.Host, d.Port, "-", d.Scheme, req.URL.String(), req.Method, req.Proto, respCode, now.Sub(reqTime), bytesWritten, 0, 0, true, true, getCacheHitStr(true, false), "-", "-", req.UserAgent(), req.Header.Get("X-Money-Trace"), d.RequestID, d.ClientSubject))
+	web.TryFlush(w)
+	return true
+}
+
+// servePrewarm authorizes and serves a pre-warm request, and returns the response code and bytes written.
+func servePrewarm(icfg interface{}, d OnRequestData) (int, uint64) {
+	w := d.W
+	req := d.R
+
+	ip, err := web.GetIP(req)
+	if err != nil {
+		log.Errorln("http_prewarm failed to get IP: " + err.Error())
+		return prewarmErr(w, http.StatusInternalServerError)
+	}
+	if !d.StatRules.Allowed(ip) {
+		log.Debugln("http_prewarm IP " + ip.String() + " FORBIDDEN")
+		return prewarmErr(w, http.StatusForbidden)
+	}
+
+	cfg, ok := icfg.(*PrewarmCfg)
+	if !ok || cfg == nil || cfg.Token == "" {
+		log.Errorln("http_prewarm request from " + ip.String() + " forbidden: no token configured")
+		return prewarmErr(w, http.StatusForbidden)
+	}
+	if !prewarmAuthorized(req, cfg.Token) {
+		log.Warnln("http_prewarm request from " + ip.String() + " unauthorized: bad or missing token")
+		return prewarmErr(w, http.StatusUnauthorized)
+	}
+
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		return prewarmErr(w, http.StatusMethodNotAllowed)
+	}
+	if d.Warmer == nil {
+		log.Errorln("http_prewarm: request has no Warmer")
+		return prewarmErr(w, http.StatusInternalServerError)
+	}
+
+	preq := PrewarmReq{}
+	if err := json.NewDecoder(req.Body).Decode(&preq); err != nil {
+		log.Debugln("http_prewarm decoding request body: " + err.Error())
+		return prewarmErr(w, http.StatusBadRequest)
+	}
+
+	urls := preq.URLs
+	if preq.Manifest != "" {
+		manifestTimeout := DefaultPrewarmManifestTimeout
+		if cfg.ManifestTimeoutMS > 0 {
+			manifestTimeout = time.Duration(cfg.ManifestTimeoutMS) * time.Millisecond
+		}
+		manifestURLs, err := web.GetURLManifest(preq.Manifest, manifestTimeout)
+		if err != nil {
+			log.Errorln("http_prewarm getting manifest '" + preq.Manifest + "': " + err.Error())
+			return prewarmErr(w, http.StatusBadGateway)
+		}
+		urls = append(urls, manifestURLs...)
+	}
+	if len(urls) == 0 {
+		return prewarmErr(w, http.StatusBadRequest)
+	}
+
+	concurrency := preq.Concurrency
+	if concurrency < 1 || concurrency > cfg.MaxConcurrency {
+		concurrency = cfg.MaxConcurrency
+	}
+
+	log.Infof("http_prewarm warming %v urls with concurrency %v for %v\n", len(urls), concurrency, ip.String())
+	resp := PrewarmResp{Results: d.Warmer.Warm(urls, concurrency)}
+	for _, result := range resp.Results {
+		if result.Success {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+		resp.Bytes += result.Bytes
+	}
+
+	bts, err := json.Marshal(resp)
+	if err != nil {
+		log.Errorln("http_prewarm marshalling response: " + err.Error())
+		return prewarmErr(w, http.StatusInternalServerError)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	written, _ := w.Write(bts)
+	return http.StatusOK, uint64(written)
+}
+
+// prewarmAuthorized returns whether the request has an `Authorization: Bearer` header matching the given token.
+func prewarmAuthorized(req *http.Request, token string) bool {
+	const bearerPrefix = "Bearer "
+	authHdr := req.Header.Get("Authorization")
+	if !strings.HasPrefix(authHdr, bearerPrefix) {
+		return false
+	}
+	reqToken := strings.TrimSpace(authHdr[len(bearerPrefix):])
+	return subtle.ConstantTimeCompare([]byte(reqToken), []byte(token)) == 1
+}
+
+func prewarmErr(w http.ResponseWriter, code int) (int, uint64) {
+	bytesWritten, _ := web.ServeErr(w, code)
+	return code, bytesWritten
+}
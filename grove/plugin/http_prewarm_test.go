@@ -0,0 +1,142 @@
+package plugin
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apache/trafficcontrol/grove/cachedata"
+	"github.com/apache/trafficcontrol/grove/remapdata"
+)
+
+// testWarmer is a Warmer which records the URLs it was asked to warm, and succeeds for every URL except those ending in "fail".
+type testWarmer struct {
+	urls        []string
+	concurrency int
+}
+
+func (w *testWarmer) Warm(urls []string, concurrency int) []cachedata.WarmResult {
+	w.urls = append(w.urls, urls...)
+	w.concurrency = concurrency
+	results := make([]cachedata.WarmResult, len(urls))
+	for i, u := range urls {
+		results[i] = cachedata.WarmResult{URL: u, Success: true, Code: http.StatusOK, CacheStatus: cachedata.WarmCacheMiss, Bytes: 10}
+		if strings.HasSuffix(u, "fail") {
+			results[i] = cachedata.WarmResult{URL: u, Code: http.StatusBadGateway, CacheStatus: cachedata.WarmCacheMiss, Error: "responded with code 502"}
+		}
+	}
+	return results
+}
+
+func TestServePrewarm(t *testing.T) {
+	_, localhost, _ := net.ParseCIDR("127.0.0.1/32")
+	cfg := &PrewarmCfg{Token: "secret", MaxConcurrency: 4}
+	body := `{"urls": ["http://example.test/a", "http://example.test/fail"], "concurrency": 100}`
+
+	tests := []struct {
+		name         string
+		cfg          interface{}
+		method       string
+		token        string
+		body         string
+		statRules    remapdata.RemapRulesStats
+		expectedCode int
+		expectWarm   bool
+	}{
+		{"success", cfg, http.MethodPost, "secret", body, remapdata.RemapRulesStats{}, http.StatusOK, true},
+		{"bad token", cfg, http.MethodPost, "wrong", body, remapdata.RemapRulesStats{}, http.StatusUnauthorized, false},
+		{"missing token", cfg, http.MethodPost, "", body, remapdata.RemapRulesStats{}, http.StatusUnauthorized, false},
+		{"no token configured", &PrewarmCfg{MaxConcurrency: 4}, http.MethodPost, "", body, remapdata.RemapRulesStats{}, http.StatusForbidden, false},
+		{"IP denied", cfg, http.MethodPost, "secret", body, remapdata.RemapRulesStats{Deny: []*net.IPNet{localhost}}, http.StatusForbidden, false},
+		{"GET", cfg, http.MethodGet, "secret", body, remapdata.RemapRulesStats{}, http.StatusMethodNotAllowed, false},
+		{"bad body", cfg, http.MethodPost, "secret", `{"urls":`, remapdata.RemapRulesStats{}, http.StatusBadRequest, false},
+		{"no urls", cfg, http.MethodPost, "secret", `{"urls": []}`, remapdata.RemapRulesStats{}, http.StatusBadRequest, false},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "http://localhost"+PrewarmEndpoint, strings.NewReader(test.body))
+		req.RemoteAddr = "127.0.0.1:12345"
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		w := httptest.NewRecorder()
+		warmer := &testWarmer{}
+		code, bytesWritten := servePrewarm(test.cfg, OnRequestData{W: w, R: req, StatRules: test.statRules, Warmer: warmer})
+
+		if code != test.expectedCode || w.Code != test.expectedCode {
+			t.Errorf("servePrewarm %v expected code %v, actual returned %v written %v", test.name, test.expectedCode, code, w.Code)
+		}
+		if bytesWritten != uint64(w.Body.Len()) {
+			t.Errorf("servePrewarm %v expected bytes written %v, actual %v", test.name, w.Body.Len(), bytesWritten)
+		}
+		if !test.expectWarm {
+			if len(warmer.urls) != 0 {
+				t.Errorf("servePrewarm %v expected no warming, actual %v", test.name, warmer.urls)
+			}
+			continue
+		}
+
+		if len(warmer.urls) != 2 {
+			t.Errorf("servePrewarm %v expected 2 URLs warmed, actual %v", test.name, warmer.urls)
+		}
+		if warmer.concurrency != cfg.MaxConcurrency {
+			t.Errorf("servePrewarm %v expected concurrency limited to %v, actual %v", test.name, cfg.MaxConcurrency, warmer.concurrency)
+		}
+		resp := PrewarmResp{}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("servePrewarm %v response unmarshalling: %v", test.name, err)
+		}
+		if resp.Succeeded != 1 || resp.Failed != 1 || resp.Bytes != 10 || len(resp.Results) != 2 {
+			t.Errorf("servePrewarm %v expected 1 succeeded 1 failed 10 bytes 2 results, actual %+v", test.name, resp)
+		}
+	}
+}
+
+func TestPrewarmManifest(t *testing.T) {
+	manifest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("http://example.test/a\nhttp://example.test/b\n"))
+	}))
+	defer manifest.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost"+PrewarmEndpoint, strings.NewReader(`{"urls": ["http://example.test/c"], "manifest": "`+manifest.URL+`"}`))
+	req.RemoteAddr = "127.0.0.1:12345"
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	warmer := &testWarmer{}
+	code, _ := servePrewarm(&PrewarmCfg{Token: "secret", MaxConcurrency: 4}, OnRequestData{W: w, R: req, Warmer: warmer})
+	if code != http.StatusOK {
+		t.Fatalf("servePrewarm with manifest expected code %v, actual %v body '%v'", http.StatusOK, code, w.Body.String())
+	}
+	expected := []string{"http://example.test/c", "http://example.test/a", "http://example.test/b"}
+	if strings.Join(warmer.urls, " ") != strings.Join(expected, " ") {
+		t.Errorf("servePrewarm with manifest expected URLs %v, actual %v", expected, warmer.urls)
+	}
+}
+
+func TestPrewarmPassesOtherPaths(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/foo", nil)
+	w := httptest.NewRecorder()
+	warmer := &testWarmer{}
+	if prewarm(&PrewarmCfg{Token: "secret"}, OnRequestData{W: w, R: req, Warmer: warmer}) {
+		t.Errorf("prewarm expected to pass non-endpoint path, actual handled")
+	}
+	if len(warmer.urls) != 0 || w.Body.Len() != 0 {
+		t.Errorf("prewarm of non-endpoint path expected no warming or response, actual warmed %v response '%v'", warmer.urls, w.Body.String())
+	}
+}
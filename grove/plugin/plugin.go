@@ -70,9 +70,19 @@ type OnRequestData struct {
 	HTTPSConns    *web.ConnMap
 	RequestID     uint64
 	Context       *interface{}
+	// Warmer fetches URLs through the cache, to pre-populate it. It may be nil.
+	Warmer Warmer
+	// ClientSubject is the subject of the client certificate verified in the TLS handshake, or empty if the client sent no verified certificate. Note remap rules may require stricter verification than the handshake; see remapdata.RemapRule.ClientCertAllowed.
+	ClientSubject string
 	cachedata.SrvrData
 }
 
+// Warmer fetches URLs through the normal remap and cache path, as if they had been requested by clients, without affecting live request stats.
+type Warmer interface {
+	// Warm requests each URL, with at most concurrency simultaneous requests, and returns the result for each URL in the same order as urls.
+	Warm(urls []string, concurrency int) []cachedata.WarmResult
+}
+
 type BeforeParentRequestData struct {
 	Req       *http.Request
 	RemapRule string
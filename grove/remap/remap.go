@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/apache/trafficcontrol/grove/chash"
+	"github.com/apache/trafficcontrol/grove/errpage"
 	"github.com/apache/trafficcontrol/grove/icache"
 	"github.com/apache/trafficcontrol/grove/plugin"
 	"github.com/apache/trafficcontrol/grove/remapdata"
@@ -111,10 +112,11 @@ type Remapping struct {
 // RemappingProducer takes an HTTP Request and returns a Remapping to be used for that request.
 // TODO rename? interface?
 type RemappingProducer struct {
-	oldURI   string
-	rule     remapdata.RemapRule
-	cacheKey string
-	failures int
+	oldURI        string
+	rule          remapdata.RemapRule
+	cacheKey      string
+	failures      int
+	clientSubject string
 }
 
 func (p *RemappingProducer) CacheKey() string                  { return p.cacheKey }
@@ -124,6 +126,11 @@ func (p *RemappingProducer) Name() string                      { return p.rule.N
 func (p *RemappingProducer) DSCP() int                         { return p.rule.DSCP }
 func (p *RemappingProducer) PluginCfg() map[string]interface{} { return p.rule.Plugins }
 func (p *RemappingProducer) Cache() icache.Cache               { return p.rule.Cache }
+func (p *RemappingProducer) ErrorPages() errpage.Pages         { return p.rule.ErrorPages }
+
+// ClientSubject returns the subject of the client certificate, verified against the rule's client CAs. It is empty if the rule doesn't verify client certificates, or the client sent none.
+func (p *RemappingProducer) ClientSubject() string { return p.clientSubject }
+
 func (p *RemappingProducer) FirstFQDN() string {
 	// TODO verify To is not allowed to be constructed with < 1 element
 	return strings.TrimPrefix(strings.TrimPrefix(p.rule.To[0].URL, "http://"), "https://")
@@ -137,6 +144,7 @@ func (p *RemappingProducer) ProxyStr() string {
 
 var ErrRuleNotFound = errors.New("remap rule not found")
 var ErrIPNotAllowed = errors.New("IP not allowed")
+var ErrClientCertNotAllowed = errors.New("client certificate not allowed")
 var ErrNoMoreRetries = errors.New("retry num exceeded")
 
 // RequestURI returns the URI of the given request. This must be used, because Go does not populate the scheme of requests that come in from clients.
@@ -158,12 +166,18 @@ func (hr simpleHTTPRequestRemapper) RemappingProducer(r *http.Request, scheme st
 		log.Debugf("Allowed %v\n", ip)
 	}
 
+	clientSubject, ok := rule.ClientCertAllowed(r.TLS)
+	if !ok {
+		return nil, ErrClientCertNotAllowed
+	}
+
 	cacheKey := rule.CacheKey(r.Method, uri)
 
 	return &RemappingProducer{
-		rule:     rule,
-		oldURI:   uri,
-		cacheKey: cacheKey,
+		rule:          rule,
+		oldURI:        uri,
+		cacheKey:      cacheKey,
+		clientSubject: clientSubject,
 	}, nil
 }
 
@@ -314,6 +328,10 @@ type RemapRuleJSON struct {
 	RetryCodes      *[]int                     `json:"retry_codes"`
 	CacheName       *string                    `json:"cache_name"`
 	Plugins         map[string]json.RawMessage `json:"plugins"`
+	ErrorPages      map[string]string          `json:"error_pages"`
+	// AllowClientSubjects and DenyClientSubjects are client certificate subjects or common names, for rules with client certificate verification.
+	AllowClientSubjects []string `json:"allow_client_subjects"`
+	DenyClientSubjects  []string `json:"deny_client_subjects"`
 }
 
 // LoadRemapRules returns the loaded rules, the global plugins, the Stats remap rules, and any error
@@ -435,6 +453,12 @@ func LoadRemapRules(path string, pluginConfigLoaders map[string]plugin.LoadFunc,
 		if rule.Deny, err = makeIPNets(jsonRule.Deny); err != nil {
 			return nil, nil, nil, fmt.Errorf("error parsing rule %v denys: %v", rule.Name, err)
 		}
+		if rule.ErrorPages, err = errpage.Load(jsonRule.ErrorPages); err != nil {
+			return nil, nil, nil, fmt.Errorf("error parsing rule %v error pages: %v", rule.Name, err)
+		}
+		if err := loadClientCertVerify(&rule, jsonRule); err != nil {
+			return nil, nil, nil, fmt.Errorf("error parsing rule %v client certificate verification: %v", rule.Name, err)
+		}
 		if rule.To, err = makeTo(jsonRule.To, rule, baseTransport); err != nil {
 			return nil, nil, nil, fmt.Errorf("error parsing rule %v to: %v", rule.Name, err)
 		}
@@ -465,6 +489,32 @@ func LoadRemapRules(path string, pluginConfigLoaders map[string]plugin.LoadFunc,
 	return rules, remapRules.Plugins, &remapRules.Stats, nil
 }
 
+// loadClientCertVerify sets the client certificate verification mode, CAs, and subject allow and deny lists of the given rule.
+func loadClientCertVerify(rule *remapdata.RemapRule, jsonRule RemapRuleJSON) error {
+	if rule.ClientCertVerifyMode = remapdata.ClientCertVerifyFromString(rule.ClientCertVerify); rule.ClientCertVerifyMode == remapdata.ClientCertVerifyInvalid {
+		return fmt.Errorf("client-cert-verify invalid: '%v'", rule.ClientCertVerify)
+	}
+	if rule.ClientCertVerifyMode == remapdata.ClientCertVerifyNone {
+		if len(jsonRule.AllowClientSubjects) > 0 || len(jsonRule.DenyClientSubjects) > 0 {
+			return errors.New("client subjects require client-cert-verify optional or required")
+		}
+		return nil
+	}
+	if !strings.HasPrefix(rule.From, "https://") {
+		return errors.New("client-cert-verify requires an https rule")
+	}
+	if rule.ClientCAFile == "" {
+		return errors.New("client-cert-verify requires a client-ca-file")
+	}
+	err := error(nil)
+	if rule.ClientCAs, err = web.LoadCertPool([]string{rule.ClientCAFile}); err != nil {
+		return err
+	}
+	rule.AllowClientSubjects = jsonRule.AllowClientSubjects
+	rule.DenyClientSubjects = jsonRule.DenyClientSubjects
+	return nil
+}
+
 const DefaultReplicas = 1024
 
 func makeRuleHash(rule remapdata.RemapRule) chash.ATSConsistentHash {
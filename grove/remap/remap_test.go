@@ -0,0 +1,89 @@
+package remap
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apache/trafficcontrol/grove/remapdata"
+	"github.com/apache/trafficcontrol/grove/testcert"
+)
+
+func TestLoadClientCertVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grove-remap-test")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	caFile, err := testcert.WriteCAFile(dir, "ca")
+	if err != nil {
+		t.Fatalf("writing CA file: %v", err)
+	}
+	notPEMFile := filepath.Join(dir, "notpem.txt")
+	if err := ioutil.WriteFile(notPEMFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		from         string
+		verify       string
+		caFile       string
+		allow        []string
+		deny         []string
+		expectErr    bool
+		expectedMode remapdata.ClientCertVerify
+	}{
+		{"default none", "https://example.net", "", "", nil, nil, false, remapdata.ClientCertVerifyNone},
+		{"none on http", "http://example.net", "none", "", nil, nil, false, remapdata.ClientCertVerifyNone},
+		{"optional", "https://example.net", "optional", caFile, nil, nil, false, remapdata.ClientCertVerifyOptional},
+		{"required with subjects", "https://example.net", "required", caFile, []string{"a"}, []string{"b"}, false, remapdata.ClientCertVerifyRequired},
+		{"invalid mode", "https://example.net", "sometimes", caFile, nil, nil, true, remapdata.ClientCertVerifyInvalid},
+		{"subjects without verify", "https://example.net", "none", "", []string{"a"}, nil, true, remapdata.ClientCertVerifyNone},
+		{"http rule", "http://example.net", "required", caFile, nil, nil, true, remapdata.ClientCertVerifyRequired},
+		{"no CA file", "https://example.net", "required", "", nil, nil, true, remapdata.ClientCertVerifyRequired},
+		{"missing CA file", "https://example.net", "required", filepath.Join(dir, "nonexistent.pem"), nil, nil, true, remapdata.ClientCertVerifyRequired},
+		{"CA file not PEM", "https://example.net", "optional", notPEMFile, nil, nil, true, remapdata.ClientCertVerifyOptional},
+	}
+
+	for _, test := range tests {
+		base := remapdata.RemapRuleBase{Name: test.name, From: test.from, ClientCertVerify: test.verify, ClientCAFile: test.caFile}
+		rule := remapdata.RemapRule{RemapRuleBase: base}
+		jsonRule := RemapRuleJSON{RemapRuleBase: base, AllowClientSubjects: test.allow, DenyClientSubjects: test.deny}
+		err := loadClientCertVerify(&rule, jsonRule)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("%v: expected error, actual nil", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: expected no error, actual %v", test.name, err)
+			continue
+		}
+		if rule.ClientCertVerifyMode != test.expectedMode {
+			t.Errorf("%v: expected mode %v, actual %v", test.name, test.expectedMode, rule.ClientCertVerifyMode)
+		}
+		if test.expectedMode != remapdata.ClientCertVerifyNone && rule.ClientCAs == nil {
+			t.Errorf("%v: expected client CAs to be loaded, actual nil", test.name)
+		}
+		if len(rule.AllowClientSubjects) != len(test.allow) || len(rule.DenyClientSubjects) != len(test.deny) {
+			t.Errorf("%v: expected allow %v deny %v, actual allow %v deny %v", test.name, test.allow, test.deny, rule.AllowClientSubjects, rule.DenyClientSubjects)
+		}
+	}
+}
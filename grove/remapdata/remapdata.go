@@ -17,6 +17,8 @@ package remapdata
 // remapdata exists as a package to avoid import cycles, for packages that need remap objects and are also included by remap.
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"net"
 	"net/http"
@@ -25,6 +27,7 @@ import (
 	"time"
 
 	"github.com/apache/trafficcontrol/grove/chash"
+	"github.com/apache/trafficcontrol/grove/errpage"
 	"github.com/apache/trafficcontrol/grove/icache"
 
 	"github.com/apache/trafficcontrol/lib/go-log"
@@ -71,6 +74,48 @@ func ParentSelectionTypeFromString(s string) ParentSelectionType {
 	return ParentSelectionTypeInvalid
 }
 
+// ClientCertVerify is the client certificate verification mode of an HTTPS remap rule.
+type ClientCertVerify string
+
+const (
+	// ClientCertVerifyNone does not request client certificates.
+	ClientCertVerifyNone = ClientCertVerify("none")
+	// ClientCertVerifyOptional verifies client certificates if they're sent, but allows clients without certificates.
+	ClientCertVerifyOptional = ClientCertVerify("optional")
+	// ClientCertVerifyRequired requires a valid client certificate.
+	ClientCertVerifyRequired = ClientCertVerify("required")
+	// ClientCertVerifyInvalid is an unknown verification mode. It is distinct from the zero value, which is a mode that was never parsed.
+	ClientCertVerifyInvalid = ClientCertVerify("invalid")
+)
+
+func (v ClientCertVerify) String() string {
+	switch v {
+	case ClientCertVerifyNone:
+		return "none"
+	case ClientCertVerifyOptional:
+		return "optional"
+	case ClientCertVerifyRequired:
+		return "required"
+	default:
+		return "invalid"
+	}
+}
+
+// ClientCertVerifyFromString returns the ClientCertVerify for the given string. The empty string is ClientCertVerifyNone.
+func ClientCertVerifyFromString(s string) ClientCertVerify {
+	s = strings.ToLower(s)
+	if s == "none" || s == "" {
+		return ClientCertVerifyNone
+	}
+	if s == "optional" {
+		return ClientCertVerifyOptional
+	}
+	if s == "required" {
+		return ClientCertVerifyRequired
+	}
+	return ClientCertVerifyInvalid
+}
+
 type RemapRulesStats struct {
 	Allow []*net.IPNet
 	Deny  []*net.IPNet
@@ -97,12 +142,16 @@ func (statRules RemapRulesStats) Allowed(ip net.IP) bool {
 }
 
 type RemapRuleBase struct {
-	Name               string          `json:"name"`
-	From               string          `json:"from"`
-	CertificateFile    string          `json:"certificate-file"`
-	CertificateKeyFile string          `json:"certificate-key-file"`
-	ConnectionClose    bool            `json:"connection-close"`
-	QueryString        QueryStringRule `json:"query-string"`
+	Name               string `json:"name"`
+	From               string `json:"from"`
+	CertificateFile    string `json:"certificate-file"`
+	CertificateKeyFile string `json:"certificate-key-file"`
+	// ClientCAFile is the file of PEM CA certificates used to verify client certificates, for HTTPS rules whose ClientCertVerify is not none.
+	ClientCAFile string `json:"client-ca-file"`
+	// ClientCertVerify is the client certificate verification mode: "none", "optional", or "required". The empty string is "none".
+	ClientCertVerify string          `json:"client-cert-verify"`
+	ConnectionClose  bool            `json:"connection-close"`
+	QueryString      QueryStringRule `json:"query-string"`
 	// ConcurrentRuleRequests is the number of concurrent requests permitted to a remap rule, that is, to an origin. If this is 0, the global config is used.
 	ConcurrentRuleRequests int                        `json:"concurrent_rule_requests"`
 	RetryNum               *int                       `json:"retry_num"`
@@ -121,6 +170,13 @@ type RemapRule struct {
 	ConsistentHash  chash.ATSConsistentHash
 	Cache           icache.Cache
 	Plugins         map[string]interface{}
+	ErrorPages      errpage.Pages
+	// ClientCertVerifyMode is the parsed ClientCertVerify.
+	ClientCertVerifyMode ClientCertVerify
+	ClientCAs            *x509.CertPool
+	// AllowClientSubjects and DenyClientSubjects are the client certificate subjects allowed and denied access. Each entry matches either the full subject distinguished name, or the subject common name.
+	AllowClientSubjects []string
+	DenyClientSubjects  []string
 }
 
 func (r *RemapRule) Allowed(ip net.IP) bool {
@@ -143,6 +199,54 @@ func (r *RemapRule) Allowed(ip net.IP) bool {
 	return false
 }
 
+// ClientCertAllowed verifies the client certificate of the given TLS connection state against the rule's client CAs, and checks its subject against the rule's client subject allow and deny lists. It returns the verified client subject, which is empty if the client sent no certificate, and whether the client is allowed. The state may be nil, for non-TLS requests.
+// This must be checked per request, not only in the TLS handshake, because clients may reuse connections for multiple hosts. Rules whose mode is not optional or required allow all clients.
+func (r *RemapRule) ClientCertAllowed(state *tls.ConnectionState) (string, bool) {
+	if r.ClientCertVerifyMode != ClientCertVerifyOptional && r.ClientCertVerifyMode != ClientCertVerifyRequired {
+		return "", true
+	}
+	if state == nil || len(state.PeerCertificates) == 0 {
+		if r.ClientCertVerifyMode == ClientCertVerifyRequired {
+			log.Debugf("rule %v client certificate required, but none sent\n", r.Name)
+			return "", false
+		}
+		return "", r.clientSubjectAllowed("", "")
+	}
+
+	cert := state.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range state.PeerCertificates[1:] {
+		intermediates.AddCert(intermediate)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: r.ClientCAs, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		log.Debugf("rule %v client certificate '%v' not verified: %v\n", r.Name, cert.Subject.String(), err)
+		return "", false
+	}
+	subject := cert.Subject.String()
+	return subject, r.clientSubjectAllowed(subject, cert.Subject.CommonName)
+}
+
+// clientSubjectAllowed returns whether the given verified client certificate subject and common name are allowed by the rule's subject allow and deny lists. The subject is empty if the client sent no certificate.
+func (r *RemapRule) clientSubjectAllowed(subject string, commonName string) bool {
+	matches := func(s string) bool { return subject != "" && (s == subject || (commonName != "" && s == commonName)) }
+	for _, s := range r.DenyClientSubjects {
+		if matches(s) {
+			log.Debugf("deny contains client subject\n")
+			return false
+		}
+	}
+	if len(r.AllowClientSubjects) == 0 {
+		return true
+	}
+	for _, s := range r.AllowClientSubjects {
+		if matches(s) {
+			log.Debugf("allow contains client subject\n")
+			return true
+		}
+	}
+	return false
+}
+
 // URI takes a request URI and maps it to the real URI to proxy-and-cache. The `failures` parameter indicates how many parents have tried and failed, indicating to skip to the nth hashed parent. Returns the URI to request, and the proxy URL (if any)
 func (r RemapRule) URI(fromURI string, path string, query string, failures int) (string, *url.URL, *http.Transport) {
 	fromHash := path
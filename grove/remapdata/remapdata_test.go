@@ -0,0 +1,94 @@
+package remapdata
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/apache/trafficcontrol/grove/testcert"
+)
+
+// makeTestCert creates a certificate with the given common name, signed by the given parent and key, failing the test on error. If parent is nil, the certificate is a self-signed CA.
+func makeTestCert(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	cert, key, err := testcert.New(commonName, parent, parentKey)
+	if err != nil {
+		t.Fatalf("creating test certificate: %v", err)
+	}
+	return cert, key
+}
+
+func TestClientCertAllowed(t *testing.T) {
+	ca, caKey := makeTestCert(t, "test-ca", nil, nil)
+	otherCA, otherCAKey := makeTestCert(t, "other-ca", nil, nil)
+	client, _ := makeTestCert(t, "client.example.net", ca, caKey)
+	otherClient, _ := makeTestCert(t, "other.example.net", ca, caKey)
+	untrustedClient, _ := makeTestCert(t, "client.example.net", otherCA, otherCAKey)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+
+	clientState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}
+	otherClientState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{otherClient}}
+	untrustedState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{untrustedClient}}
+	noCertState := &tls.ConnectionState{}
+
+	tests := []struct {
+		name            string
+		mode            ClientCertVerify
+		allow           []string
+		deny            []string
+		state           *tls.ConnectionState
+		expectedAllowed bool
+		expectedSubject string
+	}{
+		{"none without cert", ClientCertVerifyNone, nil, nil, nil, true, ""},
+		{"none ignores untrusted cert", ClientCertVerifyNone, nil, nil, untrustedState, true, ""},
+		{"optional without cert", ClientCertVerifyOptional, nil, nil, noCertState, true, ""},
+		{"optional non-TLS", ClientCertVerifyOptional, nil, nil, nil, true, ""},
+		{"optional trusted cert", ClientCertVerifyOptional, nil, nil, clientState, true, client.Subject.String()},
+		{"optional untrusted cert", ClientCertVerifyOptional, nil, nil, untrustedState, false, ""},
+		{"optional without cert with allow list", ClientCertVerifyOptional, []string{"client.example.net"}, nil, noCertState, false, ""},
+		{"required without cert", ClientCertVerifyRequired, nil, nil, noCertState, false, ""},
+		{"required non-TLS", ClientCertVerifyRequired, nil, nil, nil, false, ""},
+		{"required trusted cert", ClientCertVerifyRequired, nil, nil, clientState, true, client.Subject.String()},
+		{"required untrusted cert", ClientCertVerifyRequired, nil, nil, untrustedState, false, ""},
+		{"allowed common name", ClientCertVerifyRequired, []string{"client.example.net"}, nil, clientState, true, client.Subject.String()},
+		{"allowed subject", ClientCertVerifyRequired, []string{client.Subject.String()}, nil, clientState, true, client.Subject.String()},
+		{"not in allow list", ClientCertVerifyRequired, []string{"client.example.net"}, nil, otherClientState, false, otherClient.Subject.String()},
+		{"denied common name", ClientCertVerifyRequired, nil, []string{"client.example.net"}, clientState, false, client.Subject.String()},
+		{"deny overrides allow", ClientCertVerifyRequired, []string{"client.example.net"}, []string{"client.example.net"}, clientState, false, client.Subject.String()},
+		{"deny other subject", ClientCertVerifyRequired, nil, []string{"other.example.net"}, clientState, true, client.Subject.String()},
+	}
+
+	for _, test := range tests {
+		rule := RemapRule{
+			RemapRuleBase:        RemapRuleBase{Name: test.name},
+			ClientCertVerifyMode: test.mode,
+			ClientCAs:            clientCAs,
+			AllowClientSubjects:  test.allow,
+			DenyClientSubjects:   test.deny,
+		}
+		subject, allowed := rule.ClientCertAllowed(test.state)
+		if allowed != test.expectedAllowed {
+			t.Errorf("%v: expected allowed %v, actual %v", test.name, test.expectedAllowed, allowed)
+		}
+		if subject != test.expectedSubject {
+			t.Errorf("%v: expected subject '%v', actual '%v'", test.name, test.expectedSubject, subject)
+		}
+	}
+}
@@ -16,10 +16,15 @@ package web
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
 
 	"golang.org/x/net/ipv4"
 	"golang.org/x/net/ipv6"
@@ -66,8 +71,82 @@ func InterceptListen(network, laddr string) (net.Listener, *ConnMap, func(net.Co
 	return &InterceptListener{realListener: l, connMap: connMap}, connMap, getConnStateCallback(connMap), nil
 }
 
+// TLSClientAuth is the client certificate verification to perform in the TLS handshake, for clients requesting a particular server name.
+type TLSClientAuth struct {
+	ClientAuth tls.ClientAuthType
+	ClientCAs  *x509.CertPool
+}
+
+// TLSClientAuthsPtr holds the client certificate verification for each TLS server name (SNI), and the TLS server config built from each. It may be safely set while a listener created with it is serving, e.g. when the remap rules are reloaded.
+type TLSClientAuthsPtr struct {
+	configs      *unsafe.Pointer // *tlsClientConfigs
+	m            sync.Mutex      // serializes Set and setServerConfig
+	serverConfig *tls.Config
+}
+
+// tlsClientConfigs is the client auths for each server name, and the server config with each client auth.
+type tlsClientConfigs struct {
+	clientAuths map[string]TLSClientAuth
+	configs     map[string]*tls.Config
+}
+
+// NewTLSClientAuthsPtr returns a TLSClientAuthsPtr holding the given client auths, which may be nil.
+func NewTLSClientAuthsPtr(clientAuths map[string]TLSClientAuth) *TLSClientAuthsPtr {
+	p := (unsafe.Pointer)(&tlsClientConfigs{clientAuths: clientAuths})
+	return &TLSClientAuthsPtr{configs: &p}
+}
+
+func (p *TLSClientAuthsPtr) load() *tlsClientConfigs {
+	return (*tlsClientConfigs)(atomic.LoadPointer(p.configs))
+}
+
+// Get returns the current client auths. The returned map must not be modified.
+func (p *TLSClientAuthsPtr) Get() map[string]TLSClientAuth {
+	return p.load().clientAuths
+}
+
+// Set atomically replaces the client auths, which are used by all subsequent TLS handshakes.
+func (p *TLSClientAuthsPtr) Set(clientAuths map[string]TLSClientAuth) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.store(clientAuths)
+}
+
+// setServerConfig sets the listener config, which the server config for each server name is copied from.
+func (p *TLSClientAuthsPtr) setServerConfig(config *tls.Config) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.serverConfig = config
+	p.store(p.load().clientAuths)
+}
+
+// store builds the server config for each of the given client auths, so handshakes don't have to, and atomically stores them. Callers must hold p.m.
+func (p *TLSClientAuthsPtr) store(clientAuths map[string]TLSClientAuth) {
+	configs := make(map[string]*tls.Config, len(clientAuths))
+	if p.serverConfig != nil {
+		for serverName, clientAuth := range clientAuths {
+			serverConfig := p.serverConfig.Clone()
+			serverConfig.GetConfigForClient = nil
+			serverConfig.ClientAuth = clientAuth.ClientAuth
+			serverConfig.ClientCAs = clientAuth.ClientCAs
+			configs[strings.ToLower(serverName)] = serverConfig
+		}
+	}
+	atomic.StorePointer(p.configs, (unsafe.Pointer)(&tlsClientConfigs{clientAuths: clientAuths, configs: configs}))
+}
+
+// getConfigForClient is a tls.Config GetConfigForClient func, which returns the server config with the current client certificate verification for the requested server name.
+func (p *TLSClientAuthsPtr) getConfigForClient(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	serverConfig, ok := p.load().configs[strings.ToLower(hello.ServerName)]
+	if !ok {
+		return nil, nil // nil uses the original config
+	}
+	return serverConfig, nil
+}
+
 // InterceptListenTLS is like InterceptListen but for serving HTTPS. It returns the tls.Config, which must be set on the http.Server using this listener for HTTP/2 to be set up.
-func InterceptListenTLS(network string, laddr string, certs []tls.Certificate, h2Disabled bool) (net.Listener, *ConnMap, func(net.Conn, http.ConnState), *tls.Config, error) {
+// The clientAuths are the client certificate verification for each TLS server name (SNI), read on every handshake. Clients requesting server names not in clientAuths are not asked for certificates. It may be nil.
+func InterceptListenTLS(network string, laddr string, certs []tls.Certificate, h2Disabled bool, clientAuths *TLSClientAuthsPtr) (net.Listener, *ConnMap, func(net.Conn, http.ConnState), *tls.Config, error) {
 	config := &tls.Config{}
 	// HTTP2 is enabled if config.DisableHTTP2 is false
 	if !h2Disabled {
@@ -75,6 +154,10 @@ func InterceptListenTLS(network string, laddr string, certs []tls.Certificate, h
 	}
 	config.Certificates = certs
 	config.BuildNameToCertificate()
+	if clientAuths != nil {
+		clientAuths.setServerConfig(config)
+		config.GetConfigForClient = clientAuths.getConfigForClient
+	}
 	l, err := net.Listen(network, laddr)
 	if err != nil {
 		return l, nil, nil, nil, err
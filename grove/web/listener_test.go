@@ -0,0 +1,74 @@
+package web
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+)
+
+func TestGetConfigForClientReload(t *testing.T) {
+	config := &tls.Config{NextProtos: []string{"h2"}}
+	clientAuths := NewTLSClientAuthsPtr(nil)
+	clientAuths.setServerConfig(config)
+	getConfig := clientAuths.getConfigForClient
+
+	if serverConfig, err := getConfig(&tls.ClientHelloInfo{ServerName: "example.net"}); err != nil || serverConfig != nil {
+		t.Fatalf("expected nil config and error without client auths, actual: %v %v", serverConfig, err)
+	}
+
+	cas := x509.NewCertPool()
+	clientAuths.Set(map[string]TLSClientAuth{"example.net": {ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: cas}})
+
+	serverConfig, err := getConfig(&tls.ClientHelloInfo{ServerName: "Example.NET"})
+	if err != nil {
+		t.Fatalf("expected no error, actual: %v", err)
+	}
+	if serverConfig == nil {
+		t.Fatalf("expected config after setting client auths, actual: nil")
+	}
+	if serverConfig.ClientAuth != tls.RequireAndVerifyClientCert || serverConfig.ClientCAs != cas {
+		t.Errorf("expected client auth %v with set CAs, actual: %v %v", tls.RequireAndVerifyClientCert, serverConfig.ClientAuth, serverConfig.ClientCAs)
+	}
+	if len(serverConfig.NextProtos) != 1 || serverConfig.NextProtos[0] != "h2" {
+		t.Errorf("expected server config to copy the listener config, actual NextProtos: %v", serverConfig.NextProtos)
+	}
+	if sameConfig, _ := getConfig(&tls.ClientHelloInfo{ServerName: "example.net"}); sameConfig != serverConfig {
+		t.Errorf("expected the server config to be built once per set, actual a new config for each handshake")
+	}
+	if config.ClientAuth != tls.NoClientCert {
+		t.Errorf("expected listener config to be unmodified, actual client auth: %v", config.ClientAuth)
+	}
+
+	clientAuths.Set(nil)
+	if serverConfig, err := getConfig(&tls.ClientHelloInfo{ServerName: "example.net"}); err != nil || serverConfig != nil {
+		t.Errorf("expected nil config after removing client auths, actual: %v %v", serverConfig, err)
+	}
+}
+
+func TestGetConfigForClientInitial(t *testing.T) {
+	cas := x509.NewCertPool()
+	clientAuths := NewTLSClientAuthsPtr(map[string]TLSClientAuth{"Example.NET": {ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: cas}})
+	clientAuths.setServerConfig(&tls.Config{})
+
+	serverConfig, err := clientAuths.getConfigForClient(&tls.ClientHelloInfo{ServerName: "example.net"})
+	if err != nil || serverConfig == nil {
+		t.Fatalf("expected config for client auths given before the listener config, actual: %v %v", serverConfig, err)
+	}
+	if serverConfig.ClientAuth != tls.VerifyClientCertIfGiven || serverConfig.ClientCAs != cas || serverConfig.GetConfigForClient != nil {
+		t.Errorf("expected client auth %v with given CAs and no GetConfigForClient, actual: %v %v %v", tls.VerifyClientCertIfGiven, serverConfig.ClientAuth, serverConfig.ClientCAs, serverConfig.GetConfigForClient != nil)
+	}
+}
@@ -0,0 +1,85 @@
+package web
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseURLManifest parses a list of URLs. The manifest may be either a JSON array of strings, or plain text with one URL per line. In plain text manifests, blank lines and lines beginning with '#' are ignored.
+func ParseURLManifest(b []byte) ([]string, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		urls := []string{}
+		if err := json.Unmarshal(b, &urls); err != nil {
+			return nil, errors.New("decoding JSON manifest: " + err.Error())
+		}
+		return urls, nil
+	}
+
+	urls := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("reading manifest: " + err.Error())
+	}
+	return urls, nil
+}
+
+// GetURLManifest requests the given manifest URL directly (not through the cache), and returns the list of URLs it contains. See ParseURLManifest.
+func GetURLManifest(manifestURL string, timeout time.Duration) ([]string, error) {
+	client := http.Client{Timeout: timeout}
+	resp, err := client.Get(manifestURL)
+	if err != nil {
+		return nil, errors.New("requesting manifest: " + err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, errors.New("requesting manifest: parent returned code " + strconv.Itoa(resp.StatusCode))
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.New("reading manifest body: " + err.Error())
+	}
+	return ParseURLManifest(body)
+}
+
+// LoadURLManifest returns the list of URLs in the manifest at the given location. If the location is an HTTP or HTTPS URL, it is requested via GetURLManifest, otherwise it is treated as a file path.
+func LoadURLManifest(location string, timeout time.Duration) ([]string, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return GetURLManifest(location, timeout)
+	}
+	b, err := ioutil.ReadFile(location)
+	if err != nil {
+		return nil, errors.New("reading manifest file: " + err.Error())
+	}
+	return ParseURLManifest(b)
+}
@@ -0,0 +1,45 @@
+package web
+
+/*
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseURLManifest(t *testing.T) {
+	expected := []string{"http://foo.example.net/a", "http://foo.example.net/b?c=d"}
+
+	testManifests := map[string]string{
+		"json":        `["http://foo.example.net/a", "http://foo.example.net/b?c=d"]`,
+		"text":        "http://foo.example.net/a\nhttp://foo.example.net/b?c=d\n",
+		"textComment": "# release 42\n\n  http://foo.example.net/a  \r\n# more\nhttp://foo.example.net/b?c=d",
+	}
+
+	for name, manifest := range testManifests {
+		urls, err := ParseURLManifest([]byte(manifest))
+		if err != nil {
+			t.Errorf("ParseURLManifest %v expected nil error, actual %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(urls, expected) {
+			t.Errorf("ParseURLManifest %v expected %+v, actual %+v", name, expected, urls)
+		}
+	}
+
+	if _, err := ParseURLManifest([]byte(`["http://foo.example.net/a", 42]`)); err == nil {
+		t.Errorf("ParseURLManifest invalid JSON expected error, actual nil")
+	}
+}
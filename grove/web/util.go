@@ -15,6 +15,7 @@ package web
 */
 
 import (
+	"crypto/x509"
 	"errors"
 	"io/ioutil"
 	"net"
@@ -100,6 +101,29 @@ func GetIP(r *http.Request) (net.IP, error) {
 	return clientIP, nil
 }
 
+// ClientCertSubject returns the subject of the client certificate verified in the TLS handshake of the given request, or the empty string if the request has no verified client certificate.
+func ClientCertSubject(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.String()
+}
+
+// LoadCertPool returns a certificate pool containing the PEM certificates in all the given files.
+func LoadCertPool(paths []string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, path := range paths {
+		pem, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.New("reading certificate file '" + path + "': " + err.Error())
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("certificate file '" + path + "' contains no PEM certificates")
+		}
+	}
+	return pool, nil
+}
+
 // TryFlush calls Flush on w if it's an http.Flusher. If it isn't, it returns without error.
 func TryFlush(w http.ResponseWriter) {
 	if f, ok := w.(http.Flusher); ok {
@@ -159,6 +183,14 @@ func ServeErr(w http.ResponseWriter, code int) (uint64, error) {
 	return uint64(bytesWritten), err
 }
 
+// ServeErrBody writes the given error code to w, with the given content type and body, and returns the bytes written and any write error.
+func ServeErrBody(w http.ResponseWriter, code int, contentType string, body []byte) (uint64, error) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	bytesWritten, err := w.Write(body)
+	return uint64(bytesWritten), err
+}
+
 // TryGetBytesWritten attempts to get the real bytes written to the given conn. It takes the bytesWritten as returned by Write(). It forcibly calls Flush() in order to force a write to the conn. Then, it attempts to get the more accurate bytes written to the Conn. If this fails, the given and less accurate bytesWritten is returned. If everything succeeds, the accurate bytes written to the Conn is returned.
 func TryGetBytesWritten(w http.ResponseWriter, conn *InterceptConn, bytesWritten uint64) uint64 {
 	if wFlusher, ok := w.(http.Flusher); !ok {
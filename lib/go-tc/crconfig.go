@@ -195,3 +195,49 @@ type CRConfigStats struct {
 	TMUser          *string `json:"tm_user,omitempty"`
 	TMVersion       *string `json:"tm_version,omitempty"`
 }
+
+// CRConfigDiff is the difference between two CRConfigs, typically the current snapshot and the CRConfig which would be snapshotted, by CRConfig section.
+// The stats section, which differs with every snapshot, is not compared.
+type CRConfigDiff struct {
+	Config           CRConfigSectionDiff `json:"config"`
+	ContentServers   CRConfigSectionDiff `json:"contentServers"`
+	ContentRouters   CRConfigSectionDiff `json:"contentRouters"`
+	DeliveryServices CRConfigSectionDiff `json:"deliveryServices"`
+	EdgeLocations    CRConfigSectionDiff `json:"edgeLocations"`
+	RouterLocations  CRConfigSectionDiff `json:"trafficRouterLocations"`
+	Monitors         CRConfigSectionDiff `json:"monitors"`
+}
+
+// CRConfigSectionDiff is the difference of a single CRConfig section, keyed by the name of the section's objects, e.g. the delivery service xml_id or server host name.
+// Added contains the new value of added objects, Removed the old value of removed objects, and Changed the fields which differ in objects in both.
+type CRConfigSectionDiff struct {
+	Added   map[string]interface{}         `json:"added"`
+	Removed map[string]interface{}         `json:"removed"`
+	Changed map[string][]CRConfigFieldDiff `json:"changed"`
+}
+
+// CRConfigFieldDiff is a single changed field of a CRConfig object.
+// Field is the slash-separated path of the field within the object, e.g. "protocol/acceptHttps", or empty if the object is itself a single value, as most config parameters are. Old or New is null if the field was added or removed.
+type CRConfigFieldDiff struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type CRConfigDiffResponse struct {
+	Response CRConfigDiff `json:"response"`
+}
+
+// SnapshotHistory is a CRConfig and monitoring snapshot of a CDN, as kept in the snapshot history, without the snapshot contents.
+type SnapshotHistory struct {
+	ID         int       `json:"id" db:"id"`
+	CDN        string    `json:"cdn" db:"cdn"`
+	User       string    `json:"user" db:"username"`
+	Comment    *string   `json:"comment" db:"comment"`
+	RollbackOf *int      `json:"rollbackOf" db:"rollback_of"`
+	Created    TimeNoMod `json:"created" db:"created"`
+}
+
+type SnapshotHistoryResponse struct {
+	Response []SnapshotHistory `json:"response"`
+}
@@ -36,8 +36,11 @@ type CRStatesDeliveryService struct {
 }
 
 // IsAvailable contains whether the given cache or delivery service is available. It is designed for JSON serialization, namely in the Traffic Monitor 1.0 API.
+// Ipv4Available and Ipv6Available are whether a cache is available over each protocol. IsAvailable and Ipv4Available are the same; if a cache's IPv6 address isn't polled, Ipv6Available is also the same.
 type IsAvailable struct {
-	IsAvailable bool `json:"isAvailable"`
+	IsAvailable   bool `json:"isAvailable"`
+	Ipv4Available bool `json:"ipv4Available"`
+	Ipv6Available bool `json:"ipv6Available"`
 }
 
 // NewCRStates creates a new CR states object, initializing pointer members.
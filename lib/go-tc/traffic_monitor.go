@@ -99,19 +99,102 @@ type TMParameters struct {
 	HealthPollingFormat     string `json:"health.polling.format"`
 	HealthPollingType       string `json:"health.polling.type"`
 	HistoryCount            int    `json:"history.count"`
-	MinFreeKbps             int64
-	Thresholds              map[string]HealthThreshold `json:"health_threshold"`
+	// HealthMarkDownPolls is the number of consecutive unhealthy polls before a cache is marked unavailable. If 0, a single unhealthy poll marks the cache unavailable.
+	HealthMarkDownPolls int `json:"health.markdown.polls"`
+	// HealthMarkUpPolls is the number of consecutive healthy polls before an unavailable cache is marked available. If 0, a single healthy poll marks the cache available.
+	HealthMarkUpPolls int `json:"health.markup.polls"`
+	// HealthFlapTransitions is the number of availability transitions within HealthFlapWindowMinutes after which a cache is considered flapping, and held unavailable until its transitions fall out of the window. If 0, flapping caches are not held.
+	HealthFlapTransitions   int `json:"health.flap.transitions"`
+	HealthFlapWindowMinutes int `json:"health.flap.window.minutes"`
+	// HealthPollingIPv6 is whether to poll the health of caches over IPv6, as well as IPv4, and report their availability over each protocol separately.
+	HealthPollingIPv6 bool `json:"health.polling.ipv6"`
+	// HealthPollingInterfaces is the network interfaces whose bandwidth and capacity are summed to get the cache's bandwidth and capacity. If empty, the single interface reported by the cache is used.
+	HealthPollingInterfaces []string `json:"health.polling.interfaces"`
+	// HealthPollingInterval and HeartbeatPollingInterval are the stat and health poll intervals of caches with the profile, in milliseconds. If 0, the intervals of the monitor config are used.
+	HealthPollingInterval    int `json:"health.polling.interval"`
+	HeartbeatPollingInterval int `json:"heartbeat.polling.interval"`
+	MinFreeKbps              int64
+	Thresholds               map[string]HealthThreshold `json:"health_threshold"`
 }
 
 const DefaultHealthThresholdComparator = "<"
 
+// Health threshold aggregates, which evaluate a threshold over the Window most recent polls of a stat, rather than only the latest poll.
+// Percentiles are also valid aggregates, of the form `p` followed by the percentile, e.g. `p95`.
+const (
+	HealthThresholdAggregateAvg = "avg"
+	HealthThresholdAggregateMin = "min"
+	HealthThresholdAggregateMax = "max"
+)
+
 type HealthThreshold struct {
 	Val        float64
 	Comparator string // TODO change to enum?
+	// Aggregate is the function used to combine the Window most recent polls of the stat, e.g. "avg" or "p95". If empty, only the latest poll is used.
+	Aggregate string
+	// Window is the number of recent polls Aggregate is computed over.
+	Window int
+}
+
+// HealthThresholdPercentile returns the percentile of the given aggregate, e.g. 95 for "p95", and whether the aggregate is a percentile.
+func HealthThresholdPercentile(aggregate string) (float64, bool) {
+	if len(aggregate) < 2 || aggregate[0] != 'p' {
+		return 0, false
+	}
+	percentile, err := strconv.ParseFloat(aggregate[1:], 64)
+	if err != nil || percentile <= 0 || percentile > 100 {
+		return 0, false
+	}
+	return percentile, true
+}
+
+func validHealthThresholdAggregate(aggregate string) bool {
+	switch aggregate {
+	case HealthThresholdAggregateAvg, HealthThresholdAggregateMin, HealthThresholdAggregateMax:
+		return true
+	}
+	_, ok := HealthThresholdPercentile(aggregate)
+	return ok
+}
+
+// strToThresholdWindow parses the optional window prefix of a threshold, of the form `aggregate(window)`, e.g. `p95(10)`. It returns the aggregate, the window, and the remainder of the string. If s has no window prefix, the aggregate is empty and s is returned unmodified.
+func strToThresholdWindow(s string) (string, int, string, error) {
+	openParen := strings.Index(s, "(")
+	if openParen < 0 {
+		return "", 0, s, nil
+	}
+	closeParen := strings.Index(s, ")")
+	if closeParen < openParen {
+		return "", 0, "", fmt.Errorf("invalid threshold window: missing ')'")
+	}
+	aggregate := s[:openParen]
+	if !validHealthThresholdAggregate(aggregate) {
+		return "", 0, "", fmt.Errorf("invalid threshold window aggregate '%s'", aggregate)
+	}
+	window, err := strconv.Atoi(s[openParen+1 : closeParen])
+	if err != nil || window < 1 {
+		return "", 0, "", fmt.Errorf("invalid threshold window '%s'", s[openParen+1:closeParen])
+	}
+	return aggregate, window, s[closeParen+1:], nil
 }
 
 // strToThreshold takes a string like ">=42" and returns a HealthThreshold with a Val of `42` and a Comparator of `">="`. If no comparator exists, `DefaultHealthThresholdComparator` is used. If the string is not of the form "(>|<|)(=|)\d+" an error is returned
+// The string may be prefixed with a window of the form `aggregate(polls)`, e.g. "avg(5)<25" or "p95(10)<25", in which case the threshold is evaluated against the aggregate of the stat over that many recent polls.
 func strToThreshold(s string) (HealthThreshold, error) {
+	aggregate, window, s, err := strToThresholdWindow(s)
+	if err != nil {
+		return HealthThreshold{}, err
+	}
+	threshold, err := strToThresholdVal(s)
+	if err != nil {
+		return HealthThreshold{}, err
+	}
+	threshold.Aggregate = aggregate
+	threshold.Window = window
+	return threshold, nil
+}
+
+func strToThresholdVal(s string) (HealthThreshold, error) {
 	comparators := []string{"=", ">", "<", ">=", "<="}
 	for _, comparator := range comparators {
 		if strings.HasPrefix(s, comparator) {
@@ -175,6 +258,41 @@ func (params *TMParameters) UnmarshalJSON(bytes []byte) (err error) {
 		}
 	}
 
+	intParams := map[string]*int{
+		"health.markdown.polls":      &params.HealthMarkDownPolls,
+		"health.markup.polls":        &params.HealthMarkUpPolls,
+		"health.flap.transitions":    &params.HealthFlapTransitions,
+		"health.flap.window.minutes": &params.HealthFlapWindowMinutes,
+		"health.polling.interval":    &params.HealthPollingInterval,
+		"heartbeat.polling.interval": &params.HeartbeatPollingInterval,
+	}
+	for name, param := range intParams {
+		if vi, ok := raw[name]; ok {
+			if v, ok := vi.(float64); !ok {
+				return fmt.Errorf("Unmarshalling TMParameters %s expected integer, got %v", name, vi)
+			} else {
+				*param = int(v)
+			}
+		}
+	}
+
+	if vi, ok := raw["health.polling.ipv6"]; ok {
+		if v, err := strconv.ParseBool(fmt.Sprintf("%v", vi)); err != nil { // allows string or numeric JSON types
+			return fmt.Errorf("Unmarshalling TMParameters health.polling.ipv6 expected boolean, got %v", vi)
+		} else {
+			params.HealthPollingIPv6 = v
+		}
+	}
+
+	if vi, ok := raw["health.polling.interfaces"]; ok {
+		params.HealthPollingInterfaces = []string{}
+		for _, inf := range strings.Split(fmt.Sprintf("%v", vi), ",") {
+			if inf = strings.TrimSpace(inf); inf != "" {
+				params.HealthPollingInterfaces = append(params.HealthPollingInterfaces, inf)
+			}
+		}
+	}
+
 	params.Thresholds = map[string]HealthThreshold{}
 	thresholdPrefix := "health.threshold."
 	for k, v := range raw {
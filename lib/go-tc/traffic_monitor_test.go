@@ -0,0 +1,66 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStrToThreshold(t *testing.T) {
+	tests := map[string]HealthThreshold{
+		"42":          {Val: 42, Comparator: DefaultHealthThresholdComparator},
+		">42":         {Val: 42, Comparator: ">"},
+		"avg(5)<25":   {Val: 25, Comparator: "<", Aggregate: HealthThresholdAggregateAvg, Window: 5},
+		"p95(10)<1.5": {Val: 1.5, Comparator: "<", Aggregate: "p95", Window: 10},
+		"max(3)=0":    {Val: 0, Comparator: "=", Aggregate: HealthThresholdAggregateMax, Window: 3},
+	}
+	for s, expected := range tests {
+		actual, err := strToThreshold(s)
+		if err != nil {
+			t.Errorf("strToThreshold '%v' expected nil error, actual %v", s, err)
+		} else if actual != expected {
+			t.Errorf("strToThreshold '%v' expected %+v, actual %+v", s, expected, actual)
+		}
+	}
+
+	for _, s := range []string{"abc", "avg<25", "avg(0)<25", "median(5)<25", "p0(5)<25", "avg(5<25"} {
+		if _, err := strToThreshold(s); err == nil {
+			t.Errorf("strToThreshold '%v' expected error, actual nil", s)
+		}
+	}
+}
+
+func TestTMParametersUnmarshalPolling(t *testing.T) {
+	params := TMParameters{}
+	if err := json.Unmarshal([]byte(`{"health.polling.ipv6": "true", "health.polling.interfaces": "bond0, eth2,"}`), &params); err != nil {
+		t.Fatalf("unmarshalling TMParameters expected nil error, actual %v", err)
+	}
+	if !params.HealthPollingIPv6 {
+		t.Errorf("unmarshalling TMParameters health.polling.ipv6 expected true, actual false")
+	}
+	if len(params.HealthPollingInterfaces) != 2 || params.HealthPollingInterfaces[0] != "bond0" || params.HealthPollingInterfaces[1] != "eth2" {
+		t.Errorf("unmarshalling TMParameters health.polling.interfaces expected [bond0 eth2], actual %v", params.HealthPollingInterfaces)
+	}
+
+	if err := json.Unmarshal([]byte(`{"health.polling.ipv6": "maybe"}`), &params); err == nil {
+		t.Errorf("unmarshalling TMParameters invalid health.polling.ipv6 expected error, actual nil")
+	}
+}
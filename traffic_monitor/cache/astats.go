@@ -37,6 +37,16 @@ type AstatsSystem struct {
 	LastReload        int    `json:"lastReload"`
 	AstatsLoad        int    `json:"astatsLoad"`
 	NotAvailable      bool   `json:"notAvailable,omitempty"`
+	// Interfaces is the stats of each of the cache's network interfaces, for combining multiple interfaces. It is nil if the stats type doesn't have per-interface stats.
+	Interfaces map[string]AstatsInterface `json:"-"`
+}
+
+// AstatsInterface is the stats of a single network interface of a cache.
+type AstatsInterface struct {
+	// Speed is the speed of the interface, in megabits per second.
+	Speed    int
+	BytesIn  uint64
+	BytesOut uint64
 }
 
 type AStat struct {
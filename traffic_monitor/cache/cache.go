@@ -20,8 +20,10 @@ package cache
  */
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/apache/trafficcontrol/lib/go-log"
@@ -77,6 +79,36 @@ type Result struct {
 	PollFinished    chan<- uint64
 	PrecomputedData PrecomputedData
 	Available       bool
+	// UsingIPv6 is whether the result is from polling the cache's IPv6 address.
+	UsingIPv6 bool
+}
+
+// IPv6PollIDSuffix is appended to a cache's name to make the poll ID of its IPv6 health poll. The Handler removes it from the Result ID, and sets UsingIPv6.
+const IPv6PollIDSuffix = "#ipv6"
+
+// CombineInterfaces replaces the result's interface name, speed, and bytes with the sum of the given interfaces, so the bandwidth and capacity of the cache are those of all of them. Returns an error if the result doesn't have stats for any of the interfaces.
+func (result *Result) CombineInterfaces(names []string) error {
+	system := &result.Astats.System
+	speed := 0
+	inBytes := uint64(0)
+	outBytes := uint64(0)
+	for _, name := range names {
+		inf, ok := system.Interfaces[name]
+		if !ok {
+			return errors.New("interface '" + name + "' not found")
+		}
+		speed += inf.Speed
+		inBytes += inf.BytesIn
+		outBytes += inf.BytesOut
+	}
+	system.InfName = strings.Join(names, "+")
+	system.InfSpeed = speed
+	system.ProcNetDev = astatsProcNetDev(system.InfName, AstatsInterface{Speed: speed, BytesIn: inBytes, BytesOut: outBytes})
+
+	kbpsInMbps := int64(1000)
+	result.PrecomputedData.OutBytes = int64(outBytes)
+	result.PrecomputedData.MaxKbps = int64(speed) * kbpsInMbps
+	return nil
 }
 
 // HasStat returns whether the given stat is in the Result.
@@ -206,11 +238,12 @@ func ComputedStats() map[string]StatComputeFunc {
 func (handler Handler) Handle(id string, rdr io.Reader, format string, reqTime time.Duration, reqEnd time.Time, reqErr error, pollID uint64, pollFinished chan<- uint64) {
 	log.Debugf("poll %v %v (format '%v') handle start\n", pollID, time.Now(), format)
 	result := Result{
-		ID:           tc.CacheName(id),
+		ID:           tc.CacheName(strings.TrimSuffix(id, IPv6PollIDSuffix)),
 		Time:         reqEnd,
 		RequestTime:  reqTime,
 		PollID:       pollID,
 		PollFinished: pollFinished,
+		UsingIPv6:    strings.HasSuffix(id, IPv6PollIDSuffix),
 	}
 
 	if reqErr != nil {
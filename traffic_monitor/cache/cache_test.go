@@ -35,6 +35,38 @@ func TestHandlerPrecompute(t *testing.T) {
 	}
 }
 
+func TestCombineInterfaces(t *testing.T) {
+	result := Result{Astats: Astats{System: AstatsSystem{
+		InfName:  "eth0",
+		InfSpeed: 10000,
+		Interfaces: map[string]AstatsInterface{
+			"eth0": {Speed: 10000, BytesIn: 100, BytesOut: 1000},
+			"eth1": {Speed: 25000, BytesIn: 200, BytesOut: 2000},
+			"eth2": {Speed: 40000, BytesIn: 300, BytesOut: 3000},
+		},
+	}}}
+
+	if err := result.CombineInterfaces([]string{"eth0", "eth1"}); err != nil {
+		t.Fatalf("CombineInterfaces expected nil error, actual %v", err)
+	}
+	if result.Astats.System.InfSpeed != 35000 {
+		t.Errorf("CombineInterfaces expected speed 35000, actual %v", result.Astats.System.InfSpeed)
+	}
+	if result.PrecomputedData.OutBytes != 3000 {
+		t.Errorf("CombineInterfaces expected out bytes 3000, actual %v", result.PrecomputedData.OutBytes)
+	}
+	if result.PrecomputedData.MaxKbps != 35000000 {
+		t.Errorf("CombineInterfaces expected max kbps 35000000, actual %v", result.PrecomputedData.MaxKbps)
+	}
+	if outBytes, err := astatsOutBytes(result.Astats.System.ProcNetDev, result.Astats.System.InfName); err != nil || outBytes != 3000 {
+		t.Errorf("CombineInterfaces expected proc.net.dev out bytes 3000, actual %v error %v", outBytes, err)
+	}
+
+	if err := result.CombineInterfaces([]string{"eth0", "eth3"}); err == nil {
+		t.Errorf("CombineInterfaces with missing interface expected error, actual nil")
+	}
+}
+
 type DummyFilterNever struct {
 }
 
@@ -41,6 +41,14 @@ type AvailableStatus struct {
 	UnavailableStat string
 	// Poller is the name of the poller which set this available status
 	Poller string
+	// UnhealthyPolls is the number of consecutive polls the cache has been evaluated unhealthy, and UnhealthyPoller is the poller of the latest of them. HealthyPolls is the number of consecutive polls the cache has been evaluated healthy. These delay marking the cache unavailable and available, per its profile's health.markdown.polls and health.markup.polls.
+	UnhealthyPolls  int
+	UnhealthyPoller string
+	HealthyPolls    int
+	// Transitions is the times of the cache's recent availability changes, within its profile's health.flap.window.minutes. This is shared between copies, and MUST NOT be modified; rather, create a new slice.
+	Transitions []time.Time
+	// IPv6 is the status of the cache's IPv6 health polls, or nil if its IPv6 address isn't polled. It is shared between copies, and MUST NOT be modified; rather, create a new status.
+	IPv6 *AvailableStatus
 }
 
 // CacheAvailableStatuses is the available status of each cache.
@@ -96,6 +104,23 @@ func (t *ResultStatVal) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&v)
 }
 
+// UnmarshalJSON unmarshals the TM1.0 /publish/CacheStats format, as produced by MarshalJSON. Because that format stringifies all values, the unmarshalled Val is always a string.
+func (t *ResultStatVal) UnmarshalJSON(data []byte) error {
+	v := struct {
+		Val  string `json:"value"`
+		Time int64  `json:"time"`
+		Span uint64 `json:"span"`
+	}{}
+	json := jsoniter.ConfigFastest // TODO make configurable
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	t.Val = v.Val
+	t.Time = time.Unix(0, v.Time*int64(time.Millisecond))
+	t.Span = v.Span
+	return nil
+}
+
 func pruneStats(history []ResultStatVal, limit uint64) []ResultStatVal {
 	if uint64(len(history)) > limit {
 		history = history[:limit-1]
@@ -20,6 +20,7 @@ package cache
  */
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/apache/trafficcontrol/lib/go-tc"
@@ -242,3 +243,24 @@ func TestResultHistoryCopy(t *testing.T) {
 		}
 	}
 }
+
+func TestResultStatValJSON(t *testing.T) {
+	val := ResultStatVal{Val: 42, Time: time.Unix(1500000000, 123000000), Span: 3}
+	bts, err := json.Marshal(&val)
+	if err != nil {
+		t.Fatalf("marshalling ResultStatVal: %v", err)
+	}
+	actual := ResultStatVal{}
+	if err := json.Unmarshal(bts, &actual); err != nil {
+		t.Fatalf("unmarshalling ResultStatVal: %v", err)
+	}
+	if actual.Val != "42" {
+		t.Errorf("expected value '42', actual %v", actual.Val)
+	}
+	if !actual.Time.Equal(val.Time) {
+		t.Errorf("expected time %v, actual %v", val.Time, actual.Time)
+	}
+	if actual.Span != val.Span {
+		t.Errorf("expected span %v, actual %v", val.Span, actual.Span)
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -55,9 +56,26 @@ func astatsParse(cache tc.CacheName, rdr io.Reader) (error, map[string]interface
 
 	json := jsoniter.ConfigFastest // TODo make configurable?
 	err := json.NewDecoder(rdr).Decode(&astats)
+	if err == nil {
+		astats.System.Interfaces = astatsInterfaces(astats.System)
+	}
 	return err, astats.Ats, astats.System
 }
 
+// astatsInterfaces returns the stats of the single interface astats reports, or nil if its proc.net.dev can't be parsed.
+func astatsInterfaces(system AstatsSystem) map[string]AstatsInterface {
+	outBytes, err := astatsOutBytes(system.ProcNetDev, system.InfName)
+	if err != nil {
+		return nil
+	}
+	fields := strings.Fields(system.ProcNetDev[strings.Index(system.ProcNetDev, system.InfName)+len(system.InfName)+1:])
+	inBytes, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return nil
+	}
+	return map[string]AstatsInterface{system.InfName: {Speed: system.InfSpeed, BytesIn: inBytes, BytesOut: uint64(outBytes)}}
+}
+
 func astatsPrecompute(cache tc.CacheName, toData todata.TOData, rawStats map[string]interface{}, system AstatsSystem) PrecomputedData {
 	stats := map[tc.DeliveryServiceName]*AStat{}
 
@@ -82,7 +100,7 @@ func astatsPrecompute(cache tc.CacheName, toData todata.TOData, rawStats map[str
 	return precomputed
 }
 
-// outBytes takes the proc.net.dev string, and the interface name, and returns the bytes field
+// astatsOutBytes takes the proc.net.dev string, and the interface name, and returns the bytes field. It's shared by all stats types, which create a proc.net.dev line for their interface with astatsProcNetDev.
 func astatsOutBytes(procNetDev, iface string) (int64, error) {
 	if procNetDev == "" {
 		return 0, fmt.Errorf("procNetDev empty")
@@ -90,7 +108,7 @@ func astatsOutBytes(procNetDev, iface string) (int64, error) {
 	if iface == "" {
 		return 0, fmt.Errorf("iface empty")
 	}
-	ifacePos := strings.Index(procNetDev, iface)
+	ifacePos := strings.Index(procNetDev, iface+":")
 	if ifacePos == -1 {
 		return 0, fmt.Errorf("interface '%s' not found in proc.net.dev '%s'", iface, procNetDev)
 	}
@@ -105,6 +123,43 @@ func astatsOutBytes(procNetDev, iface string) (int64, error) {
 	return strconv.ParseInt(procNetDevIfaceBytes, 10, 64)
 }
 
+// astatsProcNetDev returns a proc.net.dev line for the given interface, for stats types which don't report proc.net.dev. Counters other than bytes are zero.
+func astatsProcNetDev(infName string, inf AstatsInterface) string {
+	return infName + ":" + strconv.FormatUint(inf.BytesIn, 10) + " 0 0 0 0 0 0 0 " + strconv.FormatUint(inf.BytesOut, 10) + " 0 0 0 0 0 0 0"
+}
+
+// astatsBestInterface returns the name of the interface with the greatest speed, and false if there are no interfaces. Interfaces with the same speed are chosen by name, so the choice is deterministic.
+func astatsBestInterface(infs map[string]AstatsInterface) (string, bool) {
+	if len(infs) == 0 {
+		return "", false
+	}
+	infNames := make([]string, 0, len(infs))
+	for infName := range infs {
+		infNames = append(infNames, infName)
+	}
+	sort.Strings(infNames)
+	best := infNames[0]
+	for _, infName := range infNames[1:] {
+		if infs[infName].Speed > infs[best].Speed {
+			best = infName
+		}
+	}
+	return best, true
+}
+
+// astatsSetInterface sets the system's interface name, speed, and proc.net.dev to the system interface with the greatest speed. It returns false and leaves the system unchanged if it has no interfaces.
+func astatsSetInterface(system *AstatsSystem) bool {
+	infName, ok := astatsBestInterface(system.Interfaces)
+	if !ok {
+		return false
+	}
+	inf := system.Interfaces[infName]
+	system.InfName = infName
+	system.InfSpeed = inf.Speed
+	system.ProcNetDev = astatsProcNetDev(infName, inf)
+	return true
+}
+
 // astatsProcessStat and its subsidiary functions act as a State Machine, flowing the stat thru states for each "." component of the stat name
 func astatsProcessStat(server tc.CacheName, stats map[tc.DeliveryServiceName]*AStat, toData todata.TOData, stat string, value interface{}) (map[tc.DeliveryServiceName]*AStat, error) {
 	parts := strings.Split(stat, ".")
@@ -171,50 +226,31 @@ func astatsProcessStatPluginRemapStats(server tc.CacheName, stats map[tc.Deliver
 	return stats, nil
 }
 
-// addCacheStat adds the given stat to the existing stat. Note this adds, it doesn't overwrite. Numbers are summed, strings are concatenated.
-// TODO make this less duplicate code somehow.
+// astatsAddCacheStat adds the given remap_stats stat to the existing stat. Note this adds, it doesn't overwrite. It's shared by all stats types whose delivery service stats come from the ATS remap_stats plugin.
 func astatsAddCacheStat(stat *AStat, name string, val interface{}) error {
+	var dst *uint64
 	switch name {
 	case "status_2xx":
-		v, ok := val.(float64)
-		if !ok {
-			return fmt.Errorf("stat '%s' value expected int actual '%v' type %T", name, val, val)
-		}
-		stat.Status2xx += uint64(v)
+		dst = &stat.Status2xx
 	case "status_3xx":
-		v, ok := val.(float64)
-		if !ok {
-			return fmt.Errorf("stat '%s' value expected int actual '%v' type %T", name, val, val)
-		}
-		stat.Status3xx += uint64(v)
+		dst = &stat.Status3xx
 	case "status_4xx":
-		v, ok := val.(float64)
-		if !ok {
-			return fmt.Errorf("stat '%s' value expected int actual '%v' type %T", name, val, val)
-		}
-		stat.Status4xx += uint64(v)
+		dst = &stat.Status4xx
 	case "status_5xx":
-		v, ok := val.(float64)
-		if !ok {
-			return fmt.Errorf("stat '%s' value expected int actual '%v' type %T", name, val, val)
-		}
-		stat.Status5xx += uint64(v)
+		dst = &stat.Status5xx
 	case "out_bytes":
-		v, ok := val.(float64)
-		if !ok {
-			return fmt.Errorf("stat '%s' value expected int actual '%v' type %T", name, val, val)
-		}
-		stat.OutBytes += uint64(v)
+		dst = &stat.OutBytes
 	case "in_bytes":
-		v, ok := val.(float64)
-		if !ok {
-			return fmt.Errorf("stat '%s' value expected int actual '%v' type %T", name, val, val)
-		}
-		stat.InBytes += uint64(v)
-	case "status_unknown":
+		dst = &stat.InBytes
+	case "status_other", "status_unknown":
 		return dsdata.ErrNotProcessedStat
 	default:
 		return fmt.Errorf("unknown stat '%s'", name)
 	}
+	v, ok := val.(float64)
+	if !ok {
+		return fmt.Errorf("stat '%s' value expected number actual '%v' type %T", name, val, val)
+	}
+	*dst += uint64(v)
 	return nil
 }
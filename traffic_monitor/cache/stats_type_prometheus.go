@@ -0,0 +1,336 @@
+package cache
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// stats_type_prometheus is the Prometheus text exposition format, for caches such as Grove, nginx, or Varnish with Prometheus exporters.
+//
+// Each sample becomes a raw stat. Samples without labels are named by their metric name, e.g. `node_load1`. Samples with labels are named by their metric name and labels, sorted by label name, e.g. `node_network_transmit_bytes_total{device="bond0"}`. Timestamps are ignored.
+//
+// Delivery Service stats are the samples of the form:
+//   `remap_stats_stat-name{fqdn="fully-qualified-domain-name.example.net"}`
+// Where `stat-name` is one of:
+//   `in_bytes`, `out_bytes`, `status_2xx`, `status_3xx`, `status_4xx`, `status_5xx`
+// optionally with the Prometheus counter suffix `_total`.
+//
+// System stats are the Prometheus node_exporter metrics:
+//   `node_load1`, `node_load5`, `node_load15`
+//   `node_network_speed_bytes{device="interface-name"}`, the interface speed in bytes per second
+//   `node_network_receive_bytes_total{device="interface-name"}` and `node_network_transmit_bytes_total`
+// The monitored interface is the interface with the greatest speed, excluding loopback. If the cache has no node_exporter network interfaces, the sums of the Delivery Service `in_bytes` and `out_bytes` are used as the interface bytes, with an unknown speed.
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+const StatsTypePrometheus = "prometheus"
+
+func init() {
+	AddStatsType(StatsTypePrometheus, prometheusParse, prometheusPrecompute)
+}
+
+const prometheusDeviceLabel = "device"
+const prometheusFQDNLabel = "fqdn"
+const prometheusRemapStatsPrefix = "remap_stats_"
+
+// prometheusInfNameNoNodeStats is the interface name used when the cache has no node_exporter network interfaces.
+const prometheusInfNameNoNodeStats = "remap"
+
+// prometheusNoNodeStatsLogged is the set of caches which have been logged as having no node_exporter network interfaces, so it's logged once per cache rather than on every poll.
+var prometheusNoNodeStatsLogged = sync.Map{}
+
+func prometheusParse(cache tc.CacheName, rdr io.Reader) (error, map[string]interface{}, AstatsSystem) {
+	if rdr == nil {
+		log.Warnln(string(cache) + " handle reader nil")
+		return errors.New("handler got nil reader"), nil, AstatsSystem{}
+	}
+
+	stats := map[string]interface{}{}
+	scanner := bufio.NewScanner(rdr)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, labels, value, err := prometheusParseSample(line)
+		if err != nil {
+			return fmt.Errorf("line %v: %v", lineNum, err), nil, AstatsSystem{}
+		}
+		stats[prometheusStatName(name, labels)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.New("reading: " + err.Error()), nil, AstatsSystem{}
+	}
+	system, ok := prometheusSystem(stats)
+	if !ok {
+		if _, logged := prometheusNoNodeStatsLogged.LoadOrStore(cache, struct{}{}); !logged {
+			log.Warnln("cache " + string(cache) + " has no node_exporter network interface stats, using the sum of its remap_stats bytes for the interface bytes")
+		}
+	}
+	return nil, stats, system
+}
+
+// prometheusParseSample parses a Prometheus text exposition sample line, of the form `name{label="value",...} value [timestamp]`.
+func prometheusParseSample(line string) (string, map[string]string, float64, error) {
+	nameEnd := strings.IndexAny(line, "{ \t")
+	if nameEnd <= 0 {
+		return "", nil, 0, errors.New("malformed sample '" + line + "'")
+	}
+	name := line[:nameEnd]
+	rest := line[nameEnd:]
+
+	labels := map[string]string(nil)
+	if strings.HasPrefix(rest, "{") {
+		err := error(nil)
+		if labels, rest, err = prometheusParseLabels(rest[1:]); err != nil {
+			return "", nil, 0, errors.New("sample '" + name + "' labels: " + err.Error())
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) < 1 || len(fields) > 2 {
+		return "", nil, 0, errors.New("sample '" + name + "' malformed value '" + rest + "'")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, errors.New("sample '" + name + "' value: " + err.Error())
+	}
+	return name, labels, value, nil
+}
+
+// prometheusParseLabels parses the labels of a sample, after the opening brace, and returns the labels and the remainder of the line after the closing brace.
+func prometheusParseLabels(s string) (map[string]string, string, error) {
+	labels := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+		eq := strings.Index(s, "=")
+		if eq <= 0 {
+			return nil, "", errors.New("malformed label")
+		}
+		labelName := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return nil, "", errors.New("label '" + labelName + "' value not quoted")
+		}
+		value := bytes.Buffer{}
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, "", errors.New("label '" + labelName + "' value unterminated")
+		}
+		labels[labelName] = value.String()
+		s = s[i+1:]
+	}
+}
+
+// prometheusLabelValueEscaper escapes label values as in the Prometheus text exposition format.
+var prometheusLabelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// prometheusStatName returns the raw stat name of the given sample. Labels are sorted, so the name of a given sample is always the same.
+func prometheusStatName(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	labelNames := make([]string, 0, len(labels))
+	for labelName := range labels {
+		labelNames = append(labelNames, labelName)
+	}
+	sort.Strings(labelNames)
+	labelStrs := make([]string, 0, len(labelNames))
+	for _, labelName := range labelNames {
+		labelStrs = append(labelStrs, labelName+`="`+prometheusLabelValueEscaper.Replace(labels[labelName])+`"`)
+	}
+	return name + "{" + strings.Join(labelStrs, ",") + "}"
+}
+
+// prometheusSystem creates the AstatsSystem from the node_exporter stats, or from the sum of the remap_stats bytes if the cache has no node_exporter network interfaces. It returns false if the remap_stats bytes were used.
+func prometheusSystem(stats map[string]interface{}) (AstatsSystem, bool) {
+	system := AstatsSystem{}
+
+	loads := []string{}
+	for _, period := range []string{"1", "5", "15"} {
+		load, _ := stats["node_load"+period].(float64)
+		loads = append(loads, strconv.FormatFloat(load, 'f', 2, 64))
+	}
+	system.ProcLoadavg = strings.Join(loads, " ") + " 0/0 0"
+	system.Interfaces = prometheusInterfaces(stats)
+	if astatsSetInterface(&system) {
+		return system, true
+	}
+	system.InfName = prometheusInfNameNoNodeStats
+	system.ProcNetDev = astatsProcNetDev(system.InfName, prometheusRemapBytes(stats))
+	return system, false
+}
+
+// prometheusRemapBytes returns the sum of the in and out bytes of all remap_stats.
+func prometheusRemapBytes(stats map[string]interface{}) AstatsInterface {
+	inf := AstatsInterface{}
+	for stat, val := range stats {
+		if !strings.HasPrefix(stat, prometheusRemapStatsPrefix) {
+			continue
+		}
+		name, _, err := prometheusParseStatName(stat)
+		if err != nil {
+			continue
+		}
+		statBytes, _ := val.(float64)
+		switch strings.TrimSuffix(strings.TrimPrefix(name, prometheusRemapStatsPrefix), "_total") {
+		case "in_bytes":
+			inf.BytesIn += uint64(statBytes)
+		case "out_bytes":
+			inf.BytesOut += uint64(statBytes)
+		}
+	}
+	return inf
+}
+
+// prometheusInterfaces returns the stats of each non-loopback node_exporter network interface.
+func prometheusInterfaces(stats map[string]interface{}) map[string]AstatsInterface {
+	const bitsPerByte = 8
+	const bitsPerMegabit = 1000000
+	infs := map[string]AstatsInterface{}
+	for stat, val := range stats {
+		if !strings.HasPrefix(stat, "node_network_transmit_bytes_total{") {
+			continue
+		}
+		_, labels, err := prometheusParseStatName(stat)
+		if err != nil || len(labels) != 1 {
+			continue
+		}
+		infName, ok := labels[prometheusDeviceLabel]
+		if !ok || infName == "lo" {
+			continue
+		}
+		outBytes, _ := val.(float64)
+		inBytes, _ := stats[prometheusDeviceStatName("node_network_receive_bytes_total", infName)].(float64)
+		speedBytes, _ := stats[prometheusDeviceStatName("node_network_speed_bytes", infName)].(float64)
+		infs[infName] = AstatsInterface{Speed: int(speedBytes * bitsPerByte / bitsPerMegabit), BytesIn: uint64(inBytes), BytesOut: uint64(outBytes)}
+	}
+	return infs
+}
+
+func prometheusDeviceStatName(name string, device string) string {
+	return prometheusStatName(name, map[string]string{prometheusDeviceLabel: device})
+}
+
+// prometheusParseStatName parses a raw stat name created by prometheusStatName into its metric name and labels.
+func prometheusParseStatName(stat string) (string, map[string]string, error) {
+	brace := strings.Index(stat, "{")
+	if brace < 0 {
+		return stat, nil, nil
+	}
+	labels, rest, err := prometheusParseLabels(stat[brace+1:])
+	if err != nil {
+		return "", nil, err
+	}
+	if rest != "" {
+		return "", nil, errors.New("malformed stat name '" + stat + "'")
+	}
+	return stat[:brace], labels, nil
+}
+
+func prometheusPrecompute(cache tc.CacheName, toData todata.TOData, rawStats map[string]interface{}, system AstatsSystem) PrecomputedData {
+	stats := map[tc.DeliveryServiceName]*AStat{}
+	precomputed := PrecomputedData{}
+	var err error
+	if precomputed.OutBytes, err = astatsOutBytes(system.ProcNetDev, system.InfName); err != nil {
+		precomputed.OutBytes = 0
+		log.Errorf("prometheusPrecompute %s handle precomputing outbytes '%v'\n", cache, err)
+	}
+
+	kbpsInMbps := int64(1000)
+	precomputed.MaxKbps = int64(system.InfSpeed) * kbpsInMbps
+
+	for stat, value := range rawStats {
+		var err error
+		stats, err = prometheusProcessStat(stats, toData, stat, value)
+		if err != nil && err != dsdata.ErrNotProcessedStat {
+			log.Infof("precomputing cache %v stat %v value %v error %v", cache, stat, value, err)
+			precomputed.Errors = append(precomputed.Errors, err)
+		}
+	}
+	precomputed.DeliveryServiceStats = stats
+	return precomputed
+}
+
+// prometheusProcessStat adds the given stat to its delivery service stats, if it's a remap_stats stat.
+func prometheusProcessStat(stats map[tc.DeliveryServiceName]*AStat, toData todata.TOData, stat string, value interface{}) (map[tc.DeliveryServiceName]*AStat, error) {
+	if !strings.HasPrefix(stat, prometheusRemapStatsPrefix) {
+		return stats, dsdata.ErrNotProcessedStat
+	}
+	name, labels, err := prometheusParseStatName(stat)
+	if err != nil {
+		return stats, err
+	}
+	fqdn, ok := labels[prometheusFQDNLabel]
+	if !ok {
+		return stats, fmt.Errorf("stat '%v' missing label '%v'", stat, prometheusFQDNLabel)
+	}
+
+	// the FQDN is `subsubdomain`.`subdomain`.`domain`. For a HTTP delivery service, `subsubdomain` will be the cache hostname; for a DNS delivery service, it will be `edge`. Then, `subdomain` is the delivery service regex.
+	fqdnParts := strings.Split(fqdn, ".")
+	if len(fqdnParts) < 3 {
+		return stats, fmt.Errorf("stat '%v' fqdn '%v' has no subdomains", stat, fqdn)
+	}
+	subsubdomain := fqdnParts[0]
+	subdomain := fqdnParts[1]
+	domain := strings.Join(fqdnParts[2:], ".")
+
+	ds, ok := toData.DeliveryServiceRegexes.DeliveryService(domain, subdomain, subsubdomain)
+	if !ok || ds == "" {
+		return stats, fmt.Errorf("no delivery service match for fqdn '%v' stat '%v'", fqdn, stat)
+	}
+
+	statName := strings.TrimSuffix(strings.TrimPrefix(name, prometheusRemapStatsPrefix), "_total")
+	dsStat, ok := stats[ds]
+	if !ok {
+		dsStat = &AStat{}
+		stats[ds] = dsStat
+	}
+	return stats, astatsAddCacheStat(dsStat, statName, value)
+}
@@ -0,0 +1,118 @@
+package cache
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+func TestPrometheusParsePrecompute(t *testing.T) {
+	dsNameFQDNs := getMockTODataDSNameDirectMatches()
+	toData := getMockTOData(dsNameFQDNs)
+
+	input := `# HELP node_load1 1m load average.
+# TYPE node_load1 gauge
+node_load1 1.25
+node_load5 0.5
+node_load15 0.05
+node_network_speed_bytes{device="lo"} 0
+node_network_transmit_bytes_total{device="lo"} 999999
+node_network_speed_bytes{device="eth0"} 1.25e+08
+node_network_receive_bytes_total{device="eth0"} 3456
+node_network_transmit_bytes_total{device="eth0"} 7890 1395066363000
+remap_stats_out_bytes_total{fqdn="ds0.example.invalid"} 100
+remap_stats_status_2xx_total{fqdn="ds0.example.invalid",method="GET"} 5
+remap_stats_in_bytes{fqdn="ds1.example.invalid"} 42
+remap_stats_status_5xx{ fqdn = "ds1.example.invalid" , } 3
+http_requests_total{code="200",path="/a \"b\""} 7
+`
+
+	err, stats, system := prometheusParse("cache0", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("prometheusParse expected nil error, actual: %v", err)
+	}
+	if v, ok := stats[`http_requests_total{code="200",path="/a \"b\""}`]; !ok || v != float64(7) {
+		t.Errorf("prometheusParse labelled stat expected 7, actual: %v %v", v, ok)
+	}
+	if system.InfName != "eth0" {
+		t.Errorf("prometheusParse InfName expected eth0, actual: %v", system.InfName)
+	}
+	if system.InfSpeed != 1000 {
+		t.Errorf("prometheusParse InfSpeed expected 1000, actual: %v", system.InfSpeed)
+	}
+	if !strings.HasPrefix(system.ProcLoadavg, "1.25 0.50 0.05 ") {
+		t.Errorf("prometheusParse ProcLoadavg expected '1.25 0.50 0.05 ...', actual: %v", system.ProcLoadavg)
+	}
+
+	prc := prometheusPrecompute(tc.CacheName("cache0"), toData, stats, system)
+	if len(prc.Errors) != 0 {
+		t.Fatalf("prometheusPrecompute Errors expected 0, actual: %+v", prc.Errors)
+	}
+	if prc.OutBytes != 7890 {
+		t.Errorf("prometheusPrecompute OutBytes expected 7890, actual: %v", prc.OutBytes)
+	}
+	if prc.MaxKbps != 1000000 {
+		t.Errorf("prometheusPrecompute MaxKbps expected 1000000, actual: %v", prc.MaxKbps)
+	}
+	if ds0, ok := prc.DeliveryServiceStats["ds0"]; !ok || ds0.OutBytes != 100 || ds0.Status2xx != 5 {
+		t.Errorf("prometheusPrecompute ds0 expected OutBytes 100 Status2xx 5, actual: %+v", ds0)
+	}
+	if ds1, ok := prc.DeliveryServiceStats["ds1"]; !ok || ds1.InBytes != 42 || ds1.Status5xx != 3 {
+		t.Errorf("prometheusPrecompute ds1 expected InBytes 42 Status5xx 3, actual: %+v", ds1)
+	}
+}
+
+func TestPrometheusParseNoNodeStats(t *testing.T) {
+	input := `remap_stats_in_bytes_total{fqdn="ds0.example.invalid"} 10
+remap_stats_out_bytes_total{fqdn="ds0.example.invalid"} 100
+remap_stats_out_bytes{fqdn="ds1.example.invalid"} 200
+remap_stats_status_2xx_total{fqdn="ds0.example.invalid"} 5
+`
+	err, stats, system := prometheusParse("cache0", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("prometheusParse expected nil error, actual: %v", err)
+	}
+	if system.InfName != prometheusInfNameNoNodeStats {
+		t.Errorf("prometheusParse InfName expected %v, actual: %v", prometheusInfNameNoNodeStats, system.InfName)
+	}
+	prc := prometheusPrecompute(tc.CacheName("cache0"), getMockTOData(nil), stats, system)
+	if prc.OutBytes != 300 {
+		t.Errorf("prometheusPrecompute OutBytes expected 300, actual: %v", prc.OutBytes)
+	}
+	if prc.MaxKbps != 0 {
+		t.Errorf("prometheusPrecompute MaxKbps expected 0, actual: %v", prc.MaxKbps)
+	}
+}
+
+func TestPrometheusParseMalformed(t *testing.T) {
+	for _, input := range []string{
+		"node_load1",
+		"node_load1 abc",
+		`node_network_speed_bytes{device="eth0} 1`,
+		`node_network_speed_bytes{device=eth0} 1`,
+	} {
+		if err, _, _ := prometheusParse("cache0", strings.NewReader(input)); err == nil {
+			t.Errorf("prometheusParse '%v' expected error, actual nil", input)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package cache
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// stats_type_stats_over_http is the Stats format produced by the stock `stats_over_http` plugin to Apache Traffic Server.
+//
+// Stats are of the form `{"global": {"name": value}}`, where values may be numbers, or numbers encoded as strings.
+//
+// Delivery Service stats are produced by the ATS `remap_stats` plugin, and are of the form:
+//   `"plugin.remap_stats.fully-qualified-domain-name.example.net.stat-name"`
+// Where `stat-name` is one of:
+//   `in_bytes`, `out_bytes`, `status_2xx`, `status_3xx`, `status_4xx`, `status_5xx`
+//
+// System stats are produced by the ATS `system_stats` plugin, and are of the form:
+//   `plugin.system_stats.loadavg.one` (and `five`, `fifteen`), the load average multiplied by 100
+//   `plugin.system_stats.net.interface-name.speed`, the interface speed in Mbps
+//   `plugin.system_stats.net.interface-name.statistics.rx_bytes` and `tx_bytes`
+// The monitored interface is the interface with the greatest speed, excluding loopback. If the cache has no `system_stats`, the ATS client bytes `proxy.process.http.user_agent_total_request_bytes` and `proxy.process.http.user_agent_total_response_bytes` are used as the interface bytes, with an unknown speed.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+
+	"github.com/json-iterator/go"
+)
+
+const StatsTypeStatsOverHTTP = "stats_over_http"
+
+func init() {
+	AddStatsType(StatsTypeStatsOverHTTP, statsOverHTTPParse, statsOverHTTPPrecompute)
+}
+
+// StatsOverHTTP is the stats JSON returned by the ATS stats_over_http plugin.
+type StatsOverHTTP struct {
+	Global map[string]interface{} `json:"global"`
+}
+
+// statsOverHTTPInfNameNoSystemStats is the interface name used when the cache has no system_stats interfaces.
+const statsOverHTTPInfNameNoSystemStats = "ats"
+
+const statsOverHTTPSystemNetPrefix = "plugin.system_stats.net."
+
+func statsOverHTTPParse(cache tc.CacheName, rdr io.Reader) (error, map[string]interface{}, AstatsSystem) {
+	if rdr == nil {
+		log.Warnln(string(cache) + " handle reader nil")
+		return errors.New("handler got nil reader"), nil, AstatsSystem{}
+	}
+
+	sh := StatsOverHTTP{}
+	json := jsoniter.ConfigFastest
+	if err := json.NewDecoder(rdr).Decode(&sh); err != nil {
+		return err, nil, AstatsSystem{}
+	}
+	if sh.Global == nil {
+		return errors.New("stats_over_http missing global object"), nil, AstatsSystem{}
+	}
+
+	// stats_over_http encodes most numbers as strings. Thresholds require float64, so convert everything numeric.
+	stats := make(map[string]interface{}, len(sh.Global))
+	for name, val := range sh.Global {
+		if str, ok := val.(string); ok {
+			if f, err := strconv.ParseFloat(str, 64); err == nil {
+				stats[name] = f
+				continue
+			}
+		}
+		stats[name] = val
+	}
+	return nil, stats, statsOverHTTPSystem(stats)
+}
+
+// statsOverHTTPSystem creates the AstatsSystem from the system_stats plugin stats, or from the ATS client bytes if the cache has no system_stats.
+func statsOverHTTPSystem(stats map[string]interface{}) AstatsSystem {
+	system := AstatsSystem{ProcLoadavg: statsOverHTTPLoadavg(stats), Interfaces: statsOverHTTPInterfaces(stats)}
+	if !astatsSetInterface(&system) {
+		inBytes, _ := stats["proxy.process.http.user_agent_total_request_bytes"].(float64)
+		outBytes, _ := stats["proxy.process.http.user_agent_total_response_bytes"].(float64)
+		system.InfName = statsOverHTTPInfNameNoSystemStats
+		system.ProcNetDev = astatsProcNetDev(system.InfName, AstatsInterface{BytesIn: uint64(inBytes), BytesOut: uint64(outBytes)})
+	}
+	return system
+}
+
+// statsOverHTTPInterfaces returns the stats of each non-loopback system_stats interface.
+func statsOverHTTPInterfaces(stats map[string]interface{}) map[string]AstatsInterface {
+	infs := map[string]AstatsInterface{}
+	for name, val := range stats {
+		if !strings.HasPrefix(name, statsOverHTTPSystemNetPrefix) || !strings.HasSuffix(name, ".statistics.tx_bytes") {
+			continue
+		}
+		infName := strings.TrimSuffix(strings.TrimPrefix(name, statsOverHTTPSystemNetPrefix), ".statistics.tx_bytes")
+		if infName == "lo" {
+			continue
+		}
+		outBytes, ok := val.(float64)
+		if !ok {
+			continue
+		}
+		infPrefix := statsOverHTTPSystemNetPrefix + infName + "."
+		speed, _ := stats[infPrefix+"speed"].(float64)
+		inBytes, _ := stats[infPrefix+"statistics.rx_bytes"].(float64)
+		infs[infName] = AstatsInterface{Speed: int(speed), BytesIn: uint64(inBytes), BytesOut: uint64(outBytes)}
+	}
+	return infs
+}
+
+// statsOverHTTPLoadavg returns the proc.loadavg string for the system_stats load averages, which are multiplied by 100. If the cache has no system_stats, the load averages are zero.
+func statsOverHTTPLoadavg(stats map[string]interface{}) string {
+	loads := []string{}
+	for _, period := range []string{"one", "five", "fifteen"} {
+		load, _ := stats["plugin.system_stats.loadavg."+period].(float64)
+		loads = append(loads, strconv.FormatFloat(load/100, 'f', 2, 64))
+	}
+	return strings.Join(loads, " ") + " 0/0 0"
+}
+
+func statsOverHTTPPrecompute(cache tc.CacheName, toData todata.TOData, rawStats map[string]interface{}, system AstatsSystem) PrecomputedData {
+	stats := map[tc.DeliveryServiceName]*AStat{}
+	precomputed := PrecomputedData{}
+	var err error
+	if precomputed.OutBytes, err = astatsOutBytes(system.ProcNetDev, system.InfName); err != nil {
+		precomputed.OutBytes = 0
+		log.Errorf("statsOverHTTPPrecompute %s handle precomputing outbytes '%v'\n", cache, err)
+	}
+
+	kbpsInMbps := int64(1000)
+	precomputed.MaxKbps = int64(system.InfSpeed) * kbpsInMbps
+
+	for stat, value := range rawStats {
+		var err error
+		stats, err = statsOverHTTPProcessStat(stats, toData, stat, value)
+		if err != nil && err != dsdata.ErrNotProcessedStat {
+			log.Infof("precomputing cache %v stat %v value %v error %v", cache, stat, value, err)
+			precomputed.Errors = append(precomputed.Errors, err)
+		}
+	}
+	precomputed.DeliveryServiceStats = stats
+	return precomputed
+}
+
+// statsOverHTTPProcessStat adds the given stat to its delivery service stats, if it's a remap_stats stat.
+func statsOverHTTPProcessStat(stats map[tc.DeliveryServiceName]*AStat, toData todata.TOData, stat string, value interface{}) (map[tc.DeliveryServiceName]*AStat, error) {
+	const remapStatsPrefix = "plugin.remap_stats."
+	if !strings.HasPrefix(stat, remapStatsPrefix) {
+		return stats, dsdata.ErrNotProcessedStat
+	}
+	statParts := strings.Split(stat[len(remapStatsPrefix):], ".")
+	if len(statParts) < 4 {
+		return stats, fmt.Errorf("stat has no remap_stats deliveryservice and name parts")
+	}
+
+	// the FQDN is `subsubdomain`.`subdomain`.`domain`. For a HTTP delivery service, `subsubdomain` will be the cache hostname; for a DNS delivery service, it will be `edge`. Then, `subdomain` is the delivery service regex.
+	subsubdomain := statParts[0]
+	subdomain := statParts[1]
+	domain := strings.Join(statParts[2:len(statParts)-1], ".")
+
+	ds, ok := toData.DeliveryServiceRegexes.DeliveryService(domain, subdomain, subsubdomain)
+	if !ok || ds == "" {
+		fqdn := fmt.Sprintf("%s.%s.%s", subsubdomain, subdomain, domain)
+		return stats, fmt.Errorf("no delivery service match for fqdn '%v' stat '%v'", fqdn, stat)
+	}
+
+	statName := statParts[len(statParts)-1]
+	dsStat, ok := stats[ds]
+	if !ok {
+		dsStat = &AStat{}
+		stats[ds] = dsStat
+	}
+	return stats, astatsAddCacheStat(dsStat, statName, value)
+}
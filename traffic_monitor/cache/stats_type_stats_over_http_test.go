@@ -0,0 +1,104 @@
+package cache
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+func TestStatsOverHTTPParsePrecompute(t *testing.T) {
+	dsNameFQDNs := getMockTODataDSNameDirectMatches()
+	toData := getMockTOData(dsNameFQDNs)
+
+	input := `{"global": {
+		"proxy.process.http.completed_requests": "1234",
+		"proxy.node.version.manager.short": "7.1.4",
+		"plugin.system_stats.loadavg.one": 125,
+		"plugin.system_stats.loadavg.five": 50,
+		"plugin.system_stats.loadavg.fifteen": 5,
+		"plugin.system_stats.net.lo.speed": 0,
+		"plugin.system_stats.net.lo.statistics.tx_bytes": 999999,
+		"plugin.system_stats.net.eth0.speed": 1000,
+		"plugin.system_stats.net.eth0.statistics.rx_bytes": 11,
+		"plugin.system_stats.net.eth0.statistics.tx_bytes": 22,
+		"plugin.system_stats.net.bond0.speed": 20000,
+		"plugin.system_stats.net.bond0.statistics.rx_bytes": "3456",
+		"plugin.system_stats.net.bond0.statistics.tx_bytes": "7890",
+		"plugin.remap_stats.ds0.example.invalid.out_bytes": "100",
+		"plugin.remap_stats.ds0.example.invalid.status_2xx": "5",
+		"plugin.remap_stats.ds1.example.invalid.in_bytes": "42",
+		"plugin.remap_stats.ds1.example.invalid.status_5xx": "3"
+	}}`
+
+	err, stats, system := statsOverHTTPParse("cache0", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("statsOverHTTPParse expected nil error, actual: %v", err)
+	}
+	if v, ok := stats["proxy.process.http.completed_requests"].(float64); !ok || v != 1234 {
+		t.Errorf("statsOverHTTPParse string number expected float64 1234, actual: %v %T", stats["proxy.process.http.completed_requests"], stats["proxy.process.http.completed_requests"])
+	}
+	if v := stats["proxy.node.version.manager.short"]; v != "7.1.4" {
+		t.Errorf("statsOverHTTPParse string expected '7.1.4', actual: %v", v)
+	}
+	if system.InfName != "bond0" {
+		t.Errorf("statsOverHTTPParse InfName expected bond0, actual: %v", system.InfName)
+	}
+	if system.InfSpeed != 20000 {
+		t.Errorf("statsOverHTTPParse InfSpeed expected 20000, actual: %v", system.InfSpeed)
+	}
+	if !strings.HasPrefix(system.ProcLoadavg, "1.25 0.50 0.05 ") {
+		t.Errorf("statsOverHTTPParse ProcLoadavg expected '1.25 0.50 0.05 ...', actual: %v", system.ProcLoadavg)
+	}
+
+	prc := statsOverHTTPPrecompute(tc.CacheName("cache0"), toData, stats, system)
+	if len(prc.Errors) != 0 {
+		t.Fatalf("statsOverHTTPPrecompute Errors expected 0, actual: %+v", prc.Errors)
+	}
+	if prc.OutBytes != 7890 {
+		t.Errorf("statsOverHTTPPrecompute OutBytes expected 7890, actual: %v", prc.OutBytes)
+	}
+	if prc.MaxKbps != 20000000 {
+		t.Errorf("statsOverHTTPPrecompute MaxKbps expected 20000000, actual: %v", prc.MaxKbps)
+	}
+	if ds0, ok := prc.DeliveryServiceStats["ds0"]; !ok || ds0.OutBytes != 100 || ds0.Status2xx != 5 {
+		t.Errorf("statsOverHTTPPrecompute ds0 expected OutBytes 100 Status2xx 5, actual: %+v", ds0)
+	}
+	if ds1, ok := prc.DeliveryServiceStats["ds1"]; !ok || ds1.InBytes != 42 || ds1.Status5xx != 3 {
+		t.Errorf("statsOverHTTPPrecompute ds1 expected InBytes 42 Status5xx 3, actual: %+v", ds1)
+	}
+}
+
+func TestStatsOverHTTPParseNoSystemStats(t *testing.T) {
+	input := `{"global": {
+		"proxy.process.http.user_agent_total_request_bytes": "1000",
+		"proxy.process.http.user_agent_total_response_bytes": "2000"
+	}}`
+	err, stats, system := statsOverHTTPParse("cache0", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("statsOverHTTPParse expected nil error, actual: %v", err)
+	}
+	prc := statsOverHTTPPrecompute(tc.CacheName("cache0"), getMockTOData(nil), stats, system)
+	if prc.OutBytes != 2000 {
+		t.Errorf("statsOverHTTPPrecompute OutBytes expected 2000, actual: %v", prc.OutBytes)
+	}
+}
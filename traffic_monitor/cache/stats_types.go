@@ -46,6 +46,13 @@ import (
 
 const DefaultStatsType = "astats"
 
+// SingleInterfaceStatsTypes are the stats types which only report the interface the cache is polled for, and so can't sum the interfaces of the health.polling.interfaces Parameter.
+var SingleInterfaceStatsTypes = map[string]struct{}{
+	"astats":         {},
+	"astats-dsnames": {},
+	StatsTypeNOOP:    {},
+}
+
 // CacheStatsTypeDecoder is a pair of functions registered for decoding a particular Stats type, for parsing stats, and creating precomputed data
 type StatsTypeDecoder struct {
 	Parse      StatsTypeParser
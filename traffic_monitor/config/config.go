@@ -20,7 +20,11 @@ package config
  */
 
 import (
+	"errors"
 	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/apache/trafficcontrol/lib/go-log"
@@ -46,35 +50,160 @@ const (
 	TMConfigBackupFile = "/opt/traffic_monitor/tmconfig.backup"
 )
 
+const (
+	// PeerCombineModeOptimistic marks a cache available if it's available locally or on any available peer.
+	PeerCombineModeOptimistic = "optimistic"
+	// PeerCombineModePessimistic uses only the local availability of caches.
+	PeerCombineModePessimistic = "pessimistic"
+	// PeerCombineModeQuorum marks a cache unavailable only if more than the PeerQuorumFraction of this and available peer monitors report it unavailable.
+	PeerCombineModeQuorum = "quorum"
+)
+
+const (
+	// NotifySinkTypeWebhook POSTs each notification as JSON to a URL.
+	NotifySinkTypeWebhook = "webhook"
+	// NotifySinkTypeEmail emails each notification through an SMTP relay, without authentication.
+	NotifySinkTypeEmail = "email"
+	// NotifySinkTypeSyslog writes each notification to the local syslog.
+	NotifySinkTypeSyslog = "syslog"
+	// NotifySinkTypeCommand runs a local command for each notification, with the notification JSON on stdin.
+	NotifySinkTypeCommand = "command"
+)
+
+// NotifySink is a destination of event notifications. Which fields are used depends on the Type.
+type NotifySink struct {
+	Type string `json:"type"`
+	// URL and Headers are used by webhook sinks.
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	// SMTPAddress, From, and To are used by email sinks.
+	SMTPAddress string   `json:"smtp_address"`
+	From        string   `json:"from"`
+	To          []string `json:"to"`
+	// Tag is used by syslog sinks. If empty, the program name is used.
+	Tag string `json:"tag"`
+	// Command and Args are used by command sinks.
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+// NotifyRoute sends the events which match it to its sinks. Empty match lists match all events.
+type NotifyRoute struct {
+	Sinks       []string `json:"sinks"`
+	CacheTypes  []string `json:"cache_types"`
+	CacheGroups []string `json:"cachegroups"`
+	CDNs        []string `json:"cdns"`
+	EventTypes  []string `json:"event_types"`
+}
+
+// DSHealthRuleDefault is the ds_health_rules key of the rule applied to delivery services without a rule of their own.
+const DSHealthRuleDefault = "*"
+
+// DSHealthRule is a set of error rate thresholds for a delivery service, computed from the status codes its caches report. Zero thresholds are not checked.
+type DSHealthRule struct {
+	// Max5xxRatio is the maximum fraction of responses which may be 5xx, from 0 to 1.
+	Max5xxRatio float64 `json:"max_5xx_ratio"`
+	// Max4xxRatio is the maximum fraction of responses which may be 4xx, from 0 to 1.
+	Max4xxRatio float64 `json:"max_4xx_ratio"`
+	// Max5xxPerSecond is the maximum rate of 5xx responses per second. This includes both origin failures and errors of the caches themselves, which the per-delivery service status counters don't distinguish.
+	Max5xxPerSecond float64 `json:"max_5xx_per_second"`
+	// MinTPS is the minimum transactions per second before the ratio thresholds are checked, so a handful of errors on an idle delivery service doesn't mark it unavailable.
+	MinTPS float64 `json:"min_tps"`
+	// DisableCacheGroups also checks the rule per cache group, disabling the delivery service in each cache group which breaches it.
+	DisableCacheGroups bool `json:"disable_cachegroups"`
+}
+
+// DSHealthRules is the error rate health rules of delivery services, keyed by delivery service name, or DSHealthRuleDefault.
+type DSHealthRules map[string]DSHealthRule
+
+// Get returns the rule for the given delivery service, or the default rule if it has none. Returns false if neither exists.
+func (r DSHealthRules) Get(ds string) (DSHealthRule, bool) {
+	if rule, ok := r[ds]; ok {
+		return rule, true
+	}
+	rule, ok := r[DSHealthRuleDefault]
+	return rule, ok
+}
+
 // Config is the configuration for the application. It includes myriad data, such as polling intervals and log locations.
 type Config struct {
-	CacheHealthPollingInterval   time.Duration `json:"-"`
-	CacheStatPollingInterval     time.Duration `json:"-"`
-	MonitorConfigPollingInterval time.Duration `json:"-"`
-	HTTPTimeout                  time.Duration `json:"-"`
-	PeerPollingInterval          time.Duration `json:"-"`
-	PeerOptimistic               bool          `json:"peer_optimistic"`
-	MaxEvents                    uint64        `json:"max_events"`
-	MaxStatHistory               uint64        `json:"max_stat_history"`
-	MaxHealthHistory             uint64        `json:"max_health_history"`
-	HealthFlushInterval          time.Duration `json:"-"`
-	StatFlushInterval            time.Duration `json:"-"`
-	StatBufferInterval           time.Duration `json:"-"`
-	LogLocationError             string        `json:"log_location_error"`
-	LogLocationWarning           string        `json:"log_location_warning"`
-	LogLocationInfo              string        `json:"log_location_info"`
-	LogLocationDebug             string        `json:"log_location_debug"`
-	LogLocationEvent             string        `json:"log_location_event"`
-	ServeReadTimeout             time.Duration `json:"-"`
-	ServeWriteTimeout            time.Duration `json:"-"`
-	HealthToStatRatio            uint64        `json:"health_to_stat_ratio"`
-	StaticFileDir                string        `json:"static_file_dir"`
-	CRConfigHistoryCount         uint64        `json:"crconfig_history_count"`
-	TrafficOpsMinRetryInterval   time.Duration `json:"-"`
-	TrafficOpsMaxRetryInterval   time.Duration `json:"-"`
-	CRConfigBackupFile           string        `json:"crconfig_backup_file"`
-	TMConfigBackupFile           string        `json:"tmconfig_backup_file"`
-	TrafficOpsDiskRetryMax       uint64        `json:"-"`
+	CacheHealthPollingInterval   time.Duration         `json:"-"`
+	CacheStatPollingInterval     time.Duration         `json:"-"`
+	MonitorConfigPollingInterval time.Duration         `json:"-"`
+	HTTPTimeout                  time.Duration         `json:"-"`
+	PeerPollingInterval          time.Duration         `json:"-"`
+	PeerOptimistic               bool                  `json:"peer_optimistic"`
+	PeerCombineMode              string                `json:"peer_combine_mode"`
+	PeerQuorumFraction           float64               `json:"peer_quorum_fraction"`
+	MaxEvents                    uint64                `json:"max_events"`
+	MaxStatHistory               uint64                `json:"max_stat_history"`
+	MaxHealthHistory             uint64                `json:"max_health_history"`
+	HealthFlushInterval          time.Duration         `json:"-"`
+	StatFlushInterval            time.Duration         `json:"-"`
+	StatBufferInterval           time.Duration         `json:"-"`
+	LogLocationError             string                `json:"log_location_error"`
+	LogLocationWarning           string                `json:"log_location_warning"`
+	LogLocationInfo              string                `json:"log_location_info"`
+	LogLocationDebug             string                `json:"log_location_debug"`
+	LogLocationEvent             string                `json:"log_location_event"`
+	ServeReadTimeout             time.Duration         `json:"-"`
+	ServeWriteTimeout            time.Duration         `json:"-"`
+	HealthToStatRatio            uint64                `json:"health_to_stat_ratio"`
+	StaticFileDir                string                `json:"static_file_dir"`
+	CRConfigHistoryCount         uint64                `json:"crconfig_history_count"`
+	TrafficOpsMinRetryInterval   time.Duration         `json:"-"`
+	TrafficOpsMaxRetryInterval   time.Duration         `json:"-"`
+	CRConfigBackupFile           string                `json:"crconfig_backup_file"`
+	TMConfigBackupFile           string                `json:"tmconfig_backup_file"`
+	TrafficOpsDiskRetryMax       uint64                `json:"-"`
+	MetricsMaxCaches             uint64                `json:"metrics_max_caches"`
+	MetricsMaxDeliveryServices   uint64                `json:"metrics_max_delivery_services"`
+	StateStreamMaxHistory        uint64                `json:"state_stream_max_history"`
+	HistoryDir                   string                `json:"history_dir"`
+	HistoryRetention             time.Duration         `json:"-"`
+	HistoryStatInterval          time.Duration         `json:"-"`
+	DSProbeURLs                  map[string]string     `json:"ds_probe_urls"`
+	DSProbeInterval              time.Duration         `json:"-"`
+	DSProbeMaxTTFB               time.Duration         `json:"-"`
+	DSProbeMaxPerCachePerSecond  float64               `json:"ds_probe_max_per_cache_per_second"`
+	DSProbeFailureThreshold      uint64                `json:"ds_probe_failure_threshold"`
+	DSHealthRules                DSHealthRules         `json:"ds_health_rules"`
+	RecordFile                   string                `json:"record_file"`
+	ReplayFile                   string                `json:"replay_file"`
+	ReplaySpeed                  float64               `json:"replay_speed"`
+	PollShardReplicas            int                   `json:"poll_shard_replicas"`
+	NotifySinks                  map[string]NotifySink `json:"notify_sinks"`
+	NotifyRoutes                 []NotifyRoute         `json:"notify_routes"`
+	NotifyDedupInterval          time.Duration         `json:"-"`
+	NotifyRateLimitPerMinute     uint64                `json:"notify_rate_limit_per_minute"`
+	InfraPollInterval            time.Duration         `json:"-"`
+	RouterStatsPath              string                `json:"router_stats_path"`
+	RouterMaxResponse            time.Duration         `json:"-"`
+	TrafficOpsMaxResponse        time.Duration         `json:"-"`
+	InfraFailureThreshold        uint64                `json:"infra_failure_threshold"`
+	CDNs                         []string              `json:"cdns"`
+	PollJitter                   float64               `json:"poll_jitter"`
+	PollFastRatio                float64               `json:"poll_fast_ratio"`
+	PollSlowRatio                float64               `json:"poll_slow_ratio"`
+	PollFastDuration             time.Duration         `json:"-"`
+	PollStableDuration           time.Duration         `json:"-"`
+	PollThresholdMargin          float64               `json:"poll_threshold_margin"`
+	PeerHTTPSPort                int                   `json:"peer_https_port"`
+	PeerCertFile                 string                `json:"peer_cert_file"`
+	PeerKeyFile                  string                `json:"peer_key_file"`
+	PeerCAFile                   string                `json:"peer_ca_file"`
+	PeerToken                    string                `json:"peer_token"`
+}
+
+// GetPeerCombineMode returns the PeerCombineMode, or if it's empty, the mode of PeerOptimistic.
+func (c Config) GetPeerCombineMode() string {
+	if c.PeerCombineMode != "" {
+		return c.PeerCombineMode
+	}
+	if c.PeerOptimistic {
+		return PeerCombineModeOptimistic
+	}
+	return PeerCombineModePessimistic
 }
 
 func (c Config) ErrorLog() log.LogLocation   { return log.LogLocation(c.LogLocationError) }
@@ -91,6 +220,8 @@ var DefaultConfig = Config{
 	HTTPTimeout:                  2 * time.Second,
 	PeerPollingInterval:          5 * time.Second,
 	PeerOptimistic:               true,
+	PeerCombineMode:              "",
+	PeerQuorumFraction:           0.5,
 	MaxEvents:                    200,
 	MaxStatHistory:               5,
 	MaxHealthHistory:             5,
@@ -112,6 +243,43 @@ var DefaultConfig = Config{
 	CRConfigBackupFile:           CRConfigBackupFile,
 	TMConfigBackupFile:           TMConfigBackupFile,
 	TrafficOpsDiskRetryMax:       2,
+	MetricsMaxCaches:             5000,
+	MetricsMaxDeliveryServices:   2000,
+	StateStreamMaxHistory:        1000,
+	HistoryDir:                   "",
+	HistoryRetention:             7 * 24 * time.Hour,
+	HistoryStatInterval:          60 * time.Second,
+	DSProbeURLs:                  map[string]string{},
+	DSProbeInterval:              30 * time.Second,
+	DSProbeMaxTTFB:               0,
+	DSProbeMaxPerCachePerSecond:  1,
+	DSProbeFailureThreshold:      2,
+	DSHealthRules:                DSHealthRules{},
+	RecordFile:                   "",
+	ReplayFile:                   "",
+	ReplaySpeed:                  1,
+	PollShardReplicas:            0,
+	NotifySinks:                  map[string]NotifySink{},
+	NotifyRoutes:                 []NotifyRoute{},
+	NotifyDedupInterval:          5 * time.Minute,
+	NotifyRateLimitPerMinute:     60,
+	InfraPollInterval:            10 * time.Second,
+	RouterStatsPath:              "/crs/stats",
+	RouterMaxResponse:            0,
+	TrafficOpsMaxResponse:        0,
+	InfraFailureThreshold:        2,
+	CDNs:                         []string{},
+	PollJitter:                   0,
+	PollFastRatio:                0,
+	PollSlowRatio:                0,
+	PollFastDuration:             time.Minute,
+	PollStableDuration:           5 * time.Minute,
+	PollThresholdMargin:          0.1,
+	PeerHTTPSPort:                0,
+	PeerCertFile:                 "",
+	PeerKeyFile:                  "",
+	PeerCAFile:                   "",
+	PeerToken:                    "",
 }
 
 // MarshalJSON marshals custom millisecond durations. Aliasing inspired by http://choly.ca/post/go-json-marshalling/
@@ -130,6 +298,16 @@ func (c *Config) MarshalJSON() ([]byte, error) {
 		StatBufferIntervalMs           uint64 `json:"stat_buffer_interval_ms"`
 		ServeReadTimeoutMs             uint64 `json:"serve_read_timeout_ms"`
 		ServeWriteTimeoutMs            uint64 `json:"serve_write_timeout_ms"`
+		HistoryRetentionHours          uint64 `json:"history_retention_hours"`
+		HistoryStatIntervalMs          uint64 `json:"history_stat_interval_ms"`
+		DSProbeIntervalMs              uint64 `json:"ds_probe_interval_ms"`
+		DSProbeMaxTTFBMs               uint64 `json:"ds_probe_max_ttfb_ms"`
+		NotifyDedupIntervalMs          uint64 `json:"notify_dedup_interval_ms"`
+		InfraPollIntervalMs            uint64 `json:"infra_poll_interval_ms"`
+		RouterMaxResponseMs            uint64 `json:"router_max_response_ms"`
+		TrafficOpsMaxResponseMs        uint64 `json:"traffic_ops_max_response_ms"`
+		PollFastDurationMs             uint64 `json:"poll_fast_duration_ms"`
+		PollStableDurationMs           uint64 `json:"poll_stable_duration_ms"`
 		*Alias
 	}{
 		CacheHealthPollingIntervalMs:   uint64(c.CacheHealthPollingInterval / time.Millisecond),
@@ -141,6 +319,16 @@ func (c *Config) MarshalJSON() ([]byte, error) {
 		HealthFlushIntervalMs:          uint64(c.HealthFlushInterval / time.Millisecond),
 		StatFlushIntervalMs:            uint64(c.StatFlushInterval / time.Millisecond),
 		StatBufferIntervalMs:           uint64(c.StatBufferInterval / time.Millisecond),
+		HistoryRetentionHours:          uint64(c.HistoryRetention / time.Hour),
+		HistoryStatIntervalMs:          uint64(c.HistoryStatInterval / time.Millisecond),
+		DSProbeIntervalMs:              uint64(c.DSProbeInterval / time.Millisecond),
+		DSProbeMaxTTFBMs:               uint64(c.DSProbeMaxTTFB / time.Millisecond),
+		NotifyDedupIntervalMs:          uint64(c.NotifyDedupInterval / time.Millisecond),
+		InfraPollIntervalMs:            uint64(c.InfraPollInterval / time.Millisecond),
+		RouterMaxResponseMs:            uint64(c.RouterMaxResponse / time.Millisecond),
+		TrafficOpsMaxResponseMs:        uint64(c.TrafficOpsMaxResponse / time.Millisecond),
+		PollFastDurationMs:             uint64(c.PollFastDuration / time.Millisecond),
+		PollStableDurationMs:           uint64(c.PollStableDuration / time.Millisecond),
 		Alias:                          (*Alias)(c),
 	})
 }
@@ -165,6 +353,16 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 		TrafficOpsDiskRetryMax         *uint64 `json:"traffic_ops_disk_retry_max"`
 		CRConfigBackupFile             *string `json:"crconfig_backup_file"`
 		TMConfigBackupFile             *string `json:"tmconfig_backup_file"`
+		HistoryRetentionHours          *uint64 `json:"history_retention_hours"`
+		HistoryStatIntervalMs          *uint64 `json:"history_stat_interval_ms"`
+		DSProbeIntervalMs              *uint64 `json:"ds_probe_interval_ms"`
+		DSProbeMaxTTFBMs               *uint64 `json:"ds_probe_max_ttfb_ms"`
+		NotifyDedupIntervalMs          *uint64 `json:"notify_dedup_interval_ms"`
+		InfraPollIntervalMs            *uint64 `json:"infra_poll_interval_ms"`
+		RouterMaxResponseMs            *uint64 `json:"router_max_response_ms"`
+		TrafficOpsMaxResponseMs        *uint64 `json:"traffic_ops_max_response_ms"`
+		PollFastDurationMs             *uint64 `json:"poll_fast_duration_ms"`
+		PollStableDurationMs           *uint64 `json:"poll_stable_duration_ms"`
 		*Alias
 	}{
 		Alias: (*Alias)(c),
@@ -222,6 +420,149 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	if aux.TMConfigBackupFile != nil {
 		c.TMConfigBackupFile = *aux.TMConfigBackupFile
 	}
+	if aux.HistoryRetentionHours != nil {
+		c.HistoryRetention = time.Duration(*aux.HistoryRetentionHours) * time.Hour
+	}
+	if aux.HistoryStatIntervalMs != nil {
+		c.HistoryStatInterval = time.Duration(*aux.HistoryStatIntervalMs) * time.Millisecond
+	}
+	if aux.DSProbeIntervalMs != nil {
+		c.DSProbeInterval = time.Duration(*aux.DSProbeIntervalMs) * time.Millisecond
+	}
+	if aux.DSProbeMaxTTFBMs != nil {
+		c.DSProbeMaxTTFB = time.Duration(*aux.DSProbeMaxTTFBMs) * time.Millisecond
+	}
+	if aux.NotifyDedupIntervalMs != nil {
+		c.NotifyDedupInterval = time.Duration(*aux.NotifyDedupIntervalMs) * time.Millisecond
+	}
+	if aux.InfraPollIntervalMs != nil {
+		c.InfraPollInterval = time.Duration(*aux.InfraPollIntervalMs) * time.Millisecond
+	}
+	if aux.RouterMaxResponseMs != nil {
+		c.RouterMaxResponse = time.Duration(*aux.RouterMaxResponseMs) * time.Millisecond
+	}
+	if aux.TrafficOpsMaxResponseMs != nil {
+		c.TrafficOpsMaxResponse = time.Duration(*aux.TrafficOpsMaxResponseMs) * time.Millisecond
+	}
+	if aux.PollFastDurationMs != nil {
+		c.PollFastDuration = time.Duration(*aux.PollFastDurationMs) * time.Millisecond
+	}
+	if aux.PollStableDurationMs != nil {
+		c.PollStableDuration = time.Duration(*aux.PollStableDurationMs) * time.Millisecond
+	}
+	switch c.PeerCombineMode {
+	case "", PeerCombineModeOptimistic, PeerCombineModePessimistic, PeerCombineModeQuorum:
+	default:
+		return errors.New("invalid peer_combine_mode '" + c.PeerCombineMode + "'")
+	}
+	if c.PeerQuorumFraction < 0 || c.PeerQuorumFraction >= 1 {
+		return errors.New("invalid peer_quorum_fraction, must be at least 0 and less than 1")
+	}
+	for ds, probeURL := range c.DSProbeURLs {
+		if u, err := url.Parse(probeURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("invalid ds_probe_urls URL '" + probeURL + "' for delivery service '" + ds + "', must be an absolute http or https URL")
+		}
+	}
+	if (c.PeerCertFile == "") != (c.PeerKeyFile == "") {
+		return errors.New("peer_cert_file and peer_key_file must both be set, or neither")
+	}
+	if len(c.DSProbeURLs) > 0 && c.DSProbeMaxPerCachePerSecond <= 0 {
+		return errors.New("invalid ds_probe_max_per_cache_per_second, must be greater than 0")
+	}
+	if c.RecordFile != "" && c.ReplayFile != "" {
+		return errors.New("record_file and replay_file must not both be set")
+	}
+	if c.ReplaySpeed < 0 {
+		return errors.New("invalid replay_speed, must not be negative")
+	}
+	if len(c.CDNs) > 0 && (c.RecordFile != "" || c.ReplayFile != "") {
+		return errors.New("cdns must not be set when recording or replaying")
+	}
+	cdns := map[string]struct{}{}
+	for _, cdn := range c.CDNs {
+		if cdn == "" || strings.ContainsAny(cdn, "/?") {
+			return errors.New("invalid cdns CDN '" + cdn + "', must be a non-empty name without '/' or '?'")
+		}
+		if _, ok := cdns[cdn]; ok {
+			return errors.New("invalid cdns, CDN '" + cdn + "' is duplicated")
+		}
+		cdns[cdn] = struct{}{}
+	}
+	if c.PollJitter < 0 || c.PollJitter >= 1 {
+		return errors.New("invalid poll_jitter, must be at least 0 and less than 1")
+	}
+	if c.PollFastRatio < 0 || c.PollFastRatio > 1 {
+		return errors.New("invalid poll_fast_ratio, must be between 0 and 1")
+	}
+	if c.PollSlowRatio != 0 && c.PollSlowRatio < 1 {
+		return errors.New("invalid poll_slow_ratio, must be 0 or at least 1")
+	}
+	if c.PollThresholdMargin < 0 {
+		return errors.New("invalid poll_threshold_margin, must not be negative")
+	}
+	if c.PollShardReplicas < 0 {
+		return errors.New("invalid poll_shard_replicas, must not be negative")
+	}
+	if !strings.HasPrefix(c.RouterStatsPath, "/") {
+		return errors.New("invalid router_stats_path, must start with '/'")
+	}
+	for name, sink := range c.NotifySinks {
+		if err := validateNotifySink(sink); err != nil {
+			return errors.New("invalid notify_sinks sink '" + name + "': " + err.Error())
+		}
+	}
+	for _, route := range c.NotifyRoutes {
+		if len(route.Sinks) == 0 {
+			return errors.New("invalid notify_routes route, must have at least one sink")
+		}
+		for _, sink := range route.Sinks {
+			if _, ok := c.NotifySinks[sink]; !ok {
+				return errors.New("invalid notify_routes sink '" + sink + "', not in notify_sinks")
+			}
+		}
+	}
+	for ds, rule := range c.DSHealthRules {
+		if rule.Max5xxRatio < 0 || rule.Max5xxRatio > 1 || rule.Max4xxRatio < 0 || rule.Max4xxRatio > 1 {
+			return errors.New("invalid ds_health_rules ratio for delivery service '" + ds + "', must be between 0 and 1")
+		}
+		if rule.Max5xxPerSecond < 0 || rule.MinTPS < 0 {
+			return errors.New("invalid ds_health_rules rate for delivery service '" + ds + "', must not be negative")
+		}
+	}
+	return nil
+}
+
+// ForCDN returns the config of monitoring the given additional CDN from cdns. Its backup files are suffixed with the CDN name, its history is stored in a subdirectory of the CDN name, and its polling isn't sharded, because this monitor isn't a peer of the CDN's monitors.
+func (c Config) ForCDN(cdn string) Config {
+	c.CRConfigBackupFile += "." + cdn
+	c.TMConfigBackupFile += "." + cdn
+	if c.HistoryDir != "" {
+		c.HistoryDir = filepath.Join(c.HistoryDir, cdn)
+	}
+	c.PollShardReplicas = 0
+	c.CDNs = nil
+	return c
+}
+
+// validateNotifySink returns an error if the given sink is missing the fields its type requires.
+func validateNotifySink(sink NotifySink) error {
+	switch sink.Type {
+	case NotifySinkTypeWebhook:
+		if u, err := url.Parse(sink.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.New("url must be an absolute http or https URL")
+		}
+	case NotifySinkTypeEmail:
+		if sink.SMTPAddress == "" || sink.From == "" || len(sink.To) == 0 {
+			return errors.New("smtp_address, from, and to must be set")
+		}
+	case NotifySinkTypeSyslog:
+	case NotifySinkTypeCommand:
+		if sink.Command == "" {
+			return errors.New("command must be set")
+		}
+	default:
+		return errors.New("unknown type '" + sink.Type + "'")
+	}
 	return nil
 }
 
@@ -23,17 +23,48 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/shard"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 )
 
-func srvCacheStats(params url.Values, errorCount threadsafe.Uint, path string, toData todata.TODataThreadsafe, statResultHistory threadsafe.ResultStatHistory, statInfoHistory threadsafe.ResultInfoHistory, monitorConfig threadsafe.TrafficMonitorConfigMap, combinedStates peer.CRStatesThreadsafe, statMaxKbpses threadsafe.CacheKbpses) ([]byte, int) {
+func srvCacheStats(params url.Values, errorCount threadsafe.Uint, path string, toData todata.TODataThreadsafe, statResultHistory threadsafe.ResultStatHistory, statInfoHistory threadsafe.ResultInfoHistory, monitorConfig threadsafe.TrafficMonitorConfigMap, combinedStates peer.CRStatesThreadsafe, statMaxKbpses threadsafe.CacheKbpses, shards shard.Threadsafe, peerStats peer.StatsThreadsafe) ([]byte, int) {
 	filter, err := NewCacheStatFilter(path, params, toData.Get().ServerTypes)
 	if err != nil {
 		HandleErr(errorCount, path, err)
 		return []byte(err.Error()), http.StatusBadRequest
 	}
-	bytes, err := threadsafe.StatsMarshall(statResultHistory, statInfoHistory.Get(), combinedStates.Get(), monitorConfig.Get(), statMaxKbpses.Get(), filter, params)
+	sh := shards.Get()
+	peerCaches := map[tc.CacheName]map[string][]cache.ResultStatVal(nil)
+	if _, local := params["local"]; !local {
+		peerCaches = shardPeerCacheStats(sh, peerStats.Get())
+	}
+	bytes, err := threadsafe.StatsMarshall(statResultHistory, statInfoHistory.Get(), combinedStates.Get(), monitorConfig.Get(), statMaxKbpses.Get(), filter, params, sh, peerCaches)
 	return WrapErrCode(errorCount, path, bytes, err)
 }
+
+// shardPeerCacheStats returns the stats of the caches this monitor doesn't poll, from the first of each cache's owners with stats. If polling isn't sharded, no stats are returned.
+func shardPeerCacheStats(sh shard.Shard, peerStats map[tc.TrafficMonitorName]cache.Stats) map[tc.CacheName]map[string][]cache.ResultStatVal {
+	caches := map[tc.CacheName]map[string][]cache.ResultStatVal{}
+	if !sh.Enabled() {
+		return caches
+	}
+	for _, stats := range peerStats {
+		for cacheName := range stats.Caches {
+			if _, ok := caches[cacheName]; ok || sh.Polls(cacheName) {
+				continue
+			}
+			// peers may still have stats of caches they polled before the shard changed, so prefer the current owners
+			for _, owner := range sh.Owners(cacheName) {
+				if ownerStats, ok := peerStats[owner].Caches[cacheName]; ok {
+					caches[cacheName] = ownerStats
+					break
+				}
+			}
+		}
+	}
+	return caches
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/apache/trafficcontrol/traffic_monitor/cache"
 	"github.com/apache/trafficcontrol/traffic_monitor/ds"
 	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
+	"github.com/apache/trafficcontrol/traffic_monitor/override"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
@@ -55,6 +56,8 @@ type CacheStatus struct {
 	BandwidthKbps          *float64 `json:"bandwidth_kbps,omitempty"`
 	BandwidthCapacityKbps  *float64 `json:"bandwidth_capacity_kbps,omitempty"`
 	ConnectionCount        *int64   `json:"connection_count,omitempty"`
+	// Override is the active override of the cache or its cachegroup, if any.
+	Override *override.Override `json:"override,omitempty"`
 }
 
 func srvAPICacheStates(
@@ -68,9 +71,25 @@ func srvAPICacheStates(
 	localCacheStatus threadsafe.CacheAvailableStatus,
 	statMaxKbpses threadsafe.CacheKbpses,
 	monitorConfig threadsafe.TrafficMonitorConfigMap,
+	overrides override.OverridesThreadsafe,
 ) ([]byte, error) {
+	toDataCopy := toData.Get()
+	statii := createCacheStatuses(toDataCopy.ServerTypes, statInfoHistory.Get(), statResultHistory, healthHistory.Get(), lastHealthDurations.Get(), localStates.Get().Caches, lastStats.Get(), localCacheStatus, statMaxKbpses, monitorConfig.Get().TrafficServer)
+	addCacheStatusOverrides(statii, overrides.Get(), toDataCopy.ServerCachegroups, time.Now())
 	json := jsoniter.ConfigFastest
-	return json.Marshal(createCacheStatuses(toData.Get().ServerTypes, statInfoHistory.Get(), statResultHistory, healthHistory.Get(), lastHealthDurations.Get(), localStates.Get().Caches, lastStats.Get(), localCacheStatus, statMaxKbpses, monitorConfig.Get().TrafficServer))
+	return json.Marshal(statii)
+}
+
+// addCacheStatusOverrides sets the override of each cache status with an active override at the given time.
+func addCacheStatusOverrides(statii map[tc.CacheName]CacheStatus, overrides override.Overrides, cacheGroups map[tc.CacheName]tc.CacheGroupName, now time.Time) {
+	for cacheName, status := range statii {
+		ov, ok := overrides.ForCache(cacheName, cacheGroups[cacheName], now)
+		if !ok {
+			continue
+		}
+		status.Override = &ov
+		statii[cacheName] = status
+	}
 }
 
 func createCacheStatuses(
@@ -84,6 +84,7 @@ func (f *CacheStatFilter) WithinStatHistoryMax(n int) bool {
 // If `stats` is empty, all stats are returned.
 // If `wildcard` is empty, `stats` is considered exact.
 // If `type` is empty, all cache types are returned.
+// The `local` parameter isn't a filter, but is valid; it returns only the stats of caches polled by this monitor, when polling is sharded.
 func NewCacheStatFilter(path string, params url.Values, cacheTypes map[tc.CacheName]tc.CacheType) (cache.Filter, error) {
 	validParams := map[string]struct{}{
 		"hc":       struct{}{},
@@ -92,6 +93,7 @@ func NewCacheStatFilter(path string, params url.Values, cacheTypes map[tc.CacheN
 		"type":     struct{}{},
 		"hosts":    struct{}{},
 		"cache":    struct{}{},
+		"local":    struct{}{},
 	}
 	if len(params) > len(validParams) {
 		return nil, fmt.Errorf("invalid query parameters")
@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package datareq
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+
+	"github.com/json-iterator/go"
+)
+
+// CDNPathPrefix prefixes the paths of the endpoints of a monitored CDN, followed by the CDN name, for example /cdn/my-cdn/publish/CrStates.
+const CDNPathPrefix = "/cdn/"
+
+// CDNParam is the query parameter naming the CDN of a request to an endpoint without a CDN path prefix.
+const CDNParam = "cdn"
+
+// CDNEndpoints are the endpoints of a monitored CDN, as returned by MakeDispatchMap.
+type CDNEndpoints struct {
+	// CDN returns the name of the CDN. It may change, because the CDN of this monitor is only known after logging in to Traffic Ops.
+	CDN       func() tc.CDNName
+	Endpoints map[string]http.HandlerFunc
+}
+
+// MakeCDNDispatchMap returns the map of paths to http.HandlerFuncs for dispatching the endpoints of several CDNs. The first CDN is the CDN of this monitor, whose endpoints are served at their usual paths, unless the request has the CDNParam parameter naming another CDN. The endpoints of every CDN are also served under CDNPathPrefix and the CDN name.
+func MakeCDNDispatchMap(cdns []CDNEndpoints, errorCount threadsafe.Uint) map[string]http.HandlerFunc {
+	muxes := make([]*http.ServeMux, len(cdns))
+	for i, cdn := range cdns {
+		muxes[i] = http.NewServeMux()
+		for path, f := range cdn.Endpoints {
+			muxes[i].HandleFunc(path, f)
+		}
+	}
+
+	// getMux returns the mux of the named CDN, or nil if it isn't monitored.
+	getMux := func(name string) *http.ServeMux {
+		for i, cdn := range cdns {
+			if string(cdn.CDN()) == name {
+				return muxes[i]
+			}
+		}
+		return nil
+	}
+
+	dispatchMap := map[string]http.HandlerFunc{}
+	if len(cdns) == 0 {
+		return dispatchMap
+	}
+	for path, f := range cdns[0].Endpoints {
+		dispatchMap[path] = scopeCDNParam(f, getMux)
+	}
+	dispatchMap[CDNPathPrefix] = func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, CDNPathPrefix)
+		path := "/"
+		if i := strings.Index(name, "/"); i >= 0 {
+			name, path = name[:i], name[i:]
+		}
+		mux := getMux(name)
+		if mux == nil {
+			http.NotFound(w, r)
+			return
+		}
+		r2 := new(http.Request)
+		*r2 = *r
+		u := *r.URL
+		u.Path = path
+		u.RawPath = ""
+		r2.URL = &u
+		mux.ServeHTTP(w, r2)
+	}
+	dispatchMap["/api/cdns"] = WrapErr(errorCount, func() ([]byte, error) {
+		return srvAPICDNs(cdns)
+	}, ContentTypeJSON)
+	return addTrailingSlashEndpoints(dispatchMap)
+}
+
+// scopeCDNParam wraps the given handler of the CDN of this monitor, serving requests whose CDNParam names another CDN with that CDN's endpoints instead. The CDNParam is removed, so endpoints which validate their parameters don't reject it.
+func scopeCDNParam(f http.HandlerFunc, getMux func(name string) *http.ServeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if _, ok := query[CDNParam]; !ok {
+			f(w, r)
+			return
+		}
+		name := query.Get(CDNParam)
+		query.Del(CDNParam)
+		r2 := new(http.Request)
+		*r2 = *r
+		u := *r.URL
+		u.RawQuery = query.Encode()
+		r2.URL = &u
+
+		mux := getMux(name)
+		if mux == nil {
+			http.NotFound(w, r2)
+			return
+		}
+		mux.ServeHTTP(w, r2)
+	}
+}
+
+func srvAPICDNs(cdns []CDNEndpoints) ([]byte, error) {
+	names := make([]tc.CDNName, 0, len(cdns))
+	for _, cdn := range cdns {
+		names = append(names, cdn.CDN())
+	}
+	json := jsoniter.ConfigFastest
+	return json.Marshal(names)
+}
@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package datareq
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+)
+
+func TestMakeCDNDispatchMap(t *testing.T) {
+	// cdnEndpoints returns endpoints of the given CDN which respond with the CDN name, the path, and the query.
+	cdnEndpoints := func(name tc.CDNName) CDNEndpoints {
+		f := func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(string(name) + " " + r.URL.Path + " " + r.URL.RawQuery))
+		}
+		return CDNEndpoints{
+			CDN:       func() tc.CDNName { return name },
+			Endpoints: addTrailingSlashEndpoints(map[string]http.HandlerFunc{"/publish/CrStates": f}),
+		}
+	}
+	dispatchMap := MakeCDNDispatchMap([]CDNEndpoints{cdnEndpoints("cdn0"), cdnEndpoints("cdn1")}, threadsafe.NewUint())
+	mux := http.NewServeMux()
+	for path, f := range dispatchMap {
+		mux.HandleFunc(path, f)
+	}
+
+	tests := []struct {
+		target       string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/publish/CrStates?raw", http.StatusOK, "cdn0 /publish/CrStates raw"},
+		{"/publish/CrStates?cdn=cdn0&raw", http.StatusOK, "cdn0 /publish/CrStates raw="},
+		{"/publish/CrStates?cdn=cdn1", http.StatusOK, "cdn1 /publish/CrStates "},
+		{"/publish/CrStates/foo?cdn=cdn1", http.StatusOK, "cdn1 /publish/CrStates/foo "},
+		{"/publish/CrStates?cdn=nonexistent", http.StatusNotFound, ""},
+		{"/cdn/cdn0/publish/CrStates", http.StatusOK, "cdn0 /publish/CrStates "},
+		{"/cdn/cdn1/publish/CrStates/foo?raw", http.StatusOK, "cdn1 /publish/CrStates/foo raw"},
+		{"/cdn/cdn1/publish/Nonexistent", http.StatusNotFound, ""},
+		{"/cdn/nonexistent/publish/CrStates", http.StatusNotFound, ""},
+		{"/api/cdns", http.StatusOK, `["cdn0","cdn1"]`},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.target, nil))
+		if w.Code != test.expectedCode {
+			t.Errorf("GET %v expected code %v, actual %v", test.target, test.expectedCode, w.Code)
+			continue
+		}
+		if test.expectedCode == http.StatusOK && w.Body.String() != test.expectedBody {
+			t.Errorf("GET %v expected body '%v', actual '%v'", test.target, test.expectedBody, w.Body.String())
+		}
+	}
+}
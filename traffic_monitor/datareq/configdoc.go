@@ -31,6 +31,20 @@ func srvConfigDoc(opsConfig threadsafe.OpsConfig) ([]byte, error) {
 	if opsConfigCopy.Password != "" {
 		opsConfigCopy.Password = "*****"
 	}
+	opsConfigCopy.APITokens = maskTokens(opsConfigCopy.APITokens)
+	opsConfigCopy.AdminTokens = maskTokens(opsConfigCopy.AdminTokens)
 	json := jsoniter.ConfigFastest
 	return json.Marshal(opsConfigCopy)
 }
+
+// maskTokens returns a mask of each of the given tokens, so callers can see how many exist.
+func maskTokens(tokens []string) []string {
+	if len(tokens) == 0 {
+		return tokens
+	}
+	masked := make([]string, len(tokens))
+	for i := range masked {
+		masked[i] = "*****"
+	}
+	return masked
+}
@@ -23,20 +23,57 @@ import (
 	"net/url"
 
 	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/override"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
+
+	"github.com/json-iterator/go"
 )
 
-func srvTRState(params url.Values, localStates peer.CRStatesThreadsafe, combinedStates peer.CRStatesThreadsafe) ([]byte, error) {
+func srvTRState(params url.Values, localStates peer.CRStatesThreadsafe, combinedStates peer.CRStatesThreadsafe, overrides override.OverridesThreadsafe, infra probe.InfraResultsThreadsafe) ([]byte, error) {
 	if _, raw := params["raw"]; raw {
-		return srvTRStateSelf(localStates)
+		return srvTRStateSelf(localStates, overrides)
+	}
+	return srvTRStateDerived(combinedStates, infra)
+}
+
+// InfraCRStates is the combined CRStates, with the availability of the CDN's Traffic Routers and Traffic Ops.
+type InfraCRStates struct {
+	tc.CRStates
+	Routers    map[string]InfraAvailable `json:"routers"`
+	TrafficOps InfraAvailable            `json:"trafficOps"`
+}
+
+// InfraAvailable is the availability of a Traffic Router or Traffic Ops. Unlike caches, these are polled by hostname, so there's no availability per address family.
+type InfraAvailable struct {
+	IsAvailable bool `json:"isAvailable"`
+}
+
+// srvTRStateDerived returns the combined states. If Traffic Routers and Traffic Ops are polled, their availability is included.
+func srvTRStateDerived(combinedStates peer.CRStatesThreadsafe, infra probe.InfraResultsThreadsafe) ([]byte, error) {
+	infraResults := infra.Get()
+	if infraResults.TrafficOps == nil {
+		return tc.CRStatesMarshall(combinedStates.Get())
 	}
-	return srvTRStateDerived(combinedStates)
+	json := jsoniter.ConfigFastest
+	return json.Marshal(newInfraCRStates(combinedStates.Get(), infraResults))
 }
 
-func srvTRStateDerived(combinedStates peer.CRStatesThreadsafe) ([]byte, error) {
-	return tc.CRStatesMarshall(combinedStates.Get())
+// newInfraCRStates returns the given states with the availability of the given Traffic Routers and Traffic Ops, which must have been polled.
+func newInfraCRStates(crStates tc.CRStates, infraResults probe.InfraResults) InfraCRStates {
+	states := InfraCRStates{
+		CRStates:   crStates,
+		Routers:    make(map[string]InfraAvailable, len(infraResults.Routers)),
+		TrafficOps: InfraAvailable{IsAvailable: infraResults.TrafficOps.Available},
+	}
+	for name, result := range infraResults.Routers {
+		states.Routers[name] = InfraAvailable{IsAvailable: result.Available}
+	}
+	return states
 }
 
-func srvTRStateSelf(localStates peer.CRStatesThreadsafe) ([]byte, error) {
-	return tc.CRStatesMarshall(localStates.Get())
+// srvTRStateSelf returns the local states, with the overrides to replicate to peers.
+func srvTRStateSelf(localStates peer.CRStatesThreadsafe, overrides override.OverridesThreadsafe) ([]byte, error) {
+	json := jsoniter.ConfigFastest
+	return json.Marshal(peer.RawCRStates{CRStates: localStates.Get(), Overrides: overrides.Get()})
 }
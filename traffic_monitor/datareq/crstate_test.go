@@ -0,0 +1,54 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package datareq
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
+)
+
+func TestNewInfraCRStates(t *testing.T) {
+	infraResults := probe.InfraResults{
+		Routers:    map[string]probe.Result{"tr0": {Available: true}, "tr1": {Available: false}},
+		TrafficOps: &probe.Result{Available: true},
+	}
+	bts, err := json.Marshal(newInfraCRStates(tc.NewCRStates(), infraResults))
+	if err != nil {
+		t.Fatalf("marshalling InfraCRStates error expected nil, actual: %v", err)
+	}
+
+	states := map[string]json.RawMessage{}
+	if err := json.Unmarshal(bts, &states); err != nil {
+		t.Fatalf("unmarshalling InfraCRStates: %v", err)
+	}
+	// Traffic Routers and Traffic Ops are polled by hostname, so they must not have the per address family fields of caches.
+	expected := map[string]string{
+		"routers":    `{"tr0":{"isAvailable":true},"tr1":{"isAvailable":false}}`,
+		"trafficOps": `{"isAvailable":true}`,
+	}
+	for key, expectedJSON := range expected {
+		if actual := string(states[key]); actual != expectedJSON {
+			t.Errorf("InfraCRStates expected %v '%v', actual '%v'", key, expectedJSON, actual)
+		}
+	}
+}
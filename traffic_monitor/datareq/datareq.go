@@ -30,9 +30,17 @@ import (
 	"unicode"
 
 	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
 	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/override"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/persist"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
+	"github.com/apache/trafficcontrol/traffic_monitor/recording"
+	"github.com/apache/trafficcontrol/traffic_monitor/shard"
+	"github.com/apache/trafficcontrol/traffic_monitor/statestream"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 	"github.com/apache/trafficcontrol/traffic_monitor/towrap"
@@ -62,6 +70,18 @@ func MakeDispatchMap(
 	lastStats threadsafe.LastStats,
 	unpolledCaches threadsafe.UnpolledCaches,
 	monitorConfig threadsafe.TrafficMonitorConfigMap,
+	stateStream statestream.Stream,
+	historyStore *persist.Store,
+	cacheVotes peer.CacheVotesThreadsafe,
+	dsProbes probe.ResultsThreadsafe,
+	infra probe.InfraResultsThreadsafe,
+	overrides override.OverridesThreadsafe,
+	combineState func(),
+	shards shard.Threadsafe,
+	peerStats peer.StatsThreadsafe,
+	pollTimings map[string]poller.PollTimings,
+	replay *recording.Replay,
+	cfg config.Config,
 ) map[string]http.HandlerFunc {
 
 	// wrap composes all universal wrapper functions. Right now, it's only the UnpolledCheck, but there may be others later. For example, security headers.
@@ -74,23 +94,23 @@ func MakeDispatchMap(
 			return srvTRConfig(opsConfig, toSession)
 		}, ContentTypeJSON)),
 		"/publish/CrStates": wrap(WrapParams(func(params url.Values, path string) ([]byte, int) {
-			bytes, err := srvTRState(params, localStates, combinedStates)
+			bytes, err := srvTRState(params, localStates, combinedStates, overrides, infra)
 			return WrapErrCode(errorCount, path, bytes, err)
 		}, ContentTypeJSON)),
 		"/publish/CacheStats": wrap(WrapParams(func(params url.Values, path string) ([]byte, int) {
-			return srvCacheStats(params, errorCount, path, toData, statResultHistory, statInfoHistory, monitorConfig, combinedStates, statMaxKbpses)
+			return srvCacheStats(params, errorCount, path, toData, statResultHistory, statInfoHistory, monitorConfig, combinedStates, statMaxKbpses, shards, peerStats)
 		}, ContentTypeJSON)),
 		"/publish/DsStats": wrap(WrapParams(func(params url.Values, path string) ([]byte, int) {
 			return srvDSStats(params, errorCount, path, toData, dsStats)
 		}, ContentTypeJSON)),
-		"/publish/EventLog": wrap(WrapErr(errorCount, func() ([]byte, error) {
-			return srvEventLog(events)
+		"/publish/EventLog": wrap(WrapParams(func(params url.Values, path string) ([]byte, int) {
+			return srvEventLog(params, errorCount, path, events, historyStore)
 		}, ContentTypeJSON)),
 		"/publish/PeerStates": wrap(WrapParams(func(params url.Values, path string) ([]byte, int) {
-			return srvPeerStates(params, errorCount, path, toData, peerStates)
+			return srvPeerStates(params, errorCount, path, toData, peerStates, cacheVotes)
 		}, ContentTypeJSON)),
 		"/publish/Stats": wrap(WrapErr(errorCount, func() ([]byte, error) {
-			return srvStats(staticAppData, healthPollInterval, lastHealthDurations, fetchCount, healthIteration, errorCount, peerStates)
+			return srvStats(staticAppData, healthPollInterval, lastHealthDurations, fetchCount, healthIteration, errorCount, peerStates, pollTimings)
 		}, ContentTypeJSON)),
 		"/publish/ConfigDoc": wrap(WrapErr(errorCount, func() ([]byte, error) {
 			return srvConfigDoc(opsConfig)
@@ -114,7 +134,7 @@ func MakeDispatchMap(
 			return srvAPITrafficOpsURI(opsConfig)
 		}, ContentTypeJSON)),
 		"/api/cache-statuses": wrap(WrapErr(errorCount, func() ([]byte, error) {
-			return srvAPICacheStates(toData, statInfoHistory, statResultHistory, healthHistory, lastHealthDurations, localStates, lastStats, localCacheStatus, statMaxKbpses, monitorConfig)
+			return srvAPICacheStates(toData, statInfoHistory, statResultHistory, healthHistory, lastHealthDurations, localStates, lastStats, localCacheStatus, statMaxKbpses, monitorConfig, overrides)
 		}, ContentTypeJSON)),
 		"/api/bandwidth-kbps": wrap(WrapBytes(func() []byte {
 			return srvAPIBandwidthKbps(toData, lastStats)
@@ -128,6 +148,26 @@ func MakeDispatchMap(
 		"/api/crconfig-history": wrap(WrapErr(errorCount, func() ([]byte, error) {
 			return srvAPICRConfigHist(toSession)
 		}, ContentTypeJSON)),
+		"/metrics": wrap(WrapBytes(func() []byte {
+			return srvMetrics(cfg, staticAppData, localStates, peerStates, dsStats, lastStats, statMaxKbpses, healthHistory, toData, fetchCount, healthIteration, errorCount)
+		}, ContentTypePrometheus)),
+		"/api/stat-history": wrap(WrapParams(func(params url.Values, path string) ([]byte, int) {
+			return srvStatHistory(params, errorCount, path, historyStore)
+		}, ContentTypeJSON)),
+		"/api/state-stream": wrap(srvStateStream(stateStream, cfg.ServeWriteTimeout)),
+		"/api/ds-probes": wrap(WrapErr(errorCount, func() ([]byte, error) {
+			return srvAPIDSProbes(dsProbes)
+		}, ContentTypeJSON)),
+		"/api/router-statuses": wrap(WrapErr(errorCount, func() ([]byte, error) {
+			return srvAPIRouterStatuses(infra)
+		}, ContentTypeJSON)),
+		"/api/traffic-ops-status": wrap(WrapErr(errorCount, func() ([]byte, error) {
+			return srvAPITrafficOpsStatus(infra)
+		}, ContentTypeJSON)),
+		"/api/overrides": wrap(srvOverrides(overrides, events, toData, tc.TrafficMonitorName(staticAppData.Hostname), combineState)),
+	}
+	if replay != nil {
+		dispatchMap["/api/replay"] = wrap(srvReplay(replay))
 	}
 	return addTrailingSlashEndpoints(dispatchMap)
 }
@@ -20,7 +20,15 @@
 package datareq
 
 import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/persist"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 
 	"github.com/json-iterator/go"
 )
@@ -30,7 +38,118 @@ type JSONEvents struct {
 	Events []health.Event `json:"events"`
 }
 
-func srvEventLog(events health.ThreadsafeEvents) ([]byte, error) {
+// JSONStatHistory represents the structure we wish to serialize to JSON, for persisted stat history.
+type JSONStatHistory struct {
+	Stats []persist.StatRecord `json:"stats"`
+	// NextOffset is the offset of the next page of records, or nil if there are no more.
+	NextOffset *int `json:"nextOffset,omitempty"`
+}
+
+// DefaultStatHistoryLimit is the number of stat history records returned, if the request has no limit.
+const DefaultStatHistoryLimit = 1000
+
+// MaxStatHistoryLimit is the greatest number of stat history records a request may ask for. Larger histories must be requested a page at a time, with the offset.
+const MaxStatHistoryLimit = 10000
+
+// srvEventLog returns the recent events in memory. If the request has filters, and a history store exists, the events are instead read from the store, which includes events from before restarts, and beyond the in-memory maximum.
+func srvEventLog(params url.Values, errorCount threadsafe.Uint, path string, events health.ThreadsafeEvents, historyStore *persist.Store) ([]byte, int) {
+	filter, err := NewHistoryFilter(params)
+	if err != nil {
+		HandleErr(errorCount, path, err)
+		return []byte(err.Error()), http.StatusBadRequest
+	}
+
+	evs := []health.Event{}
+	if !filter.Empty() && historyStore != nil {
+		if evs, err = historyStore.Events(filter); err != nil {
+			return WrapErrCode(errorCount, path, nil, errors.New("reading history store events: "+err.Error()))
+		}
+	} else {
+		for _, e := range events.Get() {
+			if filter.MatchesEvent(e) {
+				evs = append(evs, e)
+			}
+		}
+	}
+
 	json := jsoniter.ConfigFastest
-	return json.Marshal(JSONEvents{Events: events.Get()})
+	bytes, err := json.Marshal(JSONEvents{Events: evs})
+	return WrapErrCode(errorCount, path, bytes, err)
+}
+
+// srvStatHistory returns a page of the persisted stat history, from the query parameters `offset` and `limit`, in addition to the history filter. The history may be very large, so the limit defaults to DefaultStatHistoryLimit, and may not exceed MaxStatHistoryLimit.
+func srvStatHistory(params url.Values, errorCount threadsafe.Uint, path string, historyStore *persist.Store) ([]byte, int) {
+	if historyStore == nil {
+		return []byte("Stat history is not enabled"), http.StatusNotFound
+	}
+	filter, err := NewHistoryFilter(params)
+	if err != nil {
+		HandleErr(errorCount, path, err)
+		return []byte(err.Error()), http.StatusBadRequest
+	}
+	offset, err := parseIntParam(params, "offset", 0, 0, -1)
+	if err != nil {
+		HandleErr(errorCount, path, err)
+		return []byte(err.Error()), http.StatusBadRequest
+	}
+	limit, err := parseIntParam(params, "limit", DefaultStatHistoryLimit, 1, MaxStatHistoryLimit)
+	if err != nil {
+		HandleErr(errorCount, path, err)
+		return []byte(err.Error()), http.StatusBadRequest
+	}
+
+	stats, more, err := historyStore.Stats(filter, offset, limit)
+	if err != nil {
+		return WrapErrCode(errorCount, path, nil, errors.New("reading history store stats: "+err.Error()))
+	}
+	resp := JSONStatHistory{Stats: stats}
+	if more {
+		nextOffset := offset + len(stats)
+		resp.NextOffset = &nextOffset
+	}
+	json := jsoniter.ConfigFastest
+	bytes, err := json.Marshal(resp)
+	return WrapErrCode(errorCount, path, bytes, err)
+}
+
+// NewHistoryFilter returns a filter of events or stat history, from the query parameters `start` and `end`, which are inclusive Unix timestamps, and `host`, which is a cache or peer name.
+func NewHistoryFilter(params url.Values) (persist.Filter, error) {
+	filter := persist.Filter{Host: params.Get("host")}
+	var err error
+	if filter.Start, err = parseUnixParam(params, "start"); err != nil {
+		return persist.Filter{}, err
+	}
+	if filter.End, err = parseUnixParam(params, "end"); err != nil {
+		return persist.Filter{}, err
+	}
+	return filter, nil
+}
+
+func parseUnixParam(params url.Values, name string) (time.Time, error) {
+	str := params.Get(name)
+	if str == "" {
+		return time.Time{}, nil
+	}
+	i, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.New("invalid " + name + " parameter '" + str + "', must be a Unix timestamp")
+	}
+	return time.Unix(i, 0), nil
+}
+
+// parseIntParam returns the integer query parameter with the given name, or def if it isn't given. It returns an error if the parameter is less than min, or greater than max if max is not negative.
+func parseIntParam(params url.Values, name string, def int, min int, max int) (int, error) {
+	str := params.Get(name)
+	if str == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(str)
+	if err != nil || i < min || (max >= 0 && i > max) {
+		rangeStr := "at least " + strconv.Itoa(min)
+		if max >= 0 {
+			rangeStr = "between " + strconv.Itoa(min) + " and " + strconv.Itoa(max)
+		}
+		return 0, errors.New("invalid " + name + " parameter '" + str + "', must be an integer " + rangeStr)
+	}
+	return i, nil
 }
@@ -0,0 +1,296 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package datareq
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/ds"
+	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+// ContentTypePrometheus is the content type of the Prometheus text exposition format.
+const ContentTypePrometheus = "text/plain; version=0.0.4"
+
+// MetricsPrefix is the prefix of all metric names served by the metrics endpoint.
+const MetricsPrefix = "traffic_monitor_"
+
+// MetricsData is the data exported by the metrics endpoint.
+type MetricsData struct {
+	// MaxCaches and MaxDeliveryServices bound the number of per-cache and per-delivery-service series. Caches and delivery services beyond them, in name order, are omitted, and counted in the metrics_series_dropped metric. Zero disables per-cache or per-delivery-service series entirely.
+	MaxCaches           uint64
+	MaxDeliveryServices uint64
+	CRStates            tc.CRStates
+	CacheTypes          map[tc.CacheName]tc.CacheType
+	CacheGroups         map[tc.CacheName]tc.CacheGroupName
+	LastStats           dsdata.LastStats
+	MaxKbpses           cache.Kbpses
+	HealthHistory       cache.ResultHistory
+	DSStats             dsdata.StatsReadonly
+	PeersOnline         map[tc.TrafficMonitorName]bool
+	PeerTimes           map[tc.TrafficMonitorName]time.Time
+	FetchCount          uint64
+	HealthIteration     uint64
+	ErrorCount          uint64
+	StartTime           time.Time
+}
+
+func srvMetrics(
+	cfg config.Config,
+	staticAppData config.StaticAppData,
+	localStates peer.CRStatesThreadsafe,
+	peerStates peer.CRStatesPeersThreadsafe,
+	dsStats threadsafe.DSStatsReader,
+	lastStats threadsafe.LastStats,
+	statMaxKbpses threadsafe.CacheKbpses,
+	healthHistory threadsafe.ResultHistory,
+	toData todata.TODataThreadsafe,
+	fetchCount threadsafe.Uint,
+	healthIteration threadsafe.Uint,
+	errorCount threadsafe.Uint,
+) []byte {
+	td := toData.Get()
+	return getMetrics(MetricsData{
+		MaxCaches:           cfg.MetricsMaxCaches,
+		MaxDeliveryServices: cfg.MetricsMaxDeliveryServices,
+		CRStates:            localStates.Get(),
+		CacheTypes:          td.ServerTypes,
+		CacheGroups:         td.ServerCachegroups,
+		LastStats:           lastStats.Get(),
+		MaxKbpses:           statMaxKbpses.Get(),
+		HealthHistory:       healthHistory.Get(),
+		DSStats:             dsStats.Get(),
+		PeersOnline:         peerStates.GetPeersOnline(),
+		PeerTimes:           peerStates.GetQueryTimes(),
+		FetchCount:          fetchCount.Get(),
+		HealthIteration:     healthIteration.Get(),
+		ErrorCount:          errorCount.Get(),
+		StartTime:           staticAppData.StartTime,
+	})
+}
+
+// getMetrics returns the given data in the Prometheus text exposition format.
+func getMetrics(d MetricsData) []byte {
+	w := &metricsWriter{}
+
+	cacheNames := make([]string, 0, len(d.CRStates.Caches))
+	cachesAvailable := 0
+	for name, avail := range d.CRStates.Caches {
+		cacheNames = append(cacheNames, string(name))
+		if avail.IsAvailable {
+			cachesAvailable++
+		}
+	}
+	cacheNames, droppedCaches := boundNames(cacheNames, d.MaxCaches)
+
+	dsNames := make([]string, 0, len(d.CRStates.DeliveryService))
+	for name := range d.CRStates.DeliveryService {
+		dsNames = append(dsNames, string(name))
+	}
+	dsNames, droppedDSes := boundNames(dsNames, d.MaxDeliveryServices)
+
+	cacheLabels := func(name string) []string {
+		cacheName := tc.CacheName(name)
+		return []string{"cache", name, "type", string(d.CacheTypes[cacheName]), "cachegroup", string(d.CacheGroups[cacheName])}
+	}
+
+	totalKbps := 0.0
+	for _, stat := range d.LastStats.Caches {
+		totalKbps += stat.Bytes.PerSec / float64(ds.BytesPerKilobit)
+	}
+
+	w.header("caches", "gauge", "Number of monitored caches, by availability.")
+	w.sample("caches", []string{"state", "available"}, float64(cachesAvailable))
+	w.sample("caches", []string{"state", "unavailable"}, float64(len(d.CRStates.Caches)-cachesAvailable))
+
+	w.header("caches_bandwidth_kbps", "gauge", "Total bandwidth of all caches, in kilobits per second.")
+	w.sample("caches_bandwidth_kbps", nil, totalKbps)
+
+	w.header("cache_available", "gauge", "Whether the cache is available (1) or unavailable (0).")
+	for _, name := range cacheNames {
+		w.sample("cache_available", cacheLabels(name), boolMetric(d.CRStates.Caches[tc.CacheName(name)].IsAvailable))
+	}
+
+	w.header("cache_bandwidth_kbps", "gauge", "Cache bandwidth, in kilobits per second.")
+	for _, name := range cacheNames {
+		if stat, ok := d.LastStats.Caches[tc.CacheName(name)]; ok {
+			w.sample("cache_bandwidth_kbps", cacheLabels(name), stat.Bytes.PerSec/float64(ds.BytesPerKilobit))
+		}
+	}
+
+	w.header("cache_bandwidth_capacity_kbps", "gauge", "Cache bandwidth capacity, in kilobits per second.")
+	for _, name := range cacheNames {
+		if maxKbps, ok := d.MaxKbpses[tc.CacheName(name)]; ok {
+			w.sample("cache_bandwidth_capacity_kbps", cacheLabels(name), float64(maxKbps))
+		}
+	}
+
+	w.header("cache_poll_latency_seconds", "gauge", "Time taken by the latest health poll request to the cache.")
+	for _, name := range cacheNames {
+		if results := d.HealthHistory[tc.CacheName(name)]; len(results) > 0 {
+			w.sample("cache_poll_latency_seconds", cacheLabels(name), results[0].RequestTime.Seconds())
+		}
+	}
+
+	w.header("cache_poll_error", "gauge", "Whether the latest health poll of the cache failed (1) or succeeded (0).")
+	for _, name := range cacheNames {
+		if results := d.HealthHistory[tc.CacheName(name)]; len(results) > 0 {
+			w.sample("cache_poll_error", cacheLabels(name), boolMetric(results[0].Error != nil))
+		}
+	}
+
+	dsStat := func(name string) *dsdata.StatCacheStats {
+		if d.DSStats == nil {
+			return nil
+		}
+		stat, ok := d.DSStats.Get(tc.DeliveryServiceName(name))
+		if !ok {
+			return nil
+		}
+		return stat.Total()
+	}
+
+	w.header("ds_available", "gauge", "Whether the delivery service is available (1) or unavailable (0).")
+	for _, name := range dsNames {
+		w.sample("ds_available", []string{"deliveryservice", name}, boolMetric(d.CRStates.DeliveryService[tc.DeliveryServiceName(name)].IsAvailable))
+	}
+
+	w.header("ds_kbps", "gauge", "Delivery service bandwidth, in kilobits per second.")
+	for _, name := range dsNames {
+		if stat := dsStat(name); stat != nil {
+			w.sample("ds_kbps", []string{"deliveryservice", name}, stat.Kbps.Value)
+		}
+	}
+
+	w.header("ds_tps", "gauge", "Delivery service transactions per second.")
+	for _, name := range dsNames {
+		if stat := dsStat(name); stat != nil {
+			w.sample("ds_tps", []string{"deliveryservice", name}, stat.TpsTotal.Value)
+		}
+	}
+
+	w.header("ds_status_tps", "gauge", "Delivery service transactions per second, by response status code class.")
+	for _, name := range dsNames {
+		stat := dsStat(name)
+		if stat == nil {
+			continue
+		}
+		w.sample("ds_status_tps", []string{"deliveryservice", name, "class", "2xx"}, stat.Tps2xx.Value)
+		w.sample("ds_status_tps", []string{"deliveryservice", name, "class", "3xx"}, stat.Tps3xx.Value)
+		w.sample("ds_status_tps", []string{"deliveryservice", name, "class", "4xx"}, stat.Tps4xx.Value)
+		w.sample("ds_status_tps", []string{"deliveryservice", name, "class", "5xx"}, stat.Tps5xx.Value)
+	}
+
+	peerNames := make([]string, 0, len(d.PeersOnline))
+	for name := range d.PeersOnline {
+		peerNames = append(peerNames, string(name))
+	}
+	sort.Strings(peerNames)
+
+	w.header("peer_available", "gauge", "Whether the peer Traffic Monitor is online (1) or offline (0).")
+	for _, name := range peerNames {
+		w.sample("peer_available", []string{"peer", name}, boolMetric(d.PeersOnline[tc.TrafficMonitorName(name)]))
+	}
+
+	w.header("peer_last_poll_timestamp_seconds", "gauge", "Unix time of the latest poll of the peer Traffic Monitor.")
+	for _, name := range peerNames {
+		if t, ok := d.PeerTimes[tc.TrafficMonitorName(name)]; ok && !t.IsZero() {
+			w.sample("peer_last_poll_timestamp_seconds", []string{"peer", name}, float64(t.UnixNano())/float64(time.Second))
+		}
+	}
+
+	w.header("health_iterations_total", "counter", "Number of health poll iterations.")
+	w.sample("health_iterations_total", nil, float64(d.HealthIteration))
+
+	w.header("fetches_total", "counter", "Number of cache fetches.")
+	w.sample("fetches_total", nil, float64(d.FetchCount))
+
+	w.header("errors_total", "counter", "Number of errors.")
+	w.sample("errors_total", nil, float64(d.ErrorCount))
+
+	if !d.StartTime.IsZero() {
+		w.header("uptime_seconds", "gauge", "Time since Traffic Monitor started.")
+		w.sample("uptime_seconds", nil, time.Since(d.StartTime).Seconds())
+	}
+
+	w.header("goroutines", "gauge", "Number of running goroutines.")
+	w.sample("goroutines", nil, float64(runtime.NumGoroutine()))
+
+	w.header("metrics_series_dropped", "gauge", "Number of caches or delivery services omitted from per-cache or per-delivery-service metrics, because they exceeded the configured maximum.")
+	w.sample("metrics_series_dropped", []string{"kind", "cache"}, float64(droppedCaches))
+	w.sample("metrics_series_dropped", []string{"kind", "deliveryservice"}, float64(droppedDSes))
+
+	return w.buf.Bytes()
+}
+
+// boundNames sorts the given names, and returns at most max of them, along with the number omitted.
+func boundNames(names []string, max uint64) ([]string, int) {
+	sort.Strings(names)
+	if uint64(len(names)) <= max {
+		return names, 0
+	}
+	return names[:max], len(names) - int(max)
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// metricsWriter writes metrics in the Prometheus text exposition format.
+type metricsWriter struct {
+	buf bytes.Buffer
+}
+
+var metricsLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (w *metricsWriter) header(name string, metricType string, help string) {
+	w.buf.WriteString("# HELP " + MetricsPrefix + name + " " + help + "\n")
+	w.buf.WriteString("# TYPE " + MetricsPrefix + name + " " + metricType + "\n")
+}
+
+// sample writes a single sample. The labels are alternating names and values.
+func (w *metricsWriter) sample(name string, labels []string, val float64) {
+	w.buf.WriteString(MetricsPrefix + name)
+	if len(labels) > 0 {
+		w.buf.WriteString("{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.buf.WriteString(",")
+			}
+			w.buf.WriteString(labels[i] + `="` + metricsLabelEscaper.Replace(labels[i+1]) + `"`)
+		}
+		w.buf.WriteString("}")
+	}
+	w.buf.WriteString(" " + strconv.FormatFloat(val, 'g', -1, 64) + "\n")
+}
@@ -0,0 +1,117 @@
+package datareq
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
+)
+
+func getMockMetricsData() MetricsData {
+	return MetricsData{
+		MaxCaches:           10,
+		MaxDeliveryServices: 10,
+		CRStates: tc.CRStates{
+			Caches: map[tc.CacheName]tc.IsAvailable{
+				"edge0": {IsAvailable: true},
+				"edge1": {IsAvailable: false},
+			},
+			DeliveryService: map[tc.DeliveryServiceName]tc.CRStatesDeliveryService{
+				"ds0": {IsAvailable: true},
+			},
+		},
+		CacheTypes:  map[tc.CacheName]tc.CacheType{"edge0": tc.CacheTypeEdge, "edge1": tc.CacheTypeEdge},
+		CacheGroups: map[tc.CacheName]tc.CacheGroupName{"edge0": "cg0", "edge1": `c"g\1`},
+		LastStats: dsdata.LastStats{
+			Caches: map[tc.CacheName]*dsdata.LastStatsData{
+				"edge0": {Bytes: dsdata.LastStatData{PerSec: 1000}},
+			},
+		},
+		MaxKbpses: cache.Kbpses{"edge0": 10000000},
+		HealthHistory: cache.ResultHistory{
+			"edge0": []cache.Result{{RequestTime: 1500 * time.Millisecond}},
+			"edge1": []cache.Result{{Error: errors.New("timeout")}},
+		},
+		PeersOnline:     map[tc.TrafficMonitorName]bool{"tm1": true},
+		FetchCount:      42,
+		HealthIteration: 7,
+		ErrorCount:      3,
+	}
+}
+
+func TestGetMetrics(t *testing.T) {
+	metrics := string(getMetrics(getMockMetricsData()))
+
+	expectedLines := []string{
+		`# TYPE traffic_monitor_cache_available gauge`,
+		`traffic_monitor_caches{state="available"} 1`,
+		`traffic_monitor_caches{state="unavailable"} 1`,
+		`traffic_monitor_cache_available{cache="edge0",type="EDGE",cachegroup="cg0"} 1`,
+		`traffic_monitor_cache_available{cache="edge1",type="EDGE",cachegroup="c\"g\\1"} 0`,
+		`traffic_monitor_cache_bandwidth_kbps{cache="edge0",type="EDGE",cachegroup="cg0"} 8`,
+		`traffic_monitor_cache_bandwidth_capacity_kbps{cache="edge0",type="EDGE",cachegroup="cg0"} 1e+07`,
+		`traffic_monitor_cache_poll_latency_seconds{cache="edge0",type="EDGE",cachegroup="cg0"} 1.5`,
+		`traffic_monitor_cache_poll_error{cache="edge1",type="EDGE",cachegroup="c\"g\\1"} 1`,
+		`traffic_monitor_ds_available{deliveryservice="ds0"} 1`,
+		`traffic_monitor_peer_available{peer="tm1"} 1`,
+		`traffic_monitor_fetches_total 42`,
+		`traffic_monitor_health_iterations_total 7`,
+		`traffic_monitor_errors_total 3`,
+		`traffic_monitor_metrics_series_dropped{kind="cache"} 0`,
+	}
+	lines := map[string]struct{}{}
+	for _, line := range strings.Split(metrics, "\n") {
+		lines[line] = struct{}{}
+	}
+	for _, expected := range expectedLines {
+		if _, ok := lines[expected]; !ok {
+			t.Errorf("expected metrics line '%v', actual metrics:\n%v", expected, metrics)
+		}
+	}
+}
+
+func TestGetMetricsBounded(t *testing.T) {
+	data := getMockMetricsData()
+	data.MaxCaches = 1
+	data.MaxDeliveryServices = 0
+	metrics := string(getMetrics(data))
+
+	if strings.Contains(metrics, `cache="edge1"`) {
+		t.Errorf("expected cache beyond max to be omitted, actual metrics:\n%v", metrics)
+	}
+	if !strings.Contains(metrics, `cache="edge0"`) {
+		t.Errorf("expected cache within max to be present, actual metrics:\n%v", metrics)
+	}
+	if strings.Contains(metrics, `deliveryservice="ds0"`) {
+		t.Errorf("expected delivery service series to be disabled, actual metrics:\n%v", metrics)
+	}
+	if !strings.Contains(metrics, `traffic_monitor_caches{state="unavailable"} 1`) {
+		t.Errorf("expected aggregate metrics to include omitted caches, actual metrics:\n%v", metrics)
+	}
+	if !strings.Contains(metrics, "traffic_monitor_metrics_series_dropped{kind=\"cache\"} 1\ntraffic_monitor_metrics_series_dropped{kind=\"deliveryservice\"} 1\n") {
+		t.Errorf("expected dropped series counts, actual metrics:\n%v", metrics)
+	}
+}
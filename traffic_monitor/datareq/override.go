@@ -0,0 +1,111 @@
+package datareq
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/override"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+
+	"github.com/json-iterator/go"
+)
+
+// MaxOverrideRequestBytes is the maximum size of an override request body.
+const MaxOverrideRequestBytes = 1 << 16
+
+// srvOverrides returns a handler which serves the active overrides on GET, sets an override from the JSON request body on POST, and clears the override of the `type` and `name` query parameters on DELETE. Setting and clearing overrides is restricted to authorized clients by the server, see srvhttp.Auth.
+func srvOverrides(overrides override.OverridesThreadsafe, events health.ThreadsafeEvents, toData todata.TODataThreadsafe, localName tc.TrafficMonitorName, combineState func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			writeJSON(w, http.StatusOK, overrides.Get().Active(now))
+		case http.MethodPost:
+			ov := override.Override{}
+			json := jsoniter.ConfigFastest
+			if err := json.NewDecoder(io.LimitReader(r.Body, MaxOverrideRequestBytes)).Decode(&ov); err != nil {
+				http.Error(w, "malformed override: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			ov.Updated = now
+			ov.Monitor = localName
+			if err := ov.Validate(now); err != nil {
+				http.Error(w, "invalid override: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if !overrideTargetExists(ov, toData.Get()) {
+				http.Error(w, "invalid override: "+ov.Type+" '"+ov.Name+"' not found", http.StatusBadRequest)
+				return
+			}
+			overrides.Set(ov)
+			events.Add(health.OverrideEvent(ov, now))
+			combineState()
+			writeJSON(w, http.StatusOK, ov)
+		case http.MethodDelete:
+			params := r.URL.Query()
+			ov, ok := overrides.Clear(params.Get("type"), params.Get("name"), localName, now)
+			if !ok {
+				http.Error(w, "no active override of "+params.Get("type")+" '"+params.Get("name")+"'", http.StatusNotFound)
+				return
+			}
+			events.Add(health.OverrideEvent(ov, now))
+			combineState()
+			writeJSON(w, http.StatusOK, ov)
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodHead+", "+http.MethodPost+", "+http.MethodDelete)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// overrideTargetExists returns whether the cache or cachegroup of the given override exists in the Traffic Ops data.
+func overrideTargetExists(ov override.Override, toData todata.TOData) bool {
+	if ov.Type == override.TypeCache {
+		_, ok := toData.ServerTypes[tc.CacheName(ov.Name)]
+		return ok
+	}
+	for _, cacheGroup := range toData.ServerCachegroups {
+		if string(cacheGroup) == ov.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	json := jsoniter.ConfigFastest
+	bts, err := json.Marshal(v)
+	if err != nil {
+		log.Errorf("marshalling response: %v\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	w.WriteHeader(code)
+	if _, err := w.Write(bts); err != nil {
+		log.Warnf("received error writing response: %v\n", err)
+	}
+}
@@ -37,6 +37,17 @@ import (
 type APIPeerStates struct {
 	srvhttp.CommonAPIData
 	Peers map[tc.TrafficMonitorName]map[tc.CacheName][]CacheState `json:"peers"`
+	// PeerStatus is the availability and staleness of each peer.
+	PeerStatus map[tc.TrafficMonitorName]PeerStatus `json:"peerStatus"`
+	// Votes is the monitors which voted each cache available and unavailable, if states are combined by quorum.
+	Votes map[tc.CacheName]peer.CacheVotes `json:"votes,omitempty"`
+}
+
+// PeerStatus is the availability and staleness of a peer Traffic Monitor. A peer is stale if it hasn't been polled within the peer timeout; stale and unreachable peers aren't available, and don't vote.
+type PeerStatus struct {
+	Available     bool  `json:"available"`
+	Stale         bool  `json:"stale"`
+	LastPollAgeMs int64 `json:"lastPollAgeMs"`
 }
 
 // CacheState represents the available state of a cache.
@@ -44,21 +55,58 @@ type CacheState struct {
 	Value bool `json:"value"`
 }
 
-func srvPeerStates(params url.Values, errorCount threadsafe.Uint, path string, toData todata.TODataThreadsafe, peerStates peer.CRStatesPeersThreadsafe) ([]byte, int) {
+func srvPeerStates(params url.Values, errorCount threadsafe.Uint, path string, toData todata.TODataThreadsafe, peerStates peer.CRStatesPeersThreadsafe, cacheVotes peer.CacheVotesThreadsafe) ([]byte, int) {
 	filter, err := NewPeerStateFilter(path, params, toData.Get().ServerTypes)
 	if err != nil {
 		HandleErr(errorCount, path, err)
 		return []byte(err.Error()), http.StatusBadRequest
 	}
 	json := jsoniter.ConfigFastest
-	bytes, err := json.Marshal(createAPIPeerStates(peerStates.GetCrstates(), peerStates.GetPeersOnline(), filter, params))
+	bytes, err := json.Marshal(createAPIPeerStates(peerStates.GetCrstates(), peerStates.GetPeersOnline(), getPeerStatuses(peerStates), cacheVotes.Get(), filter, params))
 	return WrapErrCode(errorCount, path, bytes, err)
 }
 
-func createAPIPeerStates(peerStates map[tc.TrafficMonitorName]tc.CRStates, peersOnline map[tc.TrafficMonitorName]bool, filter *PeerStateFilter, params url.Values) APIPeerStates {
+// getPeerStatuses returns the status of each online peer.
+func getPeerStatuses(peerStates peer.CRStatesPeersThreadsafe) map[tc.TrafficMonitorName]PeerStatus {
+	now := peerStates.Now()
+	timeout := peerStates.GetTimeout()
+	queryTimes := peerStates.GetQueryTimes()
+	statuses := map[tc.TrafficMonitorName]PeerStatus{}
+	for peerName, online := range peerStates.GetPeersOnline() {
+		if !online {
+			continue
+		}
+		status := PeerStatus{Available: peerStates.GetPeerAvailability(peerName), Stale: true, LastPollAgeMs: -1}
+		if queryTime, ok := queryTimes[peerName]; ok {
+			age := now.Sub(queryTime)
+			status.Stale = age >= timeout
+			status.LastPollAgeMs = int64(age / time.Millisecond)
+		}
+		statuses[peerName] = status
+	}
+	return statuses
+}
+
+func createAPIPeerStates(peerStates map[tc.TrafficMonitorName]tc.CRStates, peersOnline map[tc.TrafficMonitorName]bool, peerStatuses map[tc.TrafficMonitorName]PeerStatus, cacheVotes map[tc.CacheName]peer.CacheVotes, filter *PeerStateFilter, params url.Values) APIPeerStates {
 	apiPeerStates := APIPeerStates{
 		CommonAPIData: srvhttp.GetCommonAPIData(params, time.Now()),
 		Peers:         map[tc.TrafficMonitorName]map[tc.CacheName][]CacheState{},
+		PeerStatus:    map[tc.TrafficMonitorName]PeerStatus{},
+	}
+
+	for peerName, status := range peerStatuses {
+		if filter.UsePeer(peerName) {
+			apiPeerStates.PeerStatus[peerName] = status
+		}
+	}
+
+	if len(cacheVotes) > 0 {
+		apiPeerStates.Votes = map[tc.CacheName]peer.CacheVotes{}
+		for cacheName, votes := range cacheVotes {
+			if filter.UseCache(cacheName) {
+				apiPeerStates.Votes[cacheName] = votes
+			}
+		}
 	}
 
 	for peer, state := range peerStates {
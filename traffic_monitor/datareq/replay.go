@@ -0,0 +1,61 @@
+package datareq
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/apache/trafficcontrol/traffic_monitor/recording"
+)
+
+// ReplayStatus is the virtual time of a replay, and whether its clock is stepped.
+type ReplayStatus struct {
+	Time    time.Time `json:"time"`
+	Stepped bool      `json:"stepped"`
+}
+
+// srvReplay returns a handler which serves the replay's virtual time on GET, and steps its clock on POST, by the Go duration of the `duration` query parameter, or to the next recorded poll if it's absent. Stepping is restricted to authorized clients by the server, see srvhttp.Auth.
+func srvReplay(replay *recording.Replay) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			writeJSON(w, http.StatusOK, ReplayStatus{Time: replay.Clock.Now(), Stepped: replay.Clock.Stepped()})
+		case http.MethodPost:
+			d := time.Duration(0)
+			if durationStr := r.URL.Query().Get("duration"); durationStr != "" {
+				var err error
+				if d, err = time.ParseDuration(durationStr); err != nil {
+					http.Error(w, "malformed duration: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			now, err := replay.Step(d)
+			if err != nil {
+				http.Error(w, "stepping replay: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			writeJSON(w, http.StatusOK, ReplayStatus{Time: now, Stepped: true})
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodHead+", "+http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/apache/trafficcontrol/lib/go-util"
 	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 
 	"github.com/json-iterator/go"
@@ -66,13 +67,27 @@ type Stats struct {
 	OldestPolledPeerMs          int64   `json:"Oldest Polled Peer Time (ms)"`
 	QueryInterval95thPercentile int64   `json:"Query Interval 95th Percentile (ms)"`
 	GCCPUFraction               float64 `json:"gc-cpu-fraction"`
+	// PollTimings are histograms of the poll intervals and durations of each poller, sorted by poller name.
+	PollTimings []PollerTimings `json:"Poll Timings,omitempty"`
 }
 
-func srvStats(staticAppData config.StaticAppData, healthPollInterval time.Duration, lastHealthDurations threadsafe.DurationMap, fetchCount threadsafe.Uint, healthIteration threadsafe.Uint, errorCount threadsafe.Uint, peerStates peer.CRStatesPeersThreadsafe) ([]byte, error) {
-	return getStats(staticAppData, healthPollInterval, lastHealthDurations.Get(), fetchCount.Get(), healthIteration.Get(), errorCount.Get(), peerStates)
+// PollerTimings are the poll timing histograms of the named poller.
+type PollerTimings struct {
+	Poller string `json:"poller"`
+	poller.PollTimingHistograms
 }
 
-func getStats(staticAppData config.StaticAppData, pollingInterval time.Duration, lastHealthTimes map[tc.CacheName]time.Duration, fetchCount uint64, healthIteration uint64, errorCount uint64, peerStates peer.CRStatesPeersThreadsafe) ([]byte, error) {
+type PollerTimingsByName []PollerTimings
+
+func (s PollerTimingsByName) Len() int           { return len(s) }
+func (s PollerTimingsByName) Less(i, j int) bool { return s[i].Poller < s[j].Poller }
+func (s PollerTimingsByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func srvStats(staticAppData config.StaticAppData, healthPollInterval time.Duration, lastHealthDurations threadsafe.DurationMap, fetchCount threadsafe.Uint, healthIteration threadsafe.Uint, errorCount threadsafe.Uint, peerStates peer.CRStatesPeersThreadsafe, pollTimings map[string]poller.PollTimings) ([]byte, error) {
+	return getStats(staticAppData, healthPollInterval, lastHealthDurations.Get(), fetchCount.Get(), healthIteration.Get(), errorCount.Get(), peerStates, pollTimings)
+}
+
+func getStats(staticAppData config.StaticAppData, pollingInterval time.Duration, lastHealthTimes map[tc.CacheName]time.Duration, fetchCount uint64, healthIteration uint64, errorCount uint64, peerStates peer.CRStatesPeersThreadsafe, pollTimings map[string]poller.PollTimings) ([]byte, error) {
 	longestPollCache, longestPollTime := getLongestPoll(lastHealthTimes)
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
@@ -104,10 +119,15 @@ func getStats(staticAppData config.StaticAppData, pollingInterval time.Duration,
 
 	oldestPolledPeer, oldestPolledPeerTime := oldestPeerPollTime(peerStates.GetQueryTimes(), peerStates.GetPeersOnline())
 	s.OldestPolledPeer = string(oldestPolledPeer)
-	s.OldestPolledPeerMs = time.Now().Sub((oldestPolledPeerTime)).Nanoseconds() / util.MSPerNS
+	s.OldestPolledPeerMs = peerStates.Now().Sub((oldestPolledPeerTime)).Nanoseconds() / util.MSPerNS
 
 	s.QueryInterval95thPercentile = getCacheTimePercentile(lastHealthTimes, 0.95).Nanoseconds() / util.MSPerNS
 
+	for name, timings := range pollTimings {
+		s.PollTimings = append(s.PollTimings, PollerTimings{Poller: name, PollTimingHistograms: timings.Get()})
+	}
+	sort.Sort(PollerTimingsByName(s.PollTimings))
+
 	json := jsoniter.ConfigDefault
 	return json.Marshal(JSONStats{Stats: s})
 }
@@ -30,6 +30,7 @@ import (
 	"github.com/apache/trafficcontrol/lib/go-util"
 	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
 
 	"github.com/json-iterator/go"
 )
@@ -117,7 +118,7 @@ func getRandResult(name tc.TrafficMonitorName) peer.Result {
 }
 
 func getMockCRStatesPeers() peer.CRStatesPeersThreadsafe {
-	ps := peer.NewCRStatesPeersThreadsafe()
+	ps := peer.NewCRStatesPeersThreadsafe(time.Now)
 
 	ps.SetTimeout(getRandDuration())
 
@@ -143,8 +144,12 @@ func TestGetStats(t *testing.T) {
 	healthIteration := uint64(rand.Int())
 	errCount := uint64(rand.Int())
 	crStatesPeers := getMockCRStatesPeers()
+	healthTimings := poller.NewPollTimings()
+	healthTimings.AddInterval(pollingInterval)
+	healthTimings.AddDuration(20 * time.Millisecond)
+	pollTimings := map[string]poller.PollTimings{"stat": poller.NewPollTimings(), "health": healthTimings}
 
-	statsBts, err := getStats(appData, pollingInterval, lastHealthTimes, fetchCount, healthIteration, errCount, crStatesPeers)
+	statsBts, err := getStats(appData, pollingInterval, lastHealthTimes, fetchCount, healthIteration, errCount, crStatesPeers, pollTimings)
 	if err != nil {
 		t.Fatalf("expected getStats error: nil, actual: %+v\n", err)
 	}
@@ -213,6 +218,12 @@ func TestGetStats(t *testing.T) {
 	if st.LastGC == "" {
 		t.Fatalf("expected getStats LastGC nonempty, actual: '%+v'\n", st.LastGC)
 	}
+	if len(st.PollTimings) != 2 || st.PollTimings[0].Poller != "health" || st.PollTimings[1].Poller != "stat" {
+		t.Fatalf("expected getStats PollTimings of health and stat, actual: '%+v'\n", st.PollTimings)
+	}
+	if st.PollTimings[0].Intervals.Count != 1 || st.PollTimings[0].Durations.Counts[1] != 1 {
+		t.Fatalf("expected getStats health PollTimings of 1 interval and 1 duration in the 25ms bucket, actual: '%+v'\n", st.PollTimings[0])
+	}
 	if st.MemAllocBytes <= 0 {
 		t.Fatalf("expected getStats MemAllocBytes >0, actual: '%+v'\n", st.MemAllocBytes)
 	}
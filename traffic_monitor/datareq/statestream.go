@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package datareq
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/traffic_monitor/statestream"
+)
+
+// ContentTypeEventStream is the content type of Server-Sent Events.
+const ContentTypeEventStream = "text/event-stream"
+
+// StateStreamKeepaliveInterval is how often a comment is sent to idle state stream clients, to keep intermediaries from closing the connection.
+const StateStreamKeepaliveInterval = 15 * time.Second
+
+// StateStreamRetryMS is the reconnection time sent to state stream clients.
+const StateStreamRetryMS = 1000
+
+// srvStateStream returns a handler which streams CRStates changes and health events as Server-Sent Events. Clients resume from the Last-Event-ID header, or the `since` query parameter, if the stream history still contains the following messages; otherwise, they receive a snapshot of the full CRStates.
+// Because the server write timeout applies to the entire response, the stream is ended shortly before writeTimeout, and clients are expected to reconnect and resume.
+func srvStateStream(stateStream statestream.Stream, writeTimeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			log.Errorf("state stream: response writer doesn't support flushing\n")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		since, resume := stateStreamResumeSeq(r)
+		msgs, sub, unsubscribe := stateStream.Subscribe(since, resume)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", ContentTypeEventStream)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+
+		buf := bytes.Buffer{}
+		buf.WriteString("retry: " + strconv.Itoa(StateStreamRetryMS) + "\n\n")
+		for _, msg := range msgs {
+			writeStateStreamMessage(&buf, msg)
+		}
+
+		var end <-chan time.Time
+		if writeTimeout > 0 {
+			endTimer := time.NewTimer(writeTimeout * 9 / 10)
+			defer endTimer.Stop()
+			end = endTimer.C
+		}
+		keepalive := time.NewTicker(StateStreamKeepaliveInterval)
+		defer keepalive.Stop()
+
+		for {
+			if buf.Len() > 0 {
+				if _, err := w.Write(buf.Bytes()); err != nil {
+					log.Infof("state stream writing to %v: %v\n", r.RemoteAddr, err)
+					return
+				}
+				flusher.Flush()
+				buf.Reset()
+			}
+
+			select {
+			case msg, ok := <-sub:
+				if !ok {
+					return // the subscriber fell behind, and will resume on reconnect
+				}
+				writeStateStreamMessage(&buf, msg)
+			case <-keepalive.C:
+				buf.WriteString(": keepalive\n\n")
+			case <-end:
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
+
+// stateStreamResumeSeq returns the sequence number the client requested to resume from, and whether it requested to resume.
+func stateStreamResumeSeq(r *http.Request) (uint64, bool) {
+	seqStr := r.Header.Get("Last-Event-ID")
+	if seqStr == "" {
+		seqStr = r.URL.Query().Get("since")
+	}
+	if seqStr == "" {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil {
+		log.Infof("state stream: malformed resume sequence '%v' from %v, sending snapshot\n", seqStr, r.RemoteAddr)
+		return 0, false
+	}
+	return seq, true
+}
+
+func writeStateStreamMessage(buf *bytes.Buffer, msg statestream.Message) {
+	buf.WriteString("id: " + strconv.FormatUint(msg.Seq, 10) + "\n")
+	buf.WriteString("event: " + msg.Type + "\n")
+	buf.WriteString("data: ")
+	buf.Write(msg.Data)
+	buf.WriteString("\n\n")
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/apache/trafficcontrol/lib/go-tc"
 	"github.com/apache/trafficcontrol/lib/go-util"
 	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
@@ -114,7 +115,7 @@ func addAvailableData(dsStats *dsdata.Stats, crStates tc.CRStates, serverCachegr
 		getEvent := func(desc string) health.Event {
 			// TODO sync.Pool?
 			return health.Event{
-				Time:        health.Time(time.Now()),
+				Time:        health.Time(events.Now()),
 				Description: desc,
 				Name:        dsName.String(),
 				Hostname:    dsName.String(),
@@ -260,8 +261,9 @@ func addLastDSStatTotals(lastStat *dsdata.LastDSStat, cachesReporting map[tc.Cac
 }
 
 // addDSPerSecStats calculates and adds the per-second delivery service stats to both the Stats and LastStats structures.
+// It also applies the delivery service's error rate health rule, if any, marking the delivery service unavailable, or disabling it in the cache groups breaching the rule.
 // Note this mutates both dsStats and lastStats, adding the per-second stats to them.
-func addDSPerSecStats(lastStats *dsdata.LastStats, dsStats *dsdata.Stats, dsName tc.DeliveryServiceName, stat *dsdata.Stat, serverCachegroups map[tc.CacheName]tc.CacheGroupName, serverTypes map[tc.CacheName]tc.CacheType, mc tc.TrafficMonitorConfigMap, events health.ThreadsafeEvents, precomputed map[tc.CacheName]cache.PrecomputedData, states peer.CRStatesThreadsafe) {
+func addDSPerSecStats(lastStats *dsdata.LastStats, dsStats *dsdata.Stats, dsName tc.DeliveryServiceName, stat *dsdata.Stat, serverCachegroups map[tc.CacheName]tc.CacheGroupName, serverTypes map[tc.CacheName]tc.CacheType, mc tc.TrafficMonitorConfigMap, events health.ThreadsafeEvents, precomputed map[tc.CacheName]cache.PrecomputedData, states peer.CRStatesThreadsafe, rules config.DSHealthRules) {
 	lastStat, lastStatExists := lastStats.DeliveryServices[dsName]
 	if !lastStatExists {
 		lastStat = newLastDSStat() // TODO sync.Pool?
@@ -312,6 +314,10 @@ func addDSPerSecStats(lastStats *dsdata.LastStats, dsStats *dsdata.Stats, dsName
 	addLastStatsToStatCacheStats(&stat.TotalStats, &lastStat.Total)
 
 	dsErr := getDSErr(dsName, stat.TotalStats, mc)
+	rule, hasRule := rules.Get(dsName.String())
+	if dsErr == nil && hasRule {
+		dsErr = getDSHealthRuleErr(stat.TotalStats, rule)
+	}
 	if dsErr != nil {
 		stat.CommonStats.IsAvailable.Value = false
 		stat.CommonStats.IsHealthy.Value = false
@@ -325,7 +331,7 @@ func addDSPerSecStats(lastStats *dsdata.LastStats, dsStats *dsdata.Stats, dsName
 	getEvent := func(desc string) health.Event {
 		// TODO sync.Pool?
 		return health.Event{
-			Time:        health.Time(time.Now()),
+			Time:        health.Time(events.Now()),
 			Description: desc,
 			Name:        dsName.String(),
 			Hostname:    dsName.String(),
@@ -340,6 +346,37 @@ func addDSPerSecStats(lastStats *dsdata.LastStats, dsStats *dsdata.Stats, dsName
 	}
 
 	lastStat.Available = stat.CommonStats.IsAvailable.Value
+
+	disabledCacheGroups := map[tc.CacheGroupName]string{}
+	if hasRule && rule.DisableCacheGroups {
+		for cacheGroup, cacheGroupStat := range stat.CacheGroups {
+			if err := getDSHealthRuleErr(*cacheGroupStat, rule); err != nil {
+				cacheGroupStat.ErrorString.Value = err.Error()
+				disabledCacheGroups[cacheGroup] = err.Error()
+			}
+		}
+	}
+	getCacheGroupEvent := func(cacheGroup tc.CacheGroupName, desc string, available bool) health.Event {
+		return health.Event{
+			Time:        health.Time(events.Now()),
+			Description: "cachegroup " + string(cacheGroup) + " - " + desc,
+			Name:        dsName.String(),
+			Hostname:    dsName.String(),
+			Type:        "DELIVERYSERVICE",
+			Available:   available,
+		}
+	}
+	for cacheGroup, reason := range disabledCacheGroups {
+		if _, ok := lastStat.DisabledCacheGroups[cacheGroup]; !ok {
+			events.Add(getCacheGroupEvent(cacheGroup, reason, false))
+		}
+	}
+	for cacheGroup := range lastStat.DisabledCacheGroups {
+		if _, ok := disabledCacheGroups[cacheGroup]; !ok {
+			events.Add(getCacheGroupEvent(cacheGroup, "REPORTED - available", true))
+		}
+	}
+	lastStat.DisabledCacheGroups = disabledCacheGroups
 }
 
 // latestBytes returns the most recent OutBytes from the given cache results, and the time of that result. It assumes zero results are not valid, but nonzero results with errors are valid.
@@ -377,9 +414,9 @@ func addCachePerSecStats(lastStats *dsdata.LastStats, cacheName tc.CacheName, pr
 //
 // Note this mutates both dsStats and lastStats, adding the per-second stats to them.
 //
-func addPerSecStats(precomputed map[tc.CacheName]cache.PrecomputedData, dsStats *dsdata.Stats, lastStats *dsdata.LastStats, serverCachegroups map[tc.CacheName]tc.CacheGroupName, serverTypes map[tc.CacheName]tc.CacheType, mc tc.TrafficMonitorConfigMap, events health.ThreadsafeEvents, states peer.CRStatesThreadsafe) {
+func addPerSecStats(precomputed map[tc.CacheName]cache.PrecomputedData, dsStats *dsdata.Stats, lastStats *dsdata.LastStats, serverCachegroups map[tc.CacheName]tc.CacheGroupName, serverTypes map[tc.CacheName]tc.CacheType, mc tc.TrafficMonitorConfigMap, events health.ThreadsafeEvents, states peer.CRStatesThreadsafe, rules config.DSHealthRules) {
 	for dsName, stat := range dsStats.DeliveryService {
-		addDSPerSecStats(lastStats, dsStats, dsName, stat, serverCachegroups, serverTypes, mc, events, precomputed, states)
+		addDSPerSecStats(lastStats, dsStats, dsName, stat, serverCachegroups, serverTypes, mc, events, precomputed, states, rules)
 	}
 	for cacheName, precomputedData := range precomputed {
 		addCachePerSecStats(lastStats, cacheName, precomputedData)
@@ -387,8 +424,9 @@ func addPerSecStats(precomputed map[tc.CacheName]cache.PrecomputedData, dsStats
 }
 
 // CreateStats aggregates and creates statistics from given precomputed stat history. It returns the created stats, information about these stats necessary for the next calculation, and any error.
+// The error rate health rules are applied to the created stats, and the cache groups they disable are set in lastStats.
 // Note lastStats is mutated, being set with the new last stats.
-func CreateStats(precomputed map[tc.CacheName]cache.PrecomputedData, toData todata.TOData, crStates tc.CRStates, lastStats *dsdata.LastStats, now time.Time, mc tc.TrafficMonitorConfigMap, events health.ThreadsafeEvents, states peer.CRStatesThreadsafe) (*dsdata.Stats, error) {
+func CreateStats(precomputed map[tc.CacheName]cache.PrecomputedData, toData todata.TOData, crStates tc.CRStates, lastStats *dsdata.LastStats, now time.Time, mc tc.TrafficMonitorConfigMap, events health.ThreadsafeEvents, states peer.CRStatesThreadsafe, rules config.DSHealthRules) (*dsdata.Stats, error) {
 	start := time.Now()
 	dsStats := dsdata.NewStats(len(toData.DeliveryServiceServers)) // TODO sync.Pool?
 	for deliveryService := range toData.DeliveryServiceServers {
@@ -452,7 +490,7 @@ func CreateStats(precomputed map[tc.CacheName]cache.PrecomputedData, toData toda
 		}
 	}
 
-	addPerSecStats(precomputed, dsStats, lastStats, toData.ServerCachegroups, toData.ServerTypes, mc, events, states)
+	addPerSecStats(precomputed, dsStats, lastStats, toData.ServerCachegroups, toData.ServerTypes, mc, events, states, rules)
 	log.Infof("CreateStats took %v\n", time.Since(start))
 	dsStats.Time = time.Now()
 	return dsStats, nil
@@ -468,6 +506,24 @@ func getDSErr(dsName tc.DeliveryServiceName, dsStats dsdata.StatCacheStats, moni
 	return nil
 }
 
+// getDSHealthRuleErr returns an error if the given stats breach the given error rate health rule.
+// The ratio thresholds aren't checked below the rule's minimum transactions per second.
+func getDSHealthRuleErr(stats dsdata.StatCacheStats, rule config.DSHealthRule) error {
+	if rule.Max5xxPerSecond > 0 && stats.Tps5xx.Value > rule.Max5xxPerSecond {
+		return fmt.Errorf("tps_5xx too high (%.2f > %v)", stats.Tps5xx.Value, rule.Max5xxPerSecond)
+	}
+	if stats.TpsTotal.Value <= 0 || stats.TpsTotal.Value < rule.MinTPS {
+		return nil
+	}
+	if ratio := stats.Tps5xx.Value / stats.TpsTotal.Value; rule.Max5xxRatio > 0 && ratio > rule.Max5xxRatio {
+		return fmt.Errorf("5xx ratio too high (%.2f > %v)", ratio, rule.Max5xxRatio)
+	}
+	if ratio := stats.Tps4xx.Value / stats.TpsTotal.Value; rule.Max4xxRatio > 0 && ratio > rule.Max4xxRatio {
+		return fmt.Errorf("4xx ratio too high (%.2f > %v)", ratio, rule.Max4xxRatio)
+	}
+	return nil
+}
+
 func SumDSAstats(ds *dsdata.StatCacheStats, cacheStat *cache.AStat) {
 	ds.OutBytes.Value += int64(cacheStat.OutBytes)
 	ds.InBytes.Value += float64(cacheStat.InBytes)
@@ -29,6 +29,7 @@ import (
 
 	"github.com/apache/trafficcontrol/lib/go-tc"
 	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
@@ -42,7 +43,7 @@ func TestCreateStats(t *testing.T) {
 	lastStatsThs := threadsafe.NewLastStats()
 	now := time.Now()
 	maxEvents := uint64(4)
-	events := health.NewThreadsafeEvents(maxEvents)
+	events := health.NewThreadsafeEvents(maxEvents, time.Now)
 	localCRStates := peer.NewCRStatesThreadsafe()
 
 	dses := []tc.DeliveryServiceName{}
@@ -66,7 +67,7 @@ func TestCreateStats(t *testing.T) {
 
 	lastStatsVal := lastStatsThs.Get()
 	lastStatsCopy := lastStatsVal.Copy()
-	dsStats, err := CreateStats(precomputeds, toData, combinedCRStates.Get(), lastStatsCopy, now, monitorConfig, events, localCRStates)
+	dsStats, err := CreateStats(precomputeds, toData, combinedCRStates.Get(), lastStatsCopy, now, monitorConfig, events, localCRStates, config.DSHealthRules{})
 
 	if err != nil {
 		t.Fatalf("CreateStats err expected: nil, actual: " + err.Error())
@@ -405,3 +406,84 @@ func TestAddLastStatsToStatCacheStatsNilVals(t *testing.T) {
 	addLastStatsToStatCacheStats(&dsdata.StatCacheStats{}, nil)
 	addLastStatsToStatCacheStats(nil, &dsdata.LastStatsData{})
 }
+
+func TestGetDSHealthRuleErr(t *testing.T) {
+	rule := config.DSHealthRule{Max5xxRatio: 0.1, Max4xxRatio: 0.5, Max5xxPerSecond: 100, MinTPS: 10}
+	tests := []struct {
+		name      string
+		tps2xx    float64
+		tps4xx    float64
+		tps5xx    float64
+		expectErr bool
+	}{
+		{"healthy", 90, 5, 5, false},
+		{"5xx ratio", 50, 0, 50, true},
+		{"4xx ratio", 20, 80, 0, true},
+		{"5xx rate", 10000, 0, 200, true},
+		{"below min tps", 2, 0, 4, false},
+		{"no traffic", 0, 0, 0, false},
+	}
+	for _, test := range tests {
+		stats := dsdata.StatCacheStats{}
+		stats.Tps2xx.Value = test.tps2xx
+		stats.Tps4xx.Value = test.tps4xx
+		stats.Tps5xx.Value = test.tps5xx
+		stats.TpsTotal.Value = test.tps2xx + test.tps4xx + test.tps5xx
+		if err := getDSHealthRuleErr(stats, rule); (err != nil) != test.expectErr {
+			t.Errorf("getDSHealthRuleErr %v expected error %v, actual: %v", test.name, test.expectErr, err)
+		}
+	}
+}
+
+func TestAddDSPerSecStatsHealthRules(t *testing.T) {
+	dsName := tc.DeliveryServiceName("ds0")
+	goodCache := tc.CacheName("cache0")
+	badCache := tc.CacheName("cache1")
+	serverCachegroups := map[tc.CacheName]tc.CacheGroupName{goodCache: "cg0", badCache: "cg1"}
+	serverTypes := map[tc.CacheName]tc.CacheType{goodCache: tc.CacheTypeEdge, badCache: tc.CacheTypeEdge}
+	rules := config.DSHealthRules{config.DSHealthRuleDefault: {Max5xxRatio: 0.1, DisableCacheGroups: true}}
+
+	lastStats := dsdata.NewLastStats(1, 2)
+	lastStat := newLastDSStat()
+	lastStat.Available = true
+	lastStat.Caches[goodCache] = &dsdata.LastStatsData{Status2xx: dsdata.LastStatData{PerSec: 1000}}
+	lastStat.Caches[badCache] = &dsdata.LastStatsData{Status2xx: dsdata.LastStatData{PerSec: 100}, Status5xx: dsdata.LastStatData{PerSec: 100}}
+	lastStats.DeliveryServices[dsName] = lastStat
+
+	stat := dsdata.NewStat()
+	stat.CommonStats.IsAvailable.Value = true
+	for cache := range serverCachegroups {
+		stat.Caches[cache] = &dsdata.StatCacheStats{}
+		stat.CommonStats.CachesReporting[cache] = true
+	}
+	dsStats := dsdata.NewStats(1)
+	dsStats.DeliveryService[dsName] = stat
+
+	events := health.NewThreadsafeEvents(10, time.Now)
+	states := peer.NewCRStatesThreadsafe()
+	addDSPerSecStats(lastStats, dsStats, dsName, stat, serverCachegroups, serverTypes, tc.TrafficMonitorConfigMap{}, events, map[tc.CacheName]cache.PrecomputedData{}, states, rules)
+
+	// 100 5xx out of 1200 total is below the ratio, so the delivery service stays available, but cg1 is disabled.
+	if !stat.CommonStats.IsAvailable.Value {
+		t.Errorf("addDSPerSecStats expected delivery service available, actual: unavailable with '%v'", stat.CommonStats.ErrorStr.Value)
+	}
+	if _, ok := lastStat.DisabledCacheGroups["cg1"]; !ok || len(lastStat.DisabledCacheGroups) != 1 {
+		t.Errorf("addDSPerSecStats expected disabled cachegroups [cg1], actual: %+v", lastStat.DisabledCacheGroups)
+	}
+	if disabled := lastStats.DisabledCacheGroups(); len(disabled[dsName]) != 1 {
+		t.Errorf("LastStats.DisabledCacheGroups expected 1 cachegroup for %v, actual: %+v", dsName, disabled)
+	}
+	if len(events.Get()) != 1 {
+		t.Errorf("addDSPerSecStats expected 1 event, actual: %+v", events.Get())
+	}
+
+	// all caches returning mostly 5xx marks the delivery service unavailable
+	lastStat.Caches[goodCache].Status5xx.PerSec = 2000
+	addDSPerSecStats(lastStats, dsStats, dsName, stat, serverCachegroups, serverTypes, tc.TrafficMonitorConfigMap{}, events, map[tc.CacheName]cache.PrecomputedData{}, states, rules)
+	if stat.CommonStats.IsAvailable.Value {
+		t.Errorf("addDSPerSecStats expected delivery service unavailable, actual: available")
+	}
+	if dsState, _ := states.GetDeliveryService(dsName); dsState.IsAvailable {
+		t.Errorf("addDSPerSecStats expected delivery service state unavailable, actual: available")
+	}
+}
@@ -365,6 +365,25 @@ func (a *LastStats) Copy() *LastStats {
 	return b
 }
 
+// DisabledCacheGroups returns the cache groups disabled by each delivery service's error rate health rule.
+func (a *LastStats) DisabledCacheGroups() DisabledCacheGroups {
+	disabled := DisabledCacheGroups{}
+	for ds, stat := range a.DeliveryServices {
+		if len(stat.DisabledCacheGroups) == 0 {
+			continue
+		}
+		cgs := make(map[tc.CacheGroupName]string, len(stat.DisabledCacheGroups))
+		for cg, reason := range stat.DisabledCacheGroups {
+			cgs[cg] = reason
+		}
+		disabled[ds] = cgs
+	}
+	return disabled
+}
+
+// DisabledCacheGroups is the cache groups in which delivery services are disabled by their error rate health rules, mapped to the reason.
+type DisabledCacheGroups map[tc.DeliveryServiceName]map[tc.CacheGroupName]string
+
 // LastDSStat maps and aggregates the last stats received for the given delivery service to caches, cache groups, types, and total.
 // TODO figure a way to associate this type with StatHTTP, with which its members correspond.
 type LastDSStat struct {
@@ -373,6 +392,8 @@ type LastDSStat struct {
 	Type        map[tc.CacheType]*LastStatsData
 	Total       LastStatsData
 	Available   bool
+	// DisabledCacheGroups is the cache groups disabled by the delivery service's error rate health rule, mapped to the reason.
+	DisabledCacheGroups map[tc.CacheGroupName]string
 }
 
 // Copy performs a deep copy of this LastDSStat object.
@@ -384,6 +405,12 @@ func (a LastDSStat) Copy() *LastDSStat {
 		Total:       a.Total,
 		Available:   a.Available,
 	}
+	if a.DisabledCacheGroups != nil {
+		b.DisabledCacheGroups = make(map[tc.CacheGroupName]string, len(a.DisabledCacheGroups))
+		for k, v := range a.DisabledCacheGroups {
+			b.DisabledCacheGroups[k] = v
+		}
+	}
 	for k, v := range a.CacheGroups {
 		b.CacheGroups[k] = v
 	}
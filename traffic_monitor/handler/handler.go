@@ -40,6 +40,11 @@ type OpsConfig struct {
 	HttpsListener string `json:"httpsListener"`
 	CertFile      string `json:"certFile"`
 	KeyFile       string `json:"keyFile"`
+	// ClientCAFile, RequireClientCert, APITokens, and AdminTokens authenticate clients of the HTTP API, see srvhttp.Auth.
+	ClientCAFile      string   `json:"clientCAFile"`
+	RequireClientCert bool     `json:"requireClientCert"`
+	APITokens         []string `json:"apiTokens"`
+	AdminTokens       []string `json:"adminTokens"`
 }
 
 type Handler interface {
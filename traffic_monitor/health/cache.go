@@ -21,6 +21,8 @@ package health
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -29,7 +31,10 @@ import (
 	"github.com/apache/trafficcontrol/lib/go-tc"
 	"github.com/apache/trafficcontrol/lib/go-util"
 	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 )
@@ -146,6 +151,36 @@ func EvalCache(result cache.ResultInfo, resultStats *threadsafe.ResultStatValHis
 		return avail, eventDescVal, eventMsg
 	}
 
+	eachThresholdStat(result, resultStats, serverInfo, serverProfile, func(stat string, threshold tc.HealthThreshold, val float64) bool {
+		if !inThreshold(threshold, val) {
+			avail, eventDescVal, eventMsg = false, eventDesc(status, exceedsThresholdMsg(stat, threshold, val)), stat
+			return false
+		}
+		return true
+	})
+	return avail, eventDescVal, eventMsg
+}
+
+// NearThreshold returns whether any threshold stat of the given cache is within the given margin of its threshold, as a fraction of the threshold's value, but hasn't exceeded it. ONLINE caches and caches missing from the monitor config are never near a threshold.
+func NearThreshold(result cache.ResultInfo, resultStats *threadsafe.ResultStatValHistory, mc *tc.TrafficMonitorConfigMap, margin float64) bool {
+	serverInfo, ok := mc.TrafficServer[string(result.ID)]
+	if !ok || tc.CacheStatusFromString(serverInfo.ServerStatus) == tc.CacheStatusOnline {
+		return false
+	}
+	serverProfile, ok := mc.Profile[serverInfo.Profile]
+	if !ok {
+		return false
+	}
+	near := false
+	eachThresholdStat(result, resultStats, serverInfo, serverProfile, func(stat string, threshold tc.HealthThreshold, val float64) bool {
+		near = nearThreshold(threshold, val, margin)
+		return !near
+	})
+	return near
+}
+
+// eachThresholdStat calls f with each threshold of the given cache's profile, and the value of its stat, computed or from resultStats, skipping stats without a numeric value. The resultStats may be nil, in which case only computed stats are evaluated. If f returns false, no more thresholds are evaluated.
+func eachThresholdStat(result cache.ResultInfo, resultStats *threadsafe.ResultStatValHistory, serverInfo tc.TrafficServer, serverProfile tc.TMProfile, f func(stat string, threshold tc.HealthThreshold, val float64) bool) {
 	computedStats := cache.ComputedStats()
 
 	for stat, threshold := range serverProfile.Parameters.Thresholds {
@@ -161,7 +196,12 @@ func EvalCache(result cache.ResultInfo, resultStats *threadsafe.ResultStatValHis
 			if len(resultStatHistory) == 0 {
 				continue
 			}
-			resultStat = resultStatHistory[0].Val
+			if threshold.Aggregate == "" {
+				resultStat = resultStatHistory[0].Val
+			} else if resultStat, ok = aggregateStat(resultStatHistory, threshold.Aggregate, threshold.Window); !ok {
+				log.Errorf("health.EvalCache threshold stat %s has no numeric values to compute %s(%d)", stat, threshold.Aggregate, threshold.Window)
+				continue
+			}
 		}
 
 		resultStatNum, ok := util.ToNumeric(resultStat)
@@ -170,51 +210,231 @@ func EvalCache(result cache.ResultInfo, resultStats *threadsafe.ResultStatValHis
 			continue
 		}
 
-		if !inThreshold(threshold, resultStatNum) {
-			return false, eventDesc(status, exceedsThresholdMsg(stat, threshold, resultStatNum)), stat
+		if !f(stat, threshold, resultStatNum) {
+			return
 		}
 	}
-
-	return avail, eventDescVal, eventMsg
 }
 
 // CalcAvailabilityWithStats calculates the availability of each cache in results.
 // statResultHistory may be nil, in which case stats won't be used to calculate availability.
-func CalcAvailability(results []cache.Result, pollerName string, statResultHistory *threadsafe.ResultStatHistory, mc tc.TrafficMonitorConfigMap, toData todata.TOData, localCacheStatusThreadsafe threadsafe.CacheAvailableStatus, localStates peer.CRStatesThreadsafe, events ThreadsafeEvents) {
+// Caches failing the delivery service probes in dsProbes are unavailable for those delivery services, but not for others.
+// The cache groups in dsDisabledCacheGroups, disabled by delivery service error rate health rules, are disabled locations of those delivery services.
+// Each evaluation is observed by the poll schedule, which polls caches faster while they're near a threshold or changing availability.
+func CalcAvailability(results []cache.Result, pollerName string, statResultHistory *threadsafe.ResultStatHistory, mc tc.TrafficMonitorConfigMap, toData todata.TOData, localCacheStatusThreadsafe threadsafe.CacheAvailableStatus, localStates peer.CRStatesThreadsafe, events ThreadsafeEvents, dsProbes probe.ResultsThreadsafe, dsDisabledCacheGroups threadsafe.DisabledCacheGroups, schedule poller.Schedule) {
 	localCacheStatuses := localCacheStatusThreadsafe.Get().Copy()
 	statResults := (*threadsafe.ResultStatValHistory)(nil)
 	for _, result := range results {
+		if result.UsingIPv6 {
+			calcIPv6Availability(result, pollerName, mc, toData, localCacheStatuses, localStates, events, schedule)
+			continue
+		}
 		if statResultHistory != nil {
 			statResultsVal := statResultHistory.LoadOrStore(result.ID)
 			statResults = &statResultsVal
 		}
-		isAvailable, whyAvailable, unavailableStat := EvalCache(cache.ToInfo(result), statResults, &mc)
+		resultInfo := cache.ToInfo(result)
+		isAvailable, whyAvailable, unavailableStat := EvalCache(resultInfo, statResults, &mc)
 
 		// if the cache is now Available, and was previously unavailable due to a threshold, make sure this poller contains the stat which exceeded the threshold.
-		if previousStatus, hasPreviousStatus := localCacheStatuses[result.ID]; isAvailable && hasPreviousStatus && !previousStatus.Available && previousStatus.UnavailableStat != "" {
+		previousStatus, hasPreviousStatus := localCacheStatuses[result.ID]
+		if isAvailable && hasPreviousStatus && !previousStatus.Available && previousStatus.UnavailableStat != "" {
 			if !result.HasStat(previousStatus.UnavailableStat) {
 				return
 			}
 		}
-		localCacheStatuses[result.ID] = cache.AvailableStatus{
+		serverInfo := mc.TrafficServer[string(result.ID)]
+		newStatus := cache.AvailableStatus{
 			Available:       isAvailable,
-			Status:          mc.TrafficServer[string(result.ID)].ServerStatus,
+			Status:          serverInfo.ServerStatus,
 			Why:             whyAvailable,
 			UnavailableStat: unavailableStat,
 			Poller:          pollerName,
-		} // TODO move within localStates?
+		}
+		// only damp availability evaluated from health; admin statuses like ONLINE and ADMIN_DOWN always apply immediately.
+		if tc.CacheStatusFromString(serverInfo.ServerStatus) == tc.CacheStatusReported {
+			newStatus = dampAvailability(previousStatus, hasPreviousStatus, newStatus, mc.Profile[serverInfo.Profile].Parameters, result.Time)
+		}
+		if schedule.Adaptive() {
+			changing := hasPreviousStatus && isAvailable != previousStatus.Available
+			schedule.Observe(string(result.ID), changing || NearThreshold(resultInfo, statResults, &mc, schedule.ThresholdMargin()), result.Time)
+		}
+		isAvailable = newStatus.Available
+		whyAvailable = newStatus.Why
+		ipv6Available := isAvailable
+		if mc.Profile[serverInfo.Profile].Parameters.HealthPollingIPv6 && serverInfo.IP6 != "" {
+			newStatus.IPv6 = previousStatus.IPv6
+			if newStatus.IPv6 != nil {
+				ipv6Available = newStatus.IPv6.Available
+			}
+		}
+		localCacheStatuses[result.ID] = newStatus // TODO move within localStates?
 
 		if available, ok := localStates.GetCache(result.ID); !ok || available.IsAvailable != isAvailable {
 			log.Infof("Changing state for %s was: %t now: %t because %s poller: %v error: %v", result.ID, available.IsAvailable, isAvailable, whyAvailable, pollerName, result.Error)
-			events.Add(Event{Time: Time(time.Now()), Description: whyAvailable + " (" + pollerName + ")", Name: string(result.ID), Hostname: string(result.ID), Type: toData.ServerTypes[result.ID].String(), Available: isAvailable})
+			events.Add(Event{Time: Time(events.Now()), Description: whyAvailable + " (" + pollerName + ")", Name: string(result.ID), Hostname: string(result.ID), Type: toData.ServerTypes[result.ID].String(), Available: isAvailable})
 		}
 
-		localStates.SetCache(result.ID, tc.IsAvailable{IsAvailable: isAvailable})
+		localStates.SetCache(result.ID, tc.IsAvailable{IsAvailable: isAvailable, Ipv4Available: isAvailable, Ipv6Available: ipv6Available})
 	}
-	calculateDeliveryServiceState(toData.DeliveryServiceServers, localStates, toData)
+	calculateDeliveryServiceState(toData.DeliveryServiceServers, localStates, toData, dsProbes.Get(), dsDisabledCacheGroups.Get())
 	localCacheStatusThreadsafe.Set(localCacheStatuses)
 }
 
+// calcIPv6Availability evaluates the given result of polling a cache's IPv6 address, and sets the cache's IPv6 status and availability. The cache's IPv4 availability is unchanged. Results are ignored until the cache's IPv4 address has been evaluated.
+func calcIPv6Availability(result cache.Result, pollerName string, mc tc.TrafficMonitorConfigMap, toData todata.TOData, localCacheStatuses cache.AvailableStatuses, localStates peer.CRStatesThreadsafe, events ThreadsafeEvents, schedule poller.Schedule) {
+	status, ok := localCacheStatuses[result.ID]
+	if !ok {
+		return
+	}
+	isAvailable, whyAvailable, unavailableStat := EvalCache(cache.ToInfo(result), nil, &mc)
+	serverInfo := mc.TrafficServer[string(result.ID)]
+	newStatus := cache.AvailableStatus{
+		Available:       isAvailable,
+		Status:          serverInfo.ServerStatus,
+		Why:             whyAvailable,
+		UnavailableStat: unavailableStat,
+		Poller:          pollerName,
+	}
+	if tc.CacheStatusFromString(serverInfo.ServerStatus) == tc.CacheStatusReported {
+		previousStatus := cache.AvailableStatus{}
+		if status.IPv6 != nil {
+			previousStatus = *status.IPv6
+		}
+		newStatus = dampAvailability(previousStatus, status.IPv6 != nil, newStatus, mc.Profile[serverInfo.Profile].Parameters, result.Time)
+	}
+	if schedule.Adaptive() {
+		schedule.Observe(string(result.ID)+cache.IPv6PollIDSuffix, status.IPv6 != nil && isAvailable != status.IPv6.Available, result.Time)
+	}
+	status.IPv6 = &newStatus
+	localCacheStatuses[result.ID] = status
+
+	available, _ := localStates.GetCache(result.ID)
+	if available.Ipv6Available != newStatus.Available {
+		log.Infof("Changing IPv6 state for %s was: %t now: %t because %s poller: %v error: %v", result.ID, available.Ipv6Available, newStatus.Available, newStatus.Why, pollerName, result.Error)
+		events.Add(Event{Time: Time(events.Now()), Description: "IPv6 " + newStatus.Why + " (" + pollerName + ")", Name: string(result.ID), Hostname: string(result.ID), Type: toData.ServerTypes[result.ID].String(), Available: newStatus.Available, AddressFamily: EventAddressFamilyIPv6})
+	}
+	available.Ipv6Available = newStatus.Available
+	localStates.SetCache(result.ID, available)
+}
+
+// dampAvailability applies the profile's markdown and markup poll counts and flap detection to the newly evaluated status of a cache, given its previous status, and returns the status to set.
+// The evaluated status is held at the previous availability until enough consecutive polls agree. A cache which has changed availability health.flap.transitions times within health.flap.window.minutes is held unavailable until its transitions fall out of the window.
+func dampAvailability(previous cache.AvailableStatus, hasPrevious bool, evaluated cache.AvailableStatus, params tc.TMParameters, now time.Time) cache.AvailableStatus {
+	status := evaluated
+	flapWindow := time.Duration(params.HealthFlapWindowMinutes) * time.Minute
+	status.Transitions = recentTransitions(previous.Transitions, now, flapWindow)
+
+	if evaluated.Available {
+		status.HealthyPolls = previous.HealthyPolls + 1
+		// a healthy poll only resets the unhealthy count of its own poller. Otherwise, the health poller, which doesn't have stats, would reset stat threshold failures.
+		if previous.UnhealthyPoller != "" && previous.UnhealthyPoller != evaluated.Poller {
+			status.UnhealthyPolls = previous.UnhealthyPolls
+			status.UnhealthyPoller = previous.UnhealthyPoller
+		}
+	} else {
+		status.UnhealthyPolls = previous.UnhealthyPolls + 1
+		status.UnhealthyPoller = evaluated.Poller
+	}
+
+	if !hasPrevious {
+		return status // on startup, there's no previous availability to hold
+	}
+
+	switch {
+	case previous.Available && !evaluated.Available && status.UnhealthyPolls < params.HealthMarkDownPolls:
+		log.Infof("health %v unhealthy poll %v of %v before marking unavailable: %v\n", evaluated.Poller, status.UnhealthyPolls, params.HealthMarkDownPolls, evaluated.Why)
+		return holdAvailability(status, previous)
+	case !previous.Available && evaluated.Available && status.HealthyPolls < params.HealthMarkUpPolls:
+		log.Infof("health %v healthy poll %v of %v before marking available\n", evaluated.Poller, status.HealthyPolls, params.HealthMarkUpPolls)
+		return holdAvailability(status, previous)
+	case previous.Available == status.Available:
+		return status
+	}
+
+	if status.Available && params.HealthFlapTransitions > 0 && len(status.Transitions) >= params.HealthFlapTransitions {
+		status = holdAvailability(status, previous)
+		status.Why = eventDesc(tc.CacheStatusFromString(status.Status), fmt.Sprintf("held unavailable: flapping (%d transitions in %d minutes)", len(status.Transitions), params.HealthFlapWindowMinutes))
+		return status
+	}
+
+	status.Transitions = append(append([]time.Time{}, status.Transitions...), now)
+	if status.Available && params.HealthMarkUpPolls > 1 {
+		status.Why += fmt.Sprintf(" (%d consecutive healthy polls)", status.HealthyPolls)
+	} else if !status.Available && params.HealthMarkDownPolls > 1 {
+		status.Why += fmt.Sprintf(" (%d consecutive unhealthy polls)", status.UnhealthyPolls)
+	}
+	if !status.Available && params.HealthFlapTransitions > 0 && len(status.Transitions) >= params.HealthFlapTransitions {
+		status.Why += fmt.Sprintf(" - flapping (%d transitions in %d minutes), holding unavailable", len(status.Transitions), params.HealthFlapWindowMinutes)
+	}
+	return status
+}
+
+// holdAvailability returns the given status, with the availability of the previous status.
+func holdAvailability(status cache.AvailableStatus, previous cache.AvailableStatus) cache.AvailableStatus {
+	status.Available = previous.Available
+	status.Why = previous.Why
+	status.UnavailableStat = previous.UnavailableStat
+	return status
+}
+
+// recentTransitions returns the transitions within the given window before now. The given transitions are not modified.
+func recentTransitions(transitions []time.Time, now time.Time, window time.Duration) []time.Time {
+	for i, transition := range transitions {
+		if now.Sub(transition) < window {
+			return transitions[i:]
+		}
+	}
+	return nil
+}
+
+// aggregateStat returns the aggregate of the window most recent numeric values in the given stat history, and false if the history has no numeric values. If the history has fewer than window values, all values are used.
+func aggregateStat(history []cache.ResultStatVal, aggregate string, window int) (float64, bool) {
+	vals := make([]float64, 0, window)
+	for _, statVal := range history {
+		val, ok := util.ToNumeric(statVal.Val)
+		if !ok {
+			continue
+		}
+		// the history stores consecutive identical values once, with the number of polls in the Span
+		for i := uint64(0); i < statVal.Span && len(vals) < window; i++ {
+			vals = append(vals, val)
+		}
+		if len(vals) >= window {
+			break
+		}
+	}
+	if len(vals) == 0 {
+		return 0, false
+	}
+
+	switch aggregate {
+	case tc.HealthThresholdAggregateAvg:
+		sum := 0.0
+		for _, val := range vals {
+			sum += val
+		}
+		return sum / float64(len(vals)), true
+	case tc.HealthThresholdAggregateMin:
+		sort.Float64s(vals)
+		return vals[0], true
+	case tc.HealthThresholdAggregateMax:
+		sort.Float64s(vals)
+		return vals[len(vals)-1], true
+	}
+	percentile, ok := tc.HealthThresholdPercentile(aggregate)
+	if !ok {
+		log.Errorf("health aggregateStat invalid aggregate '%v'", aggregate)
+		return 0, false
+	}
+	sort.Float64s(vals)
+	rank := int(math.Ceil(percentile / 100 * float64(len(vals)))) // nearest-rank percentile
+	if rank < 1 {
+		rank = 1
+	}
+	return vals[rank-1], true
+}
+
 func setErr(newResult *cache.Result, err error) {
 	newResult.Error = err
 	newResult.Available = false
@@ -222,6 +442,9 @@ func setErr(newResult *cache.Result, err error) {
 
 // ExceedsThresholdMsg returns a human-readable message for why the given value exceeds the threshold. It does NOT check whether the value actually exceeds the threshold; call `InThreshold` to check first.
 func exceedsThresholdMsg(stat string, threshold tc.HealthThreshold, val float64) string {
+	if threshold.Aggregate != "" {
+		stat = fmt.Sprintf("%s(%d) %s", threshold.Aggregate, threshold.Window, stat)
+	}
 	switch threshold.Comparator {
 	case "=":
 		return fmt.Sprintf("%s not equal (%.2f != %.2f)", stat, val, threshold.Val)
@@ -238,6 +461,22 @@ func exceedsThresholdMsg(stat string, threshold tc.HealthThreshold, val float64)
 	}
 }
 
+// nearThreshold returns whether the given value is in the threshold, but within the given margin of it, as a fraction of the threshold's value. Equality thresholds are never near.
+func nearThreshold(threshold tc.HealthThreshold, val float64, margin float64) bool {
+	if !inThreshold(threshold, val) {
+		return false
+	}
+	distance := math.Abs(threshold.Val) * margin
+	switch threshold.Comparator {
+	case "<", "<=":
+		return val >= threshold.Val-distance
+	case ">", ">=":
+		return val <= threshold.Val+distance
+	default:
+		return false
+	}
+}
+
 func inThreshold(threshold tc.HealthThreshold, val float64) bool {
 	switch threshold.Comparator {
 	case "=":
@@ -261,7 +500,7 @@ func eventDesc(status tc.CacheStatus, message string) string {
 }
 
 //calculateDeliveryServiceState calculates the state of delivery services from the new cache state data `cacheState` and the CRConfig data `deliveryServiceServers` and puts the calculated state in the outparam `deliveryServiceStates`
-func calculateDeliveryServiceState(deliveryServiceServers map[tc.DeliveryServiceName][]tc.CacheName, states peer.CRStatesThreadsafe, toData todata.TOData) {
+func calculateDeliveryServiceState(deliveryServiceServers map[tc.DeliveryServiceName][]tc.CacheName, states peer.CRStatesThreadsafe, toData todata.TOData, probeResults probe.Results, dsDisabledCacheGroups dsdata.DisabledCacheGroups) {
 	cacheStates := states.GetCaches() // map[tc.CacheName]IsAvailable
 
 	deliveryServices := states.GetDeliveryServices()
@@ -270,17 +509,17 @@ func calculateDeliveryServiceState(deliveryServiceServers map[tc.DeliveryService
 			log.Infof("CRConfig does not have delivery service %s, but traffic monitor poller does; skipping\n", deliveryServiceName)
 			continue
 		}
-		deliveryServiceState.DisabledLocations = getDisabledLocations(deliveryServiceName, toData.DeliveryServiceServers[deliveryServiceName], cacheStates, toData.ServerCachegroups)
+		deliveryServiceState.DisabledLocations = getDisabledLocations(deliveryServiceName, toData.DeliveryServiceServers[deliveryServiceName], cacheStates, toData.ServerCachegroups, probeResults, dsDisabledCacheGroups[deliveryServiceName])
 		states.SetDeliveryService(deliveryServiceName, deliveryServiceState)
 	}
 }
 
-func getDisabledLocations(deliveryService tc.DeliveryServiceName, deliveryServiceServers []tc.CacheName, cacheStates map[tc.CacheName]tc.IsAvailable, serverCacheGroups map[tc.CacheName]tc.CacheGroupName) []tc.CacheGroupName {
+func getDisabledLocations(deliveryService tc.DeliveryServiceName, deliveryServiceServers []tc.CacheName, cacheStates map[tc.CacheName]tc.IsAvailable, serverCacheGroups map[tc.CacheName]tc.CacheGroupName, probeResults probe.Results, ruleDisabled map[tc.CacheGroupName]string) []tc.CacheGroupName {
 	disabledLocations := []tc.CacheGroupName{} // it's important this isn't nil, so it serialises to the JSON `[]` instead of `null`
-	dsCacheStates := getDeliveryServiceCacheAvailability(cacheStates, deliveryServiceServers)
+	dsCacheStates := getDeliveryServiceCacheAvailability(deliveryService, cacheStates, deliveryServiceServers, probeResults)
 	dsCachegroupsAvailable := getDeliveryServiceCachegroupAvailability(dsCacheStates, serverCacheGroups)
 	for cg, avail := range dsCachegroupsAvailable {
-		if avail {
+		if _, disabled := ruleDisabled[cg]; avail && !disabled {
 			continue
 		}
 		disabledLocations = append(disabledLocations, cg)
@@ -288,10 +527,14 @@ func getDisabledLocations(deliveryService tc.DeliveryServiceName, deliveryServic
 	return disabledLocations
 }
 
-func getDeliveryServiceCacheAvailability(cacheStates map[tc.CacheName]tc.IsAvailable, deliveryServiceServers []tc.CacheName) map[tc.CacheName]tc.IsAvailable {
+func getDeliveryServiceCacheAvailability(deliveryService tc.DeliveryServiceName, cacheStates map[tc.CacheName]tc.IsAvailable, deliveryServiceServers []tc.CacheName, probeResults probe.Results) map[tc.CacheName]tc.IsAvailable {
 	dsCacheStates := map[tc.CacheName]tc.IsAvailable{}
 	for _, server := range deliveryServiceServers {
-		dsCacheStates[server] = cacheStates[server]
+		state := cacheStates[server]
+		if !probeResults.Available(deliveryService, server) {
+			state.IsAvailable = false
+		}
+		dsCacheStates[server] = state
 	}
 	return dsCacheStates
 }
@@ -20,6 +20,7 @@ package health
  */
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -27,6 +28,8 @@ import (
 	"github.com/apache/trafficcontrol/lib/go-tc"
 	"github.com/apache/trafficcontrol/traffic_monitor/cache"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 )
@@ -99,13 +102,13 @@ func TestCalcAvailabilityThresholds(t *testing.T) {
 
 	localCacheStatusThreadsafe := threadsafe.NewCacheAvailableStatus()
 	localStates := peer.NewCRStatesThreadsafe()
-	events := NewThreadsafeEvents(200)
+	events := NewThreadsafeEvents(200, time.Now)
 
 	// test that a normal stat poll over the kbps threshold marks down
 
 	pollerName := "stat"
 	results := []cache.Result{result}
-	CalcAvailability(results, pollerName, statResultHistory, mc, toData, localCacheStatusThreadsafe, localStates, events)
+	CalcAvailability(results, pollerName, statResultHistory, mc, toData, localCacheStatusThreadsafe, localStates, events, probe.NewResultsThreadsafe(), threadsafe.NewDisabledCacheGroups(), poller.Schedule{})
 
 	localCacheStatuses := localCacheStatusThreadsafe.Get()
 	if localCacheStatus, ok := localCacheStatuses[result.ID]; !ok {
@@ -130,7 +133,7 @@ func TestCalcAvailabilityThresholds(t *testing.T) {
 	GetVitals(&healthResult, &result, nil)
 	healthPollerName := "health"
 	healthResults := []cache.Result{healthResult}
-	CalcAvailability(healthResults, healthPollerName, nil, mc, toData, localCacheStatusThreadsafe, localStates, events)
+	CalcAvailability(healthResults, healthPollerName, nil, mc, toData, localCacheStatusThreadsafe, localStates, events, probe.NewResultsThreadsafe(), threadsafe.NewDisabledCacheGroups(), poller.Schedule{})
 
 	localCacheStatuses = localCacheStatusThreadsafe.Get()
 	if localCacheStatus, ok := localCacheStatuses[result.ID]; !ok {
@@ -147,3 +150,217 @@ func TestCalcAvailabilityThresholds(t *testing.T) {
 		t.Fatalf("localCacheStatus.Why expected 'availableBandwidthInKbps too low' actual %v", localCacheStatus.Why)
 	}
 }
+
+func TestCalcAvailabilityIPv6(t *testing.T) {
+	result := cache.Result{
+		ID: "myCacheName",
+		Astats: cache.Astats{
+			Ats: map[string]interface{}{},
+			System: cache.AstatsSystem{
+				InfName:     "bond0",
+				InfSpeed:    20000,
+				ProcNetDev:  "bond0: 1234567891011121 123456789101    0    5    0     0          0  9876543 12345678910111213 1234567891011    0 1234    0     0       0          0",
+				ProcLoadavg: "5.43 4.32 3.21 3/1234 32109",
+			},
+		},
+		Time:      time.Now(),
+		Available: true,
+	}
+	GetVitals(&result, nil, nil)
+
+	mc := tc.TrafficMonitorConfigMap{
+		TrafficServer: map[string]tc.TrafficServer{
+			string(result.ID): {ServerStatus: string(tc.CacheStatusReported), Profile: "myProfileName", IP6: "2001:db8::42"},
+		},
+		Profile: map[string]tc.TMProfile{
+			"myProfileName": {Name: "myProfileName", Parameters: tc.TMParameters{HealthPollingIPv6: true}},
+		},
+	}
+	toData := todata.TOData{
+		ServerTypes:            map[tc.CacheName]tc.CacheType{result.ID: tc.CacheTypeEdge},
+		DeliveryServiceServers: map[tc.DeliveryServiceName][]tc.CacheName{},
+		ServerCachegroups:      map[tc.CacheName]tc.CacheGroupName{},
+	}
+	localCacheStatusThreadsafe := threadsafe.NewCacheAvailableStatus()
+	localStates := peer.NewCRStatesThreadsafe()
+	events := NewThreadsafeEvents(200, time.Now)
+	localStates.AddCache(result.ID, tc.IsAvailable{}) // the monitor config seeds local states
+
+	ipv6Result := result
+	ipv6Result.UsingIPv6 = true
+	ipv6Result.Available = false
+	ipv6Result.Error = errors.New("connection refused")
+
+	// an IPv6 result before the cache's IPv4 address is evaluated is ignored
+	CalcAvailability([]cache.Result{ipv6Result}, "health", nil, mc, toData, localCacheStatusThreadsafe, localStates, events, probe.NewResultsThreadsafe(), threadsafe.NewDisabledCacheGroups(), poller.Schedule{})
+	if status, ok := localCacheStatusThreadsafe.Get()[result.ID]; ok {
+		t.Fatalf("IPv6 result before IPv4 result expected no status, actual %+v", status)
+	}
+
+	CalcAvailability([]cache.Result{result, ipv6Result}, "health", nil, mc, toData, localCacheStatusThreadsafe, localStates, events, probe.NewResultsThreadsafe(), threadsafe.NewDisabledCacheGroups(), poller.Schedule{})
+	available, _ := localStates.GetCache(result.ID)
+	if !available.IsAvailable || !available.Ipv4Available || available.Ipv6Available {
+		t.Errorf("IPv6 poll failure expected available true IPv4 true IPv6 false, actual %+v", available)
+	}
+	if status := localCacheStatusThreadsafe.Get()[result.ID]; status.IPv6 == nil || status.IPv6.Available {
+		t.Errorf("IPv6 poll failure expected unavailable IPv6 status, actual %+v", status.IPv6)
+	}
+
+	// a subsequent IPv4 result keeps the IPv6 availability
+	CalcAvailability([]cache.Result{result}, "health", nil, mc, toData, localCacheStatusThreadsafe, localStates, events, probe.NewResultsThreadsafe(), threadsafe.NewDisabledCacheGroups(), poller.Schedule{})
+	if available, _ := localStates.GetCache(result.ID); !available.IsAvailable || available.Ipv6Available {
+		t.Errorf("IPv4 poll after IPv6 failure expected available true IPv6 false, actual %+v", available)
+	}
+}
+
+func TestGetDisabledLocationsProbes(t *testing.T) {
+	ds := tc.DeliveryServiceName("myDS")
+	otherDS := tc.DeliveryServiceName("otherDS")
+	dsServers := []tc.CacheName{"edgeA1", "edgeB1", "edgeB2"}
+	cacheStates := map[tc.CacheName]tc.IsAvailable{
+		"edgeA1": {IsAvailable: true},
+		"edgeB1": {IsAvailable: true},
+		"edgeB2": {IsAvailable: true},
+	}
+	serverCachegroups := map[tc.CacheName]tc.CacheGroupName{
+		"edgeA1": "cgA",
+		"edgeB1": "cgB",
+		"edgeB2": "cgB",
+	}
+
+	probeResults := probe.Results{}
+	probeResults.Add(ds, "edgeA1", probe.Result{Error: "bad status 502", Failures: 2, Available: false})
+	probeResults.Add(ds, "edgeB1", probe.Result{Error: "bad status 502", Failures: 2, Available: false})
+	probeResults.Add(ds, "edgeB2", probe.Result{Status: 200, Available: true})
+
+	disabled := getDisabledLocations(ds, dsServers, cacheStates, serverCachegroups, probeResults, nil)
+	if len(disabled) != 1 || disabled[0] != "cgA" {
+		t.Errorf("getDisabledLocations with failed probes expected: [cgA], actual: %v", disabled)
+	}
+
+	disabled = getDisabledLocations(otherDS, dsServers, cacheStates, serverCachegroups, probeResults, nil)
+	if len(disabled) != 0 {
+		t.Errorf("getDisabledLocations of delivery service with no failed probes expected: [], actual: %v", disabled)
+	}
+
+	disabled = getDisabledLocations(otherDS, dsServers, cacheStates, serverCachegroups, probeResults, map[tc.CacheGroupName]string{"cgB": "5xx ratio too high (0.50 > 0.1)"})
+	if len(disabled) != 1 || disabled[0] != "cgB" {
+		t.Errorf("getDisabledLocations with health rule disabled cachegroup expected: [cgB], actual: %v", disabled)
+	}
+}
+
+func TestDampAvailability(t *testing.T) {
+	params := tc.TMParameters{HealthMarkDownPolls: 3, HealthMarkUpPolls: 2, HealthFlapTransitions: 3, HealthFlapWindowMinutes: 10}
+	status := string(tc.CacheStatusReported)
+	healthy := cache.AvailableStatus{Available: true, Status: status, Why: "REPORTED - available", Poller: "stat"}
+	unhealthy := cache.AvailableStatus{Available: false, Status: status, Why: "REPORTED - loadavg too high", UnavailableStat: "loadavg", Poller: "stat"}
+
+	now := time.Now()
+	poll := func(previous cache.AvailableStatus, evaluated cache.AvailableStatus) cache.AvailableStatus {
+		now = now.Add(time.Second)
+		return dampAvailability(previous, true, evaluated, params, now)
+	}
+
+	st := dampAvailability(cache.AvailableStatus{}, false, healthy, params, now)
+	if !st.Available {
+		t.Fatalf("dampAvailability first poll expected available, actual unavailable")
+	}
+
+	// two unhealthy polls don't mark down, the third does
+	for i := 1; i <= 2; i++ {
+		if st = poll(st, unhealthy); !st.Available {
+			t.Fatalf("dampAvailability unhealthy poll %v of 3 expected available, actual unavailable", i)
+		}
+	}
+	healthPoll := healthy
+	healthPoll.Poller = "health"
+	if st = poll(st, healthPoll); st.UnhealthyPolls != 2 {
+		t.Fatalf("dampAvailability healthy poll from a different poller expected to keep unhealthy polls 2, actual %v", st.UnhealthyPolls)
+	}
+	if st = poll(st, unhealthy); st.Available {
+		t.Fatalf("dampAvailability unhealthy poll 3 of 3 expected unavailable, actual available")
+	} else if !strings.Contains(st.Why, "loadavg too high") || !strings.Contains(st.Why, "3 consecutive unhealthy polls") {
+		t.Fatalf("dampAvailability markdown Why expected threshold and poll count, actual '%v'", st.Why)
+	}
+
+	// one healthy poll doesn't mark up, the second does
+	if st = poll(st, healthy); st.Available {
+		t.Fatalf("dampAvailability healthy poll 1 of 2 expected unavailable, actual available")
+	}
+	if st = poll(st, healthy); !st.Available {
+		t.Fatalf("dampAvailability healthy poll 2 of 2 expected available, actual unavailable")
+	}
+
+	// the third transition within the window marks down as flapping, and holds the cache down
+	for i := 0; i < 3; i++ {
+		st = poll(st, unhealthy)
+	}
+	if st.Available || !strings.Contains(st.Why, "flapping") {
+		t.Fatalf("dampAvailability third transition expected unavailable flapping, actual available %v why '%v'", st.Available, st.Why)
+	}
+	for i := 0; i < 5; i++ {
+		if st = poll(st, healthy); st.Available {
+			t.Fatalf("dampAvailability flapping expected held unavailable, actual available")
+		}
+	}
+
+	// after the transitions age out of the window, the cache is marked available
+	now = now.Add(11 * time.Minute)
+	if st = poll(st, healthy); !st.Available {
+		t.Fatalf("dampAvailability after flap window expected available, actual unavailable: %v", st.Why)
+	}
+}
+
+func TestAggregateStat(t *testing.T) {
+	history := []cache.ResultStatVal{
+		{Val: float64(10), Span: 2},
+		{Val: float64(40), Span: 1},
+		{Val: "not a number", Span: 1},
+		{Val: float64(30), Span: 3},
+	}
+	tests := []struct {
+		aggregate string
+		window    int
+		expected  float64
+	}{
+		{tc.HealthThresholdAggregateAvg, 3, 20},
+		{tc.HealthThresholdAggregateMax, 3, 40},
+		{tc.HealthThresholdAggregateMin, 5, 10},
+		{tc.HealthThresholdAggregateMax, 100, 40},
+		{"p50", 6, 30},
+		{"p95", 6, 40},
+		{"p1", 6, 10},
+	}
+	for _, test := range tests {
+		val, ok := aggregateStat(history, test.aggregate, test.window)
+		if !ok {
+			t.Errorf("aggregateStat %v(%v) expected ok, actual false", test.aggregate, test.window)
+		} else if val != test.expected {
+			t.Errorf("aggregateStat %v(%v) expected %v, actual %v", test.aggregate, test.window, test.expected, val)
+		}
+	}
+}
+
+func TestNearThreshold(t *testing.T) {
+	tests := []struct {
+		comparator string
+		val        float64
+		expected   bool
+	}{
+		{"<", 50, false},
+		{"<", 95, true},
+		{"<", 100, false}, // exceeded
+		{"<=", 90, true},
+		{">", 105, true},
+		{">", 120, false},
+		{">", 100, false}, // exceeded
+		{">=", 110, true},
+		{"=", 100, false},
+	}
+	for _, test := range tests {
+		threshold := tc.HealthThreshold{Val: 100, Comparator: test.comparator}
+		if actual := nearThreshold(threshold, test.val, 0.1); actual != test.expected {
+			t.Errorf("nearThreshold %v %v margin 0.1 value %v expected %v, actual %v", test.comparator, threshold.Val, test.val, test.expected, actual)
+		}
+	}
+}
@@ -20,11 +20,14 @@ package health
  */
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/traffic_monitor/override"
 )
 
 type Time time.Time
@@ -33,6 +36,15 @@ func (t Time) MarshalJSON() ([]byte, error) {
 	return []byte(fmt.Sprintf("%d", time.Time(t).Unix())), nil
 }
 
+func (t *Time) UnmarshalJSON(data []byte) error {
+	i, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return errors.New("parsing time: " + err.Error())
+	}
+	*t = Time(time.Unix(i, 0))
+	return nil
+}
+
 // Event represents an event change in aggregated data. For example, a cache being marked as unavailable.
 type Event struct {
 	Time        Time   `json:"time"`
@@ -42,6 +54,16 @@ type Event struct {
 	Hostname    string `json:"hostname"`
 	Type        string `json:"type"`
 	Available   bool   `json:"isAvailable"`
+	// AddressFamily is EventAddressFamilyIPv6 for events of a cache's IPv6 availability, which changes independently of its overall availability. It's empty for all other events.
+	AddressFamily string `json:"addressFamily,omitempty"`
+}
+
+// EventAddressFamilyIPv6 is the AddressFamily of events of a cache's IPv6 availability.
+const EventAddressFamilyIPv6 = "ipv6"
+
+// OverrideEvent returns the event of the given override being set, cleared, or expiring at the given time.
+func OverrideEvent(ov override.Override, now time.Time) Event {
+	return Event{Time: Time(now), Description: ov.Describe(now), Name: ov.Name, Hostname: ov.Name, Type: override.EventType, Available: ov.Available && ov.Active(now)}
 }
 
 // Events provides safe access for multiple goroutines readers and a single writer to a stored Events slice.
@@ -50,6 +72,8 @@ type ThreadsafeEvents struct {
 	m         *sync.RWMutex
 	nextIndex *uint64
 	max       uint64
+	listeners *[]func(Event)
+	now       func() time.Time
 }
 
 func copyEvents(a []Event) []Event {
@@ -58,10 +82,22 @@ func copyEvents(a []Event) []Event {
 	return b
 }
 
-// NewEvents creates a new single-writer-multiple-reader Threadsafe object
-func NewThreadsafeEvents(maxEvents uint64) ThreadsafeEvents {
+// NewEvents creates a new single-writer-multiple-reader Threadsafe object. The given func returns the current time, which events are created at. This is time.Now, except when replaying a recording.
+func NewThreadsafeEvents(maxEvents uint64, now func() time.Time) ThreadsafeEvents {
 	i := uint64(0)
-	return ThreadsafeEvents{m: &sync.RWMutex{}, events: &[]Event{}, nextIndex: &i, max: maxEvents}
+	return ThreadsafeEvents{m: &sync.RWMutex{}, events: &[]Event{}, nextIndex: &i, max: maxEvents, listeners: &[]func(Event){}, now: now}
+}
+
+// Now returns the current time, for the Time of new events.
+func (o *ThreadsafeEvents) Now() time.Time {
+	return o.now()
+}
+
+// AddListener adds a func to be called with each subsequently added Event, after its Index is set. Listeners are called synchronously by Add, and thus should not block.
+func (o *ThreadsafeEvents) AddListener(f func(Event)) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	*o.listeners = append(*o.listeners, f)
 }
 
 // Get returns the internal slice of Events for reading. This MUST NOT be modified. If modification is necessary, copy the slice.
@@ -85,5 +121,9 @@ func (o *ThreadsafeEvents) Add(e Event) {
 	// o.m.Lock()
 	*o.events = events
 	*o.nextIndex++
+	listeners := *o.listeners
 	o.m.Unlock()
+	for _, listener := range listeners {
+		listener(e)
+	}
 }
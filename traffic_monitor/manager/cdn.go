@@ -0,0 +1,297 @@
+package manager
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/datareq"
+	"github.com/apache/trafficcontrol/traffic_monitor/handler"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/notify"
+	"github.com/apache/trafficcontrol/traffic_monitor/override"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/persist"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
+	"github.com/apache/trafficcontrol/traffic_monitor/recording"
+	"github.com/apache/trafficcontrol/traffic_monitor/shard"
+	"github.com/apache/trafficcontrol/traffic_monitor/statestream"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+	"github.com/apache/trafficcontrol/traffic_monitor/towrap"
+)
+
+// CDNMonitor is the monitoring of a single CDN: the state of its pollers and managers, which is served by its endpoints, and the channels which start it once Traffic Ops has been logged in to.
+type CDNMonitor struct {
+	// cdn is the name of an additional CDN from the config cdns, or empty for the CDN of this monitor.
+	cdn                  tc.CDNName
+	cfg                  config.Config
+	opsConfig            threadsafe.OpsConfig
+	toSession            towrap.ITrafficOpsSession
+	toData               todata.TODataThreadsafe
+	opsConfigSubscribers []chan<- handler.OpsConfig
+	toChangeSubscribers  []chan<- towrap.ITrafficOpsSession
+	healthTick           <-chan uint64
+	healthPollInterval   time.Duration
+	localStates          peer.CRStatesThreadsafe
+	peerStates           peer.CRStatesPeersThreadsafe
+	combinedStates       peer.CRStatesThreadsafe
+	statInfoHistory      threadsafe.ResultInfoHistory
+	statResultHistory    threadsafe.ResultStatHistory
+	statMaxKbpses        threadsafe.CacheKbpses
+	healthHistory        threadsafe.ResultHistory
+	lastStats            threadsafe.LastStats
+	dsStats              threadsafe.DSStatsReader
+	events               health.ThreadsafeEvents
+	lastHealthDurations  threadsafe.DurationMap
+	fetchCount           threadsafe.Uint
+	healthIteration      threadsafe.Uint
+	errorCount           threadsafe.Uint
+	localCacheStatus     threadsafe.CacheAvailableStatus
+	unpolledCaches       threadsafe.UnpolledCaches
+	monitorConfig        threadsafe.TrafficMonitorConfigMap
+	stateStream          statestream.Stream
+	historyStore         *persist.Store
+	cacheVotes           peer.CacheVotesThreadsafe
+	dsProbes             probe.ResultsThreadsafe
+	infra                probe.InfraResultsThreadsafe
+	overrides            override.OverridesThreadsafe
+	combineState         func()
+	shards               shard.Threadsafe
+	peerStats            peer.StatsThreadsafe
+	pollTimings          map[string]poller.PollTimings
+	replay               *recording.Replay
+}
+
+// CDN returns the name of the monitored CDN. For the CDN of this monitor, this is empty until its Traffic Ops data has been fetched.
+func (m CDNMonitor) CDN() tc.CDNName {
+	if m.cdn != "" {
+		return m.cdn
+	}
+	return m.toData.Get().CDN
+}
+
+// endpoints returns the data request endpoints of the CDN.
+func (m CDNMonitor) endpoints(staticAppData config.StaticAppData) map[string]http.HandlerFunc {
+	return datareq.MakeDispatchMap(
+		m.opsConfig,
+		m.toSession,
+		m.localStates,
+		m.peerStates,
+		m.combinedStates,
+		m.statInfoHistory,
+		m.statResultHistory,
+		m.statMaxKbpses,
+		m.healthHistory,
+		m.dsStats,
+		m.events,
+		staticAppData,
+		m.healthPollInterval,
+		m.lastHealthDurations,
+		m.fetchCount,
+		m.healthIteration,
+		m.errorCount,
+		m.toData,
+		m.localCacheStatus,
+		m.lastStats,
+		m.unpolledCaches,
+		m.monitorConfig,
+		m.stateStream,
+		m.historyStore,
+		m.cacheVotes,
+		m.dsProbes,
+		m.infra,
+		m.overrides,
+		m.combineState,
+		m.shards,
+		m.peerStats,
+		m.pollTimings,
+		m.replay,
+		m.cfg,
+	)
+}
+
+// StartCDNMonitor starts the poller and handler goroutines of monitoring a CDN. The cdn is the name of an additional CDN, or empty for the CDN of this monitor. Polling begins once the returned monitor's subscribers are sent the ops config and Traffic Ops session, by StartOpsConfigManager.
+// If the replay isn't nil, polls are replayed from it, and its virtual clock is used for event times and peer staleness.
+func StartCDNMonitor(cdn tc.CDNName, toSession towrap.ITrafficOpsSession, cfg config.Config, appData config.StaticAppData, recorder *recording.Recorder, replay *recording.Replay) (CDNMonitor, error) {
+	m := CDNMonitor{
+		cdn:             cdn,
+		cfg:             cfg,
+		replay:          replay,
+		opsConfig:       threadsafe.NewOpsConfig(),
+		toSession:       toSession,
+		localStates:     peer.NewCRStatesThreadsafe(), // this is the local state as discoverer by this traffic_monitor
+		fetchCount:      threadsafe.NewUint(),         // note this is the number of individual caches fetched from, not the number of times all the caches were polled.
+		healthIteration: threadsafe.NewUint(),
+		errorCount:      threadsafe.NewUint(),
+		toData:          todata.NewThreadsafe(),
+	}
+
+	cacheHealthHandler := cache.NewHandler()
+	cacheHealthPoller := poller.NewCache(cfg.CacheHealthPollingInterval, true, cacheHealthHandler, cfg, appData)
+	cacheStatHandler := cache.NewPrecomputeHandler(m.toData)
+	cacheStatPoller := poller.NewCache(cfg.CacheStatPollingInterval, false, cacheStatHandler, cfg, appData)
+	monitorConfigPoller := poller.NewMonitorConfig(cfg.MonitorConfigPollingInterval)
+	peerHandler := peer.NewHandler()
+	peerPoller := poller.NewCache(cfg.PeerPollingInterval, false, peerHandler, cfg, appData)
+	m.peerStats = peer.NewStatsThreadsafe() // when polling is sharded, the cache stats of caches polled by peers
+	peerStatPoller := poller.NewCache(cfg.CacheStatPollingInterval, false, peer.NewStatsHandler(m.peerStats), cfg, appData)
+	pollType := ""
+	now := time.Now
+	if replay != nil {
+		pollType = poller.PollerTypeReplay
+		now = replay.Clock.Now
+	}
+	for _, p := range []*poller.CachePoller{&cacheHealthPoller, &cacheStatPoller, &peerPoller, &peerStatPoller} {
+		p.Recorder = recorder
+		p.PollType = pollType
+	}
+	// the health and stat polls of a cache share a schedule, so both are faster while the cache is near a threshold or changing state
+	schedule := poller.NewSchedule(poller.NewScheduleConfig(cfg))
+	cacheHealthPoller.Schedule = schedule
+	cacheStatPoller.Schedule = schedule
+	m.pollTimings = map[string]poller.PollTimings{
+		"health": cacheHealthPoller.Timings,
+		"stat":   cacheStatPoller.Timings,
+		"peer":   peerPoller.Timings,
+	}
+	m.opsConfigSubscribers = []chan<- handler.OpsConfig{monitorConfigPoller.OpsConfigChannel}
+	m.toChangeSubscribers = []chan<- towrap.ITrafficOpsSession{monitorConfigPoller.SessionChannel}
+	m.healthTick = cacheHealthPoller.TickChan
+	m.healthPollInterval = cacheHealthPoller.Config.Interval
+
+	go monitorConfigPoller.Poll()
+	go cacheHealthPoller.Poll()
+	go cacheStatPoller.Poll()
+	go peerPoller.Poll()
+	go peerStatPoller.Poll()
+
+	m.events = health.NewThreadsafeEvents(cfg.MaxEvents, now)
+	m.stateStream = statestream.New(cfg.StateStreamMaxHistory)
+	m.events.AddListener(m.stateStream.PublishEvent)
+
+	if len(cfg.NotifyRoutes) > 0 {
+		if cfg.ReplayFile != "" {
+			log.Warnln("replaying, not sending notifications")
+		} else {
+			notifyListener, err := notify.Start(cfg, m.toData, appData.Hostname)
+			if err != nil {
+				return m, fmt.Errorf("starting notifications: %v", err)
+			}
+			m.events.AddListener(notifyListener)
+		}
+	}
+
+	if cfg.HistoryDir != "" {
+		store, err := persist.Open(cfg.HistoryDir, cfg.HistoryRetention)
+		if err != nil {
+			return m, fmt.Errorf("opening history store '%v': %v", cfg.HistoryDir, err)
+		}
+		m.historyStore = store
+		m.events.AddListener(func(e health.Event) {
+			if err := store.AddEvent(e); err != nil {
+				log.Errorf("persisting event: %v\n", err)
+			}
+		})
+	}
+
+	cachesChanged := make(chan struct{})
+	m.peerStates = peer.NewCRStatesPeersThreadsafe(now) // each peer's last state is saved in this map
+	m.shards = shard.NewThreadsafe()
+
+	m.monitorConfig = StartMonitorConfigManager(
+		monitorConfigPoller.ConfigChannel,
+		m.localStates,
+		m.peerStates,
+		cacheStatPoller.ConfigChannel,
+		cacheHealthPoller.ConfigChannel,
+		peerPoller.ConfigChannel,
+		peerStatPoller.ConfigChannel,
+		monitorConfigPoller.IntervalChan,
+		cachesChanged,
+		cfg,
+		appData,
+		toSession,
+		m.toData,
+		m.shards,
+	)
+
+	m.dsProbes = StartDSProber(cfg, m.monitorConfig, m.toData, m.events)
+	m.infra = StartInfraMonitor(cfg, toSession, m.toData, m.events)
+
+	m.overrides = override.NewOverridesThreadsafe()
+
+	m.combinedStates, m.cacheVotes, m.combineState = StartStateCombiner(m.events, m.peerStates, m.localStates, m.toData, m.stateStream, m.overrides, cfg, tc.TrafficMonitorName(appData.Hostname), m.shards)
+
+	StartPeerManager(
+		peerHandler.ResultChannel,
+		m.peerStates,
+		m.events,
+		m.overrides,
+		m.combineState,
+	)
+
+	StartOverrideExpirer(m.overrides, m.events, m.combineState)
+
+	var dsDisabledCacheGroups threadsafe.DisabledCacheGroups
+	m.statInfoHistory, m.statResultHistory, m.statMaxKbpses, _, m.lastStats, m.dsStats, m.unpolledCaches, m.localCacheStatus, dsDisabledCacheGroups = StartStatHistoryManager(
+		cacheStatHandler.ResultChan(),
+		m.localStates,
+		m.combinedStates,
+		m.toData,
+		cachesChanged,
+		m.errorCount,
+		cfg,
+		m.monitorConfig,
+		m.events,
+		m.combineState,
+		m.dsProbes,
+		m.shards,
+		schedule,
+	)
+
+	m.lastHealthDurations, m.healthHistory = StartHealthResultManager(
+		cacheHealthHandler.ResultChan(),
+		m.toData,
+		m.localStates,
+		m.monitorConfig,
+		m.combinedStates,
+		m.fetchCount,
+		m.errorCount,
+		cfg,
+		m.events,
+		m.localCacheStatus,
+		m.dsProbes,
+		dsDisabledCacheGroups,
+		schedule,
+	)
+
+	if m.historyStore != nil {
+		StartStatPersister(m.historyStore, cfg.HistoryStatInterval, m.localStates, m.localCacheStatus, m.lastStats, m.statMaxKbpses, m.statInfoHistory)
+	}
+	return m, nil
+}
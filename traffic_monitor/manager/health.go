@@ -28,6 +28,8 @@ import (
 	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 )
@@ -48,6 +50,9 @@ func StartHealthResultManager(
 	cfg config.Config,
 	events health.ThreadsafeEvents,
 	localCacheStatus threadsafe.CacheAvailableStatus,
+	dsProbes probe.ResultsThreadsafe,
+	dsDisabledCacheGroups threadsafe.DisabledCacheGroups,
+	schedule poller.Schedule,
 ) (threadsafe.DurationMap, threadsafe.ResultHistory) {
 	lastHealthDurations := threadsafe.NewDurationMap()
 	healthHistory := threadsafe.NewResultHistory()
@@ -64,6 +69,9 @@ func StartHealthResultManager(
 		events,
 		localCacheStatus,
 		cfg,
+		dsProbes,
+		dsDisabledCacheGroups,
+		schedule,
 	)
 	return lastHealthDurations, healthHistory
 }
@@ -81,8 +89,12 @@ func healthResultManagerListen(
 	events health.ThreadsafeEvents,
 	localCacheStatus threadsafe.CacheAvailableStatus,
 	cfg config.Config,
+	dsProbes probe.ResultsThreadsafe,
+	dsDisabledCacheGroups threadsafe.DisabledCacheGroups,
+	schedule poller.Schedule,
 ) {
-	lastHealthEndTimes := map[tc.CacheName]time.Time{}
+	lastHealthEndTimes := map[healthResultKey]time.Time{}
+	ipv6HealthHistory := cache.ResultHistory{}
 	// This reads at least 1 value from the cacheHealthChan. Then, we loop, and try to read from the channel some more. If there's nothing to read, we hit `default` and process. If there is stuff to read, we read it, then inner-loop trying to read more. If we're continuously reading and the channel is never empty, and we hit the tick time, process anyway even though the channel isn't empty, to prevent never processing (starvation).
 	var ticker *time.Ticker
 
@@ -100,8 +112,12 @@ func healthResultManagerListen(
 			localCacheStatus,
 			lastHealthEndTimes,
 			healthHistory,
+			ipv6HealthHistory,
 			results,
 			cfg,
+			dsProbes,
+			dsDisabledCacheGroups,
+			schedule,
 		)
 	}
 
@@ -132,7 +148,13 @@ func healthResultManagerListen(
 	}
 }
 
-// processHealthResult processes the given health results, adding their stats to the CacheAvailableStatus. Note this is NOT threadsafe, because it non-atomically gets CacheAvailableStatuses, Events, LastHealthDurations and later updates them. This MUST NOT be called from multiple threads.
+// healthResultKey is the key of a cache's health results over a single address family.
+type healthResultKey struct {
+	ID        tc.CacheName
+	UsingIPv6 bool
+}
+
+// processHealthResult processes the given health results, adding their stats to the CacheAvailableStatus. IPv6 results are kept in the given ipv6HealthHistory, rather than the shared healthHistory, and the shared lastHealthDurations are those of the IPv4 polls. Note this is NOT threadsafe, because it non-atomically gets CacheAvailableStatuses, Events, LastHealthDurations and later updates them. This MUST NOT be called from multiple threads.
 func processHealthResult(
 	cacheHealthChan <-chan cache.Result,
 	toData todata.TODataThreadsafe,
@@ -144,10 +166,14 @@ func processHealthResult(
 	errorCount threadsafe.Uint,
 	events health.ThreadsafeEvents,
 	localCacheStatusThreadsafe threadsafe.CacheAvailableStatus,
-	lastHealthEndTimes map[tc.CacheName]time.Time,
+	lastHealthEndTimes map[healthResultKey]time.Time,
 	healthHistory threadsafe.ResultHistory,
+	ipv6HealthHistory cache.ResultHistory,
 	results []cache.Result,
 	cfg config.Config,
+	dsProbes probe.ResultsThreadsafe,
+	dsDisabledCacheGroups threadsafe.DisabledCacheGroups,
+	schedule poller.Schedule,
 ) {
 	if len(results) == 0 {
 		return
@@ -164,13 +190,19 @@ func processHealthResult(
 	healthHistoryCopy := healthHistory.Get().Copy()
 	for i, healthResult := range results {
 		fetchCount.Inc()
+		// IPv4 and IPv6 results are kept in separate histories, so vitals are only computed from the previous result of the same address family
+		familyHistory := healthHistoryCopy
+		if healthResult.UsingIPv6 {
+			familyHistory = ipv6HealthHistory
+		}
 		var prevResult cache.Result
-		healthResultHistory := healthHistoryCopy[healthResult.ID]
+		healthResultHistory := familyHistory[healthResult.ID]
 		if len(healthResultHistory) != 0 {
 			prevResult = healthResultHistory[len(healthResultHistory)-1]
 		}
 
 		if healthResult.Error == nil {
+			combineInterfaces(&healthResult, &monitorConfigCopy)
 			health.GetVitals(&healthResult, &prevResult, &monitorConfigCopy)
 			results[i] = healthResult
 		}
@@ -181,23 +213,35 @@ func processHealthResult(
 			maxHistory = 1
 		}
 
-		healthHistoryCopy[healthResult.ID] = pruneHistory(append([]cache.Result{healthResult}, healthHistoryCopy[healthResult.ID]...), maxHistory)
+		familyHistory[healthResult.ID] = pruneHistory(append([]cache.Result{healthResult}, familyHistory[healthResult.ID]...), maxHistory)
 	}
 
 	pollerName := "health"
 	statResultHistoryNil := (*threadsafe.ResultStatHistory)(nil) // health poller doesn't have stats
-	health.CalcAvailability(results, pollerName, statResultHistoryNil, monitorConfigCopy, toDataCopy, localCacheStatusThreadsafe, localStates, events)
+	health.CalcAvailability(results, pollerName, statResultHistoryNil, monitorConfigCopy, toDataCopy, localCacheStatusThreadsafe, localStates, events, dsProbes, dsDisabledCacheGroups, schedule)
 
 	healthHistory.Set(healthHistoryCopy)
 	// TODO determine if we should combineCrStates() here
 
 	lastHealthDurations := threadsafe.CopyDurationMap(lastHealthDurationsThreadsafe.Get())
 	for _, healthResult := range results {
-		if lastHealthStart, ok := lastHealthEndTimes[healthResult.ID]; ok {
+		key := healthResultKey{ID: healthResult.ID, UsingIPv6: healthResult.UsingIPv6}
+		if lastHealthStart, ok := lastHealthEndTimes[key]; ok && !healthResult.UsingIPv6 {
 			d := time.Since(lastHealthStart)
 			lastHealthDurations[healthResult.ID] = d
 		}
-		lastHealthEndTimes[healthResult.ID] = time.Now()
+		lastHealthEndTimes[key] = time.Now()
 	}
 	lastHealthDurationsThreadsafe.Set(lastHealthDurations)
 }
+
+// combineInterfaces sums the interfaces in the result's profile's health.polling.interfaces, if any, so the cache's bandwidth and capacity are those of all of them. If the result is missing any of the interfaces, it's logged and left unchanged.
+func combineInterfaces(result *cache.Result, mc *tc.TrafficMonitorConfigMap) {
+	infs := mc.Profile[mc.TrafficServer[string(result.ID)].Profile].Parameters.HealthPollingInterfaces
+	if len(infs) == 0 || result.Error != nil {
+		return
+	}
+	if err := result.CombineInterfaces(infs); err != nil {
+		log.Warnf("combining interfaces %v of %v: %v\n", infs, result.ID, err)
+	}
+}
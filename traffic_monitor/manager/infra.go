@@ -0,0 +1,186 @@
+package manager
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+	"github.com/apache/trafficcontrol/traffic_monitor/towrap"
+
+	"github.com/json-iterator/go"
+)
+
+// DefaultRouterAPIPort is the port of the Traffic Router API, if the CRConfig doesn't have one.
+const DefaultRouterAPIPort = 3333
+
+// routerTarget is the stats URL of a Traffic Router, and the address to request it from.
+type routerTarget struct {
+	url  string
+	addr string
+}
+
+// StartInfraMonitor starts the goroutine which polls the stats of each Traffic Router in the CRConfig, and pings Traffic Ops, every interval, and returns the results. Routers and Traffic Ops whose availability changes create an event. If the interval is 0, or polls are being replayed, no goroutine is started, and the results are always empty.
+func StartInfraMonitor(
+	cfg config.Config,
+	toSession towrap.ITrafficOpsSession,
+	toData todata.TODataThreadsafe,
+	events health.ThreadsafeEvents,
+) probe.InfraResultsThreadsafe {
+	results := probe.NewInfraResultsThreadsafe()
+	if cfg.InfraPollInterval == 0 {
+		return results
+	}
+	if cfg.ReplayFile != "" {
+		log.Warnln("Traffic Router and Traffic Ops health is not recorded, not polling while replaying")
+		return results
+	}
+	client := probe.NewClient(cfg.HTTPTimeout)
+	go func() {
+		for {
+			start := time.Now()
+			pollInfra(client, cfg, toSession, toData.Get().CDN, results, events)
+			time.Sleep(cfg.InfraPollInterval - time.Since(start))
+		}
+	}()
+	return results
+}
+
+// pollInfra polls every Traffic Router concurrently, and pings Traffic Ops, and sets the results.
+func pollInfra(client *http.Client, cfg config.Config, toSession towrap.ITrafficOpsSession, cdn tc.CDNName, results probe.InfraResultsThreadsafe, events health.ThreadsafeEvents) {
+	type routerResult struct {
+		name   string
+		result probe.Result
+	}
+
+	targets := map[string]routerTarget{}
+	if cdn != "" {
+		if crConfigBytes, _, err := toSession.LastCRConfig(string(cdn)); err != nil {
+			log.Errorf("polling Traffic Routers: getting CRConfig: %v\n", err)
+		} else {
+			targets = getRouterTargets(crConfigBytes, cfg.RouterStatsPath)
+		}
+	}
+
+	resultChan := make(chan routerResult, len(targets))
+	for name, target := range targets {
+		go func(name string, target routerTarget) {
+			resultChan <- routerResult{name: name, result: probe.Probe(client, target.url, target.addr, cfg.RouterMaxResponse)}
+		}(name, target)
+	}
+
+	toResult, loggedIn := pingTrafficOps(toSession, cfg.TrafficOpsMaxResponse)
+
+	oldResults := results.Get()
+	newResults := probe.InfraResults{Routers: map[string]probe.Result{}}
+	for range targets {
+		r := <-resultChan
+		old, hasOld := oldResults.Routers[r.name]
+		result := old.Next(r.result, cfg.InfraFailureThreshold)
+		newResults.Routers[r.name] = result
+		addInfraEvent(events, probe.EventTypeTrafficRouter, r.name, hasOld, old, result)
+	}
+
+	newResults.TrafficOps = oldResults.TrafficOps
+	if loggedIn {
+		old := probe.Result{}
+		if oldResults.TrafficOps != nil {
+			old = *oldResults.TrafficOps
+		}
+		toResult = old.Next(toResult, cfg.InfraFailureThreshold)
+		newResults.TrafficOps = &toResult
+		addInfraEvent(events, probe.EventTypeTrafficOps, "traffic_ops", oldResults.TrafficOps != nil, old, toResult)
+	}
+
+	results.Set(newResults)
+}
+
+// addInfraEvent adds an event if the availability of the given router or Traffic Ops changed. Newly polled routers and Traffic Ops only create an event if they're unavailable.
+func addInfraEvent(events health.ThreadsafeEvents, eventType string, name string, hasOld bool, old probe.Result, result probe.Result) {
+	if (hasOld && old.Available == result.Available) || (!hasOld && result.Available) {
+		return
+	}
+	desc := "health check succeeded"
+	if !result.Available {
+		desc = "health check failed: " + result.Error
+	}
+	log.Infof("Changing %s state for %s now: %t because %s\n", eventType, name, result.Available, desc)
+	events.Add(health.Event{Time: health.Time(events.Now()), Description: desc, Name: name, Hostname: name, Type: eventType, Available: result.Available})
+}
+
+// pingTrafficOps pings Traffic Ops, failing if it doesn't respond successfully, or if maxResponse is nonzero and it took longer. The returned result's Failures and Available are not set, see Result.Next. Returns false if there's no Traffic Ops session yet, and thus nothing was pinged.
+func pingTrafficOps(toSession towrap.ITrafficOpsSession, maxResponse time.Duration) (probe.Result, bool) {
+	result := probe.Result{Time: time.Now()}
+	start := time.Now()
+	err := toSession.Ping()
+	duration := time.Since(start)
+	if err == towrap.ErrNilSession {
+		return result, false
+	}
+	result.TTFBMs = float64(duration) / float64(time.Millisecond)
+	if err != nil {
+		result.Error = "pinging: " + err.Error()
+		return result, true
+	}
+	result.Status = http.StatusOK
+	if maxResponse > 0 && duration > maxResponse {
+		result.Error = "response time " + duration.String() + " over " + maxResponse.String()
+	}
+	return result, true
+}
+
+// getRouterTargets returns the stats URL of each Traffic Router in the given CRConfig which is ONLINE or REPORTED.
+func getRouterTargets(crConfigBytes []byte, statsPath string) map[string]routerTarget {
+	crConfig := tc.CRConfig{}
+	json := jsoniter.ConfigFastest
+	if err := json.Unmarshal(crConfigBytes, &crConfig); err != nil {
+		log.Errorf("polling Traffic Routers: unmarshalling CRConfig: %v\n", err)
+		return nil
+	}
+	targets := map[string]routerTarget{}
+	for name, router := range crConfig.ContentRouters {
+		if router.IP == nil || *router.IP == "" {
+			continue
+		}
+		if router.ServerStatus == nil {
+			continue
+		}
+		if status := tc.CacheStatusFromString(string(*router.ServerStatus)); status != tc.CacheStatusReported && status != tc.CacheStatusOnline {
+			continue
+		}
+		port := DefaultRouterAPIPort
+		if router.APIPort != nil {
+			if p, err := strconv.Atoi(*router.APIPort); err == nil && p > 0 {
+				port = p
+			}
+		}
+		addr := net.JoinHostPort(*router.IP, strconv.Itoa(port))
+		targets[name] = routerTarget{url: "http://" + addr + statsPath, addr: addr}
+	}
+	return targets
+}
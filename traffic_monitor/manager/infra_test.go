@@ -0,0 +1,108 @@
+package manager
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
+	"github.com/apache/trafficcontrol/traffic_monitor/towrap"
+)
+
+// fakeInfraSession is a Traffic Ops session with a CRConfig and ping result. Other methods panic.
+type fakeInfraSession struct {
+	towrap.ITrafficOpsSession
+	crConfig []byte
+	pingErr  error
+}
+
+func (s fakeInfraSession) LastCRConfig(cdn string) ([]byte, time.Time, error) {
+	return s.crConfig, time.Now(), nil
+}
+
+func (s fakeInfraSession) Ping() error {
+	return s.pingErr
+}
+
+func TestGetRouterTargets(t *testing.T) {
+	crConfig := []byte(`{"contentRouters": {
+		"tr0": {"ip": "192.0.2.1", "api.port": "3443", "status": "ONLINE"},
+		"tr1": {"ip": "192.0.2.2", "status": "REPORTED"},
+		"tr2": {"ip": "192.0.2.3", "status": "OFFLINE"},
+		"tr3": {"status": "ONLINE"}
+	}}`)
+	targets := getRouterTargets(crConfig, "/crs/stats")
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 ONLINE or REPORTED routers with IPs, actual %+v", targets)
+	}
+	if targets["tr0"].url != "http://192.0.2.1:3443/crs/stats" {
+		t.Errorf("expected tr0 stats URL on its API port, actual %v", targets["tr0"].url)
+	}
+	if targets["tr1"].addr != "192.0.2.2:3333" {
+		t.Errorf("expected tr1 on the default API port, actual %v", targets["tr1"].addr)
+	}
+}
+
+func TestPollInfra(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/crs/stats" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+
+	session := fakeInfraSession{
+		crConfig: []byte(`{"contentRouters": {"tr0": {"ip": "` + host + `", "api.port": "` + port + `", "status": "ONLINE"}}}`),
+		pingErr:  errors.New("connection refused"),
+	}
+	cfg := config.DefaultConfig
+	cfg.InfraFailureThreshold = 1
+	results := probe.NewInfraResultsThreadsafe()
+	events := health.NewThreadsafeEvents(10, time.Now)
+
+	pollInfra(probe.NewClient(time.Second), cfg, session, "cdn0", results, events)
+
+	r := results.Get()
+	if router, ok := r.Routers["tr0"]; !ok || !router.Available {
+		t.Errorf("expected tr0 available, actual %+v", r.Routers)
+	}
+	if r.TrafficOps == nil || r.TrafficOps.Available {
+		t.Errorf("expected Traffic Ops unavailable, actual %+v", r.TrafficOps)
+	}
+	if es := events.Get(); len(es) != 1 || es[0].Type != probe.EventTypeTrafficOps || es[0].Available {
+		t.Errorf("expected one Traffic Ops unavailable event, actual %+v", es)
+	}
+
+	session.pingErr = towrap.ErrNilSession
+	pollInfra(probe.NewClient(time.Second), cfg, session, "cdn0", results, events)
+	if r := results.Get(); r.TrafficOps == nil || r.TrafficOps.Available {
+		t.Errorf("expected Traffic Ops result kept without a session, actual %+v", r.TrafficOps)
+	}
+}
@@ -28,14 +28,11 @@ import (
 	"golang.org/x/sys/unix"
 
 	"github.com/apache/trafficcontrol/lib/go-log"
-	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/lib/go-tc"
 	"github.com/apache/trafficcontrol/traffic_monitor/config"
-	"github.com/apache/trafficcontrol/traffic_monitor/handler"
-	"github.com/apache/trafficcontrol/traffic_monitor/health"
-	"github.com/apache/trafficcontrol/traffic_monitor/peer"
 	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/recording"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
-	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 	"github.com/apache/trafficcontrol/traffic_monitor/towrap"
 )
 
@@ -43,108 +40,52 @@ import (
 // Start starts the poller and handler goroutines
 //
 func Start(opsConfigFile string, cfg config.Config, appData config.StaticAppData, trafficMonitorConfigFileName string) error {
-	toSession := towrap.ITrafficOpsSession(towrap.NewTrafficOpsSessionThreadsafe(nil, cfg.CRConfigHistoryCount, cfg))
-
-	localStates := peer.NewCRStatesThreadsafe() // this is the local state as discoverer by this traffic_monitor
-	fetchCount := threadsafe.NewUint()          // note this is the number of individual caches fetched from, not the number of times all the caches were polled.
-	healthIteration := threadsafe.NewUint()
-	errorCount := threadsafe.NewUint()
-
-	toData := todata.NewThreadsafe()
-
-	cacheHealthHandler := cache.NewHandler()
-	cacheHealthPoller := poller.NewCache(cfg.CacheHealthPollingInterval, true, cacheHealthHandler, cfg, appData)
-	cacheStatHandler := cache.NewPrecomputeHandler(toData)
-	cacheStatPoller := poller.NewCache(cfg.CacheStatPollingInterval, false, cacheStatHandler, cfg, appData)
-	monitorConfigPoller := poller.NewMonitorConfig(cfg.MonitorConfigPollingInterval)
-	peerHandler := peer.NewHandler()
-	peerPoller := poller.NewCache(cfg.PeerPollingInterval, false, peerHandler, cfg, appData)
-
-	go monitorConfigPoller.Poll()
-	go cacheHealthPoller.Poll()
-	go cacheStatPoller.Poll()
-	go peerPoller.Poll()
-
-	events := health.NewThreadsafeEvents(cfg.MaxEvents)
-
-	cachesChanged := make(chan struct{})
-	peerStates := peer.NewCRStatesPeersThreadsafe() // each peer's last state is saved in this map
-
-	monitorConfig := StartMonitorConfigManager(
-		monitorConfigPoller.ConfigChannel,
-		localStates,
-		peerStates,
-		cacheStatPoller.ConfigChannel,
-		cacheHealthPoller.ConfigChannel,
-		peerPoller.ConfigChannel,
-		monitorConfigPoller.IntervalChan,
-		cachesChanged,
-		cfg,
-		appData,
-		toSession,
-		toData,
-	)
-
-	combinedStates, combineStateFunc := StartStateCombiner(events, peerStates, localStates, toData)
-
-	StartPeerManager(
-		peerHandler.ResultChannel,
-		peerStates,
-		events,
-		combineStateFunc,
-	)
+	session := towrap.NewTrafficOpsSessionThreadsafe(nil, cfg.CRConfigHistoryCount, cfg)
+	toSession := towrap.ITrafficOpsSession(session)
+
+	// when recording, polls and Traffic Ops fetches are written to the record file. When replaying, they're read from the replay file instead, and Traffic Ops and caches aren't contacted.
+	recorder := (*recording.Recorder)(nil)
+	replay := (*recording.Replay)(nil)
+	if cfg.RecordFile != "" {
+		r, err := recording.NewRecorder(cfg.RecordFile)
+		if err != nil {
+			return fmt.Errorf("opening record file '%v': %v", cfg.RecordFile, err)
+		}
+		recorder = r
+		toSession = recording.NewRecordingSession(toSession, recorder)
+		log.Infof("recording polls and Traffic Ops data to %v\n", cfg.RecordFile)
+	} else if cfg.ReplayFile != "" {
+		r, err := recording.Load(cfg.ReplayFile, cfg.ReplaySpeed)
+		if err != nil {
+			return fmt.Errorf("loading replay file '%v': %v", cfg.ReplayFile, err)
+		}
+		replay = r
+		toSession = recording.NewReplaySession(replay)
+		poller.AddReplayPollerType(replay)
+		log.Infof("replaying polls and Traffic Ops data from %v, starting at %v\n", cfg.ReplayFile, replay.Clock.Now())
+	}
 
-	statInfoHistory, statResultHistory, statMaxKbpses, _, lastKbpsStats, dsStats, unpolledCaches, localCacheStatus := StartStatHistoryManager(
-		cacheStatHandler.ResultChan(),
-		localStates,
-		combinedStates,
-		toData,
-		cachesChanged,
-		errorCount,
-		cfg,
-		monitorConfig,
-		events,
-		combineStateFunc,
-	)
+	monitor, err := StartCDNMonitor("", toSession, cfg, appData, recorder, replay)
+	if err != nil {
+		return err
+	}
+	monitors := []CDNMonitor{monitor}
 
-	lastHealthDurations, healthHistory := StartHealthResultManager(
-		cacheHealthHandler.ResultChan(),
-		toData,
-		localStates,
-		monitorConfig,
-		combinedStates,
-		fetchCount,
-		errorCount,
-		cfg,
-		events,
-		localCacheStatus,
-	)
+	// additional CDNs share the Traffic Ops session, each with its own state, polling, and backup files
+	for _, cdn := range cfg.CDNs {
+		cdnCfg := cfg.ForCDN(cdn)
+		cdnMonitor, err := StartCDNMonitor(tc.CDNName(cdn), session.NewCDNSession(cfg.CRConfigHistoryCount, cdnCfg), cdnCfg, appData, nil, nil)
+		if err != nil {
+			return fmt.Errorf("starting CDN '%v': %v", cdn, err)
+		}
+		monitors = append(monitors, cdnMonitor)
+		go healthTickListener(cdnMonitor.healthTick, cdnMonitor.healthIteration)
+	}
 
 	StartOpsConfigManager(
 		opsConfigFile,
-		toSession,
-		toData,
-		[]chan<- handler.OpsConfig{monitorConfigPoller.OpsConfigChannel},
-		[]chan<- towrap.ITrafficOpsSession{monitorConfigPoller.SessionChannel},
-		localStates,
-		peerStates,
-		combinedStates,
-		statInfoHistory,
-		statResultHistory,
-		statMaxKbpses,
-		healthHistory,
-		lastKbpsStats,
-		dsStats,
-		events,
+		monitors,
 		appData,
-		cacheHealthPoller.Config.Interval,
-		lastHealthDurations,
-		fetchCount,
-		healthIteration,
-		errorCount,
-		localCacheStatus,
-		unpolledCaches,
-		monitorConfig,
 		cfg,
 	)
 
@@ -152,7 +93,7 @@ func Start(opsConfigFile string, cfg config.Config, appData config.StaticAppData
 		return fmt.Errorf("starting monitor config file poller: %v", err)
 	}
 
-	healthTickListener(cacheHealthPoller.TickChan, healthIteration)
+	healthTickListener(monitor.healthTick, monitor.healthIteration)
 	return nil
 }
 
@@ -33,6 +33,7 @@ import (
 	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
 	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/shard"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 	"github.com/apache/trafficcontrol/traffic_monitor/towrap"
@@ -125,12 +126,14 @@ func StartMonitorConfigManager(
 	statURLSubscriber chan<- poller.CachePollerConfig,
 	healthURLSubscriber chan<- poller.CachePollerConfig,
 	peerURLSubscriber chan<- poller.CachePollerConfig,
+	peerStatURLSubscriber chan<- poller.CachePollerConfig,
 	toIntervalSubscriber chan<- time.Duration,
 	cachesChangeSubscriber chan<- struct{},
 	cfg config.Config,
 	staticAppData config.StaticAppData,
 	toSession towrap.ITrafficOpsSession,
 	toData todata.TODataThreadsafe,
+	shards shard.Threadsafe,
 ) threadsafe.TrafficMonitorConfigMap {
 	monitorConfig := threadsafe.NewTrafficMonitorConfigMap()
 	go monitorConfigListen(monitorConfig,
@@ -140,12 +143,14 @@ func StartMonitorConfigManager(
 		statURLSubscriber,
 		healthURLSubscriber,
 		peerURLSubscriber,
+		peerStatURLSubscriber,
 		toIntervalSubscriber,
 		cachesChangeSubscriber,
 		cfg,
 		staticAppData,
 		toSession,
 		toData,
+		shards,
 	)
 	return monitorConfig
 }
@@ -158,6 +163,26 @@ func trafficOpsHealthConnectionTimeoutToDuration(t int) time.Duration {
 	return time.Duration(t) * time.Millisecond
 }
 
+// createPeerPollConfig returns the poll config of the given peer Traffic Monitor. If a peer HTTPS port is configured, the peer is polled over HTTPS, with the configured client certificate and CA.
+func createPeerPollConfig(srv tc.TrafficMonitor, cfg config.Config) poller.PollConfig {
+	if cfg.PeerHTTPSPort == 0 {
+		url := fmt.Sprintf("http://%s:%d/publish/CrStates?raw", srv.IP, srv.Port)
+		return poller.PollConfig{URL: url, Host: srv.FQDN, Token: cfg.PeerToken}
+	}
+	url := fmt.Sprintf("https://%s:%d/publish/CrStates?raw", srv.IP, cfg.PeerHTTPSPort)
+	return poller.PollConfig{
+		URL:   url,
+		Host:  srv.FQDN,
+		Token: cfg.PeerToken,
+		TLS: poller.ClientTLS{
+			CertFile:   cfg.PeerCertFile,
+			KeyFile:    cfg.PeerKeyFile,
+			CAFile:     cfg.PeerCAFile,
+			ServerName: srv.FQDN,
+		},
+	}
+}
+
 // trafficOpsPeerPollIntervalToDuration takes the int from Traffic Ops, which is in milliseconds, and returns a time.Duration
 // TODO change Traffic Ops Client API to a time.Duration
 func trafficOpsPeerPollIntervalToDuration(t int) time.Duration {
@@ -182,6 +207,25 @@ func trafficOpsTOPollIntervalToDuration(t int) time.Duration {
 	return time.Duration(t) * time.Millisecond
 }
 
+// removeSingleInterfacePollingInterfaces removes and logs the health.polling.interfaces Parameter of profiles whose stats format only reports a single interface, which can't be summed.
+func removeSingleInterfacePollingInterfaces(mc *tc.TrafficMonitorConfigMap) {
+	for name, profile := range mc.Profile {
+		if len(profile.Parameters.HealthPollingInterfaces) == 0 {
+			continue
+		}
+		format := profile.Parameters.HealthPollingFormat
+		if format == "" {
+			format = cache.DefaultStatsType
+		}
+		if _, ok := cache.SingleInterfaceStatsTypes[format]; !ok {
+			continue
+		}
+		log.Errorf("monitor config profile %v health.polling.interfaces %v not supported by health.polling.format '%v', which only reports a single interface; ignoring\n", name, profile.Parameters.HealthPollingInterfaces, format)
+		profile.Parameters.HealthPollingInterfaces = nil
+		mc.Profile[name] = profile
+	}
+}
+
 // PollIntervalRatio is the ratio of the configuration interval to poll. The configured intervals are 'target' times, so we actually poll at some small fraction less, in attempt to make the actual poll marginally less than the target.
 const PollIntervalRatio = float64(0.97) // TODO make config?
 
@@ -195,12 +239,14 @@ func monitorConfigListen(
 	statURLSubscriber chan<- poller.CachePollerConfig,
 	healthURLSubscriber chan<- poller.CachePollerConfig,
 	peerURLSubscriber chan<- poller.CachePollerConfig,
+	peerStatURLSubscriber chan<- poller.CachePollerConfig,
 	toIntervalSubscriber chan<- time.Duration,
 	cachesChangeSubscriber chan<- struct{},
 	cfg config.Config,
 	staticAppData config.StaticAppData,
 	toSession towrap.ITrafficOpsSession,
 	toData todata.TODataThreadsafe,
+	shards shard.Threadsafe,
 ) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -216,6 +262,7 @@ func monitorConfigListen(
 	for pollerMonitorCfg := range monitorConfigPollChan {
 		monitorConfig := pollerMonitorCfg.Cfg
 		cdn := pollerMonitorCfg.CDN
+		removeSingleInterfacePollingInterfaces(&monitorConfig)
 		monitorConfigTS.Set(monitorConfig)
 		if err := toData.Update(toSession, cdn); err != nil {
 			log.Errorln("Updating Traffic Ops Data: " + err.Error())
@@ -240,7 +287,7 @@ func monitorConfigListen(
 
 			srvStatus := tc.CacheStatusFromString(srv.ServerStatus)
 			if srvStatus == tc.CacheStatusOnline {
-				localStates.AddCache(cacheName, tc.IsAvailable{IsAvailable: true})
+				localStates.AddCache(cacheName, tc.IsAvailable{IsAvailable: true, Ipv4Available: true, Ipv6Available: true})
 				continue
 			}
 			if srvStatus == tc.CacheStatusOffline {
@@ -277,10 +324,18 @@ func monitorConfigListen(
 				log.Warnln("profile " + srv.Profile + " health.connection.timeout Parameter is missing or zero, using default " + DefaultHealthConnectionTimeout.String())
 			}
 
-			healthURLs[srv.HostName] = poller.PollConfig{URL: pollURLStr, Host: srv.FQDN, Timeout: connTimeout, Format: format, PollType: pollType}
+			// the profile may override the poll intervals of the monitor config; zero uses the poller's interval
+			healthInterval := trafficOpsHealthPollIntervalToDuration(monitorConfig.Profile[srv.Profile].Parameters.HeartbeatPollingInterval)
+			statInterval := trafficOpsStatPollIntervalToDuration(monitorConfig.Profile[srv.Profile].Parameters.HealthPollingInterval)
+
+			healthURLs[srv.HostName] = poller.PollConfig{URL: pollURLStr, Host: srv.FQDN, Timeout: connTimeout, Format: format, PollType: pollType, Interval: healthInterval}
+			if monitorConfig.Profile[srv.Profile].Parameters.HealthPollingIPv6 && srv.IP6 != "" {
+				ipv6URL := createServerHealthPollURL(monitorConfig.Profile[srv.Profile].Parameters.HealthPollingURL, ipv6Server(srv))
+				healthURLs[srv.HostName+cache.IPv6PollIDSuffix] = poller.PollConfig{URL: ipv6URL, Host: srv.FQDN, Timeout: connTimeout, Format: format, PollType: pollType, Interval: healthInterval}
+			}
 
 			statURL := createServerStatPollURL(pollURLStr)
-			statURLs[srv.HostName] = poller.PollConfig{URL: statURL, Host: srv.FQDN, Timeout: connTimeout, Format: format, PollType: pollType}
+			statURLs[srv.HostName] = poller.PollConfig{URL: statURL, Host: srv.FQDN, Timeout: connTimeout, Format: format, PollType: pollType, Interval: statInterval}
 		}
 
 		peerSet := map[tc.TrafficMonitorName]struct{}{}
@@ -291,19 +346,29 @@ func monitorConfigListen(
 			if tc.CacheStatusFromString(srv.ServerStatus) != tc.CacheStatusOnline {
 				continue
 			}
-			// TODO: the URL should be config driven. -jse
-			url := fmt.Sprintf("http://%s:%d/publish/CrStates?raw", srv.IP, srv.Port)
-			peerURLs[srv.HostName] = poller.PollConfig{URL: url, Host: srv.FQDN} // TODO determine timeout.
+			peerURLs[srv.HostName] = createPeerPollConfig(srv, cfg) // TODO determine timeout.
 			peerSet[tc.TrafficMonitorName(srv.HostName)] = struct{}{}
 		}
 
-		statURLSubscriber <- poller.CachePollerConfig{Urls: statURLs, Interval: intervals.Stat, NoKeepAlive: intervals.StatNoKeepAlive}
-		healthURLSubscriber <- poller.CachePollerConfig{Urls: healthURLs, Interval: intervals.Health, NoKeepAlive: intervals.HealthNoKeepAlive}
-		peerURLSubscriber <- poller.CachePollerConfig{Urls: peerURLs, Interval: intervals.Peer, NoKeepAlive: intervals.PeerNoKeepAlive}
-		toIntervalSubscriber <- intervals.TO
 		peerStates.SetTimeout((intervals.Peer + cfg.HTTPTimeout) * 2)
 		peerStates.SetPeers(peerSet)
 
+		// when sharded, membership is re-evaluated every monitor config poll, so caches of a peer which goes offline are rebalanced within a poll
+		sh := shard.Shard{}
+		if cfg.PollShardReplicas > 0 {
+			sh = getShard(tc.TrafficMonitorName(staticAppData.Hostname), cfg.PollShardReplicas, peerSet, peerStates)
+			if prevShard := shards.Get(); !sh.SameMembers(prevShard) {
+				log.Infof("cache polling shard members changed to %v, with %v replicas\n", sh.Members, sh.Replicas)
+			}
+		}
+		shards.Set(sh)
+
+		statURLSubscriber <- poller.CachePollerConfig{Urls: filterShardURLs(statURLs, sh), Interval: intervals.Stat, NoKeepAlive: intervals.StatNoKeepAlive}
+		healthURLSubscriber <- poller.CachePollerConfig{Urls: filterShardURLs(healthURLs, sh), Interval: intervals.Health, NoKeepAlive: intervals.HealthNoKeepAlive}
+		peerURLSubscriber <- poller.CachePollerConfig{Urls: peerURLs, Interval: intervals.Peer, NoKeepAlive: intervals.PeerNoKeepAlive}
+		peerStatURLSubscriber <- poller.CachePollerConfig{Urls: createPeerStatPollConfigs(peerURLs, sh), Interval: intervals.Stat, NoKeepAlive: intervals.PeerNoKeepAlive}
+		toIntervalSubscriber <- intervals.TO
+
 		for cacheName := range localStates.GetCaches() {
 			if _, exists := monitorConfig.TrafficServer[string(cacheName)]; !exists {
 				log.Warnf("Removing %s from localStates", cacheName)
@@ -366,6 +431,16 @@ func createServerHealthPollURL(pollingURLStr string, srv tc.TrafficServer) strin
 	return pollingURLStr
 }
 
+// ipv6Server returns a copy of the given server whose IP is its bracketed IPv6 address, without any prefix length, for creating its IPv6 poll URL.
+func ipv6Server(srv tc.TrafficServer) tc.TrafficServer {
+	ip := srv.IP6
+	if i := strings.Index(ip, "/"); i >= 0 {
+		ip = ip[:i]
+	}
+	srv.IP = "[" + ip + "]"
+	return srv
+}
+
 // createServerStatPollURL takes the health polling URL string, and modifies it to be the stat poll URL.
 // Note this does not replace template variables with server values, healthPollURLStr must be the health URL for a given server, not a template.
 func createServerStatPollURL(healthPollURLStr string) string {
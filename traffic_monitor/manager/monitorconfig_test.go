@@ -24,6 +24,7 @@ import (
 	"testing"
 
 	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
 )
 
 func TestCreateServerHealthPollURL(t *testing.T) {
@@ -89,6 +90,18 @@ func TestCreateServerHealthPollURLTemplateAndServerPort(t *testing.T) {
 	}
 }
 
+func TestCreateServerHealthPollURLIPv6(t *testing.T) {
+	tmpl := `http://${hostname}/_astats?application=&inf.name=${interface_name}`
+	srv := tc.TrafficServer{IP: "192.0.2.42", IP6: "2001:db8::42/64", InterfaceName: "george", Port: 8080}
+
+	expected := `http://[2001:db8::42]:8080/_astats?application=system&inf.name=` + srv.InterfaceName
+	actual := createServerHealthPollURL(tmpl, ipv6Server(srv))
+
+	if expected != actual {
+		t.Errorf("expected createServerHealthPollURL '%v' actual: '%v'", expected, actual)
+	}
+}
+
 func TestCreateServerStatPollURL(t *testing.T) {
 	tmpl := `http://${hostname}/_astats?application=&inf.name=${interface_name}`
 	srv := tc.TrafficServer{IP: "192.0.2.42", InterfaceName: "george"}
@@ -100,3 +113,52 @@ func TestCreateServerStatPollURL(t *testing.T) {
 		t.Errorf("expected createServerStatPollURL '" + expected + "' actual: '" + actual + "'")
 	}
 }
+
+func TestCreatePeerPollConfig(t *testing.T) {
+	srv := tc.TrafficMonitor{IP: "192.0.2.42", Port: 80, FQDN: "tm.example.net"}
+	cfg := config.DefaultConfig
+
+	pollCfg := createPeerPollConfig(srv, cfg)
+	if expected := "http://192.0.2.42:80/publish/CrStates?raw"; pollCfg.URL != expected {
+		t.Errorf("expected createPeerPollConfig URL '%v' actual: '%v'", expected, pollCfg.URL)
+	}
+
+	cfg.PeerHTTPSPort = 443
+	cfg.PeerCertFile = "/etc/tm/peer.crt"
+	cfg.PeerKeyFile = "/etc/tm/peer.key"
+	cfg.PeerCAFile = "/etc/tm/ca.crt"
+	cfg.PeerToken = "secret"
+	pollCfg = createPeerPollConfig(srv, cfg)
+	if expected := "https://192.0.2.42:443/publish/CrStates?raw"; pollCfg.URL != expected {
+		t.Errorf("expected createPeerPollConfig HTTPS URL '%v' actual: '%v'", expected, pollCfg.URL)
+	}
+	if pollCfg.TLS.CertFile != cfg.PeerCertFile || pollCfg.TLS.KeyFile != cfg.PeerKeyFile || pollCfg.TLS.CAFile != cfg.PeerCAFile {
+		t.Errorf("expected createPeerPollConfig TLS files '%v' '%v' '%v' actual: %+v", cfg.PeerCertFile, cfg.PeerKeyFile, cfg.PeerCAFile, pollCfg.TLS)
+	}
+	if pollCfg.TLS.ServerName != srv.FQDN {
+		t.Errorf("expected createPeerPollConfig TLS server name '%v' actual: '%v'", srv.FQDN, pollCfg.TLS.ServerName)
+	}
+	if pollCfg.Token != cfg.PeerToken {
+		t.Errorf("expected createPeerPollConfig token '%v' actual: '%v'", cfg.PeerToken, pollCfg.Token)
+	}
+}
+
+func TestRemoveSingleInterfacePollingInterfaces(t *testing.T) {
+	infs := []string{"bond0", "eth2"}
+	mc := tc.TrafficMonitorConfigMap{Profile: map[string]tc.TMProfile{
+		"default":    {Parameters: tc.TMParameters{HealthPollingInterfaces: infs}},
+		"astats":     {Parameters: tc.TMParameters{HealthPollingFormat: "astats", HealthPollingInterfaces: infs}},
+		"prometheus": {Parameters: tc.TMParameters{HealthPollingFormat: "prometheus", HealthPollingInterfaces: infs}},
+		"none":       {Parameters: tc.TMParameters{HealthPollingFormat: "astats"}},
+	}}
+	removeSingleInterfacePollingInterfaces(&mc)
+
+	for _, name := range []string{"default", "astats", "none"} {
+		if actual := mc.Profile[name].Parameters.HealthPollingInterfaces; len(actual) != 0 {
+			t.Errorf("removeSingleInterfacePollingInterfaces profile %v expected no interfaces, actual %v", name, actual)
+		}
+	}
+	if actual := mc.Profile["prometheus"].Parameters.HealthPollingInterfaces; len(actual) != len(infs) {
+		t.Errorf("removeSingleInterfacePollingInterfaces profile prometheus expected interfaces %v, actual %v", infs, actual)
+	}
+}
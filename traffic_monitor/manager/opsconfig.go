@@ -35,11 +35,9 @@ import (
 	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/datareq"
 	"github.com/apache/trafficcontrol/traffic_monitor/handler"
-	"github.com/apache/trafficcontrol/traffic_monitor/health"
-	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/recording"
 	"github.com/apache/trafficcontrol/traffic_monitor/srvhttp"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
-	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 	"github.com/apache/trafficcontrol/traffic_monitor/towrap"
 	to "github.com/apache/trafficcontrol/traffic_ops/client"
 
@@ -50,31 +48,14 @@ import (
 // Note the OpsConfigManager is in charge of the httpServer, because ops config changes trigger server changes. If other things needed to trigger server restarts, the server could be put in its own goroutine with signal channels
 func StartOpsConfigManager(
 	opsConfigFile string,
-	toSession towrap.ITrafficOpsSession,
-	toData todata.TODataThreadsafe,
-	opsConfigChangeSubscribers []chan<- handler.OpsConfig,
-	toChangeSubscribers []chan<- towrap.ITrafficOpsSession,
-	localStates peer.CRStatesThreadsafe,
-	peerStates peer.CRStatesPeersThreadsafe,
-	combinedStates peer.CRStatesThreadsafe,
-	statInfoHistory threadsafe.ResultInfoHistory,
-	statResultHistory threadsafe.ResultStatHistory,
-	statMaxKbpses threadsafe.CacheKbpses,
-	healthHistory threadsafe.ResultHistory,
-	lastStats threadsafe.LastStats,
-	dsStats threadsafe.DSStatsReader,
-	events health.ThreadsafeEvents,
+	monitors []CDNMonitor,
 	staticAppData config.StaticAppData,
-	healthPollInterval time.Duration,
-	lastHealthDurations threadsafe.DurationMap,
-	fetchCount threadsafe.Uint,
-	healthIteration threadsafe.Uint,
-	errorCount threadsafe.Uint,
-	localCacheStatus threadsafe.CacheAvailableStatus,
-	unpolledCaches threadsafe.UnpolledCaches,
-	monitorConfig threadsafe.TrafficMonitorConfigMap,
 	cfg config.Config,
 ) (threadsafe.OpsConfig, error) {
+	// the first monitor is of the CDN of this monitor, and the rest are additional CDNs sharing its Traffic Ops session, HTTP server, and error count.
+	toSession := monitors[0].toSession
+	errorCount := monitors[0].errorCount
+	opsConfig := monitors[0].opsConfig
 
 	handleErr := func(err error) {
 		errorCount.Inc()
@@ -83,7 +64,6 @@ func StartOpsConfigManager(
 
 	httpServer := srvhttp.Server{}
 	httpsServer := srvhttp.Server{}
-	opsConfig := threadsafe.NewOpsConfig()
 
 	// TODO remove change subscribers, give Threadsafes directly to the things that need them. If they only set vars, and don't actually do work on change.
 	onChange := func(bytes []byte, err error) {
@@ -107,30 +87,18 @@ func StartOpsConfigManager(
 			listenAddress = newOpsConfig.HttpListener
 		}
 
-		endpoints := datareq.MakeDispatchMap(
-			opsConfig,
-			toSession,
-			localStates,
-			peerStates,
-			combinedStates,
-			statInfoHistory,
-			statResultHistory,
-			statMaxKbpses,
-			healthHistory,
-			dsStats,
-			events,
-			staticAppData,
-			healthPollInterval,
-			lastHealthDurations,
-			fetchCount,
-			healthIteration,
-			errorCount,
-			toData,
-			localCacheStatus,
-			lastStats,
-			unpolledCaches,
-			monitorConfig,
-		)
+		cdnEndpoints := []datareq.CDNEndpoints{}
+		for _, m := range monitors {
+			cdnEndpoints = append(cdnEndpoints, datareq.CDNEndpoints{CDN: m.CDN, Endpoints: m.endpoints(staticAppData)})
+		}
+		endpoints := datareq.MakeCDNDispatchMap(cdnEndpoints, errorCount)
+
+		auth := srvhttp.Auth{
+			ClientCAFile:      newOpsConfig.ClientCAFile,
+			RequireClientCert: newOpsConfig.RequireClientCert,
+			Tokens:            newOpsConfig.APITokens,
+			AdminTokens:       newOpsConfig.AdminTokens,
+		}
 
 		// If the HTTPS Listener is defined in the traffic_ops.cfg file then it creates the HTTPS endpoint and the corresponding HTTP endpoint as a redirect
 		if newOpsConfig.HttpsListener != "" {
@@ -140,100 +108,114 @@ func StartOpsConfigManager(
 				handleErr(fmt.Errorf("MonitorConfigPoller: error creating HTTP server: %s\n", err))
 				return
 			}
-			err = httpsServer.Run(endpoints, httpsListenAddress, cfg.ServeReadTimeout, cfg.ServeWriteTimeout, cfg.StaticFileDir, true, newOpsConfig.CertFile, newOpsConfig.KeyFile)
+			err = httpsServer.Run(endpoints, httpsListenAddress, cfg.ServeReadTimeout, cfg.ServeWriteTimeout, cfg.StaticFileDir, true, newOpsConfig.CertFile, newOpsConfig.KeyFile, auth)
 			if err != nil {
 				handleErr(fmt.Errorf("MonitorConfigPoller: error creating HTTPS server: %s\n", err))
 				return
 			}
 		} else {
-			err = httpServer.Run(endpoints, listenAddress, cfg.ServeReadTimeout, cfg.ServeWriteTimeout, cfg.StaticFileDir, false, "", "")
+			err = httpServer.Run(endpoints, listenAddress, cfg.ServeReadTimeout, cfg.ServeWriteTimeout, cfg.StaticFileDir, false, "", "", auth)
 			if err != nil {
 				handleErr(fmt.Errorf("MonitorConfigPoller: error creating HTTP server: %s\n", err))
 				return
 			}
 		}
 
-		// TODO config? parameter?
-		useCache := false
-		trafficOpsRequestTimeout := time.Second * time.Duration(10)
-		var realToSession *to.Session
-		var toAddr net.Addr
-		var toLoginCount uint64
-
-		// fixed an issue here where traffic_monitor loops forever, doing nothing useful if traffic_ops is down,
-		// and would never logging in again.  since traffic_monitor  is just starting up here, keep retrying until traffic_ops is reachable and a session can be established.
 		backoff, err := util.NewBackoff(cfg.TrafficOpsMinRetryInterval, cfg.TrafficOpsMaxRetryInterval, util.DefaultFactor)
 		if err != nil {
 			log.Errorf("possible invalid backoff arguments, will use a fixed sleep interval: %v, will use a fallback duration: %v", err, util.ConstantBackoffDuration)
 			// use a fallback constant duration.
 			backoff = util.NewConstantBackoff(util.ConstantBackoffDuration)
 		}
-		for {
-			realToSession, toAddr, err = to.LoginWithAgent(newOpsConfig.Url, newOpsConfig.Username, newOpsConfig.Password, newOpsConfig.Insecure, staticAppData.UserAgent, useCache, trafficOpsRequestTimeout)
-			if err != nil {
-				handleErr(fmt.Errorf("MonitorConfigPoller: error instantiating Session with traffic_ops (%v): %s\n", toAddr, err))
-				duration := backoff.BackoffDuration()
-				log.Errorf("retrying in %v\n", duration)
-				time.Sleep(duration)
 
-				if toSession.BackupFileExists() && (toLoginCount >= cfg.TrafficOpsDiskRetryMax) {
-					jar, err := cookiejar.New(nil)
-					if err != nil {
-						log.Errorf("Err getting cookiejar")
-						continue
+		if replay, ok := toSession.(recording.ReplaySession); ok {
+			// a replay doesn't log in to Traffic Ops, and only has the recorded CDN
+			newOpsConfig.CdnName = replay.CDN()
+		} else {
+			// TODO config? parameter?
+			useCache := false
+			trafficOpsRequestTimeout := time.Second * time.Duration(10)
+			var realToSession *to.Session
+			var toAddr net.Addr
+			var toLoginCount uint64
+
+			// fixed an issue here where traffic_monitor loops forever, doing nothing useful if traffic_ops is down,
+			// and would never logging in again.  since traffic_monitor  is just starting up here, keep retrying until traffic_ops is reachable and a session can be established.
+			for {
+				realToSession, toAddr, err = to.LoginWithAgent(newOpsConfig.Url, newOpsConfig.Username, newOpsConfig.Password, newOpsConfig.Insecure, staticAppData.UserAgent, useCache, trafficOpsRequestTimeout)
+				if err != nil {
+					handleErr(fmt.Errorf("MonitorConfigPoller: error instantiating Session with traffic_ops (%v): %s\n", toAddr, err))
+					duration := backoff.BackoffDuration()
+					log.Errorf("retrying in %v\n", duration)
+					time.Sleep(duration)
+
+					if toSession.BackupFileExists() && (toLoginCount >= cfg.TrafficOpsDiskRetryMax) {
+						jar, err := cookiejar.New(nil)
+						if err != nil {
+							log.Errorf("Err getting cookiejar")
+							continue
+						}
+
+						realToSession = to.NewSession(newOpsConfig.Username, newOpsConfig.Password, newOpsConfig.Url, staticAppData.UserAgent, &http.Client{
+							Timeout: trafficOpsRequestTimeout,
+							Transport: &http.Transport{
+								TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+							},
+							Jar: jar,
+						}, useCache)
+						toSession.Set(realToSession)
+						// At this point we have a valid 'dummy' session. This will allow us to pull from disk but will also retry when TO comes up
+						log.Errorf("error instantiating Session with traffic_ops, backup disk files exist, creating empty traffic_ops session to read")
+						break
 					}
 
-					realToSession = to.NewSession(newOpsConfig.Username, newOpsConfig.Password, newOpsConfig.Url, staticAppData.UserAgent, &http.Client{
-						Timeout: trafficOpsRequestTimeout,
-						Transport: &http.Transport{
-							TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-						},
-						Jar: jar,
-					}, useCache)
+					toLoginCount++
+					continue
+				} else {
 					toSession.Set(realToSession)
-					// At this point we have a valid 'dummy' session. This will allow us to pull from disk but will also retry when TO comes up
-					log.Errorf("error instantiating Session with traffic_ops, backup disk files exist, creating empty traffic_ops session to read")
 					break
 				}
+			}
 
-				toLoginCount++
-				continue
+			if cdn, err := getMonitorCDN(realToSession, staticAppData.Hostname); err != nil {
+				handleErr(fmt.Errorf("getting CDN name from Traffic Ops, using config CDN '%s': %s\n", newOpsConfig.CdnName, err))
 			} else {
-				toSession.Set(realToSession)
-				break
+				if newOpsConfig.CdnName != "" && newOpsConfig.CdnName != cdn {
+					log.Warnf("%s Traffic Ops CDN '%s' doesn't match config CDN '%s' - using Traffic Ops CDN\n", staticAppData.Hostname, cdn, newOpsConfig.CdnName)
+				}
+				newOpsConfig.CdnName = cdn
 			}
 		}
 
-		if cdn, err := getMonitorCDN(realToSession, staticAppData.Hostname); err != nil {
-			handleErr(fmt.Errorf("getting CDN name from Traffic Ops, using config CDN '%s': %s\n", newOpsConfig.CdnName, err))
-		} else {
-			if newOpsConfig.CdnName != "" && newOpsConfig.CdnName != cdn {
-				log.Warnf("%s Traffic Ops CDN '%s' doesn't match config CDN '%s' - using Traffic Ops CDN\n", staticAppData.Hostname, cdn, newOpsConfig.CdnName)
+		for _, m := range monitors {
+			cdnOpsConfig := newOpsConfig
+			if m.cdn != "" {
+				cdnOpsConfig.CdnName = string(m.cdn)
+				m.opsConfig.Set(cdnOpsConfig)
 			}
-			newOpsConfig.CdnName = cdn
-		}
 
-		// fixed an issue when traffic_monitor receives corrupt data, CRConfig, from traffic_ops.
-		// Will loop and retry until a good CRConfig is received from traffic_ops
-		backoff.Reset()
-		for {
-			if err := toData.Fetch(toSession, newOpsConfig.CdnName); err != nil {
-				handleErr(fmt.Errorf("Error getting Traffic Ops data: %v\n", err))
-				duration := backoff.BackoffDuration()
-				log.Errorf("retrying in %v\n", duration)
-				time.Sleep(duration)
-				continue
+			// fixed an issue when traffic_monitor receives corrupt data, CRConfig, from traffic_ops.
+			// Will loop and retry until a good CRConfig is received from traffic_ops
+			backoff.Reset()
+			for {
+				if err := m.toData.Fetch(m.toSession, cdnOpsConfig.CdnName); err != nil {
+					handleErr(fmt.Errorf("Error getting Traffic Ops data for CDN '%s': %v\n", cdnOpsConfig.CdnName, err))
+					duration := backoff.BackoffDuration()
+					log.Errorf("retrying in %v\n", duration)
+					time.Sleep(duration)
+					continue
+				}
+				break
 			}
-			break
-		}
 
-		// These must be in a goroutine, because the monitorConfigPoller tick sends to a channel this select listens for. Thus, if we block on sends to the monitorConfigPoller, we have a livelock race condition.
-		// More generically, we're using goroutines as an infinite chan buffer, to avoid potential livelocks
-		for _, subscriber := range opsConfigChangeSubscribers {
-			go func(s chan<- handler.OpsConfig) { s <- newOpsConfig }(subscriber)
-		}
-		for _, subscriber := range toChangeSubscribers {
-			go func(s chan<- towrap.ITrafficOpsSession) { s <- toSession }(subscriber)
+			// These must be in a goroutine, because the monitorConfigPoller tick sends to a channel this select listens for. Thus, if we block on sends to the monitorConfigPoller, we have a livelock race condition.
+			// More generically, we're using goroutines as an infinite chan buffer, to avoid potential livelocks
+			for _, subscriber := range m.opsConfigSubscribers {
+				go func(s chan<- handler.OpsConfig) { s <- cdnOpsConfig }(subscriber)
+			}
+			for _, subscriber := range m.toChangeSubscribers {
+				go func(s chan<- towrap.ITrafficOpsSession, toSession towrap.ITrafficOpsSession) { s <- toSession }(subscriber, m.toSession)
+			}
 		}
 	}
 
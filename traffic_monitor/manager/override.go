@@ -0,0 +1,47 @@
+package manager
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"time"
+
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/override"
+)
+
+// OverrideExpireInterval is how often overrides are checked for expiry.
+const OverrideExpireInterval = time.Second
+
+// StartOverrideExpirer starts a goroutine which clears overrides when they expire, adding an event and combining states for each.
+func StartOverrideExpirer(overrides override.OverridesThreadsafe, events health.ThreadsafeEvents, combineState func()) {
+	go func() {
+		previous := time.Now()
+		for now := range time.Tick(OverrideExpireInterval) {
+			expired := overrides.Expire(previous, now)
+			for _, ov := range expired {
+				events.Add(health.OverrideEvent(ov, now))
+			}
+			if len(expired) > 0 {
+				combineState()
+			}
+			previous = now
+		}
+	}()
+}
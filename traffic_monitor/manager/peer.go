@@ -20,22 +20,29 @@ package manager
  */
 
 import (
+	"time"
+
 	"github.com/apache/trafficcontrol/lib/go-util"
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/override"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
 )
 
-// StartPeerManager listens for peer results, and when it gets one, it adds it to the peerStates list, and optimistically combines the good results into combinedStates
+// StartPeerManager listens for peer results, and when it gets one, it adds it to the peerStates list, merges the peer's overrides, and optimistically combines the good results into combinedStates
 func StartPeerManager(
 	peerChan <-chan peer.Result,
 	peerStates peer.CRStatesPeersThreadsafe,
 	events health.ThreadsafeEvents,
+	overrides override.OverridesThreadsafe,
 	combineState func(),
 ) {
 	go func() {
 		for peerResult := range peerChan {
 			comparePeerState(events, peerResult, peerStates)
 			peerStates.Set(peerResult)
+			if peerResult.Available {
+				mergePeerOverrides(events, overrides, peerResult.Overrides)
+			}
 			combineState()
 			peerResult.PollFinished <- peerResult.PollID
 		}
@@ -55,3 +62,14 @@ func comparePeerState(events health.ThreadsafeEvents, result peer.Result, peerSt
 		events.Add(health.Event{Time: health.Time(result.Time), Description: description, Name: result.ID.String(), Hostname: result.ID.String(), Type: "PEER", Available: result.Available})
 	}
 }
+
+// mergePeerOverrides merges the given peer overrides, adding an event for each which was newer than the local override.
+func mergePeerOverrides(events health.ThreadsafeEvents, overrides override.OverridesThreadsafe, peerOverrides override.Overrides) {
+	if len(peerOverrides) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, ov := range overrides.Merge(peerOverrides) {
+		events.Add(health.OverrideEvent(ov, now))
+	}
+}
@@ -0,0 +1,86 @@
+package manager
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR nCONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/traffic_monitor/ds"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/persist"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+)
+
+// StartStatPersister starts the goroutine which records downsampled cache stats to the given store, every interval. Does not return.
+func StartStatPersister(
+	store *persist.Store,
+	interval time.Duration,
+	localStates peer.CRStatesThreadsafe,
+	localCacheStatus threadsafe.CacheAvailableStatus,
+	lastStats threadsafe.LastStats,
+	statMaxKbpses threadsafe.CacheKbpses,
+	statInfoHistory threadsafe.ResultInfoHistory,
+) {
+	go func() {
+		tick := time.NewTicker(interval)
+		for now := range tick.C {
+			records := statRecords(now, localStates, localCacheStatus, lastStats, statMaxKbpses, statInfoHistory)
+			if err := store.AddStats(records); err != nil {
+				log.Errorf("persisting stats: %v\n", err)
+			}
+		}
+	}()
+}
+
+// statRecords returns a stat record of each locally monitored cache, at the given time.
+func statRecords(
+	now time.Time,
+	localStates peer.CRStatesThreadsafe,
+	localCacheStatus threadsafe.CacheAvailableStatus,
+	lastStats threadsafe.LastStats,
+	statMaxKbpses threadsafe.CacheKbpses,
+	statInfoHistory threadsafe.ResultInfoHistory,
+) []persist.StatRecord {
+	statuses := localCacheStatus.Get()
+	lastStatsVal := lastStats.Get()
+	maxKbpses := statMaxKbpses.Get()
+	infoHistory := statInfoHistory.Get()
+
+	records := []persist.StatRecord{}
+	for cacheName, available := range localStates.GetCaches() {
+		record := persist.StatRecord{
+			Time:         health.Time(now),
+			Cache:        cacheName,
+			Available:    available.IsAvailable,
+			Status:       statuses[cacheName].Status,
+			CapacityKbps: maxKbpses[cacheName],
+		}
+		if stat, ok := lastStatsVal.Caches[cacheName]; ok {
+			record.BandwidthKbps = stat.Bytes.PerSec / float64(ds.BytesPerKilobit)
+		}
+		if infos := infoHistory[cacheName]; len(infos) > 0 {
+			record.LoadAverage = infos[0].Vitals.LoadAvg
+		}
+		records = append(records, record)
+	}
+	return records
+}
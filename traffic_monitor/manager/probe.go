@@ -0,0 +1,169 @@
+package manager
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR nCONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
+	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+// probeTarget is a delivery service probe to make through a particular cache.
+type probeTarget struct {
+	ds   tc.DeliveryServiceName
+	url  string
+	addr string
+}
+
+// StartDSProber starts the goroutine which probes the configured URL of each delivery service through each of its caches, every interval, and returns the probe results. If no probe URLs are configured, no goroutine is started, and the results are always empty.
+func StartDSProber(
+	cfg config.Config,
+	monitorConfig threadsafe.TrafficMonitorConfigMap,
+	toData todata.TODataThreadsafe,
+	events health.ThreadsafeEvents,
+) probe.ResultsThreadsafe {
+	results := probe.NewResultsThreadsafe()
+	if len(cfg.DSProbeURLs) == 0 {
+		return results
+	}
+	if cfg.ReplayFile != "" {
+		log.Warnln("delivery service probes are not recorded, not probing while replaying")
+		return results
+	}
+	probeURLs := map[tc.DeliveryServiceName]string{}
+	for ds, probeURL := range cfg.DSProbeURLs {
+		probeURLs[tc.DeliveryServiceName(ds)] = probeURL
+	}
+	client := probe.NewClient(cfg.HTTPTimeout)
+	spacing := time.Duration(float64(time.Second) / cfg.DSProbeMaxPerCachePerSecond)
+	go func() {
+		lastProbes := map[tc.CacheName]time.Time{}
+		for {
+			start := time.Now()
+			probeDeliveryServices(client, probeURLs, spacing, cfg, monitorConfig.Get(), toData.Get(), results, lastProbes, events)
+			time.Sleep(cfg.DSProbeInterval - time.Since(start))
+		}
+	}()
+	return results
+}
+
+// probeDeliveryServices probes every target once, concurrently across caches but sequentially on each cache, with at least spacing between the probes of each cache. The lastProbes are the times of the last probe of each cache, and are updated. Caches whose probes change their availability for a delivery service create an event.
+func probeDeliveryServices(
+	client *http.Client,
+	probeURLs map[tc.DeliveryServiceName]string,
+	spacing time.Duration,
+	cfg config.Config,
+	mc tc.TrafficMonitorConfigMap,
+	toData todata.TOData,
+	results probe.ResultsThreadsafe,
+	lastProbes map[tc.CacheName]time.Time,
+	events health.ThreadsafeEvents,
+) {
+	type cacheResults struct {
+		cache     tc.CacheName
+		lastProbe time.Time
+		results   map[tc.DeliveryServiceName]probe.Result
+	}
+
+	targets := getProbeTargets(probeURLs, mc, toData)
+	resultChan := make(chan cacheResults, len(targets))
+	for cache, cacheTargets := range targets {
+		go func(cache tc.CacheName, cacheTargets []probeTarget, lastProbe time.Time) {
+			r := cacheResults{cache: cache, results: map[tc.DeliveryServiceName]probe.Result{}}
+			for _, target := range cacheTargets {
+				time.Sleep(time.Until(lastProbe.Add(spacing)))
+				lastProbe = time.Now()
+				r.results[target.ds] = probe.Probe(client, target.url, target.addr, cfg.DSProbeMaxTTFB)
+			}
+			r.lastProbe = lastProbe
+			resultChan <- r
+		}(cache, cacheTargets, lastProbes[cache])
+	}
+
+	oldResults := results.Get()
+	newResults := probe.Results{}
+	for range targets {
+		r := <-resultChan
+		lastProbes[r.cache] = r.lastProbe
+		for ds, result := range r.results {
+			old, hasOld := oldResults[ds][r.cache]
+			result = old.Next(result, cfg.DSProbeFailureThreshold)
+			newResults.Add(ds, r.cache, result)
+			if (hasOld && old.Available == result.Available) || (!hasOld && result.Available) {
+				continue
+			}
+			desc := "delivery service " + string(ds) + " probe succeeded"
+			if !result.Available {
+				desc = "delivery service " + string(ds) + " probe failed: " + result.Error
+			}
+			log.Infof("Changing probe state for %s on %s now: %t because %s\n", ds, r.cache, result.Available, desc)
+			events.Add(health.Event{Time: health.Time(events.Now()), Description: desc, Name: string(r.cache), Hostname: string(r.cache), Type: toData.ServerTypes[r.cache].String(), Available: result.Available})
+		}
+	}
+	for cache := range lastProbes {
+		if _, ok := targets[cache]; !ok {
+			delete(lastProbes, cache)
+		}
+	}
+	results.Set(newResults)
+}
+
+// getProbeTargets returns the probes to make through each cache. Only caches which are assigned to a delivery service with a probe URL, and are REPORTED or ONLINE, are probed.
+func getProbeTargets(probeURLs map[tc.DeliveryServiceName]string, mc tc.TrafficMonitorConfigMap, toData todata.TOData) map[tc.CacheName][]probeTarget {
+	targets := map[tc.CacheName][]probeTarget{}
+	for ds, probeURL := range probeURLs {
+		u, err := url.Parse(probeURL)
+		if err != nil {
+			log.Errorf("probing delivery service %v: parsing URL '%v': %v\n", ds, probeURL, err)
+			continue
+		}
+		for _, cache := range toData.DeliveryServiceServers[ds] {
+			server, ok := mc.TrafficServer[string(cache)]
+			if !ok || server.IP == "" {
+				continue
+			}
+			if status := tc.CacheStatusFromString(server.ServerStatus); status != tc.CacheStatusReported && status != tc.CacheStatusOnline {
+				continue
+			}
+			port := server.Port
+			if u.Scheme == "https" {
+				port = server.HTTPSPort
+			}
+			if port == 0 {
+				port = 80
+				if u.Scheme == "https" {
+					port = 443
+				}
+			}
+			targets[cache] = append(targets[cache], probeTarget{ds: ds, url: probeURL, addr: net.JoinHostPort(server.IP, strconv.Itoa(port))})
+		}
+	}
+	return targets
+}
@@ -0,0 +1,79 @@
+package manager
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/shard"
+)
+
+// PeerStatsPath is the path and query of the peer cache stats polled when polling is sharded. The `local` parameter returns only the stats of caches polled by the peer itself, so stats aren't relayed between peers.
+const PeerStatsPath = "/publish/CacheStats?local&hc=0"
+
+// getShard returns this monitor's shard of the cache polling. The members are this monitor, and the given ONLINE peers which are available or haven't been polled yet. Thus, a starting cluster divides polling immediately, and a peer which goes offline is dropped, and its caches rebalanced across the remaining members.
+func getShard(self tc.TrafficMonitorName, replicas int, peers map[tc.TrafficMonitorName]struct{}, peerStates peer.CRStatesPeersThreadsafe) shard.Shard {
+	queryTimes := peerStates.GetQueryTimes()
+	members := []tc.TrafficMonitorName{self}
+	for peerName := range peers {
+		if _, polled := queryTimes[peerName]; polled && !peerStates.GetPeerAvailability(peerName) {
+			continue
+		}
+		members = append(members, peerName)
+	}
+	return shard.New(self, replicas, members)
+}
+
+// filterShardURLs returns the poll configs of the caches this monitor polls. IPv6 poll IDs are polled by the owner of their cache.
+func filterShardURLs(urls map[string]poller.PollConfig, sh shard.Shard) map[string]poller.PollConfig {
+	if !sh.Enabled() {
+		return urls
+	}
+	filtered := map[string]poller.PollConfig{}
+	for id, pollCfg := range urls {
+		if sh.Polls(tc.CacheName(strings.TrimSuffix(id, cache.IPv6PollIDSuffix))) {
+			filtered[id] = pollCfg
+		}
+	}
+	return filtered
+}
+
+// createPeerStatPollConfigs returns the poll configs of the cache stats of the shard's peer members, from their CrStates poll configs. If polling isn't sharded, no peer stats are polled.
+func createPeerStatPollConfigs(peerURLs map[string]poller.PollConfig, sh shard.Shard) map[string]poller.PollConfig {
+	statURLs := map[string]poller.PollConfig{}
+	if !sh.Enabled() {
+		return statURLs
+	}
+	for _, member := range sh.Members {
+		pollCfg, ok := peerURLs[string(member)]
+		if !ok {
+			continue // self
+		}
+		if i := strings.Index(pollCfg.URL, "/publish/"); i >= 0 {
+			pollCfg.URL = pollCfg.URL[:i] + PeerStatsPath
+		}
+		statURLs[string(member)] = pollCfg
+	}
+	return statURLs
+}
@@ -0,0 +1,79 @@
+package manager
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/shard"
+)
+
+func TestFilterShardURLs(t *testing.T) {
+	urls := map[string]poller.PollConfig{}
+	for _, name := range []string{"edge0", "edge1", "edge2", "edge3", "edge4", "edge5"} {
+		urls[name] = poller.PollConfig{URL: "http://" + name + "/_astats"}
+		urls[name+cache.IPv6PollIDSuffix] = poller.PollConfig{URL: "http://[::1]/_astats"}
+	}
+
+	if filtered := filterShardURLs(urls, shard.Shard{}); len(filtered) != len(urls) {
+		t.Errorf("expected unsharded monitor to poll all %v URLs, actual %v", len(urls), len(filtered))
+	}
+
+	members := []tc.TrafficMonitorName{"tm0", "tm1"}
+	polled := 0
+	for _, member := range members {
+		sh := shard.New(member, 1, members)
+		filtered := filterShardURLs(urls, sh)
+		for id := range filtered {
+			if _, ok := filtered[id+cache.IPv6PollIDSuffix]; !ok && len(id) == len("edge0") {
+				t.Errorf("expected %v to poll the IPv6 address of %v", member, id)
+			}
+		}
+		polled += len(filtered)
+	}
+	if polled != len(urls) {
+		t.Errorf("expected each URL to be polled by exactly 1 monitor, actual %v polls of %v URLs", polled, len(urls))
+	}
+}
+
+func TestCreatePeerStatPollConfigs(t *testing.T) {
+	peerURLs := map[string]poller.PollConfig{
+		"tm1": {URL: "http://tm1:80/publish/CrStates?raw", Host: "tm1.example.net"},
+		"tm2": {URL: "https://tm2:443/publish/CrStates?raw", Host: "tm2.example.net"},
+	}
+	if statURLs := createPeerStatPollConfigs(peerURLs, shard.Shard{}); len(statURLs) != 0 {
+		t.Errorf("expected no peer stats polled when unsharded, actual %+v", statURLs)
+	}
+
+	sh := shard.New("tm0", 1, []tc.TrafficMonitorName{"tm0", "tm1"}) // tm2 is unavailable
+	statURLs := createPeerStatPollConfigs(peerURLs, sh)
+	if len(statURLs) != 1 {
+		t.Fatalf("expected only member peer stats polled, actual %+v", statURLs)
+	}
+	if expected := "http://tm1:80" + PeerStatsPath; statURLs["tm1"].URL != expected {
+		t.Errorf("expected peer stat URL %v, actual %v", expected, statURLs["tm1"].URL)
+	}
+	if statURLs["tm1"].Host != "tm1.example.net" {
+		t.Errorf("expected peer stat host to be kept, actual %v", statURLs["tm1"].Host)
+	}
+}
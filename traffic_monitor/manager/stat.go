@@ -31,6 +31,9 @@ import (
 	"github.com/apache/trafficcontrol/traffic_monitor/ds"
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/poller"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
+	"github.com/apache/trafficcontrol/traffic_monitor/shard"
 	"github.com/apache/trafficcontrol/traffic_monitor/threadsafe"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 )
@@ -42,14 +45,19 @@ func pruneHistory(history []cache.Result, limit uint64) []cache.Result {
 	return history
 }
 
-func getNewCaches(localStates peer.CRStatesThreadsafe, monitorConfigTS threadsafe.TrafficMonitorConfigMap) map[tc.CacheName]struct{} {
+func getNewCaches(localStates peer.CRStatesThreadsafe, monitorConfigTS threadsafe.TrafficMonitorConfigMap, shards shard.Threadsafe) map[tc.CacheName]struct{} {
 	monitorConfig := monitorConfigTS.Get()
+	sh := shards.Get()
 	caches := map[tc.CacheName]struct{}{}
 	for cacheName := range localStates.GetCaches() {
 		// ONLINE and OFFLINE caches are not polled.
 		if ts, ok := monitorConfig.TrafficServer[string(cacheName)]; !ok || ts.ServerStatus == string(tc.CacheStatusOnline) || ts.ServerStatus == string(tc.CacheStatusOffline) {
 			continue
 		}
+		// caches polled by other monitors of the shard are never polled locally
+		if !sh.Polls(cacheName) {
+			continue
+		}
 		caches[cacheName] = struct{}{}
 	}
 	return caches
@@ -57,7 +65,7 @@ func getNewCaches(localStates peer.CRStatesThreadsafe, monitorConfigTS threadsaf
 
 // StartStatHistoryManager fetches the full statistics data from ATS Astats. This includes everything needed for all calculations, such as Delivery Services. This is expensive, though, and may be hard on ATS, so it should poll less often.
 // For a fast 'is it alive' poll, use the Health Result Manager poll.
-// Returns the stat history, the duration between the stat poll for each cache, the last Kbps data, the calculated Delivery Service stats, and the unpolled caches list.
+// Returns the stat history, the duration between the stat poll for each cache, the last Kbps data, the calculated Delivery Service stats, the unpolled caches list, the local cache statuses, and the cache groups disabled by delivery service health rules.
 func StartStatHistoryManager(
 	cacheStatChan <-chan cache.Result,
 	localStates peer.CRStatesThreadsafe,
@@ -69,7 +77,10 @@ func StartStatHistoryManager(
 	monitorConfig threadsafe.TrafficMonitorConfigMap,
 	events health.ThreadsafeEvents,
 	combineState func(),
-) (threadsafe.ResultInfoHistory, threadsafe.ResultStatHistory, threadsafe.CacheKbpses, threadsafe.DurationMap, threadsafe.LastStats, threadsafe.DSStatsReader, threadsafe.UnpolledCaches, threadsafe.CacheAvailableStatus) {
+	dsProbes probe.ResultsThreadsafe,
+	shards shard.Threadsafe,
+	schedule poller.Schedule,
+) (threadsafe.ResultInfoHistory, threadsafe.ResultStatHistory, threadsafe.CacheKbpses, threadsafe.DurationMap, threadsafe.LastStats, threadsafe.DSStatsReader, threadsafe.UnpolledCaches, threadsafe.CacheAvailableStatus, threadsafe.DisabledCacheGroups) {
 	statInfoHistory := threadsafe.NewResultInfoHistory()
 	statResultHistory := threadsafe.NewResultStatHistory()
 	statMaxKbpses := threadsafe.NewCacheKbpses()
@@ -79,6 +90,7 @@ func StartStatHistoryManager(
 	dsStats := threadsafe.NewDSStats()
 	unpolledCaches := threadsafe.NewUnpolledCaches()
 	localCacheStatus := threadsafe.NewCacheAvailableStatus()
+	dsDisabledCacheGroups := threadsafe.NewDisabledCacheGroups()
 
 	precomputedData := map[tc.CacheName]cache.PrecomputedData{}
 
@@ -96,9 +108,9 @@ func StartStatHistoryManager(
 
 	process := func(results []cache.Result) {
 		if haveCachesChanged() {
-			unpolledCaches.SetNewCaches(getNewCaches(localStates, monitorConfig))
+			unpolledCaches.SetNewCaches(getNewCaches(localStates, monitorConfig, shards))
 		}
-		processStatResults(results, statInfoHistory, statResultHistory, statMaxKbpses, combinedStates, lastStats, toData.Get(), errorCount, dsStats, lastStatEndTimes, lastStatDurations, unpolledCaches, monitorConfig.Get(), precomputedData, lastResults, localStates, events, localCacheStatus, overrideMap, combineState)
+		processStatResults(results, statInfoHistory, statResultHistory, statMaxKbpses, combinedStates, lastStats, toData.Get(), errorCount, dsStats, lastStatEndTimes, lastStatDurations, unpolledCaches, monitorConfig.Get(), precomputedData, lastResults, localStates, events, localCacheStatus, overrideMap, combineState, dsProbes, dsDisabledCacheGroups, cfg.DSHealthRules, schedule)
 	}
 
 	go func() {
@@ -217,7 +229,7 @@ func StartStatHistoryManager(
 			}
 		}
 	}()
-	return statInfoHistory, statResultHistory, statMaxKbpses, lastStatDurations, lastStats, &dsStats, unpolledCaches, localCacheStatus
+	return statInfoHistory, statResultHistory, statMaxKbpses, lastStatDurations, lastStats, &dsStats, unpolledCaches, localCacheStatus, dsDisabledCacheGroups
 }
 
 func stacktrace() []byte {
@@ -254,6 +266,10 @@ func processStatResults(
 	localCacheStatusThreadsafe threadsafe.CacheAvailableStatus,
 	overrideMap map[tc.CacheName]bool,
 	combineState func(),
+	dsProbes probe.ResultsThreadsafe,
+	dsDisabledCacheGroups threadsafe.DisabledCacheGroups,
+	dsHealthRules config.DSHealthRules,
+	schedule poller.Schedule,
 ) {
 	if len(results) == 0 {
 		return
@@ -277,6 +293,9 @@ func processStatResults(
 			maxStats = 1
 		}
 
+		combineInterfaces(&result, &mc)
+		results[i] = result
+
 		// TODO determine if we want to add results with errors, or just print the errors now and don't add them.
 		if lastResult, ok := lastResults[result.ID]; ok && result.Error == nil {
 			health.GetVitals(&result, &lastResult, &mc) // TODO precompute
@@ -308,7 +327,7 @@ func processStatResults(
 
 	lastStatsVal := lastStats.Get()
 	lastStatsCopy := lastStatsVal.Copy()
-	newDsStats, err := ds.CreateStats(precomputedData, toData, combinedStates, lastStatsCopy, time.Now(), mc, events, localStates)
+	newDsStats, err := ds.CreateStats(precomputedData, toData, combinedStates, lastStatsCopy, events.Now(), mc, events, localStates, dsHealthRules)
 
 	if err != nil {
 		errorCount.Inc()
@@ -316,10 +335,11 @@ func processStatResults(
 	} else {
 		dsStats.Set(*newDsStats)
 		lastStats.Set(*lastStatsCopy)
+		dsDisabledCacheGroups.Set(lastStatsCopy.DisabledCacheGroups())
 	}
 
 	pollerName := "stat"
-	health.CalcAvailability(results, pollerName, &statResultHistoryThreadsafe, mc, toData, localCacheStatusThreadsafe, localStates, events)
+	health.CalcAvailability(results, pollerName, &statResultHistoryThreadsafe, mc, toData, localCacheStatusThreadsafe, localStates, events, dsProbes, dsDisabledCacheGroups, schedule)
 	combineState()
 
 	endTime := time.Now()
@@ -27,14 +27,20 @@ import (
 
 	"github.com/apache/trafficcontrol/lib/go-log"
 	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/override"
 	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/shard"
+	"github.com/apache/trafficcontrol/traffic_monitor/statestream"
 	"github.com/apache/trafficcontrol/traffic_monitor/todata"
 )
 
-// StartStateCombiner starts the State Combiner goroutine, and returns the threadsafe CombinedStates, and a func to signal to combine states.
-func StartStateCombiner(events health.ThreadsafeEvents, peerStates peer.CRStatesPeersThreadsafe, localStates peer.CRStatesThreadsafe, toData todata.TODataThreadsafe) (peer.CRStatesThreadsafe, func()) {
+// StartStateCombiner starts the State Combiner goroutine, and returns the threadsafe CombinedStates, the threadsafe votes of each cache when combining by quorum, and a func to signal to combine states. Each combination's changes are published to the given stateStream. Active overrides take precedence over the combined availability of caches. The localName is the name of this Traffic Monitor, for quorum votes. If polling is sharded, only the monitors polling each cache vote on it.
+func StartStateCombiner(events health.ThreadsafeEvents, peerStates peer.CRStatesPeersThreadsafe, localStates peer.CRStatesThreadsafe, toData todata.TODataThreadsafe, stateStream statestream.Stream, overrides override.OverridesThreadsafe, cfg config.Config, localName tc.TrafficMonitorName, shards shard.Threadsafe) (peer.CRStatesThreadsafe, peer.CacheVotesThreadsafe, func()) {
 	combinedStates := peer.NewCRStatesThreadsafe()
+	cacheVotes := peer.NewCacheVotesThreadsafe()
+	peerCombineMode := cfg.GetPeerCombineMode()
 
 	// the chan buffer just reduces the number of goroutines on our infinite buffer hack in combineState(), no real writer will block, since combineState() writes in a goroutine.
 	combineStateChan := make(chan struct{}, 5)
@@ -58,11 +64,15 @@ func StartStateCombiner(events health.ThreadsafeEvents, peerStates peer.CRStates
 		overrideMap := map[tc.CacheName]bool{}
 		for range combineStateChan {
 			drain(combineStateChan)
-			combineCrStates(events, true, peerStates, localStates.Get(), combinedStates, overrideMap, toData.Get())
+			toDataCopy := toData.Get()
+			localStatesCopy := localStates.Get()
+			combineCrStates(events, peerCombineMode, cfg.PeerQuorumFraction, localName, shards.Get(), peerStates, localStatesCopy, combinedStates, cacheVotes, overrideMap, toDataCopy)
+			applyOverrides(overrides.Get(), localStatesCopy, combinedStates, toDataCopy, time.Now())
+			stateStream.PublishStates(combinedStates.Get())
 		}
 	}()
 
-	return combinedStates, combineState
+	return combinedStates, cacheVotes, combineState
 }
 
 func combineCacheState(cacheName tc.CacheName, localCacheState tc.IsAvailable, events health.ThreadsafeEvents, peerOptimistic bool, peerStates peer.CRStatesPeersThreadsafe, localStates tc.CRStates, combinedStates peer.CRStatesThreadsafe, overrideMap map[tc.CacheName]bool, toData todata.TOData) {
@@ -111,10 +121,182 @@ func combineCacheState(cacheName tc.CacheName, localCacheState tc.IsAvailable, e
 	}
 
 	if overrideCondition != "" {
-		events.Add(health.Event{Time: health.Time(time.Now()), Description: fmt.Sprintf("Health protocol override condition %s", overrideCondition), Name: cacheName.String(), Hostname: cacheName.String(), Type: toData.ServerTypes[cacheName].String(), Available: available})
+		events.Add(health.Event{Time: health.Time(events.Now()), Description: fmt.Sprintf("Health protocol override condition %s", overrideCondition), Name: cacheName.String(), Hostname: cacheName.String(), Type: toData.ServerTypes[cacheName].String(), Available: available})
 	}
 
-	combinedStates.AddCache(cacheName, tc.IsAvailable{IsAvailable: available})
+	ipv6Available := localCacheState.Ipv6Available
+	if peerOptimistic && !ipv6Available {
+		for _, peerCrStates := range availablePeerStates(peerStates) {
+			if peerCacheState, ok := peerCrStates.Caches[cacheName]; ok && peerIPv6Available(peerCacheState) {
+				ipv6Available = true
+				break
+			}
+		}
+	}
+	combinedStates.AddCache(cacheName, tc.IsAvailable{IsAvailable: available, Ipv4Available: available, Ipv6Available: ipv6Available})
+}
+
+// combineCacheStateQuorum combines the given cache's local state with the states of the given available peers, marking it unavailable only if more than quorumFraction of this and the peer monitors report it unavailable. Returns the votes of each monitor.
+func combineCacheStateQuorum(
+	cacheName tc.CacheName,
+	localCacheState tc.IsAvailable,
+	events health.ThreadsafeEvents,
+	localName tc.TrafficMonitorName,
+	quorumFraction float64,
+	availablePeerStates map[tc.TrafficMonitorName]tc.CRStates,
+	combinedStates peer.CRStatesThreadsafe,
+	overrideMap map[tc.CacheName]bool,
+	toData todata.TOData,
+) peer.CacheVotes {
+	votes := peer.CacheVotes{Available: []tc.TrafficMonitorName{}, Unavailable: []tc.TrafficMonitorName{}} // important to initialize, so JSON is `[]` not `null`
+	vote := func(monitor tc.TrafficMonitorName, available bool) {
+		if available {
+			votes.Available = append(votes.Available, monitor)
+		} else {
+			votes.Unavailable = append(votes.Unavailable, monitor)
+		}
+	}
+
+	vote(localName, localCacheState.IsAvailable)
+	for peerName, peerCrStates := range availablePeerStates {
+		if peerCacheState, ok := peerCrStates.Caches[cacheName]; ok {
+			vote(peerName, peerCacheState.IsAvailable)
+		}
+	}
+	sort.Sort(TrafficMonitorNameSlice(votes.Available))
+	sort.Sort(TrafficMonitorNameSlice(votes.Unavailable))
+
+	available := float64(len(votes.Unavailable)) <= quorumFraction*float64(len(votes.Available)+len(votes.Unavailable))
+
+	overrideCondition := ""
+	if override := overrideMap[cacheName]; available != localCacheState.IsAvailable && !override {
+		overrideCondition = "detected"
+		overrideMap[cacheName] = true
+	} else if available == localCacheState.IsAvailable && override {
+		overrideCondition = "cleared"
+		overrideMap[cacheName] = false
+	}
+
+	if overrideCondition != "" {
+		events.Add(health.Event{Time: health.Time(events.Now()), Description: fmt.Sprintf("Health protocol quorum override %s; available on %s; unavailable on %s", overrideCondition, monitorNamesStr(votes.Available), monitorNamesStr(votes.Unavailable)), Name: cacheName.String(), Hostname: cacheName.String(), Type: toData.ServerTypes[cacheName].String(), Available: available})
+	}
+
+	ipv6Unavailable := 0
+	if !localCacheState.Ipv6Available {
+		ipv6Unavailable++
+	}
+	ipv6Votes := 1
+	for _, peerCrStates := range availablePeerStates {
+		if peerCacheState, ok := peerCrStates.Caches[cacheName]; ok {
+			ipv6Votes++
+			if !peerIPv6Available(peerCacheState) {
+				ipv6Unavailable++
+			}
+		}
+	}
+	ipv6Available := float64(ipv6Unavailable) <= quorumFraction*float64(ipv6Votes)
+
+	combinedStates.AddCache(cacheName, tc.IsAvailable{IsAvailable: available, Ipv4Available: available, Ipv6Available: ipv6Available})
+	return votes
+}
+
+// combineCacheStateSharded combines the given cache's state when polling is sharded. Only the monitors polling the cache vote: this monitor if it polls the cache, and the available peers which poll it.
+// In quorum mode, the cache is unavailable if more than quorumFraction of the voters report it unavailable. In pessimistic mode, the local state is used if this monitor polls the cache. Otherwise, the cache is available if any voter reports it available. If no monitor polling the cache has reported, the local state is used. Returns the votes of each monitor.
+func combineCacheStateSharded(
+	cacheName tc.CacheName,
+	localCacheState tc.IsAvailable,
+	events health.ThreadsafeEvents,
+	peerCombineMode string,
+	quorumFraction float64,
+	sh shard.Shard,
+	availablePeerStates map[tc.TrafficMonitorName]tc.CRStates,
+	combinedStates peer.CRStatesThreadsafe,
+	toData todata.TOData,
+) peer.CacheVotes {
+	votes := peer.CacheVotes{Available: []tc.TrafficMonitorName{}, Unavailable: []tc.TrafficMonitorName{}} // important to initialize, so JSON is `[]` not `null`
+	ipv6Available := 0
+	ipv6Unavailable := 0
+	vote := func(monitor tc.TrafficMonitorName, available bool, ipv6 bool) {
+		if available {
+			votes.Available = append(votes.Available, monitor)
+		} else {
+			votes.Unavailable = append(votes.Unavailable, monitor)
+		}
+		if ipv6 {
+			ipv6Available++
+		} else {
+			ipv6Unavailable++
+		}
+	}
+
+	pollsLocally := sh.Polls(cacheName)
+	if pollsLocally {
+		vote(sh.Self, localCacheState.IsAvailable, localCacheState.Ipv6Available)
+	}
+	for _, owner := range sh.Owners(cacheName) {
+		if owner == sh.Self {
+			continue
+		}
+		if peerCrStates, ok := availablePeerStates[owner]; ok {
+			if peerCacheState, ok := peerCrStates.Caches[cacheName]; ok {
+				vote(owner, peerCacheState.IsAvailable, peerIPv6Available(peerCacheState))
+			}
+		}
+	}
+	sort.Sort(TrafficMonitorNameSlice(votes.Available))
+	sort.Sort(TrafficMonitorNameSlice(votes.Unavailable))
+
+	state := localCacheState
+	numVotes := len(votes.Available) + len(votes.Unavailable)
+	switch {
+	case numVotes == 0:
+	case peerCombineMode == config.PeerCombineModePessimistic && pollsLocally:
+	case peerCombineMode == config.PeerCombineModeQuorum:
+		state.IsAvailable = float64(len(votes.Unavailable)) <= quorumFraction*float64(numVotes)
+		state.Ipv4Available = state.IsAvailable
+		state.Ipv6Available = float64(ipv6Unavailable) <= quorumFraction*float64(numVotes)
+	default:
+		state.IsAvailable = len(votes.Available) > 0
+		state.Ipv4Available = state.IsAvailable
+		state.Ipv6Available = ipv6Available > 0
+	}
+
+	if prevState, ok := combinedStates.GetCache(cacheName); ok && !pollsLocally && prevState.IsAvailable != state.IsAvailable {
+		events.Add(health.Event{Time: health.Time(events.Now()), Description: fmt.Sprintf("Health protocol shard state changed; available on %s; unavailable on %s", monitorNamesStr(votes.Available), monitorNamesStr(votes.Unavailable)), Name: cacheName.String(), Hostname: cacheName.String(), Type: toData.ServerTypes[cacheName].String(), Available: state.IsAvailable})
+	}
+
+	combinedStates.AddCache(cacheName, tc.IsAvailable{IsAvailable: state.IsAvailable, Ipv4Available: state.Ipv4Available, Ipv6Available: state.Ipv6Available})
+	return votes
+}
+
+// peerIPv6Available returns whether the given peer's state of a cache is available over IPv6. Peers which don't report availability per protocol are assumed to have the same IPv6 availability as their overall availability.
+func peerIPv6Available(state tc.IsAvailable) bool {
+	if state.IsAvailable && !state.Ipv4Available {
+		return true // the peer doesn't report availability per protocol
+	}
+	return state.Ipv6Available
+}
+
+// availablePeerStates returns the CRStates of peers which are available, that is, reachable, online, and not stale.
+func availablePeerStates(peerStates peer.CRStatesPeersThreadsafe) map[tc.TrafficMonitorName]tc.CRStates {
+	available := map[tc.TrafficMonitorName]tc.CRStates{}
+	for peerName, peerCrStates := range peerStates.GetCrstates() {
+		if peerStates.GetPeerAvailability(peerName) {
+			available[peerName] = peerCrStates
+		}
+	}
+	return available
+}
+
+func monitorNamesStr(names []tc.TrafficMonitorName) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	strs := make([]string, len(names))
+	for i, name := range names {
+		strs[i] = name.String()
+	}
+	return strings.Join(strs, ", ")
 }
 
 func combineDSState(
@@ -183,9 +365,26 @@ func pruneCombinedCaches(combinedStates peer.CRStatesThreadsafe, localStates tc.
 	}
 }
 
-func combineCrStates(events health.ThreadsafeEvents, peerOptimistic bool, peerStates peer.CRStatesPeersThreadsafe, localStates tc.CRStates, combinedStates peer.CRStatesThreadsafe, overrideMap map[tc.CacheName]bool, toData todata.TOData) {
-	for cacheName, localCacheState := range localStates.Caches { // localStates gets pruned when servers are disabled, it's the source of truth
-		combineCacheState(cacheName, localCacheState, events, peerOptimistic, peerStates, localStates, combinedStates, overrideMap, toData)
+func combineCrStates(events health.ThreadsafeEvents, peerCombineMode string, quorumFraction float64, localName tc.TrafficMonitorName, sh shard.Shard, peerStates peer.CRStatesPeersThreadsafe, localStates tc.CRStates, combinedStates peer.CRStatesThreadsafe, cacheVotes peer.CacheVotesThreadsafe, overrideMap map[tc.CacheName]bool, toData todata.TOData) {
+	peerOptimistic := peerCombineMode == config.PeerCombineModeOptimistic
+	if sh.Enabled() {
+		peerCrStates := availablePeerStates(peerStates)
+		votes := make(map[tc.CacheName]peer.CacheVotes, len(localStates.Caches))
+		for cacheName, localCacheState := range localStates.Caches { // localStates gets pruned when servers are disabled, it's the source of truth
+			votes[cacheName] = combineCacheStateSharded(cacheName, localCacheState, events, peerCombineMode, quorumFraction, sh, peerCrStates, combinedStates, toData)
+		}
+		cacheVotes.Set(votes)
+	} else if peerCombineMode == config.PeerCombineModeQuorum {
+		peerCrStates := availablePeerStates(peerStates)
+		votes := make(map[tc.CacheName]peer.CacheVotes, len(localStates.Caches))
+		for cacheName, localCacheState := range localStates.Caches { // localStates gets pruned when servers are disabled, it's the source of truth
+			votes[cacheName] = combineCacheStateQuorum(cacheName, localCacheState, events, localName, quorumFraction, peerCrStates, combinedStates, overrideMap, toData)
+		}
+		cacheVotes.Set(votes)
+	} else {
+		for cacheName, localCacheState := range localStates.Caches { // localStates gets pruned when servers are disabled, it's the source of truth
+			combineCacheState(cacheName, localCacheState, events, peerOptimistic, peerStates, localStates, combinedStates, overrideMap, toData)
+		}
 	}
 
 	for deliveryServiceName, localDeliveryService := range localStates.DeliveryService {
@@ -196,6 +395,18 @@ func combineCrStates(events health.ThreadsafeEvents, peerOptimistic bool, peerSt
 	pruneCombinedCaches(combinedStates, localStates)
 }
 
+// applyOverrides sets the combined availability of each cache with an active override at the given time to the override's availability.
+func applyOverrides(overrides override.Overrides, localStates tc.CRStates, combinedStates peer.CRStatesThreadsafe, toData todata.TOData, now time.Time) {
+	if len(overrides) == 0 {
+		return
+	}
+	for cacheName := range localStates.Caches {
+		if ov, ok := overrides.ForCache(cacheName, toData.ServerCachegroups[cacheName], now); ok {
+			combinedStates.AddCache(cacheName, tc.IsAvailable{IsAvailable: ov.Available, Ipv4Available: ov.Available, Ipv6Available: ov.Available})
+		}
+	}
+}
+
 // CacheNameSlice is a slice of cache names, which fulfills the `sort.Interface` interface.
 type CacheGroupNameSlice []tc.CacheGroupName
 
@@ -203,6 +414,13 @@ func (p CacheGroupNameSlice) Len() int           { return len(p) }
 func (p CacheGroupNameSlice) Less(i, j int) bool { return p[i] < p[j] }
 func (p CacheGroupNameSlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
+// TrafficMonitorNameSlice is a slice of Traffic Monitor names, which fulfills the `sort.Interface` interface.
+type TrafficMonitorNameSlice []tc.TrafficMonitorName
+
+func (p TrafficMonitorNameSlice) Len() int           { return len(p) }
+func (p TrafficMonitorNameSlice) Less(i, j int) bool { return p[i] < p[j] }
+func (p TrafficMonitorNameSlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
 // intersection returns strings in both a and b.
 // Note this modifies a and b. Specifically, it sorts them. If that isn't acceptable, pass copies of your real data.
 func intersection(a []tc.CacheGroupName, b []tc.CacheGroupName) []tc.CacheGroupName {
@@ -0,0 +1,153 @@
+package manager
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/peer"
+	"github.com/apache/trafficcontrol/traffic_monitor/shard"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+func TestCombineCrStatesQuorum(t *testing.T) {
+	peerStates := peer.NewCRStatesPeersThreadsafe(time.Now)
+	peerCacheStates := map[tc.TrafficMonitorName]map[tc.CacheName]bool{
+		"tm1": {"edge0": false, "edge1": true},
+		"tm2": {"edge0": false, "edge1": true},
+		"tm3": {"edge0": true, "edge1": false}, // unreachable, doesn't vote
+	}
+	onlinePeers := map[tc.TrafficMonitorName]struct{}{}
+	for peerName, caches := range peerCacheStates {
+		crStates := tc.NewCRStates()
+		for cacheName, available := range caches {
+			crStates.Caches[cacheName] = tc.IsAvailable{IsAvailable: available}
+		}
+		peerStates.Set(peer.Result{ID: peerName, Available: peerName != "tm3", PeerStates: crStates, Time: time.Now()})
+		onlinePeers[peerName] = struct{}{}
+	}
+	peerStates.SetPeers(onlinePeers)
+
+	localStates := tc.NewCRStates()
+	localStates.Caches["edge0"] = tc.IsAvailable{IsAvailable: true}  // unavailable on a majority
+	localStates.Caches["edge1"] = tc.IsAvailable{IsAvailable: false} // available on a majority
+	localStates.Caches["edge2"] = tc.IsAvailable{IsAvailable: false} // not on any peer
+
+	events := health.NewThreadsafeEvents(10, time.Now)
+	combinedStates := peer.NewCRStatesThreadsafe()
+	cacheVotes := peer.NewCacheVotesThreadsafe()
+	overrideMap := map[tc.CacheName]bool{}
+	combineCrStates(events, config.PeerCombineModeQuorum, 0.5, "tm0", shard.Shard{}, peerStates, localStates, combinedStates, cacheVotes, overrideMap, *todata.New())
+
+	expected := map[tc.CacheName]bool{"edge0": false, "edge1": true, "edge2": false}
+	for cacheName, expectedAvailable := range expected {
+		if actual, _ := combinedStates.GetCache(cacheName); actual.IsAvailable != expectedAvailable {
+			t.Errorf("expected cache %v available %v, actual %v", cacheName, expectedAvailable, actual.IsAvailable)
+		}
+	}
+
+	votes := cacheVotes.Get()["edge0"]
+	if len(votes.Available) != 1 || votes.Available[0] != "tm0" || len(votes.Unavailable) != 2 || votes.Unavailable[0] != "tm1" || votes.Unavailable[1] != "tm2" {
+		t.Errorf("expected edge0 votes available [tm0] unavailable [tm1 tm2], actual %+v", votes)
+	}
+
+	overrideEvents := 0
+	for _, e := range events.Get() {
+		if strings.HasPrefix(e.Description, "Health protocol quorum override detected") {
+			overrideEvents++
+		}
+	}
+	if overrideEvents != 2 {
+		t.Errorf("expected 2 override events, actual %+v", events.Get())
+	}
+
+	// a tie is available, with a quorum fraction of 0.5
+	localStates.Caches["edge0"] = tc.IsAvailable{IsAvailable: true}
+	peerStates.Set(peer.Result{ID: "tm2", Available: false, Time: time.Now()})
+	combineCrStates(events, config.PeerCombineModeQuorum, 0.5, "tm0", shard.Shard{}, peerStates, localStates, combinedStates, cacheVotes, overrideMap, *todata.New())
+	if actual, _ := combinedStates.GetCache("edge0"); !actual.IsAvailable {
+		t.Errorf("expected tied cache to be available")
+	}
+	if overrideMap["edge0"] {
+		t.Errorf("expected override to be cleared")
+	}
+}
+
+func TestCombineCrStatesSharded(t *testing.T) {
+	sh := shard.New("tm0", 1, []tc.TrafficMonitorName{"tm0", "tm1", "tm2"})
+
+	// find a cache polled only by this monitor, and one polled only by tm1
+	localCache, remoteCache := tc.CacheName(""), tc.CacheName("")
+	for i := 0; i < 100 && (localCache == "" || remoteCache == ""); i++ {
+		cacheName := tc.CacheName("edge" + strconv.Itoa(i))
+		switch sh.Owners(cacheName)[0] {
+		case "tm0":
+			localCache = cacheName
+		case "tm1":
+			remoteCache = cacheName
+		}
+	}
+	if localCache == "" || remoteCache == "" {
+		t.Fatalf("expected caches owned by tm0 and tm1")
+	}
+
+	peerStates := peer.NewCRStatesPeersThreadsafe(time.Now)
+	onlinePeers := map[tc.TrafficMonitorName]struct{}{}
+	for _, peerName := range []tc.TrafficMonitorName{"tm1", "tm2"} {
+		crStates := tc.NewCRStates()
+		crStates.Caches[localCache] = tc.IsAvailable{IsAvailable: true}
+		crStates.Caches[remoteCache] = tc.IsAvailable{IsAvailable: peerName != "tm1"} // only the owner's vote counts
+		peerStates.Set(peer.Result{ID: peerName, Available: true, PeerStates: crStates, Time: time.Now()})
+		onlinePeers[peerName] = struct{}{}
+	}
+	peerStates.SetPeers(onlinePeers)
+
+	localStates := tc.NewCRStates()
+	localStates.Caches[localCache] = tc.IsAvailable{IsAvailable: false}
+	localStates.Caches[remoteCache] = tc.IsAvailable{IsAvailable: true} // not polled locally
+
+	events := health.NewThreadsafeEvents(10, time.Now)
+	combinedStates := peer.NewCRStatesThreadsafe()
+	cacheVotes := peer.NewCacheVotesThreadsafe()
+	combineCrStates(events, config.PeerCombineModeOptimistic, 0.5, "tm0", sh, peerStates, localStates, combinedStates, cacheVotes, map[tc.CacheName]bool{}, *todata.New())
+
+	if actual, _ := combinedStates.GetCache(localCache); actual.IsAvailable {
+		t.Errorf("expected locally polled unavailable cache to be unavailable, despite peers which don't poll it")
+	}
+	if actual, _ := combinedStates.GetCache(remoteCache); actual.IsAvailable {
+		t.Errorf("expected cache unavailable on its owner to be unavailable")
+	}
+	if votes := cacheVotes.Get()[remoteCache]; len(votes.Unavailable) != 1 || votes.Unavailable[0] != "tm1" || len(votes.Available) != 0 {
+		t.Errorf("expected %v votes available [] unavailable [tm1], actual %+v", remoteCache, votes)
+	}
+
+	// when the owner goes offline, and no owner has reported, the local state is used
+	peerStates.Set(peer.Result{ID: "tm1", Available: false, Time: time.Now()})
+	combineCrStates(events, config.PeerCombineModeOptimistic, 0.5, "tm0", sh, peerStates, localStates, combinedStates, cacheVotes, map[tc.CacheName]bool{}, *todata.New())
+	if actual, _ := combinedStates.GetCache(remoteCache); !actual.IsAvailable {
+		t.Errorf("expected cache with no reporting owner to use the local state")
+	}
+}
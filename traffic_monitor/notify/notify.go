@@ -0,0 +1,287 @@
+package notify
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/override"
+	"github.com/apache/trafficcontrol/traffic_monitor/probe"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+// The event types which routes match on, from the type of each event.
+const (
+	EventTypeCache           = "cache"
+	EventTypeDeliveryService = "deliveryservice"
+	EventTypePeer            = "peer"
+	EventTypeOverride        = "override"
+	EventTypeTrafficRouter   = "trafficrouter"
+	EventTypeTrafficOps      = "trafficops"
+)
+
+// The status of a notification.
+const (
+	// StatusFiring notifies that something became unavailable.
+	StatusFiring = "firing"
+	// StatusResolved notifies that something which was notified as firing became available again.
+	StatusResolved = "resolved"
+)
+
+// EventBufferSize is the number of events queued for notification. If sinks fall so far behind the queue fills, events are dropped.
+const EventBufferSize = 1000
+
+// Notification is a health event, as sent to sinks.
+type Notification struct {
+	Status      string    `json:"status"`
+	Time        time.Time `json:"time"`
+	Monitor     string    `json:"monitor"`
+	CDN         string    `json:"cdn"`
+	EventType   string    `json:"eventType"`
+	Type        string    `json:"type"`
+	Name        string    `json:"name"`
+	Hostname    string    `json:"hostname"`
+	CacheGroup  string    `json:"cachegroup,omitempty"`
+	Available   bool      `json:"isAvailable"`
+	Description string    `json:"description"`
+	// AddressFamily is the address family of the availability, if the event is of a single address family, such as a cache's IPv6 availability.
+	AddressFamily string `json:"addressFamily,omitempty"`
+}
+
+// NewNotification creates the notification of the given event, with the cachegroup and CDN of the given Traffic Ops data. The Status is set when the notification is routed.
+func NewNotification(e health.Event, toData todata.TOData, monitor string) Notification {
+	n := Notification{
+		Time:          time.Time(e.Time),
+		Monitor:       monitor,
+		CDN:           string(toData.CDN),
+		EventType:     eventType(e),
+		Type:          e.Type,
+		Name:          e.Name,
+		Hostname:      e.Hostname,
+		Available:     e.Available,
+		Description:   e.Description,
+		AddressFamily: e.AddressFamily,
+	}
+	if n.EventType == EventTypeCache {
+		n.CacheGroup = string(toData.ServerCachegroups[tc.CacheName(e.Name)])
+	}
+	return n
+}
+
+// eventType returns the type of the given event. Events whose type isn't a peer, override, delivery service, Traffic Router, or Traffic Ops are cache events, whose type is the cache type.
+func eventType(e health.Event) string {
+	switch e.Type {
+	case "PEER":
+		return EventTypePeer
+	case override.EventType:
+		return EventTypeOverride
+	case "DELIVERYSERVICE", "Delivery Service":
+		return EventTypeDeliveryService
+	case probe.EventTypeTrafficRouter:
+		return EventTypeTrafficRouter
+	case probe.EventTypeTrafficOps:
+		return EventTypeTrafficOps
+	}
+	return EventTypeCache
+}
+
+// Summary returns a one-line description of the notification, for sinks which send text.
+func (n Notification) Summary() string {
+	state := "unavailable"
+	if n.Available {
+		state = "available"
+	}
+	return fmt.Sprintf("%s %s %s %s: %s", strings.ToUpper(n.Status), n.Type, n.Name, state, n.Description)
+}
+
+// Router decides which sinks each notification is sent to. Unavailable events fire, and are deduplicated until the dedup interval passes; available events only notify, as resolved, when they follow a firing notification. Each sink is sent at most the rate limit of notifications per minute.
+// Router is not safe for multiple goroutines.
+type Router struct {
+	routes        []config.NotifyRoute
+	dedupInterval time.Duration
+	rateLimit     uint64
+	firing        map[string]time.Time
+	sinkWindows   map[string]rateWindow
+}
+
+type rateWindow struct {
+	Start time.Time
+	Count uint64
+}
+
+// NewRouter creates a new Router. A rateLimitPerMinute of 0 doesn't limit notifications.
+func NewRouter(routes []config.NotifyRoute, dedupInterval time.Duration, rateLimitPerMinute uint64) *Router {
+	return &Router{
+		routes:        routes,
+		dedupInterval: dedupInterval,
+		rateLimit:     rateLimitPerMinute,
+		firing:        map[string]time.Time{},
+		sinkWindows:   map[string]rateWindow{},
+	}
+}
+
+// Route sets the Status of the given notification, and returns the names of the sinks to send it to. If the notification shouldn't be sent, no sinks are returned.
+// The firing state only changes when the notification is sent to at least one sink, so a firing notification dropped by the rate limit isn't later resolved, and a dropped resolved notification leaves it firing.
+func (r *Router) Route(n *Notification, now time.Time) []string {
+	sinks := r.matchingSinks(*n)
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	key := n.EventType + "/" + n.Name
+	if n.AddressFamily != "" {
+		key += "/" + n.AddressFamily // the availability of a single address family fires and resolves independently of the overall availability
+	}
+	if !n.Available {
+		if firedAt, ok := r.firing[key]; ok && now.Sub(firedAt) < r.dedupInterval {
+			return nil
+		}
+		n.Status = StatusFiring
+	} else {
+		if _, ok := r.firing[key]; !ok {
+			return nil
+		}
+		n.Status = StatusResolved
+	}
+
+	allowed := make([]string, 0, len(sinks))
+	for _, sink := range sinks {
+		if !r.allow(sink, now) {
+			log.Warnf("notification sink %v exceeded %v notifications per minute, dropping: %v\n", sink, r.rateLimit, n.Summary())
+			continue
+		}
+		allowed = append(allowed, sink)
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	if n.Status == StatusFiring {
+		r.firing[key] = now
+	} else {
+		delete(r.firing, key)
+	}
+	return allowed
+}
+
+// matchingSinks returns the distinct sinks of all routes matching the given notification.
+func (r *Router) matchingSinks(n Notification) []string {
+	sinks := []string{}
+	seen := map[string]struct{}{}
+	for _, route := range r.routes {
+		if !routeMatches(route, n) {
+			continue
+		}
+		for _, sink := range route.Sinks {
+			if _, ok := seen[sink]; ok {
+				continue
+			}
+			seen[sink] = struct{}{}
+			sinks = append(sinks, sink)
+		}
+	}
+	return sinks
+}
+
+// routeMatches returns whether the given route matches the notification. Cache type and cachegroup lists only match cache events.
+func routeMatches(route config.NotifyRoute, n Notification) bool {
+	if len(route.EventTypes) > 0 && !contains(route.EventTypes, n.EventType) {
+		return false
+	}
+	if len(route.CacheTypes) > 0 && (n.EventType != EventTypeCache || !contains(route.CacheTypes, n.Type)) {
+		return false
+	}
+	if len(route.CacheGroups) > 0 && (n.EventType != EventTypeCache || !contains(route.CacheGroups, n.CacheGroup)) {
+		return false
+	}
+	if len(route.CDNs) > 0 && !contains(route.CDNs, n.CDN) {
+		return false
+	}
+	return true
+}
+
+func contains(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
+// allow returns whether the given sink may be sent another notification, within its rate limit for the minute.
+func (r *Router) allow(sink string, now time.Time) bool {
+	if r.rateLimit == 0 {
+		return true
+	}
+	window := r.sinkWindows[sink]
+	if now.Sub(window.Start) >= time.Minute {
+		window = rateWindow{Start: now}
+	}
+	if window.Count >= r.rateLimit {
+		return false
+	}
+	window.Count++
+	r.sinkWindows[sink] = window
+	return true
+}
+
+// Start creates the configured sinks, and starts the notification goroutine. It returns an event listener, which queues events for notification without blocking. If the queue is full, the event is dropped, and an error logged.
+func Start(cfg config.Config, toData todata.TODataThreadsafe, monitor string) (func(health.Event), error) {
+	sinks := map[string]Sink{}
+	for name, sinkCfg := range cfg.NotifySinks {
+		sink, err := NewSink(sinkCfg, cfg.HTTPTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("creating notify sink '%v': %v", name, err)
+		}
+		sinks[name] = sink
+	}
+	router := NewRouter(cfg.NotifyRoutes, cfg.NotifyDedupInterval, cfg.NotifyRateLimitPerMinute)
+
+	events := make(chan health.Event, EventBufferSize)
+	go func() {
+		for e := range events {
+			n := NewNotification(e, toData.Get(), monitor)
+			for _, sinkName := range router.Route(&n, time.Now()) {
+				go send(sinkName, sinks[sinkName], n)
+			}
+		}
+	}()
+
+	return func(e health.Event) {
+		select {
+		case events <- e:
+		default:
+			log.Errorf("notification queue full, dropping event %v %v: %v\n", e.Type, e.Name, e.Description)
+		}
+	}, nil
+}
+
+func send(sinkName string, sink Sink, n Notification) {
+	if err := sink.Send(n); err != nil {
+		log.Errorf("sending notification to sink %v: %v\n", sinkName, err)
+	}
+}
@@ -0,0 +1,191 @@
+package notify
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+	"github.com/apache/trafficcontrol/traffic_monitor/todata"
+)
+
+func TestNewNotification(t *testing.T) {
+	toData := todata.New()
+	toData.CDN = "cdn0"
+	toData.ServerCachegroups["edge0"] = "cg0"
+	e := health.Event{Time: health.Time(time.Now()), Name: "edge0", Hostname: "edge0", Type: "EDGE", Description: "REPORTED - unavailable"}
+
+	n := NewNotification(e, *toData, "tm0")
+	if n.EventType != EventTypeCache || n.CacheGroup != "cg0" || n.CDN != "cdn0" || n.Monitor != "tm0" {
+		t.Errorf("expected cache notification in cg0 on cdn0 from tm0, actual %+v", n)
+	}
+
+	e.AddressFamily = health.EventAddressFamilyIPv6
+	if n := NewNotification(e, *toData, "tm0"); n.AddressFamily != health.EventAddressFamilyIPv6 {
+		t.Errorf("expected notification address family %v, actual %+v", health.EventAddressFamilyIPv6, n)
+	}
+
+	e.Type = "DELIVERYSERVICE"
+	if n := NewNotification(e, *toData, "tm0"); n.EventType != EventTypeDeliveryService || n.CacheGroup != "" {
+		t.Errorf("expected delivery service notification without cachegroup, actual %+v", n)
+	}
+}
+
+func TestRouterRoutes(t *testing.T) {
+	routes := []config.NotifyRoute{
+		{Sinks: []string{"edges"}, CacheTypes: []string{"EDGE"}},
+		{Sinks: []string{"cg0", "edges"}, CacheGroups: []string{"cg0"}},
+		{Sinks: []string{"ds"}, EventTypes: []string{EventTypeDeliveryService}, CDNs: []string{"cdn0"}},
+	}
+	r := NewRouter(routes, time.Minute, 0)
+	now := time.Now()
+
+	n := Notification{EventType: EventTypeCache, Type: "EDGE", Name: "edge0", CacheGroup: "cg0", CDN: "cdn0"}
+	if sinks := r.Route(&n, now); len(sinks) != 2 || sinks[0] != "edges" || sinks[1] != "cg0" {
+		t.Errorf("expected sinks [edges cg0], actual %v", sinks)
+	}
+	n = Notification{EventType: EventTypeCache, Type: "MID", Name: "mid0", CacheGroup: "cg1", CDN: "cdn0"}
+	if sinks := r.Route(&n, now); len(sinks) != 0 {
+		t.Errorf("expected no sinks for unrouted mid, actual %v", sinks)
+	}
+	n = Notification{EventType: EventTypeDeliveryService, Type: "DELIVERYSERVICE", Name: "ds0", CDN: "cdn1"}
+	if sinks := r.Route(&n, now); len(sinks) != 0 {
+		t.Errorf("expected no sinks for delivery service on another CDN, actual %v", sinks)
+	}
+}
+
+func TestRouterDedupAndResolve(t *testing.T) {
+	r := NewRouter([]config.NotifyRoute{{Sinks: []string{"all"}}}, time.Minute, 0)
+	now := time.Now()
+
+	available := Notification{EventType: EventTypeCache, Type: "EDGE", Name: "edge0", Available: true}
+	if sinks := r.Route(&available, now); len(sinks) != 0 {
+		t.Errorf("expected available event without a firing notification not to notify, actual %v", sinks)
+	}
+
+	unavailable := Notification{EventType: EventTypeCache, Type: "EDGE", Name: "edge0", Available: false}
+	if sinks := r.Route(&unavailable, now); len(sinks) != 1 || unavailable.Status != StatusFiring {
+		t.Errorf("expected firing notification, actual %v %+v", sinks, unavailable)
+	}
+	if sinks := r.Route(&unavailable, now.Add(time.Second)); len(sinks) != 0 {
+		t.Errorf("expected duplicate within the dedup interval to be suppressed, actual %v", sinks)
+	}
+	if sinks := r.Route(&unavailable, now.Add(2*time.Minute)); len(sinks) != 1 {
+		t.Errorf("expected repeat after the dedup interval to notify, actual %v", sinks)
+	}
+	if sinks := r.Route(&available, now.Add(3*time.Minute)); len(sinks) != 1 || available.Status != StatusResolved {
+		t.Errorf("expected resolved notification, actual %v %+v", sinks, available)
+	}
+	if sinks := r.Route(&available, now.Add(4*time.Minute)); len(sinks) != 0 {
+		t.Errorf("expected second available event not to notify, actual %v", sinks)
+	}
+}
+
+func TestRouterRateLimit(t *testing.T) {
+	r := NewRouter([]config.NotifyRoute{{Sinks: []string{"all"}}}, time.Minute, 2)
+	now := time.Now()
+	sent := 0
+	for _, name := range []string{"edge0", "edge1", "edge2"} {
+		n := Notification{EventType: EventTypeCache, Type: "EDGE", Name: name}
+		sent += len(r.Route(&n, now))
+	}
+	if sent != 2 {
+		t.Errorf("expected 2 notifications within the rate limit, actual %v", sent)
+	}
+	n := Notification{EventType: EventTypeCache, Type: "EDGE", Name: "edge3"}
+	if sinks := r.Route(&n, now.Add(time.Minute)); len(sinks) != 1 {
+		t.Errorf("expected notification in the next minute to be sent, actual %v", sinks)
+	}
+}
+
+func TestRouterRateLimitedFiringNotResolved(t *testing.T) {
+	r := NewRouter([]config.NotifyRoute{{Sinks: []string{"all"}}}, time.Minute, 1)
+	now := time.Now()
+
+	edge0 := Notification{EventType: EventTypeCache, Type: "EDGE", Name: "edge0"}
+	if sinks := r.Route(&edge0, now); len(sinks) != 1 {
+		t.Fatalf("expected firing notification, actual %v", sinks)
+	}
+	edge1 := Notification{EventType: EventTypeCache, Type: "EDGE", Name: "edge1"}
+	if sinks := r.Route(&edge1, now); len(sinks) != 0 {
+		t.Fatalf("expected firing notification over the rate limit to be dropped, actual %v", sinks)
+	}
+	edge1.Available = true
+	if sinks := r.Route(&edge1, now.Add(time.Minute)); len(sinks) != 0 {
+		t.Errorf("expected no resolved notification for a dropped firing notification, actual %v", sinks)
+	}
+	edge1.Available = false
+	if sinks := r.Route(&edge1, now.Add(2*time.Minute)); len(sinks) != 1 || edge1.Status != StatusFiring {
+		t.Errorf("expected firing notification after a dropped one not to be deduplicated, actual %v %+v", sinks, edge1)
+	}
+}
+
+func TestRouterAddressFamily(t *testing.T) {
+	r := NewRouter([]config.NotifyRoute{{Sinks: []string{"all"}}}, time.Minute, 0)
+	now := time.Now()
+
+	ipv6 := Notification{EventType: EventTypeCache, Type: "EDGE", Name: "edge0", AddressFamily: health.EventAddressFamilyIPv6, Description: "poll failed"}
+	if sinks := r.Route(&ipv6, now); len(sinks) != 1 {
+		t.Fatalf("expected IPv6 firing notification, actual %v", sinks)
+	}
+	all := Notification{EventType: EventTypeCache, Type: "EDGE", Name: "edge0", Description: "IPv6 is not the address family of this event"}
+	if sinks := r.Route(&all, now); len(sinks) != 1 {
+		t.Errorf("expected overall firing notification not to be deduplicated with IPv6, actual %v", sinks)
+	}
+	ipv6.Available = true
+	if sinks := r.Route(&ipv6, now); len(sinks) != 1 || ipv6.Status != StatusResolved {
+		t.Errorf("expected IPv6 resolved notification, actual %v %+v", sinks, ipv6)
+	}
+	all.Available = true
+	if sinks := r.Route(&all, now); len(sinks) != 1 || all.Status != StatusResolved {
+		t.Errorf("expected overall resolved notification, actual %v %+v", sinks, all)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	received := Notification{}
+	auth := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("unmarshalling webhook body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	sink, err := NewSink(config.NotifySink{Type: config.NotifySinkTypeWebhook, URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer abc"}}, time.Second)
+	if err != nil {
+		t.Fatalf("creating webhook sink: %v", err)
+	}
+	n := Notification{Status: StatusFiring, EventType: EventTypeCache, Type: "EDGE", Name: "edge0", Description: "down"}
+	if err := sink.Send(n); err != nil {
+		t.Fatalf("sending webhook: %v", err)
+	}
+	if received.Name != "edge0" || received.Status != StatusFiring || auth != "Bearer abc" {
+		t.Errorf("expected firing edge0 notification with auth header, actual %+v %v", received, auth)
+	}
+}
@@ -0,0 +1,155 @@
+package notify
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/syslog"
+	"net/http"
+	"net/smtp"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+
+	"github.com/json-iterator/go"
+)
+
+// Sink sends notifications to a destination.
+type Sink interface {
+	Send(n Notification) error
+}
+
+// NewSink creates the sink of the given config. The timeout limits webhook requests and commands.
+func NewSink(cfg config.NotifySink, timeout time.Duration) (Sink, error) {
+	switch cfg.Type {
+	case config.NotifySinkTypeWebhook:
+		return webhookSink{url: cfg.URL, headers: cfg.Headers, client: &http.Client{Timeout: timeout}}, nil
+	case config.NotifySinkTypeEmail:
+		return emailSink{addr: cfg.SMTPAddress, from: cfg.From, to: cfg.To}, nil
+	case config.NotifySinkTypeSyslog:
+		w, err := syslog.New(syslog.LOG_DAEMON|syslog.LOG_WARNING, cfg.Tag)
+		if err != nil {
+			return nil, errors.New("connecting to syslog: " + err.Error())
+		}
+		return syslogSink{w: w}, nil
+	case config.NotifySinkTypeCommand:
+		return commandSink{command: cfg.Command, args: cfg.Args, timeout: timeout}, nil
+	}
+	return nil, errors.New("unknown sink type '" + cfg.Type + "'")
+}
+
+// webhookSink POSTs each notification as JSON.
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (s webhookSink) Send(n Notification) error {
+	json := jsoniter.ConfigFastest // TODO make configurable?
+	body, err := json.Marshal(n)
+	if err != nil {
+		return errors.New("marshalling notification: " + err.Error())
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("creating request: " + err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, val := range s.headers {
+		req.Header.Set(name, val)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.New("posting to " + s.url + ": " + err.Error())
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body) // read the body, so the connection can be reused
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("posting to %v: response code %v", s.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// emailSink emails each notification through an SMTP relay, such as a local MTA.
+type emailSink struct {
+	addr string
+	from string
+	to   []string
+}
+
+func (s emailSink) Send(n Notification) error {
+	msg := bytes.Buffer{}
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&msg, "Subject: [Traffic Monitor %s] %s\r\n", n.Monitor, n.Summary())
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&msg, "Status: %s\r\nTime: %s\r\nMonitor: %s\r\nCDN: %s\r\nType: %s\r\nName: %s\r\n", n.Status, n.Time.Format(time.RFC3339), n.Monitor, n.CDN, n.Type, n.Name)
+	if n.CacheGroup != "" {
+		fmt.Fprintf(&msg, "Cachegroup: %s\r\n", n.CacheGroup)
+	}
+	fmt.Fprintf(&msg, "Available: %t\r\nDescription: %s\r\n", n.Available, n.Description)
+	if err := smtp.SendMail(s.addr, nil, s.from, s.to, msg.Bytes()); err != nil {
+		return errors.New("sending email via " + s.addr + ": " + err.Error())
+	}
+	return nil
+}
+
+// syslogSink writes each notification to syslog, firing notifications as warnings and resolved notifications as notices.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func (s syslogSink) Send(n Notification) error {
+	if n.Status == StatusResolved {
+		return s.w.Notice(n.Summary())
+	}
+	return s.w.Warning(n.Summary())
+}
+
+// commandSink runs a command for each notification, with the notification JSON on stdin.
+type commandSink struct {
+	command string
+	args    []string
+	timeout time.Duration
+}
+
+func (s commandSink) Send(n Notification) error {
+	json := jsoniter.ConfigFastest // TODO make configurable?
+	body, err := json.Marshal(n)
+	if err != nil {
+		return errors.New("marshalling notification: " + err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, s.command, s.args...)
+	cmd.Stdin = bytes.NewReader(body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("running %v: %v: %s", s.command, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package override
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+const (
+	// TypeCache is the type of an override of a single cache.
+	TypeCache = "cache"
+	// TypeCacheGroup is the type of an override of every cache in a cachegroup.
+	TypeCacheGroup = "cachegroup"
+)
+
+// TombstoneRetention is how long cleared and expired overrides are kept, so their clearing replicates to peers which still have them.
+const TombstoneRetention = time.Hour
+
+// Override is a manual override of the availability of a cache or cachegroup, until it expires.
+type Override struct {
+	// Type is TypeCache or TypeCacheGroup.
+	Type string `json:"type"`
+	// Name is the name of the cache or cachegroup.
+	Name string `json:"name"`
+	// Available is whether the override forces the caches up, or down.
+	Available bool   `json:"available"`
+	Reason    string `json:"reason"`
+	// Expires is when the override automatically clears. Clearing an override sets Expires to the time it was cleared.
+	Expires time.Time `json:"expires"`
+	// Updated is when the override was last set or cleared. When overrides are replicated, the most recently updated wins.
+	Updated time.Time `json:"updated"`
+	// Monitor is the name of the Traffic Monitor the override was set or cleared on.
+	Monitor tc.TrafficMonitorName `json:"monitor"`
+}
+
+// Key returns the key identifying the override's cache or cachegroup.
+func (o Override) Key() string {
+	return o.Type + "/" + o.Name
+}
+
+// Active returns whether the override applies at the given time.
+func (o Override) Active(now time.Time) bool {
+	return now.Before(o.Expires)
+}
+
+// EventType is the type of health events of overrides.
+const EventType = "OVERRIDE"
+
+// Describe returns a description of the override at the given time, for the event log.
+func (o Override) Describe(now time.Time) string {
+	switch {
+	case o.Active(now):
+		state := "unavailable"
+		if o.Available {
+			state = "available"
+		}
+		return "Override set on " + string(o.Monitor) + ": " + o.Type + " forced " + state + " until " + o.Expires.UTC().Format(time.RFC3339) + ": " + o.Reason
+	case !o.Updated.Before(o.Expires):
+		return "Override cleared on " + string(o.Monitor) + ": " + o.Reason
+	}
+	return "Override expired: " + o.Reason
+}
+
+// Validate returns an error if the override is malformed, or expired at the given time.
+func (o Override) Validate(now time.Time) error {
+	if o.Type != TypeCache && o.Type != TypeCacheGroup {
+		return errors.New("type must be '" + TypeCache + "' or '" + TypeCacheGroup + "'")
+	}
+	if strings.TrimSpace(o.Name) == "" {
+		return errors.New("name is required")
+	}
+	if strings.TrimSpace(o.Reason) == "" {
+		return errors.New("reason is required")
+	}
+	if !o.Active(now) {
+		return errors.New("expires must be in the future")
+	}
+	return nil
+}
+
+// Overrides is a set of overrides, keyed by Override.Key. It includes inactive overrides, until they pass the TombstoneRetention.
+type Overrides map[string]Override
+
+// Copy returns a copy of the overrides.
+func (o Overrides) Copy() Overrides {
+	c := make(Overrides, len(o))
+	for k, v := range o {
+		c[k] = v
+	}
+	return c
+}
+
+// ForCache returns the active override of the given cache in the given cachegroup at the given time, and whether one exists. A cache override takes precedence over a cachegroup override.
+func (o Overrides) ForCache(cache tc.CacheName, cacheGroup tc.CacheGroupName, now time.Time) (Override, bool) {
+	if ov, ok := o[Override{Type: TypeCache, Name: string(cache)}.Key()]; ok && ov.Active(now) {
+		return ov, true
+	}
+	if ov, ok := o[Override{Type: TypeCacheGroup, Name: string(cacheGroup)}.Key()]; ok && ov.Active(now) {
+		return ov, true
+	}
+	return Override{}, false
+}
+
+// Active returns the overrides active at the given time, sorted by key.
+func (o Overrides) Active(now time.Time) []Override {
+	keys := []string{}
+	for key, ov := range o {
+		if ov.Active(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	active := make([]Override, 0, len(keys)) // important to initialize, so JSON is `[]` not `null`
+	for _, key := range keys {
+		active = append(active, o[key])
+	}
+	return active
+}
+
+// OverridesThreadsafe is the overrides, safe for multiple goroutines.
+type OverridesThreadsafe struct {
+	m         *sync.RWMutex
+	overrides *Overrides
+}
+
+// NewOverridesThreadsafe returns a new, empty OverridesThreadsafe.
+func NewOverridesThreadsafe() OverridesThreadsafe {
+	o := Overrides{}
+	return OverridesThreadsafe{m: &sync.RWMutex{}, overrides: &o}
+}
+
+// Get returns the overrides. Callers MUST NOT modify the returned overrides.
+func (o OverridesThreadsafe) Get() Overrides {
+	o.m.RLock()
+	defer o.m.RUnlock()
+	return *o.overrides
+}
+
+// Set sets the given override, replacing any override of the same cache or cachegroup.
+func (o OverridesThreadsafe) Set(ov Override) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	overrides := o.overrides.Copy()
+	overrides[ov.Key()] = ov
+	*o.overrides = overrides
+}
+
+// Clear clears the active override of the given type and name at the given time, keeping it as a tombstone so the clearing replicates. Returns the cleared override, and false if there was no active override.
+func (o OverridesThreadsafe) Clear(overrideType string, name string, monitor tc.TrafficMonitorName, now time.Time) (Override, bool) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	key := Override{Type: overrideType, Name: name}.Key()
+	ov, ok := (*o.overrides)[key]
+	if !ok || !ov.Active(now) {
+		return Override{}, false
+	}
+	cleared := ov
+	cleared.Expires = now
+	cleared.Updated = now
+	cleared.Monitor = monitor
+	overrides := o.overrides.Copy()
+	overrides[key] = cleared
+	*o.overrides = overrides
+	return cleared, true
+}
+
+// Merge merges the given overrides from a peer, keeping the most recently updated override of each cache and cachegroup. Returns the peer overrides which were newer.
+func (o OverridesThreadsafe) Merge(peerOverrides Overrides) []Override {
+	o.m.Lock()
+	defer o.m.Unlock()
+	newer := []Override{}
+	overrides := Overrides(nil)
+	for key, peerOv := range peerOverrides {
+		if ov, ok := (*o.overrides)[key]; ok && !peerOv.Updated.After(ov.Updated) {
+			continue
+		}
+		if overrides == nil {
+			overrides = o.overrides.Copy()
+		}
+		overrides[key] = peerOv
+		newer = append(newer, peerOv)
+	}
+	if overrides != nil {
+		*o.overrides = overrides
+	}
+	return newer
+}
+
+// Expire removes overrides which have been inactive for longer than the TombstoneRetention, and returns the overrides which expired since the given previous time.
+func (o OverridesThreadsafe) Expire(previous time.Time, now time.Time) []Override {
+	o.m.Lock()
+	defer o.m.Unlock()
+	expired := []Override{}
+	overrides := Overrides(nil)
+	for key, ov := range *o.overrides {
+		if ov.Expires.After(previous) && !ov.Active(now) && ov.Updated.Before(ov.Expires) {
+			expired = append(expired, ov)
+		}
+		if now.Sub(ov.Expires) <= TombstoneRetention {
+			continue
+		}
+		if overrides == nil {
+			overrides = o.overrides.Copy()
+		}
+		delete(overrides, key)
+	}
+	if overrides != nil {
+		*o.overrides = overrides
+	}
+	return expired
+}
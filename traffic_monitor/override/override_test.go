@@ -0,0 +1,121 @@
+package override
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+func TestOverridesForCache(t *testing.T) {
+	now := time.Now()
+	overrides := Overrides{}
+	cgOv := Override{Type: TypeCacheGroup, Name: "cg0", Available: false, Reason: "maintenance", Expires: now.Add(time.Hour)}
+	cacheOv := Override{Type: TypeCache, Name: "cache0", Available: true, Reason: "false positive", Expires: now.Add(time.Hour)}
+	expiredOv := Override{Type: TypeCache, Name: "cache1", Available: true, Reason: "old", Expires: now.Add(-time.Minute)}
+	for _, ov := range []Override{cgOv, cacheOv, expiredOv} {
+		overrides[ov.Key()] = ov
+	}
+
+	if ov, ok := overrides.ForCache("cache0", "cg0", now); !ok || ov != cacheOv {
+		t.Errorf("ForCache with cache and cachegroup overrides expected cache override, actual %+v %v", ov, ok)
+	}
+	if ov, ok := overrides.ForCache("cache1", "cg0", now); !ok || ov != cgOv {
+		t.Errorf("ForCache with expired cache override expected cachegroup override, actual %+v %v", ov, ok)
+	}
+	if ov, ok := overrides.ForCache("cache2", "cg1", now); ok {
+		t.Errorf("ForCache without overrides expected none, actual %+v", ov)
+	}
+	if active := overrides.Active(now); len(active) != 2 || active[0] != cacheOv || active[1] != cgOv {
+		t.Errorf("Active expected cache and cachegroup overrides, actual %+v", active)
+	}
+}
+
+func TestOverridesValidate(t *testing.T) {
+	now := time.Now()
+	valid := Override{Type: TypeCache, Name: "cache0", Reason: "maintenance", Expires: now.Add(time.Hour)}
+	if err := valid.Validate(now); err != nil {
+		t.Errorf("Validate valid override expected nil error, actual %v", err)
+	}
+	invalid := map[string]Override{}
+	ov := valid
+	ov.Type = "server"
+	invalid["type"] = ov
+	ov = valid
+	ov.Name = " "
+	invalid["name"] = ov
+	ov = valid
+	ov.Reason = ""
+	invalid["reason"] = ov
+	ov = valid
+	ov.Expires = now
+	invalid["expires"] = ov
+	for field, ov := range invalid {
+		if err := ov.Validate(now); err == nil {
+			t.Errorf("Validate invalid %v expected error, actual nil", field)
+		}
+	}
+}
+
+func TestOverridesThreadsafeMergeClearExpire(t *testing.T) {
+	now := time.Now()
+	overrides := NewOverridesThreadsafe()
+	local := Override{Type: TypeCache, Name: "cache0", Reason: "maintenance", Expires: now.Add(time.Hour), Updated: now.Add(-time.Minute), Monitor: "tm0"}
+	overrides.Set(local)
+
+	older := local
+	older.Updated = now.Add(-2 * time.Minute)
+	older.Monitor = "tm1"
+	if newer := overrides.Merge(Overrides{older.Key(): older}); len(newer) != 0 {
+		t.Errorf("Merge older override expected no newer overrides, actual %+v", newer)
+	}
+
+	cleared := local
+	cleared.Expires = now
+	cleared.Updated = now
+	cleared.Monitor = "tm1"
+	if newer := overrides.Merge(Overrides{cleared.Key(): cleared}); len(newer) != 1 || newer[0] != cleared {
+		t.Errorf("Merge cleared override expected cleared override newer, actual %+v", newer)
+	}
+	if _, ok := overrides.Get().ForCache("cache0", "", now); ok {
+		t.Errorf("ForCache after merging cleared override expected none, actual active")
+	}
+	if _, ok := overrides.Clear(TypeCache, "cache0", tc.TrafficMonitorName("tm0"), now); ok {
+		t.Errorf("Clear of cleared override expected false, actual true")
+	}
+
+	expiring := Override{Type: TypeCacheGroup, Name: "cg0", Reason: "maintenance", Expires: now.Add(time.Second), Updated: now}
+	overrides.Set(expiring)
+	if expired := overrides.Expire(now, now.Add(time.Second)); len(expired) != 1 || expired[0] != expiring {
+		t.Errorf("Expire expected expiring override, actual %+v", expired)
+	}
+	if expired := overrides.Expire(now.Add(time.Second), now.Add(2*time.Second)); len(expired) != 0 {
+		t.Errorf("Expire again expected no expired overrides, actual %+v", expired)
+	}
+	if len(overrides.Get()) != 2 {
+		t.Errorf("Expire before tombstone retention expected 2 overrides, actual %v", len(overrides.Get()))
+	}
+	overrides.Expire(now, now.Add(TombstoneRetention+2*time.Second))
+	if len(overrides.Get()) != 0 {
+		t.Errorf("Expire after tombstone retention expected no overrides, actual %+v", overrides.Get())
+	}
+}
@@ -122,14 +122,16 @@ type CRStatesPeersThreadsafe struct {
 	peerTimes  map[tc.TrafficMonitorName]time.Time
 	peerOnline map[tc.TrafficMonitorName]bool
 	timeout    *time.Duration
+	now        func() time.Time
 	m          *sync.RWMutex
 }
 
-// NewCRStatesPeersThreadsafe creates a new CRStatesPeers object safe for multiple goroutine readers and a single writer.
-func NewCRStatesPeersThreadsafe() CRStatesPeersThreadsafe {
+// NewCRStatesPeersThreadsafe creates a new CRStatesPeers object safe for multiple goroutine readers and a single writer. The given func returns the current time, which peer poll times are compared to, to determine if peers are stale. This is time.Now, except when replaying a recording.
+func NewCRStatesPeersThreadsafe(now func() time.Time) CRStatesPeersThreadsafe {
 	timeout := time.Hour // default to a large timeout
 	return CRStatesPeersThreadsafe{
 		m:          &sync.RWMutex{},
+		now:        now,
 		timeout:    &timeout,
 		peerOnline: map[tc.TrafficMonitorName]bool{},
 		crStates:   map[tc.TrafficMonitorName]tc.CRStates{},
@@ -138,6 +140,18 @@ func NewCRStatesPeersThreadsafe() CRStatesPeersThreadsafe {
 	}
 }
 
+// Now returns the current time, which peer poll times are compared to.
+func (t *CRStatesPeersThreadsafe) Now() time.Time {
+	return t.now()
+}
+
+// GetTimeout returns the time after its latest poll that a peer is considered stale, and thus unavailable.
+func (t *CRStatesPeersThreadsafe) GetTimeout() time.Duration {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	return *t.timeout
+}
+
 func (t *CRStatesPeersThreadsafe) SetTimeout(timeout time.Duration) {
 	t.m.Lock()
 	defer t.m.Unlock()
@@ -183,7 +197,7 @@ func copyPeerAvailable(a map[tc.TrafficMonitorName]bool) map[tc.TrafficMonitorNa
 // GetPeerAvailability returns the state of the given peer
 func (t *CRStatesPeersThreadsafe) GetPeerAvailability(peer tc.TrafficMonitorName) bool {
 	t.m.RLock()
-	availability := t.peerStates[peer] && t.peerOnline[peer] && time.Since(t.peerTimes[peer]) < *t.timeout
+	availability := t.peerStates[peer] && t.peerOnline[peer] && t.now().Sub(t.peerTimes[peer]) < *t.timeout
 	t.m.RUnlock()
 	return availability
 }
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/override"
 
 	"github.com/json-iterator/go"
 )
@@ -44,11 +45,18 @@ type Result struct {
 	Available    bool
 	Errors       []error
 	PeerStates   tc.CRStates
+	Overrides    override.Overrides
 	PollID       uint64
 	PollFinished chan<- uint64
 	Time         time.Time
 }
 
+// RawCRStates is the local CRStates of a Traffic Monitor, as served to its peers, with its overrides to replicate to them.
+type RawCRStates struct {
+	tc.CRStates
+	Overrides override.Overrides `json:"overrides,omitempty"`
+}
+
 // Handle handles a response from a polled Traffic Monitor peer, parsing the data and forwarding it to the ResultChannel.
 func (handler Handler) Handle(id string, r io.Reader, format string, reqTime time.Duration, reqEnd time.Time, err error, pollID uint64, pollFinished chan<- uint64) {
 	result := Result{
@@ -66,8 +74,11 @@ func (handler Handler) Handle(id string, r io.Reader, format string, reqTime tim
 
 	if r != nil {
 		json := jsoniter.ConfigFastest // TODo make configurable?
-		err = json.NewDecoder(r).Decode(&result.PeerStates)
+		rawStates := RawCRStates{}
+		err = json.NewDecoder(r).Decode(&rawStates)
 		if err == nil {
+			result.PeerStates = rawStates.CRStates
+			result.Overrides = rawStates.Overrides
 			result.Available = true
 		} else {
 			result.Errors = append(result.Errors, err)
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/apache/trafficcontrol/lib/go-tc"
 )
@@ -49,3 +50,21 @@ func TestCrStates(t *testing.T) {
 	}
 
 }
+
+func TestGetPeerAvailabilityClock(t *testing.T) {
+	// the peer states' clock may be a replay's virtual clock, far from the real time
+	now := time.Now().Add(-24 * time.Hour)
+	peerStates := NewCRStatesPeersThreadsafe(func() time.Time { return now })
+	peerStates.SetTimeout(10 * time.Second)
+	peerName := tc.TrafficMonitorName("tm0")
+	peerStates.Set(Result{ID: peerName, Available: true, PeerStates: tc.NewCRStates(), Time: now.Add(-5 * time.Second)})
+	peerStates.SetPeers(map[tc.TrafficMonitorName]struct{}{peerName: {}})
+
+	if !peerStates.GetPeerAvailability(peerName) {
+		t.Errorf("GetPeerAvailability of peer polled 5s before the clock time with 10s timeout expected available, actual unavailable")
+	}
+	now = now.Add(10 * time.Second)
+	if peerStates.GetPeerAvailability(peerName) {
+		t.Errorf("GetPeerAvailability of peer polled 15s before the clock time with 10s timeout expected unavailable, actual available")
+	}
+}
@@ -0,0 +1,111 @@
+package peer
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+
+	"github.com/json-iterator/go"
+)
+
+// StatsHandler handles the cache stats of peer Traffic Monitors, polled when cache polling is sharded. This fulfills the common `Handler` interface.
+type StatsHandler struct {
+	stats StatsThreadsafe
+}
+
+// NewStatsHandler returns a new peer StatsHandler, which stores the stats it receives in the given StatsThreadsafe.
+func NewStatsHandler(stats StatsThreadsafe) StatsHandler {
+	return StatsHandler{stats: stats}
+}
+
+// Handle handles a /publish/CacheStats response from a polled Traffic Monitor peer. If the poll failed, the peer's previous stats are removed, so stale stats aren't served.
+func (handler StatsHandler) Handle(id string, r io.Reader, format string, reqTime time.Duration, reqEnd time.Time, err error, pollID uint64, pollFinished chan<- uint64) {
+	defer func() { pollFinished <- pollID }()
+	peer := tc.TrafficMonitorName(id)
+	if err != nil {
+		log.Warnf("polling peer %v cache stats: %v\n", id, err)
+		handler.stats.Delete(peer)
+		return
+	}
+	if r == nil {
+		return
+	}
+	json := jsoniter.ConfigFastest // TODO make configurable?
+	stats := cache.Stats{}
+	if err := json.NewDecoder(r).Decode(&stats); err != nil {
+		log.Warnf("decoding peer %v cache stats: %v\n", id, err)
+		handler.stats.Delete(peer)
+		return
+	}
+	handler.stats.Set(peer, stats)
+}
+
+// StatsThreadsafe provides safe access for multiple goroutines to read the cache stats of peer Traffic Monitors, with a single goroutine writer.
+type StatsThreadsafe struct {
+	stats *map[tc.TrafficMonitorName]cache.Stats
+	m     *sync.RWMutex
+}
+
+// NewStatsThreadsafe returns a new, empty StatsThreadsafe object.
+func NewStatsThreadsafe() StatsThreadsafe {
+	stats := map[tc.TrafficMonitorName]cache.Stats{}
+	return StatsThreadsafe{stats: &stats, m: &sync.RWMutex{}}
+}
+
+// Get returns the cache stats of all peers. Callers MUST NOT modify the returned map or its stats.
+func (t StatsThreadsafe) Get() map[tc.TrafficMonitorName]cache.Stats {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	return *t.stats
+}
+
+// Set sets the cache stats of the given peer. The map is copied, so previous Get results are never modified.
+func (t StatsThreadsafe) Set(peer tc.TrafficMonitorName, stats cache.Stats) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	newStats := make(map[tc.TrafficMonitorName]cache.Stats, len(*t.stats)+1)
+	for name, peerStats := range *t.stats {
+		newStats[name] = peerStats
+	}
+	newStats[peer] = stats
+	*t.stats = newStats
+}
+
+// Delete removes the cache stats of the given peer.
+func (t StatsThreadsafe) Delete(peer tc.TrafficMonitorName) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if _, ok := (*t.stats)[peer]; !ok {
+		return
+	}
+	newStats := make(map[tc.TrafficMonitorName]cache.Stats, len(*t.stats))
+	for name, peerStats := range *t.stats {
+		if name != peer {
+			newStats[name] = peerStats
+		}
+	}
+	*t.stats = newStats
+}
@@ -0,0 +1,58 @@
+package peer
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"sync"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+// CacheVotes is the Traffic Monitors which voted a cache available and unavailable, when combining states by quorum.
+type CacheVotes struct {
+	Available   []tc.TrafficMonitorName `json:"available"`
+	Unavailable []tc.TrafficMonitorName `json:"unavailable"`
+}
+
+// CacheVotesThreadsafe provides safe access for multiple goroutines to read the votes of each cache, with a single goroutine writer.
+type CacheVotesThreadsafe struct {
+	votes *map[tc.CacheName]CacheVotes
+	m     *sync.RWMutex
+}
+
+// NewCacheVotesThreadsafe creates a new CacheVotesThreadsafe object safe for multiple goroutine readers and a single writer.
+func NewCacheVotesThreadsafe() CacheVotesThreadsafe {
+	votes := map[tc.CacheName]CacheVotes{}
+	return CacheVotesThreadsafe{m: &sync.RWMutex{}, votes: &votes}
+}
+
+// Get returns the internal map of cache votes. The returned map MUST NOT be modified.
+func (t *CacheVotesThreadsafe) Get() map[tc.CacheName]CacheVotes {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	return *t.votes
+}
+
+// Set sets the internal map of cache votes. The given map MUST NOT be modified after calling. This MUST NOT be called by multiple goroutines.
+func (t *CacheVotesThreadsafe) Set(votes map[tc.CacheName]CacheVotes) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	*t.votes = votes
+}
@@ -0,0 +1,344 @@
+package persist
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+
+	"github.com/json-iterator/go"
+)
+
+const (
+	// EventsPrefix is the file name prefix of event segments.
+	EventsPrefix = "events-"
+	// StatsPrefix is the file name prefix of stat segments.
+	StatsPrefix = "stats-"
+	// SegmentSuffix is the file name suffix of all segments.
+	SegmentSuffix = ".json"
+
+	segmentDayFormat = "20060102"
+	segmentDay       = 24 * time.Hour
+)
+
+// StatRecord is a downsampled record of a cache's stats.
+type StatRecord struct {
+	Time          health.Time  `json:"time"`
+	Cache         tc.CacheName `json:"cache"`
+	Available     bool         `json:"isAvailable"`
+	Status        string       `json:"status"`
+	BandwidthKbps float64      `json:"bandwidthKbps"`
+	CapacityKbps  int64        `json:"bandwidthCapacityKbps"`
+	LoadAverage   float64      `json:"loadAverage"`
+}
+
+// Filter selects stored records. Zero values do not filter.
+type Filter struct {
+	// Start and End are the inclusive time range of records.
+	Start time.Time
+	End   time.Time
+	// Host is the name of the cache or peer of records.
+	Host string
+}
+
+func (f Filter) matches(t time.Time, hosts ...string) bool {
+	if !f.Start.IsZero() && t.Before(f.Start) {
+		return false
+	}
+	if !f.End.IsZero() && t.After(f.End) {
+		return false
+	}
+	if f.Host == "" {
+		return true
+	}
+	for _, host := range hosts {
+		if host == f.Host {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesEvent returns whether the given event matches the filter. The host matches either the event's name or hostname.
+func (f Filter) MatchesEvent(e health.Event) bool {
+	return f.matches(time.Time(e.Time), e.Name, e.Hostname)
+}
+
+// Empty returns whether the filter selects all records.
+func (f Filter) Empty() bool {
+	return f.Start.IsZero() && f.End.IsZero() && f.Host == ""
+}
+
+// overlapsDay returns whether the given UTC day segment may contain records matching the filter.
+func (f Filter) overlapsDay(day time.Time) bool {
+	if !f.End.IsZero() && day.After(f.End) {
+		return false
+	}
+	if !f.Start.IsZero() && day.Add(segmentDay).Before(f.Start) {
+		return false
+	}
+	return true
+}
+
+// Store is an on-disk store of events and downsampled stat records, so they survive restarts. It is safe for multiple goroutines.
+// Records are appended as JSON lines to one segment file per kind per UTC day, for example events-20060102.json. Segments are deleted once all their records are older than the retention.
+type Store struct {
+	m         *sync.Mutex
+	dir       string
+	retention time.Duration
+	segments  map[string]*segment
+}
+
+type segment struct {
+	day  string
+	file *os.File
+}
+
+// Open opens the store in the given directory, creating it if it doesn't exist, and deletes segments older than the retention. A retention of 0 keeps segments forever.
+func Open(dir string, retention time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.New("creating directory: " + err.Error())
+	}
+	s := &Store{m: &sync.Mutex{}, dir: dir, retention: retention, segments: map[string]*segment{}}
+	if err := s.prune(time.Now()); err != nil {
+		return nil, errors.New("pruning: " + err.Error())
+	}
+	return s, nil
+}
+
+// AddEvent appends the given event to the store.
+func (s *Store) AddEvent(e health.Event) error {
+	return s.add(EventsPrefix, time.Time(e.Time), e)
+}
+
+// AddStats appends the given stat records, all of which should be from the same time, to the store.
+func (s *Store) AddStats(records []StatRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	vals := make([]interface{}, len(records))
+	for i, record := range records {
+		vals[i] = record
+	}
+	return s.add(StatsPrefix, time.Time(records[0].Time), vals...)
+}
+
+func (s *Store) add(prefix string, t time.Time, vals ...interface{}) error {
+	json := jsoniter.ConfigFastest
+	buf := []byte{}
+	for _, val := range vals {
+		bts, err := json.Marshal(val)
+		if err != nil {
+			return errors.New("marshalling: " + err.Error())
+		}
+		buf = append(buf, bts...)
+		buf = append(buf, '\n')
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	seg, err := s.segment(prefix, t)
+	if err != nil {
+		return err
+	}
+	if _, err := seg.file.Write(buf); err != nil {
+		return errors.New("writing segment: " + err.Error())
+	}
+	return nil
+}
+
+// segment returns the open segment of the given prefix for the given time, opening it and closing the previous segment if necessary. The caller MUST hold the lock.
+func (s *Store) segment(prefix string, t time.Time) (*segment, error) {
+	day := t.UTC().Format(segmentDayFormat)
+	if seg, ok := s.segments[prefix]; ok {
+		if seg.day == day {
+			return seg, nil
+		}
+		if err := seg.file.Close(); err != nil {
+			log.Errorf("persist closing segment %v%v: %v\n", prefix, seg.day, err)
+		}
+		delete(s.segments, prefix)
+		if err := s.prune(time.Now()); err != nil {
+			log.Errorf("persist pruning: %v\n", err)
+		}
+	}
+	file, err := os.OpenFile(filepath.Join(s.dir, prefix+day+SegmentSuffix), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.New("opening segment: " + err.Error())
+	}
+	seg := &segment{day: day, file: file}
+	s.segments[prefix] = seg
+	return seg, nil
+}
+
+// prune deletes segments which are entirely older than the retention. The caller MUST hold the lock, or be the only user of the store.
+func (s *Store) prune(now time.Time) error {
+	if s.retention == 0 {
+		return nil
+	}
+	names, days, err := s.segmentFiles("")
+	if err != nil {
+		return err
+	}
+	cutoff := now.Add(-s.retention)
+	for i, name := range names {
+		if !days[i].Add(segmentDay).Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+			return errors.New("removing segment '" + name + "': " + err.Error())
+		}
+		log.Infof("persist removed expired segment %v\n", name)
+	}
+	return nil
+}
+
+// segmentFiles returns the names and days of segment files with the given prefix, or all segments if prefix is empty, sorted by name.
+func (s *Store) segmentFiles(prefix string) ([]string, []time.Time, error) {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, nil, errors.New("reading directory: " + err.Error())
+	}
+	names := []string{}
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasPrefix(info.Name(), prefix) && strings.HasSuffix(info.Name(), SegmentSuffix) {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+
+	segNames := []string{}
+	days := []time.Time{}
+	for _, name := range names {
+		dayStr := strings.TrimSuffix(name, SegmentSuffix)
+		dayStr = dayStr[strings.LastIndex(dayStr, "-")+1:]
+		day, err := time.Parse(segmentDayFormat, dayStr)
+		if err != nil {
+			continue // not a segment
+		}
+		segNames = append(segNames, name)
+		days = append(days, day)
+	}
+	return segNames, days, nil
+}
+
+// errStopScan may be returned by scan funcs to stop scanning without error.
+var errStopScan = errors.New("stop scan")
+
+// scan calls f with each line of each segment of the given prefix which may contain records matching the filter, in chronological order.
+func (s *Store) scan(prefix string, filter Filter, f func(line []byte) error) error {
+	s.m.Lock()
+	names, days, err := s.segmentFiles(prefix)
+	s.m.Unlock()
+	if err != nil {
+		return err
+	}
+	for i, name := range names {
+		if !filter.overlapsDay(days[i]) {
+			continue
+		}
+		if err := scanFile(filepath.Join(s.dir, name), f); err == errStopScan {
+			return nil
+		} else if err != nil {
+			return errors.New("reading segment '" + name + "': " + err.Error())
+		}
+	}
+	return nil
+}
+
+func scanFile(path string, f func(line []byte) error) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil // pruned since listing
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := f(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Events returns the stored events matching the filter, newest first. Lines which fail to parse, such as a partial line written before a crash, are skipped.
+func (s *Store) Events(filter Filter) ([]health.Event, error) {
+	json := jsoniter.ConfigFastest
+	events := []health.Event{}
+	err := s.scan(EventsPrefix, filter, func(line []byte) error {
+		e := health.Event{}
+		if err := json.Unmarshal(line, &e); err != nil {
+			log.Warnf("persist skipping malformed event '%v': %v\n", string(line), err)
+			return nil
+		}
+		if filter.MatchesEvent(e) {
+			events = append(events, e)
+		}
+		return nil
+	})
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, err
+}
+
+// Stats returns the stored stat records matching the filter, oldest first, skipping the first offset matching records and returning at most limit, and whether more matching records exist. A limit of 0 returns all records. Lines which fail to parse are skipped.
+func (s *Store) Stats(filter Filter, offset int, limit int) ([]StatRecord, bool, error) {
+	json := jsoniter.ConfigFastest
+	records := []StatRecord{}
+	matched := 0
+	more := false
+	err := s.scan(StatsPrefix, filter, func(line []byte) error {
+		record := StatRecord{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			log.Warnf("persist skipping malformed stat record '%v': %v\n", string(line), err)
+			return nil
+		}
+		if !filter.matches(time.Time(record.Time), string(record.Cache)) {
+			return nil
+		}
+		if matched++; matched <= offset {
+			return nil
+		}
+		if limit > 0 && len(records) == limit {
+			more = true
+			return errStopScan
+		}
+		records = append(records, record)
+		return nil
+	})
+	return records, more, err
+}
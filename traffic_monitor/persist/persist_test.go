@@ -0,0 +1,168 @@
+package persist
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+)
+
+func TestStoreEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tm-persist")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Unix(time.Now().Unix(), 0)
+	yesterday := now.Add(-24 * time.Hour)
+
+	store, err := Open(dir, 0)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	for _, e := range []health.Event{
+		{Time: health.Time(yesterday), Name: "edge0", Hostname: "edge0", Description: "old"},
+		{Time: health.Time(now), Name: "edge0", Hostname: "edge0", Description: "new"},
+		{Time: health.Time(now), Name: "edge1", Hostname: "edge1", Description: "other"},
+	} {
+		if err := store.AddEvent(e); err != nil {
+			t.Fatalf("adding event: %v", err)
+		}
+	}
+
+	// a new store, as after a restart
+	store, err = Open(dir, 0)
+	if err != nil {
+		t.Fatalf("reopening store: %v", err)
+	}
+
+	events, err := store.Events(Filter{Host: "edge0"})
+	if err != nil {
+		t.Fatalf("reading events: %v", err)
+	}
+	if len(events) != 2 || events[0].Description != "new" || events[1].Description != "old" {
+		t.Errorf("expected host events newest first, actual %+v", events)
+	}
+	if !time.Time(events[0].Time).Equal(now) {
+		t.Errorf("expected event time %v, actual %v", now, time.Time(events[0].Time))
+	}
+
+	events, err = store.Events(Filter{Start: now.Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("reading events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("expected 2 events in time range, actual %+v", events)
+	}
+
+	if err := store.AddStats([]StatRecord{{Time: health.Time(now), Cache: "edge0", Available: true, BandwidthKbps: 42}}); err != nil {
+		t.Fatalf("adding stats: %v", err)
+	}
+	stats, _, err := store.Stats(Filter{Host: "edge0", End: now}, 0, 0)
+	if err != nil {
+		t.Fatalf("reading stats: %v", err)
+	}
+	if len(stats) != 1 || stats[0].BandwidthKbps != 42 || !stats[0].Available {
+		t.Errorf("expected stat record, actual %+v", stats)
+	}
+}
+
+func TestStorePrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tm-persist")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	oldName := EventsPrefix + old.UTC().Format(segmentDayFormat) + SegmentSuffix
+	if err := ioutil.WriteFile(filepath.Join(dir, oldName), []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("writing old segment: %v", err)
+	}
+	otherName := "other.json"
+	if err := ioutil.WriteFile(filepath.Join(dir, otherName), []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("writing other file: %v", err)
+	}
+
+	if _, err := Open(dir, 7*24*time.Hour); err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, oldName)); !os.IsNotExist(err) {
+		t.Errorf("expected expired segment to be removed, actual stat error %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, otherName)); err != nil {
+		t.Errorf("expected non-segment file to be kept, actual stat error %v", err)
+	}
+}
+
+func TestStoreStatsPaging(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tm-persist")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := Open(dir, 0)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	now := time.Now()
+	records := []StatRecord{}
+	for i := 0; i < 5; i++ {
+		records = append(records, StatRecord{Time: health.Time(now.Add(time.Duration(i) * time.Second)), Cache: "edge0", BandwidthKbps: float64(i)})
+	}
+	if err := store.AddStats(records); err != nil {
+		t.Fatalf("adding stats: %v", err)
+	}
+
+	tests := []struct {
+		offset       int
+		limit        int
+		expectedKbps []float64
+		expectedMore bool
+	}{
+		{0, 2, []float64{0, 1}, true},
+		{2, 2, []float64{2, 3}, true},
+		{4, 2, []float64{4}, false},
+		{3, 2, []float64{3, 4}, false},
+		{5, 2, []float64{}, false},
+		{1, 0, []float64{1, 2, 3, 4}, false},
+	}
+	for _, test := range tests {
+		stats, more, err := store.Stats(Filter{}, test.offset, test.limit)
+		if err != nil {
+			t.Fatalf("reading stats offset %v limit %v: %v", test.offset, test.limit, err)
+		}
+		actualKbps := []float64{}
+		for _, stat := range stats {
+			actualKbps = append(actualKbps, stat.BandwidthKbps)
+		}
+		if fmt.Sprint(actualKbps) != fmt.Sprint(test.expectedKbps) || more != test.expectedMore {
+			t.Errorf("stats offset %v limit %v expected %v more %v, actual %v more %v", test.offset, test.limit, test.expectedKbps, test.expectedMore, actualKbps, more)
+		}
+	}
+}
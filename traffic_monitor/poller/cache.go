@@ -30,6 +30,7 @@ import (
 	"github.com/apache/trafficcontrol/lib/go-log"
 	"github.com/apache/trafficcontrol/traffic_monitor/config"
 	"github.com/apache/trafficcontrol/traffic_monitor/handler"
+	"github.com/apache/trafficcontrol/traffic_monitor/recording"
 )
 
 type CachePoller struct {
@@ -38,6 +39,14 @@ type CachePoller struct {
 	TickChan       chan uint64
 	GlobalContexts map[string]interface{}
 	Handler        handler.Handler
+	// Recorder records every poll result, if not nil.
+	Recorder *recording.Recorder
+	// PollType is the poller type of every poll, if not empty, overriding the type of each poll.
+	PollType string
+	// Schedule determines the interval before each poll.
+	Schedule Schedule
+	// Timings records the timing of every poll.
+	Timings PollTimings
 }
 
 type PollConfig struct {
@@ -46,6 +55,11 @@ type PollConfig struct {
 	Timeout  time.Duration
 	Format   string
 	PollType string
+	// Token is sent as a bearer token, if not empty.
+	Token string
+	TLS   ClientTLS
+	// Interval is the interval between polls, if not zero, overriding the interval of the poller, for example from the cache's profile.
+	Interval time.Duration
 }
 
 type CachePollerConfig struct {
@@ -76,6 +90,8 @@ func NewCache(
 		},
 		GlobalContexts: GetGlobalContexts(cfg, appData),
 		Handler:        handler,
+		Schedule:       NewSchedule(ScheduleConfig{Jitter: cfg.PollJitter}),
+		Timings:        NewPollTimings(),
 	}
 }
 
@@ -101,6 +117,9 @@ func (p CachePoller) Poll() {
 			kill := make(chan struct{})
 			killChans[info.ID] = kill
 
+			if p.PollType != "" {
+				info.PollType = p.PollType
+			}
 			if _, ok := pollers[info.PollType]; !ok {
 				if info.PollType != "" { // don't warn for missing parameters
 					log.Warnln("CachePoller.Poll: poll type '" + info.PollType + "' not found, using default poll type '" + DefaultPollerType + "'")
@@ -114,12 +133,25 @@ func (p CachePoller) Poll() {
 				Timeout:     info.Timeout,
 				NoKeepAlive: info.NoKeepAlive,
 				PollerID:    info.ID,
+				Token:       info.Token,
+				TLS:         info.TLS,
 			}
 			pollerCtx := interface{}(nil)
 			if pollerObj.Init != nil {
 				pollerCtx = pollerObj.Init(pollerCfg, p.GlobalContexts[info.PollType])
 			}
-			go poller(info.Interval, info.ID, info.URL, info.Host, info.Format, p.Handler, pollerObj.Poll, pollerCtx, kill)
+			pollFunc := pollerObj.Poll
+			if p.Recorder != nil {
+				pollFunc = recordPolls(p.Recorder, info.ID, pollFunc)
+			}
+			interval := info.Interval
+			if info.PollConfig.Interval > 0 {
+				interval = info.PollConfig.Interval
+			}
+			if info.PollType == PollerTypeReplay {
+				interval = 0 // replayed polls are paced by the replay's virtual clock, not the poll interval
+			}
+			go poller(interval, info.ID, info.URL, info.Host, info.Format, p.Handler, pollFunc, pollerCtx, p.Schedule, p.Timings, kill)
 		}
 		p.Config = newConfig
 	}
@@ -144,19 +176,23 @@ func poller(
 	handler handler.Handler,
 	pollFunc PollerFunc,
 	pollCtx interface{},
+	schedule Schedule,
+	timings PollTimings,
 	die <-chan struct{},
 ) {
 	pollSpread := time.Duration(rand.Float64()*float64(interval/time.Nanosecond)) * time.Nanosecond
 	time.Sleep(pollSpread)
-	tick := time.NewTicker(interval)
+	nextInterval := schedule.Next(id, interval, time.Now())
+	timer := time.NewTimer(nextInterval)
 	lastTime := time.Now()
 	for {
 		select {
-		case <-tick.C:
+		case <-timer.C:
 			realInterval := time.Now().Sub(lastTime)
-			if realInterval > interval+(time.Millisecond*100) {
-				log.Debugf("Intended Duration: %v Actual Duration: %v\n", interval, realInterval)
+			if realInterval > nextInterval+(time.Millisecond*100) {
+				log.Debugf("Intended Duration: %v Actual Duration: %v\n", nextInterval, realInterval)
 			}
+			timings.AddInterval(realInterval)
 			lastTime = time.Now()
 
 			pollID := atomic.AddUint64(&pollNum, 1)
@@ -169,10 +205,15 @@ func poller(
 			} else {
 			}
 			log.Debugf("poll %v %v poller end\n", pollID, time.Now())
+			timings.AddDuration(reqTime)
 			go handler.Handle(id, rdr, format, reqTime, reqEnd, err, pollID, pollFinishedChan)
 			<-pollFinishedChan
+
+			// the interval is from the start of this poll, so slow polls don't delay the schedule; if a poll takes longer than the interval, the next starts immediately.
+			nextInterval = schedule.Next(id, interval, time.Now())
+			timer.Reset(nextInterval - time.Now().Sub(lastTime))
 		case <-die:
-			tick.Stop()
+			timer.Stop()
 			return
 		}
 	}
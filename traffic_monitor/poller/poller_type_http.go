@@ -21,10 +21,12 @@ package poller
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/apache/trafficcontrol/lib/go-log"
@@ -70,13 +72,91 @@ func httpInit(cfg PollerConfig, globalCtxI interface{}) interface{} {
 		}
 	}
 
+	client := gctx.Client
+	tlsVerified := false
+	if cfg.TLS != (ClientTLS{}) {
+		tlsClient, err := newTLSClient(*gctx.Client, cfg.TLS)
+		if err != nil {
+			log.Errorf("poller %v creating TLS client, using default client: %v\n", cfg.PollerID, err)
+		} else {
+			client = tlsClient
+			tlsVerified = cfg.TLS.CAFile != ""
+		}
+	}
+
 	return &HTTPPollCtx{
-		Client:      gctx.Client,
+		Client:      client,
 		UserAgent:   gctx.UserAgent,
 		NoKeepAlive: cfg.NoKeepAlive,
 		URL:         cfg.URL,
 		Host:        cfg.Host,
 		PollerID:    cfg.PollerID,
+		Token:       pollToken(cfg, tlsVerified),
+	}
+}
+
+// pollToken returns the token to send with polls. The token is not sent to HTTPS servers whose certificates aren't verified, because anyone able to intercept the connection could take it.
+func pollToken(cfg PollerConfig, tlsVerified bool) string {
+	if cfg.Token == "" || tlsVerified || !strings.HasPrefix(strings.ToLower(cfg.URL), "https:") {
+		return cfg.Token
+	}
+	log.Warnf("poller %v not sending token to '%v', because the server certificate is not verified. Set a CA file to verify it.\n", cfg.PollerID, cfg.URL)
+	return ""
+}
+
+// newTLSClient returns a copy of the given client, with a copy of its transport using the given TLS configuration.
+func newTLSClient(client http.Client, clientTLS ClientTLS) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: true, ServerName: clientTLS.ServerName}
+	if clientTLS.CAFile != "" {
+		pem, err := ioutil.ReadFile(clientTLS.CAFile)
+		if err != nil {
+			return nil, errors.New("reading CA file: " + err.Error())
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("CA file '" + clientTLS.CAFile + "' contains no certificates")
+		}
+		tlsConfig.RootCAs = pool
+		tlsConfig.InsecureSkipVerify = false
+	}
+	if clientTLS.CertFile != "" || clientTLS.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(clientTLS.CertFile, clientTLS.KeyFile)
+		if err != nil {
+			return nil, errors.New("loading client certificate: " + err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport := &http.Transport{}
+	if baseTransport, ok := client.Transport.(*http.Transport); ok {
+		transport = copyTransport(baseTransport)
+	} else if client.Transport != nil {
+		log.Warnf("creating TLS client: transport expected type *http.Transport actual %T, using a new transport\n", client.Transport)
+	}
+	transport.TLSClientConfig = tlsConfig
+	client.Transport = transport
+	return &client, nil
+}
+
+// copyTransport returns a new transport with the exported settings of the given transport, but none of its connections.
+func copyTransport(t *http.Transport) *http.Transport {
+	return &http.Transport{
+		Proxy:                  t.Proxy,
+		DialContext:            t.DialContext,
+		Dial:                   t.Dial,
+		DialTLS:                t.DialTLS,
+		TLSClientConfig:        t.TLSClientConfig,
+		TLSHandshakeTimeout:    t.TLSHandshakeTimeout,
+		DisableKeepAlives:      t.DisableKeepAlives,
+		DisableCompression:     t.DisableCompression,
+		MaxIdleConns:           t.MaxIdleConns,
+		MaxIdleConnsPerHost:    t.MaxIdleConnsPerHost,
+		MaxConnsPerHost:        t.MaxConnsPerHost,
+		IdleConnTimeout:        t.IdleConnTimeout,
+		ResponseHeaderTimeout:  t.ResponseHeaderTimeout,
+		ExpectContinueTimeout:  t.ExpectContinueTimeout,
+		TLSNextProto:           t.TLSNextProto,
+		ProxyConnectHeader:     t.ProxyConnectHeader,
+		MaxResponseHeaderBytes: t.MaxResponseHeaderBytes,
 	}
 }
 
@@ -92,6 +172,7 @@ type HTTPPollCtx struct {
 	URL         string
 	Host        string
 	PollerID    string
+	Token       string
 }
 
 func httpPoll(ctxI interface{}, url string, host string, pollID uint64) ([]byte, time.Time, time.Duration, error) {
@@ -105,6 +186,9 @@ func httpPoll(ctxI interface{}, url string, host string, pollID uint64) ([]byte,
 		req.Header.Set("Connection", "keep-alive")
 	}
 	req.Host = host
+	if ctx.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+ctx.Token)
+	}
 	startReq := time.Now()
 	resp, err := ctx.Client.Do(req)
 	reqEnd := time.Now()
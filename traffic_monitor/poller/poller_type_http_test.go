@@ -0,0 +1,81 @@
+package poller
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewTLSClientKeepsTransport(t *testing.T) {
+	baseTLS := &tls.Config{InsecureSkipVerify: true}
+	baseTransport := &http.Transport{
+		TLSClientConfig:       baseTLS,
+		DisableKeepAlives:     true,
+		MaxIdleConnsPerHost:   7,
+		IdleConnTimeout:       42 * time.Second,
+		ResponseHeaderTimeout: 5 * time.Second,
+	}
+	base := http.Client{Transport: baseTransport, Timeout: 3 * time.Second}
+
+	client, err := newTLSClient(base, ClientTLS{ServerName: "cache.example"})
+	if err != nil {
+		t.Fatalf("newTLSClient expected nil error, actual %v", err)
+	}
+	if client.Timeout != base.Timeout {
+		t.Errorf("newTLSClient expected timeout %v, actual %v", base.Timeout, client.Timeout)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("newTLSClient expected transport type *http.Transport, actual %T", client.Transport)
+	}
+	if transport == baseTransport {
+		t.Fatalf("newTLSClient expected a copy of the base transport, actual the base transport")
+	}
+	if !transport.DisableKeepAlives || transport.MaxIdleConnsPerHost != 7 || transport.IdleConnTimeout != 42*time.Second || transport.ResponseHeaderTimeout != 5*time.Second {
+		t.Errorf("newTLSClient expected base transport settings, actual %+v", transport)
+	}
+	if transport.TLSClientConfig == nil || transport.TLSClientConfig.ServerName != "cache.example" {
+		t.Errorf("newTLSClient expected TLS server name 'cache.example', actual %+v", transport.TLSClientConfig)
+	}
+	if baseTransport.TLSClientConfig != baseTLS || baseTLS.ServerName != "" {
+		t.Errorf("newTLSClient expected base transport TLS config unchanged, actual %+v", baseTransport.TLSClientConfig)
+	}
+}
+
+func TestPollToken(t *testing.T) {
+	tests := []struct {
+		url         string
+		tlsVerified bool
+		expected    string
+	}{
+		{"http://peer.example:80/publish/CrStates", false, "secret"},
+		{"https://peer.example:443/publish/CrStates", true, "secret"},
+		{"https://peer.example:443/publish/CrStates", false, ""},
+		{"HTTPS://peer.example:443/publish/CrStates", false, ""},
+	}
+	for _, test := range tests {
+		if actual := pollToken(PollerConfig{URL: test.url, Token: "secret"}, test.tlsVerified); actual != test.expected {
+			t.Errorf("pollToken '%v' verified %v expected '%v', actual '%v'", test.url, test.tlsVerified, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package poller
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+	"github.com/apache/trafficcontrol/traffic_monitor/recording"
+)
+
+// PollerTypeReplay is the poller type which replays recorded polls instead of polling. It is only available after AddReplayPollerType is called.
+const PollerTypeReplay = "replay"
+
+// AddReplayPollerType adds the replay poller type, which returns the polls recorded in the given replay. This MUST be called on startup, before any pollers are created.
+func AddReplayPollerType(replay *recording.Replay) {
+	globalInit := func(cfg config.Config, appData config.StaticAppData) interface{} { return replay }
+	AddPollerType(PollerTypeReplay, globalInit, replayInit, replayPoll)
+}
+
+type ReplayPollCtx struct {
+	Replay   *recording.Replay
+	PollerID string
+}
+
+func replayInit(cfg PollerConfig, globalCtxI interface{}) interface{} {
+	return &ReplayPollCtx{Replay: globalCtxI.(*recording.Replay), PollerID: cfg.PollerID}
+}
+
+// replayPoll returns the next recorded poll of the poller, at the virtual time it was recorded. When the poller's recorded polls are exhausted, it blocks forever, so the states at the end of the recording remain.
+func replayPoll(ctxI interface{}, url string, host string, pollID uint64) ([]byte, time.Time, time.Duration, error) {
+	ctx := (ctxI).(*ReplayPollCtx)
+	rec, ok := ctx.Replay.NextPoll(ctx.PollerID, url)
+	if !ok {
+		log.Infof("replay of poller %v %v finished\n", ctx.PollerID, url)
+		select {}
+	}
+	if rec.Error != "" {
+		return nil, rec.Time, rec.Duration, errors.New(rec.Error)
+	}
+	return rec.Data, rec.Time, rec.Duration, nil
+}
+
+// recordPolls returns a PollerFunc which records the results of the given PollerFunc of the given poller ID.
+func recordPolls(recorder *recording.Recorder, id string, pollFunc PollerFunc) PollerFunc {
+	return func(ctx interface{}, url string, host string, pollID uint64) ([]byte, time.Time, time.Duration, error) {
+		bts, reqEnd, reqTime, err := pollFunc(ctx, url, host, pollID)
+		rec := recording.Record{Type: recording.TypePoll, ID: id, URL: url, Time: reqEnd, Duration: reqTime, Data: bts}
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		recorder.Record(rec)
+		return bts, reqEnd, reqTime, err
+	}
+}
@@ -41,6 +41,19 @@ type PollerConfig struct {
 	Timeout     time.Duration
 	NoKeepAlive bool
 	PollerID    string
+	Token       string
+	TLS         ClientTLS
+}
+
+// ClientTLS is the TLS client configuration of a poll. The zero value uses the poller type's default, which doesn't verify the server certificate or present a client certificate.
+type ClientTLS struct {
+	// CertFile and KeyFile are the PEM files of the client certificate to present. If empty, no certificate is presented.
+	CertFile string
+	KeyFile  string
+	// CAFile is the PEM file of the CAs to verify the server certificate. If empty, the server certificate is not verified.
+	CAFile string
+	// ServerName is the name to verify the server certificate against, if it isn't the host of the URL.
+	ServerName string
 }
 
 // PollerGlobalInit performs global initialization, and returns a global context object.
@@ -0,0 +1,204 @@
+package poller
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/traffic_monitor/config"
+)
+
+// ScheduleConfig is the configuration of a Schedule.
+type ScheduleConfig struct {
+	// Jitter is the fraction of each interval by which it's randomly lengthened or shortened, so polls of different caches don't synchronize.
+	Jitter float64
+	// FastRatio is the fraction of the interval polled at, for FastDuration after a cache is near a threshold or changes state. If 0, polls aren't made faster.
+	FastRatio    float64
+	FastDuration time.Duration
+	// SlowRatio is the multiple of the interval polled at, once a cache has been stable for StableDuration. If 0, polls aren't made slower.
+	SlowRatio      float64
+	StableDuration time.Duration
+	// ThresholdMargin is the fraction of a threshold's value within which a stat is near the threshold.
+	ThresholdMargin float64
+}
+
+// NewScheduleConfig returns the schedule config of the given monitor config.
+func NewScheduleConfig(cfg config.Config) ScheduleConfig {
+	return ScheduleConfig{
+		Jitter:          cfg.PollJitter,
+		FastRatio:       cfg.PollFastRatio,
+		FastDuration:    cfg.PollFastDuration,
+		SlowRatio:       cfg.PollSlowRatio,
+		StableDuration:  cfg.PollStableDuration,
+		ThresholdMargin: cfg.PollThresholdMargin,
+	}
+}
+
+// Schedule adapts the interval between the polls of each poll ID. Polls are faster while the cache is near a threshold or has recently changed state, and slower while it's stable, and every interval is jittered. The zero value polls at the given interval. Schedule is safe for multiple goroutines.
+type Schedule struct {
+	cfg   ScheduleConfig
+	m     *sync.RWMutex
+	polls *map[string]pollSchedule
+}
+
+type pollSchedule struct {
+	fastUntil   time.Time
+	stableSince time.Time // zero if the cache isn't stable
+}
+
+// NewSchedule returns a new Schedule with the given config.
+func NewSchedule(cfg ScheduleConfig) Schedule {
+	polls := map[string]pollSchedule{}
+	return Schedule{cfg: cfg, m: &sync.RWMutex{}, polls: &polls}
+}
+
+// Adaptive returns whether the schedule changes intervals from Observe, and thus whether it's worth calling.
+func (s Schedule) Adaptive() bool {
+	return s.m != nil && (s.cfg.FastRatio > 0 || s.cfg.SlowRatio > 0)
+}
+
+// ThresholdMargin returns the fraction of a threshold's value within which a stat is near the threshold, and the cache should be polled faster.
+func (s Schedule) ThresholdMargin() float64 {
+	return s.cfg.ThresholdMargin
+}
+
+// Observe records the evaluation of the health of the given poll ID at the given time. The urgent argument is whether the cache is near a threshold, or changed state.
+func (s Schedule) Observe(id string, urgent bool, now time.Time) {
+	if !s.Adaptive() {
+		return
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	poll := (*s.polls)[id]
+	if urgent {
+		poll.fastUntil = now.Add(s.cfg.FastDuration)
+		poll.stableSince = time.Time{}
+	} else if poll.stableSince.IsZero() {
+		poll.stableSince = now
+	}
+	(*s.polls)[id] = poll
+}
+
+// Interval returns the interval until the next poll of the given ID, without jitter, given the base interval of its poller.
+func (s Schedule) Interval(id string, interval time.Duration, now time.Time) time.Duration {
+	if !s.Adaptive() {
+		return interval
+	}
+	s.m.RLock()
+	poll, ok := (*s.polls)[id]
+	s.m.RUnlock()
+	if !ok {
+		return interval
+	}
+	if s.cfg.FastRatio > 0 && now.Before(poll.fastUntil) {
+		return time.Duration(float64(interval) * s.cfg.FastRatio)
+	}
+	if s.cfg.SlowRatio > 0 && !poll.stableSince.IsZero() && now.Sub(poll.stableSince) >= s.cfg.StableDuration {
+		return time.Duration(float64(interval) * s.cfg.SlowRatio)
+	}
+	return interval
+}
+
+// Next returns the jittered interval until the next poll of the given ID.
+func (s Schedule) Next(id string, interval time.Duration, now time.Time) time.Duration {
+	interval = s.Interval(id, interval, now)
+	if s.cfg.Jitter > 0 {
+		interval += time.Duration((rand.Float64()*2 - 1) * s.cfg.Jitter * float64(interval))
+	}
+	return interval
+}
+
+// HistogramBucketsMs are the upper bounds of the buckets of poll timing histograms, in milliseconds. Durations longer than the last bucket are counted in a final overflow bucket.
+var HistogramBucketsMs = []int64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000}
+
+// Histogram counts durations in the buckets of HistogramBucketsMs.
+type Histogram struct {
+	BucketsMs []int64  `json:"bucketsMs"`
+	Counts    []uint64 `json:"counts"`
+	Count     uint64   `json:"count"`
+	SumMs     int64    `json:"sumMs"`
+}
+
+// NewHistogram returns a new empty Histogram.
+func NewHistogram() Histogram {
+	return Histogram{BucketsMs: HistogramBucketsMs, Counts: make([]uint64, len(HistogramBucketsMs)+1)}
+}
+
+// Add counts the given duration.
+func (h *Histogram) Add(d time.Duration) {
+	ms := int64(d / time.Millisecond)
+	i := 0
+	for i < len(h.BucketsMs) && ms > h.BucketsMs[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.SumMs += ms
+}
+
+// Copy returns a deep copy of the histogram.
+func (h Histogram) Copy() Histogram {
+	counts := make([]uint64, len(h.Counts))
+	copy(counts, h.Counts)
+	h.Counts = counts
+	return h
+}
+
+// PollTimingHistograms are histograms of the timing of a poller's polls.
+type PollTimingHistograms struct {
+	// Intervals are the times between the starts of consecutive polls of each poll ID.
+	Intervals Histogram `json:"intervals"`
+	// Durations are the times polls took.
+	Durations Histogram `json:"durations"`
+}
+
+// PollTimings records the timing of a poller's polls. It is safe for multiple goroutines.
+type PollTimings struct {
+	m          *sync.Mutex
+	histograms *PollTimingHistograms
+}
+
+// NewPollTimings returns a new PollTimings.
+func NewPollTimings() PollTimings {
+	return PollTimings{m: &sync.Mutex{}, histograms: &PollTimingHistograms{Intervals: NewHistogram(), Durations: NewHistogram()}}
+}
+
+// AddInterval records the time between the starts of two consecutive polls.
+func (t PollTimings) AddInterval(d time.Duration) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.histograms.Intervals.Add(d)
+}
+
+// AddDuration records the time a poll took.
+func (t PollTimings) AddDuration(d time.Duration) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.histograms.Durations.Add(d)
+}
+
+// Get returns a copy of the histograms.
+func (t PollTimings) Get() PollTimingHistograms {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return PollTimingHistograms{Intervals: t.histograms.Intervals.Copy(), Durations: t.histograms.Durations.Copy()}
+}
@@ -0,0 +1,78 @@
+package poller
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleInterval(t *testing.T) {
+	interval := 10 * time.Second
+	s := NewSchedule(ScheduleConfig{FastRatio: 0.5, FastDuration: time.Minute, SlowRatio: 2, StableDuration: 5 * time.Minute})
+	start := time.Now()
+
+	if actual := s.Interval("unobserved", interval, start); actual != interval {
+		t.Errorf("Schedule.Interval of unobserved ID expected %v, actual %v", interval, actual)
+	}
+
+	s.Observe("cache", true, start)
+	if actual := s.Interval("cache", interval, start.Add(time.Second)); actual != interval/2 {
+		t.Errorf("Schedule.Interval after urgent observation expected %v, actual %v", interval/2, actual)
+	}
+
+	s.Observe("cache", false, start.Add(time.Second))
+	if actual := s.Interval("cache", interval, start.Add(2*time.Minute)); actual != interval {
+		t.Errorf("Schedule.Interval after fast duration expected %v, actual %v", interval, actual)
+	}
+	if actual := s.Interval("cache", interval, start.Add(10*time.Minute)); actual != interval*2 {
+		t.Errorf("Schedule.Interval after stable duration expected %v, actual %v", interval*2, actual)
+	}
+
+	s.Observe("cache", true, start.Add(10*time.Minute))
+	if actual := s.Interval("cache", interval, start.Add(10*time.Minute)); actual != interval/2 {
+		t.Errorf("Schedule.Interval of stable cache after urgent observation expected %v, actual %v", interval/2, actual)
+	}
+
+	if actual := (Schedule{}).Interval("cache", interval, start); actual != interval {
+		t.Errorf("zero Schedule.Interval expected %v, actual %v", interval, actual)
+	}
+}
+
+func TestScheduleJitter(t *testing.T) {
+	interval := 10 * time.Second
+	s := NewSchedule(ScheduleConfig{Jitter: 0.1})
+	for i := 0; i < 100; i++ {
+		if actual := s.Next("cache", interval, time.Now()); actual < 9*time.Second || actual > 11*time.Second {
+			t.Fatalf("Schedule.Next with jitter 0.1 expected between 9s and 11s, actual %v", actual)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram()
+	h.Add(5 * time.Millisecond)
+	h.Add(10 * time.Millisecond)
+	h.Add(11 * time.Millisecond)
+	h.Add(time.Hour)
+	if h.Counts[0] != 2 || h.Counts[1] != 1 || h.Counts[len(h.Counts)-1] != 1 || h.Count != 4 {
+		t.Errorf("Histogram expected counts [2 1 ... 1] of 4, actual %v of %v", h.Counts, h.Count)
+	}
+}
@@ -0,0 +1,63 @@
+package probe
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"sync"
+)
+
+// The event types of Traffic Router and Traffic Ops health events.
+const (
+	EventTypeTrafficRouter = "TRAFFIC_ROUTER"
+	EventTypeTrafficOps    = "TRAFFIC_OPS"
+)
+
+// InfraResults is the health of the CDN's Traffic Routers and Traffic Ops, polled like delivery service probes.
+type InfraResults struct {
+	// Routers is the result of the last stats poll of each Traffic Router, by hostname.
+	Routers map[string]Result `json:"routers"`
+	// TrafficOps is the result of the last ping of Traffic Ops, or nil if it hasn't been pinged.
+	TrafficOps *Result `json:"trafficOps,omitempty"`
+}
+
+// InfraResultsThreadsafe wraps InfraResults to be safe for multiple reader goroutines and one writer.
+type InfraResultsThreadsafe struct {
+	results *InfraResults
+	m       *sync.RWMutex
+}
+
+// NewInfraResultsThreadsafe returns a new, empty InfraResultsThreadsafe.
+func NewInfraResultsThreadsafe() InfraResultsThreadsafe {
+	return InfraResultsThreadsafe{results: &InfraResults{Routers: map[string]Result{}}, m: &sync.RWMutex{}}
+}
+
+// Get returns the results. The returned Routers MUST NOT be modified.
+func (r InfraResultsThreadsafe) Get() InfraResults {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return *r.results
+}
+
+// Set sets the results. This MUST NOT be called by multiple goroutines.
+func (r InfraResultsThreadsafe) Set(v InfraResults) {
+	r.m.Lock()
+	*r.results = v
+	r.m.Unlock()
+}
@@ -0,0 +1,176 @@
+package probe
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+// Result is the result of probing a delivery service through a cache.
+type Result struct {
+	Time time.Time `json:"time"`
+	// Status is the HTTP status code of the response, or 0 if no response was received.
+	Status int `json:"status"`
+	// TTFBMs is the time in milliseconds from sending the request to receiving the first byte of the response.
+	TTFBMs float64 `json:"ttfbMs"`
+	// Error is why the probe failed, or empty if it succeeded.
+	Error string `json:"error,omitempty"`
+	// Failures is the number of consecutive failed probes, including this one.
+	Failures uint64 `json:"consecutiveFailures"`
+	// Available is whether the cache should serve the delivery service, per the probe failure threshold.
+	Available bool `json:"isAvailable"`
+}
+
+// Failed returns whether the probe itself failed. Note a single failed probe may still be Available, if the failure threshold hasn't been reached.
+func (r Result) Failed() bool {
+	return r.Error != ""
+}
+
+// Next returns the given new result, with its Failures and Available set from the previous result r and the number of consecutive failures at which the cache becomes unavailable for the delivery service.
+func (r Result) Next(next Result, failureThreshold uint64) Result {
+	if next.Failed() {
+		next.Failures = r.Failures + 1
+	} else {
+		next.Failures = 0
+	}
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	next.Available = next.Failures < failureThreshold
+	return next
+}
+
+// Results is the probe results of each cache of each delivery service.
+type Results map[tc.DeliveryServiceName]map[tc.CacheName]Result
+
+// Add adds the given result to the results.
+func (r Results) Add(ds tc.DeliveryServiceName, cache tc.CacheName, result Result) {
+	if _, ok := r[ds]; !ok {
+		r[ds] = map[tc.CacheName]Result{}
+	}
+	r[ds][cache] = result
+}
+
+// Available returns whether the given cache is available for the given delivery service, per its probes. Caches which haven't been probed are available.
+func (r Results) Available(ds tc.DeliveryServiceName, cache tc.CacheName) bool {
+	result, ok := r[ds][cache]
+	return !ok || result.Available
+}
+
+// ResultsThreadsafe wraps Results to be safe for multiple reader goroutines and one writer.
+type ResultsThreadsafe struct {
+	results *Results
+	m       *sync.RWMutex
+}
+
+// NewResultsThreadsafe returns a new, empty ResultsThreadsafe.
+func NewResultsThreadsafe() ResultsThreadsafe {
+	r := Results{}
+	return ResultsThreadsafe{results: &r, m: &sync.RWMutex{}}
+}
+
+// Get returns the results. The returned map MUST NOT be modified.
+func (r ResultsThreadsafe) Get() Results {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return *r.results
+}
+
+// Set sets the results. This MUST NOT be called by multiple goroutines.
+func (r ResultsThreadsafe) Set(v Results) {
+	r.m.Lock()
+	*r.results = v
+	r.m.Unlock()
+}
+
+type dialAddrKey struct{}
+
+// NewClient returns a client for Probe, with the given timeout. The client doesn't reuse connections, because requests for the same URL go to different caches.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if cacheAddr, ok := ctx.Value(dialAddrKey{}).(string); ok {
+					addr = cacheAddr
+				}
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse // a redirect is the cache's response, not something to follow to another host
+		},
+	}
+}
+
+// Probe requests the given URL from the cache at the given host:port address, rather than the address of the URL's host, so the Host header and TLS server name are those of the delivery service. The client must be from NewClient.
+// The probe fails if no response is received, if the status isn't 2xx or 3xx, or if maxTTFB is nonzero and the first byte took longer. The returned result's Failures and Available are not set, see Result.Next.
+func Probe(client *http.Client, probeURL string, addr string, maxTTFB time.Duration) Result {
+	result := Result{Time: time.Now()}
+	req, err := http.NewRequest(http.MethodGet, probeURL, nil)
+	if err != nil {
+		result.Error = "creating request: " + err.Error()
+		return result
+	}
+	req.Header.Set("User-Agent", "traffic_monitor/probe")
+
+	start := time.Now()
+	ttfb := time.Duration(0)
+	trace := &httptrace.ClientTrace{
+		WroteRequest:         func(httptrace.WroteRequestInfo) { start = time.Now() },
+		GotFirstResponseByte: func() { ttfb = time.Since(start) },
+	}
+	ctx := context.WithValue(httptrace.WithClientTrace(req.Context(), trace), dialAddrKey{}, addr)
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		result.Error = "requesting: " + err.Error()
+		return result
+	}
+	resp.Body.Close() // only the status and first byte matter, and connections aren't reused
+
+	result.Status = resp.StatusCode
+	result.TTFBMs = float64(ttfb) / float64(time.Millisecond)
+	if err := checkResponse(resp.StatusCode, ttfb, maxTTFB); err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func checkResponse(status int, ttfb time.Duration, maxTTFB time.Duration) error {
+	if status < 200 || status > 399 {
+		return errors.New("bad status " + strconv.Itoa(status))
+	}
+	if maxTTFB > 0 && ttfb > maxTTFB {
+		return errors.New("time to first byte " + ttfb.String() + " over " + maxTTFB.String())
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package probe
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbe(t *testing.T) {
+	hosts := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts <- r.Host
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	client := NewClient(time.Second)
+
+	result := Probe(client, "http://ds.example.invalid/probe", addr, 0)
+	if result.Failed() {
+		t.Fatalf("Probe expected: success, actual: %v", result.Error)
+	}
+	if result.Status != http.StatusOK {
+		t.Errorf("Probe status expected: %v, actual: %v", http.StatusOK, result.Status)
+	}
+	if host := <-hosts; host != "ds.example.invalid" {
+		t.Errorf("Probe host header expected: ds.example.invalid, actual: %v", host)
+	}
+
+	result = Probe(client, "http://ds.example.invalid/missing", addr, 0)
+	if !result.Failed() || result.Status != http.StatusNotFound {
+		t.Errorf("Probe of missing object expected: failed 404, actual: status %v error '%v'", result.Status, result.Error)
+	}
+}
+
+func TestResultNext(t *testing.T) {
+	threshold := uint64(2)
+	failed := Result{Error: "bad status 502"}
+	succeeded := Result{Status: 200}
+
+	r := Result{}.Next(failed, threshold)
+	if !r.Available || r.Failures != 1 {
+		t.Errorf("first failure expected: available with 1 failure, actual: available %v failures %v", r.Available, r.Failures)
+	}
+	r = r.Next(failed, threshold)
+	if r.Available || r.Failures != 2 {
+		t.Errorf("failure at threshold expected: unavailable with 2 failures, actual: available %v failures %v", r.Available, r.Failures)
+	}
+	r = r.Next(succeeded, threshold)
+	if !r.Available || r.Failures != 0 {
+		t.Errorf("success expected: available with 0 failures, actual: available %v failures %v", r.Available, r.Failures)
+	}
+}
+
+func TestResultsAvailable(t *testing.T) {
+	results := Results{}
+	results.Add("ds", "cache0", Result{Available: false})
+	if results.Available("ds", "cache0") {
+		t.Errorf("Available of failing cache expected: false, actual: true")
+	}
+	if !results.Available("ds", "cache1") {
+		t.Errorf("Available of unprobed cache expected: true, actual: false")
+	}
+	if !results.Available("otherDS", "cache0") {
+		t.Errorf("Available of unprobed delivery service expected: true, actual: false")
+	}
+}
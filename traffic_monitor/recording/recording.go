@@ -0,0 +1,333 @@
+package recording
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+
+	"github.com/json-iterator/go"
+)
+
+const (
+	// TypePoll is the record of a cache or peer poll.
+	TypePoll = "poll"
+	// TypeCRConfig is the record of a CRConfig fetch from Traffic Ops.
+	TypeCRConfig = "crconfig"
+	// TypeMonitorConfig is the record of a monitor config fetch from Traffic Ops.
+	TypeMonitorConfig = "monitorconfig"
+)
+
+// Record is a single recorded poll result or Traffic Ops fetch.
+type Record struct {
+	Type string `json:"type"`
+	// ID is the poller ID of a poll, which is the cache or peer name, or the CDN of a Traffic Ops fetch.
+	ID string `json:"id"`
+	// URL is the polled URL. It is empty for Traffic Ops fetches.
+	URL string `json:"url,omitempty"`
+	// Time is the time the request finished.
+	Time time.Time `json:"time"`
+	// Duration is the length of time the request took.
+	Duration time.Duration `json:"duration,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	// Data is the raw bytes received, or the monitor config JSON.
+	Data []byte `json:"data,omitempty"`
+}
+
+// Recorder writes records to a log, which is a gzipped file of JSON records, one per line. It is safe for multiple goroutines.
+type Recorder struct {
+	file *os.File
+	gz   *gzip.Writer
+	m    *sync.Mutex
+}
+
+// NewRecorder opens the given log file for recording, appending to it if it exists.
+func NewRecorder(fileName string) (*Recorder, error) {
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.New("opening recording file: " + err.Error())
+	}
+	return &Recorder{file: file, gz: gzip.NewWriter(file), m: &sync.Mutex{}}, nil
+}
+
+// Record writes the given record to the log. The log is flushed after every record, so a Traffic Monitor which is killed loses no more than the record being written. Errors are logged, rather than returned, so recording never interrupts monitoring.
+func (r *Recorder) Record(rec Record) {
+	json := jsoniter.ConfigFastest
+	bts, err := json.Marshal(rec)
+	if err != nil {
+		log.Errorf("recording %v %v: marshalling: %v\n", rec.Type, rec.ID, err)
+		return
+	}
+	bts = append(bts, '\n')
+	r.m.Lock()
+	defer r.m.Unlock()
+	if _, err := r.gz.Write(bts); err != nil {
+		log.Errorf("recording %v %v: writing: %v\n", rec.Type, rec.ID, err)
+		return
+	}
+	if err := r.gz.Flush(); err != nil {
+		log.Errorf("recording %v %v: flushing: %v\n", rec.Type, rec.ID, err)
+	}
+}
+
+// Close finishes the log and closes its file.
+func (r *Recorder) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.gz.Close(); err != nil {
+		r.file.Close()
+		return errors.New("closing recording: " + err.Error())
+	}
+	return r.file.Close()
+}
+
+// Read reads all records from the given log. Logs appended to by several recorders are read in full.
+func Read(rd io.Reader) ([]Record, error) {
+	gz, err := gzip.NewReader(rd)
+	if err != nil {
+		return nil, errors.New("reading recording: " + err.Error())
+	}
+	defer gz.Close()
+	records := []Record{}
+	json := jsoniter.ConfigFastest
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024*1024)
+	for scanner.Scan() {
+		rec := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, errors.New("reading recording: decoding record " + err.Error())
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil && err != io.ErrUnexpectedEOF {
+		return nil, errors.New("reading recording: " + err.Error())
+	}
+	return records, nil
+}
+
+// Clock is a virtual clock for replaying a log. It starts at the time of the first record when it's created, and advances at a multiple of real time, or, if it's stepped, only when Step is called. It is safe for multiple goroutines.
+type Clock struct {
+	start     time.Time
+	realStart time.Time
+	speed     float64
+	// stepped is the current time of a stepped clock, which is nil if the clock isn't stepped.
+	stepped *time.Time
+	cond    *sync.Cond
+}
+
+// NewClock returns a virtual clock starting now at the given time, and advancing at the given multiple of real time. A speed greater than 1 fast-forwards the replay. If the speed is 0, the clock is stepped, and only advances when Step is called.
+func NewClock(start time.Time, speed float64) *Clock {
+	c := &Clock{start: start, realStart: time.Now(), speed: speed, cond: sync.NewCond(&sync.Mutex{})}
+	if speed == 0 {
+		c.stepped = &start
+	}
+	return c
+}
+
+// Now returns the current virtual time.
+func (c *Clock) Now() time.Time {
+	if c.stepped == nil {
+		return c.start.Add(time.Duration(float64(time.Since(c.realStart)) * c.speed))
+	}
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+	return *c.stepped
+}
+
+// Stepped returns whether the clock only advances when Step is called.
+func (c *Clock) Stepped() bool {
+	return c.stepped != nil
+}
+
+// Step advances a stepped clock to the given virtual time, waking everything sleeping until it. It returns an error if the clock isn't stepped, or the time is before the current virtual time.
+func (c *Clock) Step(t time.Time) error {
+	if c.stepped == nil {
+		return errors.New("clock is not stepped")
+	}
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+	if t.Before(*c.stepped) {
+		return errors.New("time " + t.String() + " is before the current virtual time " + c.stepped.String())
+	}
+	*c.stepped = t
+	c.cond.Broadcast()
+	return nil
+}
+
+// SleepUntil sleeps until the given virtual time. It returns immediately if the time has passed.
+func (c *Clock) SleepUntil(t time.Time) {
+	if c.stepped == nil {
+		if d := t.Sub(c.Now()); d > 0 {
+			time.Sleep(time.Duration(float64(d) / c.speed))
+		}
+		return
+	}
+	c.cond.L.Lock()
+	for c.stepped.Before(t) {
+		c.cond.Wait()
+	}
+	c.cond.L.Unlock()
+}
+
+// Replay is a loaded log, from which records are replayed in the order and at the virtual times they were recorded. It is safe for multiple goroutines.
+type Replay struct {
+	Clock          *Clock
+	polls          map[string][]Record
+	crConfigs      []Record
+	monitorConfigs []Record
+	cdn            string
+	m              *sync.Mutex
+}
+
+// Load loads the log in the given file for replaying. The virtual clock starts at the time of its first record, and advances at the given speed, see NewClock.
+func Load(fileName string, speed float64) (*Replay, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, errors.New("opening recording file: " + err.Error())
+	}
+	defer file.Close()
+	records, err := Read(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplay(records, speed)
+}
+
+// NewReplay returns a Replay of the given records, whose virtual clock advances at the given speed, see NewClock.
+func NewReplay(records []Record, speed float64) (*Replay, error) {
+	if len(records) == 0 {
+		return nil, errors.New("recording has no records")
+	}
+	sort.Stable(recordsByTime(records))
+	r := &Replay{Clock: NewClock(records[0].Time, speed), polls: map[string][]Record{}, m: &sync.Mutex{}}
+	for _, rec := range records {
+		switch rec.Type {
+		case TypePoll:
+			key := pollKey(rec.ID, rec.URL)
+			r.polls[key] = append(r.polls[key], rec)
+		case TypeCRConfig:
+			r.crConfigs = append(r.crConfigs, rec)
+			r.cdn = rec.ID
+		case TypeMonitorConfig:
+			r.monitorConfigs = append(r.monitorConfigs, rec)
+			r.cdn = rec.ID
+		default:
+			log.Warnf("replay: unknown record type '%v', skipping\n", rec.Type)
+		}
+	}
+	return r, nil
+}
+
+// CDN returns the CDN of the last recorded Traffic Ops fetch.
+func (r *Replay) CDN() string {
+	return r.cdn
+}
+
+// NextPoll returns the next recorded poll of the given poller ID and URL, sleeping until the virtual clock reaches the time it was recorded. Returns false if no polls of it remain.
+func (r *Replay) NextPoll(id string, url string) (Record, bool) {
+	key := pollKey(id, url)
+	r.m.Lock()
+	polls := r.polls[key]
+	if len(polls) == 0 {
+		r.m.Unlock()
+		return Record{}, false
+	}
+	rec := polls[0]
+	r.polls[key] = polls[1:]
+	r.m.Unlock()
+	r.Clock.SleepUntil(rec.Time)
+	return rec, true
+}
+
+// Latest returns the latest record of the given Traffic Ops fetch type at the current virtual time, or the first if none were recorded before it. Returns false if none of the type were recorded.
+func (r *Replay) Latest(recordType string) (Record, bool) {
+	records := r.monitorConfigs
+	if recordType == TypeCRConfig {
+		records = r.crConfigs
+	}
+	if len(records) == 0 {
+		return Record{}, false
+	}
+	now := r.Clock.Now()
+	latest := records[0]
+	for _, rec := range records[1:] {
+		if rec.Time.After(now) {
+			break
+		}
+		latest = rec
+	}
+	return latest, true
+}
+
+// Step advances a stepped virtual clock by the given duration, or, if it's 0, to the time of the next recorded poll. Returns the new virtual time, or an error if the clock isn't stepped, or no polls remain.
+func (r *Replay) Step(d time.Duration) (time.Time, error) {
+	now := r.Clock.Now()
+	if d < 0 {
+		return now, errors.New("step duration must not be negative")
+	}
+	next := now.Add(d)
+	if d == 0 {
+		var ok bool
+		if next, ok = r.nextPollTime(now); !ok {
+			return now, errors.New("no recorded polls remain")
+		}
+	}
+	if err := r.Clock.Step(next); err != nil {
+		return now, err
+	}
+	return next, nil
+}
+
+// nextPollTime returns the time of the earliest remaining recorded poll after the given time, and false if there is none.
+func (r *Replay) nextPollTime(after time.Time) (time.Time, bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	next := time.Time{}
+	for _, polls := range r.polls {
+		for _, rec := range polls {
+			if !rec.Time.After(after) {
+				continue
+			}
+			if next.IsZero() || rec.Time.Before(next) {
+				next = rec.Time
+			}
+			break // polls are sorted by time, so the rest are later
+		}
+	}
+	return next, !next.IsZero()
+}
+
+func pollKey(id string, url string) string {
+	return id + " " + url
+}
+
+type recordsByTime []Record
+
+func (r recordsByTime) Len() int           { return len(r) }
+func (r recordsByTime) Less(i, j int) bool { return r[i].Time.Before(r[j].Time) }
+func (r recordsByTime) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
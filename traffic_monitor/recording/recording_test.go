@@ -0,0 +1,209 @@
+package recording
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tm-recording")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "record.gz")
+
+	now := time.Now().Truncate(time.Millisecond)
+	written := []Record{
+		{Type: TypeCRConfig, ID: "cdn0", Time: now, Duration: time.Second, Data: []byte(`{"stats":{}}`)},
+		{Type: TypePoll, ID: "cache0", URL: "http://192.0.2.1/_astats", Time: now.Add(time.Second), Duration: 5 * time.Millisecond, Data: []byte("\x00binary\xff")},
+		{Type: TypePoll, ID: "cache0", URL: "http://192.0.2.1/_astats", Time: now.Add(2 * time.Second), Error: "fetch error: timeout"},
+	}
+
+	// a restarted recorder appends to the same log
+	for _, recs := range [][]Record{written[:1], written[1:]} {
+		recorder, err := NewRecorder(fileName)
+		if err != nil {
+			t.Fatalf("NewRecorder expected nil error, actual: %v", err)
+		}
+		for _, rec := range recs {
+			recorder.Record(rec)
+		}
+		if err := recorder.Close(); err != nil {
+			t.Fatalf("Recorder.Close expected nil error, actual: %v", err)
+		}
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("opening record file: %v", err)
+	}
+	defer file.Close()
+	read, err := Read(file)
+	if err != nil {
+		t.Fatalf("Read expected nil error, actual: %v", err)
+	}
+	if len(read) != len(written) {
+		t.Fatalf("Read expected %v records, actual: %v", len(written), len(read))
+	}
+	for i, rec := range read {
+		if rec.Type != written[i].Type || rec.ID != written[i].ID || rec.URL != written[i].URL || !rec.Time.Equal(written[i].Time) || rec.Duration != written[i].Duration || rec.Error != written[i].Error || string(rec.Data) != string(written[i].Data) {
+			t.Errorf("Read record %v expected: %+v, actual: %+v", i, written[i], rec)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	records := []Record{
+		{Type: TypePoll, ID: "cache0", URL: "http://192.0.2.1/stat", Time: start.Add(2 * time.Second), Data: []byte("second")},
+		{Type: TypeMonitorConfig, ID: "cdn0", Time: start, Data: []byte(`{}`)},
+		{Type: TypeCRConfig, ID: "cdn0", Time: start, Data: []byte("crconfig0")},
+		{Type: TypePoll, ID: "cache0", URL: "http://192.0.2.1/stat", Time: start.Add(time.Second), Data: []byte("first")},
+		{Type: TypePoll, ID: "cache0", URL: "http://192.0.2.1/health", Time: start.Add(time.Second), Data: []byte("health")},
+		{Type: TypeCRConfig, ID: "cdn0", Time: start.Add(10 * time.Second), Data: []byte("crconfig1")},
+		{Type: TypeCRConfig, ID: "cdn0", Time: start.Add(2 * time.Hour), Data: []byte("crconfig2")},
+	}
+	replay, err := NewReplay(records, 1)
+	if err != nil {
+		t.Fatalf("NewReplay expected nil error, actual: %v", err)
+	}
+	if replay.CDN() != "cdn0" {
+		t.Errorf("Replay.CDN expected: cdn0, actual: %v", replay.CDN())
+	}
+
+	// the virtual clock starts at the first record; jump it forward so the test doesn't sleep
+	replay.Clock = NewClock(start.Add(time.Minute), 1)
+
+	for _, expected := range []string{"first", "second"} {
+		rec, ok := replay.NextPoll("cache0", "http://192.0.2.1/stat")
+		if !ok || string(rec.Data) != expected {
+			t.Errorf("Replay.NextPoll expected: %v, actual: %v %v", expected, ok, string(rec.Data))
+		}
+	}
+	if _, ok := replay.NextPoll("cache0", "http://192.0.2.1/stat"); ok {
+		t.Errorf("Replay.NextPoll of finished poller expected: false, actual: true")
+	}
+	if rec, ok := replay.NextPoll("cache0", "http://192.0.2.1/health"); !ok || string(rec.Data) != "health" {
+		t.Errorf("Replay.NextPoll of health poller expected: health, actual: %v %v", ok, string(rec.Data))
+	}
+
+	session := NewReplaySession(replay)
+	if bts, err := session.CRConfigRaw("cdn0"); err != nil || string(bts) != "crconfig1" {
+		t.Errorf("ReplaySession.CRConfigRaw expected the latest CRConfig at the virtual time: crconfig1, actual: %v %v", string(bts), err)
+	}
+	if _, err := session.TrafficMonitorConfigMap("cdn0"); err != nil {
+		t.Errorf("ReplaySession.TrafficMonitorConfigMap expected nil error, actual: %v", err)
+	}
+	if _, err := session.Servers(); err != ErrNotRecorded {
+		t.Errorf("ReplaySession.Servers expected: %v, actual: %v", ErrNotRecorded, err)
+	}
+
+	if _, err := NewReplay(nil, 1); err == nil {
+		t.Errorf("NewReplay of no records expected error, actual: nil")
+	}
+}
+
+func TestClockSpeed(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	clock := NewClock(start, 1000)
+	time.Sleep(10 * time.Millisecond)
+	if elapsed := clock.Now().Sub(start); elapsed < 10*time.Second {
+		t.Errorf("Clock of speed 1000 expected at least 10s elapsed after 10ms, actual: %v", elapsed)
+	}
+	if err := clock.Step(start.Add(2 * time.Hour)); err == nil {
+		t.Errorf("Clock.Step of unstepped clock expected error, actual: nil")
+	}
+	realStart := time.Now()
+	clock.SleepUntil(clock.Now().Add(10 * time.Second))
+	if slept := time.Since(realStart); slept > time.Second {
+		t.Errorf("Clock.SleepUntil 10s of speed 1000 expected to sleep about 10ms, actual: %v", slept)
+	}
+}
+
+func TestReplayStep(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	records := []Record{
+		{Type: TypeMonitorConfig, ID: "cdn0", Time: start, Data: []byte(`{}`)},
+		{Type: TypePoll, ID: "cache0", URL: "http://192.0.2.1/stat", Time: start.Add(10 * time.Second), Data: []byte("first")},
+		{Type: TypePoll, ID: "cache1", URL: "http://192.0.2.2/stat", Time: start.Add(5 * time.Second), Data: []byte("other")},
+		{Type: TypePoll, ID: "cache0", URL: "http://192.0.2.1/stat", Time: start.Add(20 * time.Second), Data: []byte("second")},
+	}
+	replay, err := NewReplay(records, 0)
+	if err != nil {
+		t.Fatalf("NewReplay expected nil error, actual: %v", err)
+	}
+	if !replay.Clock.Stepped() {
+		t.Fatalf("NewReplay of speed 0 expected stepped clock, actual: not stepped")
+	}
+	if now := replay.Clock.Now(); !now.Equal(start) {
+		t.Errorf("stepped Clock.Now expected the first record time %v, actual: %v", start, now)
+	}
+
+	polled := make(chan string)
+	go func() {
+		rec, _ := replay.NextPoll("cache0", "http://192.0.2.1/stat")
+		polled <- string(rec.Data)
+	}()
+
+	if now, err := replay.Step(0); err != nil || !now.Equal(start.Add(5*time.Second)) {
+		t.Errorf("Replay.Step(0) expected the next poll time %v, actual: %v %v", start.Add(5*time.Second), now, err)
+	}
+	select {
+	case data := <-polled:
+		t.Fatalf("Replay.NextPoll expected to sleep until stepped to its time, actual: returned %v", data)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if now, err := replay.Step(5 * time.Second); err != nil || !now.Equal(start.Add(10*time.Second)) {
+		t.Errorf("Replay.Step(5s) expected %v, actual: %v %v", start.Add(10*time.Second), now, err)
+	}
+	select {
+	case data := <-polled:
+		if data != "first" {
+			t.Errorf("Replay.NextPoll after step expected: first, actual: %v", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Replay.NextPoll expected to return after stepping to its time, actual: still sleeping")
+	}
+
+	if _, err := replay.Step(-time.Second); err == nil {
+		t.Errorf("Replay.Step of negative duration expected error, actual: nil")
+	}
+	if now, err := replay.Step(0); err != nil || !now.Equal(start.Add(20*time.Second)) {
+		t.Errorf("Replay.Step(0) expected the next poll time %v, actual: %v %v", start.Add(20*time.Second), now, err)
+	}
+	if _, err := replay.Step(0); err == nil {
+		t.Errorf("Replay.Step(0) with no remaining polls expected error, actual: nil")
+	}
+}
+
+func TestNewTORecord(t *testing.T) {
+	rec := newTORecord(TypeCRConfig, "cdn0", time.Now(), nil, errors.New("Traffic Ops unreachable"))
+	if rec.Error != "Traffic Ops unreachable" || rec.ID != "cdn0" || rec.Duration < 0 {
+		t.Errorf("newTORecord expected error record of cdn0, actual: %+v", rec)
+	}
+}
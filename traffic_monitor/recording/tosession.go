@@ -0,0 +1,145 @@
+package recording
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/towrap"
+	"github.com/apache/trafficcontrol/traffic_ops/client"
+
+	"github.com/json-iterator/go"
+)
+
+// ErrNotRecorded is returned by a ReplaySession for Traffic Ops data which isn't recorded.
+var ErrNotRecorded = errors.New("not recorded")
+
+// RecordingSession wraps a Traffic Ops session, recording the CRConfig and monitor config fetches.
+type RecordingSession struct {
+	towrap.ITrafficOpsSession
+	recorder *Recorder
+}
+
+// NewRecordingSession returns a Traffic Ops session which records the fetches of the given session to the given recorder.
+func NewRecordingSession(session towrap.ITrafficOpsSession, recorder *Recorder) RecordingSession {
+	return RecordingSession{ITrafficOpsSession: session, recorder: recorder}
+}
+
+// CRConfigRaw returns and records the CRConfig from the wrapped session.
+func (s RecordingSession) CRConfigRaw(cdn string) ([]byte, error) {
+	start := time.Now()
+	bts, err := s.ITrafficOpsSession.CRConfigRaw(cdn)
+	s.recorder.Record(newTORecord(TypeCRConfig, cdn, start, bts, err))
+	return bts, err
+}
+
+// TrafficMonitorConfigMap returns and records the monitor config from the wrapped session.
+func (s RecordingSession) TrafficMonitorConfigMap(cdn string) (*tc.TrafficMonitorConfigMap, error) {
+	start := time.Now()
+	mc, err := s.ITrafficOpsSession.TrafficMonitorConfigMap(cdn)
+	bts := []byte(nil)
+	if err == nil {
+		json := jsoniter.ConfigFastest
+		if bts, err = json.Marshal(mc); err != nil {
+			return nil, errors.New("marshalling monitor config for recording: " + err.Error())
+		}
+	}
+	s.recorder.Record(newTORecord(TypeMonitorConfig, cdn, start, bts, err))
+	return mc, err
+}
+
+func newTORecord(recordType string, cdn string, start time.Time, bts []byte, err error) Record {
+	rec := Record{Type: recordType, ID: cdn, Time: time.Now(), Data: bts}
+	rec.Duration = rec.Time.Sub(start)
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	return rec
+}
+
+// ReplaySession is a Traffic Ops session which returns the CRConfig and monitor config recorded in a log, as of the replay's virtual time. Other Traffic Ops data isn't recorded, and returns ErrNotRecorded.
+type ReplaySession struct {
+	replay *Replay
+}
+
+// NewReplaySession returns a Traffic Ops session replaying the given log.
+func NewReplaySession(replay *Replay) ReplaySession {
+	return ReplaySession{replay: replay}
+}
+
+// CDN returns the CDN of the replayed log.
+func (s ReplaySession) CDN() string {
+	return s.replay.CDN()
+}
+
+// CRConfigRaw returns the recorded CRConfig. The cdn is ignored, because a log only records one CDN.
+func (s ReplaySession) CRConfigRaw(cdn string) ([]byte, error) {
+	bts, _, err := s.LastCRConfig(cdn)
+	return bts, err
+}
+
+// LastCRConfig returns the recorded CRConfig, and the time it was recorded.
+func (s ReplaySession) LastCRConfig(cdn string) ([]byte, time.Time, error) {
+	rec, ok := s.replay.Latest(TypeCRConfig)
+	if !ok {
+		return nil, time.Time{}, errors.New("no CRConfig recorded")
+	}
+	if rec.Error != "" {
+		return nil, rec.Time, errors.New(rec.Error)
+	}
+	return rec.Data, rec.Time, nil
+}
+
+// TrafficMonitorConfigMap returns the recorded monitor config.
+func (s ReplaySession) TrafficMonitorConfigMap(cdn string) (*tc.TrafficMonitorConfigMap, error) {
+	rec, ok := s.replay.Latest(TypeMonitorConfig)
+	if !ok {
+		return nil, errors.New("no monitor config recorded")
+	}
+	if rec.Error != "" {
+		return nil, errors.New(rec.Error)
+	}
+	mc := &tc.TrafficMonitorConfigMap{}
+	json := jsoniter.ConfigFastest
+	if err := json.Unmarshal(rec.Data, mc); err != nil {
+		return nil, errors.New("unmarshalling recorded monitor config: " + err.Error())
+	}
+	return mc, nil
+}
+
+// Set does nothing, because a replay doesn't use Traffic Ops.
+func (s ReplaySession) Set(session *client.Session) {}
+
+func (s ReplaySession) URL() (string, error)  { return "", ErrNotRecorded }
+func (s ReplaySession) User() (string, error) { return "", ErrNotRecorded }
+
+func (s ReplaySession) Servers() ([]tc.Server, error)   { return nil, ErrNotRecorded }
+func (s ReplaySession) Profiles() ([]tc.Profile, error) { return nil, ErrNotRecorded }
+func (s ReplaySession) Parameters(profileName string) ([]tc.Parameter, error) {
+	return nil, ErrNotRecorded
+}
+func (s ReplaySession) DeliveryServices() ([]tc.DeliveryService, error) { return nil, ErrNotRecorded }
+func (s ReplaySession) CacheGroups() ([]tc.CacheGroupNullable, error)   { return nil, ErrNotRecorded }
+
+func (s ReplaySession) CRConfigHistory() []towrap.CRConfigStat { return []towrap.CRConfigStat{} }
+func (s ReplaySession) BackupFileExists() bool                 { return false }
+func (s ReplaySession) Ping() error                            { return ErrNotRecorded }
@@ -0,0 +1,199 @@
+package shard
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+// VirtualNodes is the number of points each monitor is given on the hash ring. More points spread caches more evenly across monitors, and move fewer caches when a monitor joins or leaves.
+const VirtualNodes = 100
+
+type ringPoint struct {
+	Hash    uint64
+	Monitor tc.TrafficMonitorName
+}
+
+type ringPoints []ringPoint
+
+func (p ringPoints) Len() int      { return len(p) }
+func (p ringPoints) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p ringPoints) Less(i, j int) bool {
+	if p[i].Hash != p[j].Hash {
+		return p[i].Hash < p[j].Hash
+	}
+	return p[i].Monitor < p[j].Monitor
+}
+
+// Ring is a consistent hash ring of Traffic Monitors. Every monitor building a ring from the same set of monitors assigns every cache to the same owners, without needing to communicate.
+type Ring struct {
+	points   ringPoints
+	monitors int
+}
+
+// NewRing creates a hash ring of the given monitors. Duplicate monitors are ignored.
+func NewRing(monitors []tc.TrafficMonitorName) Ring {
+	seen := map[tc.TrafficMonitorName]struct{}{}
+	points := ringPoints{}
+	for _, monitor := range monitors {
+		if _, ok := seen[monitor]; ok {
+			continue
+		}
+		seen[monitor] = struct{}{}
+		for i := 0; i < VirtualNodes; i++ {
+			points = append(points, ringPoint{Hash: hash(string(monitor) + "#" + strconv.Itoa(i)), Monitor: monitor})
+		}
+	}
+	sort.Sort(points)
+	return Ring{points: points, monitors: len(seen)}
+}
+
+// Owners returns the n distinct monitors responsible for polling the given cache, in order of preference. If the ring has fewer than n monitors, all monitors are returned.
+func (r Ring) Owners(cache tc.CacheName, n int) []tc.TrafficMonitorName {
+	if n > r.monitors {
+		n = r.monitors
+	}
+	if n <= 0 {
+		return nil
+	}
+	h := hash(string(cache))
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i].Hash >= h })
+	owners := make([]tc.TrafficMonitorName, 0, n)
+	for i := 0; i < len(r.points) && len(owners) < n; i++ {
+		monitor := r.points[(start+i)%len(r.points)].Monitor
+		if !containsMonitor(owners, monitor) {
+			owners = append(owners, monitor)
+		}
+	}
+	return owners
+}
+
+func containsMonitor(monitors []tc.TrafficMonitorName, monitor tc.TrafficMonitorName) bool {
+	for _, m := range monitors {
+		if m == monitor {
+			return true
+		}
+	}
+	return false
+}
+
+// hash returns the FNV-1a hash of s, mixed so similar names (e.g. edge-1 and edge-2) spread across the whole ring.
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Shard is this Traffic Monitor's view of how cache polling is divided among the monitors of the CDN.
+// The zero value is a disabled shard, in which this monitor polls every cache.
+type Shard struct {
+	// Self is the name of this Traffic Monitor.
+	Self tc.TrafficMonitorName
+	// Replicas is the number of monitors which poll each cache. If Replicas is 0, sharding is disabled.
+	Replicas int
+	// Members are the monitors currently sharing the polling, including Self.
+	Members []tc.TrafficMonitorName
+	ring    Ring
+}
+
+// New creates a shard of the given members. The members are sorted, so the order they're given in doesn't matter.
+func New(self tc.TrafficMonitorName, replicas int, members []tc.TrafficMonitorName) Shard {
+	sorted := make([]string, 0, len(members))
+	for _, member := range members {
+		sorted = append(sorted, string(member))
+	}
+	sort.Strings(sorted)
+	shard := Shard{Self: self, Replicas: replicas, Members: make([]tc.TrafficMonitorName, 0, len(sorted))}
+	for _, member := range sorted {
+		shard.Members = append(shard.Members, tc.TrafficMonitorName(member))
+	}
+	shard.ring = NewRing(shard.Members)
+	return shard
+}
+
+// Enabled returns whether polling is sharded.
+func (s Shard) Enabled() bool {
+	return s.Replicas > 0
+}
+
+// Owners returns the monitors which poll the given cache. If sharding is disabled, nil is returned.
+func (s Shard) Owners(cache tc.CacheName) []tc.TrafficMonitorName {
+	if !s.Enabled() {
+		return nil
+	}
+	return s.ring.Owners(cache, s.Replicas)
+}
+
+// Polls returns whether this monitor polls the given cache. If sharding is disabled, this is always true.
+func (s Shard) Polls(cache tc.CacheName) bool {
+	if !s.Enabled() {
+		return true
+	}
+	return containsMonitor(s.Owners(cache), s.Self)
+}
+
+// SameMembers returns whether the given shard divides caches among the same monitors, with the same number of replicas.
+func (s Shard) SameMembers(other Shard) bool {
+	if s.Self != other.Self || s.Replicas != other.Replicas || len(s.Members) != len(other.Members) {
+		return false
+	}
+	for i, member := range s.Members {
+		if other.Members[i] != member {
+			return false
+		}
+	}
+	return true
+}
+
+// Threadsafe provides safe access for multiple goroutines to read a Shard, with a single goroutine writer.
+type Threadsafe struct {
+	shard *Shard
+	m     *sync.RWMutex
+}
+
+// NewThreadsafe returns a new Threadsafe object, containing a disabled shard.
+func NewThreadsafe() Threadsafe {
+	return Threadsafe{m: &sync.RWMutex{}, shard: &Shard{}}
+}
+
+// Get returns the current shard. Callers MUST NOT modify the returned Members.
+func (t Threadsafe) Get() Shard {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	return *t.shard
+}
+
+// Set sets the current shard. This MUST NOT be called by multiple goroutines.
+func (t Threadsafe) Set(shard Shard) {
+	t.m.Lock()
+	*t.shard = shard
+	t.m.Unlock()
+}
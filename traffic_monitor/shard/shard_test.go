@@ -0,0 +1,111 @@
+package shard
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+func testCaches(n int) []tc.CacheName {
+	caches := []tc.CacheName{}
+	for i := 0; i < n; i++ {
+		caches = append(caches, tc.CacheName(fmt.Sprintf("edge-%d", i)))
+	}
+	return caches
+}
+
+func TestShardDisabled(t *testing.T) {
+	s := New("tm0", 0, []tc.TrafficMonitorName{"tm0", "tm1"})
+	if s.Enabled() {
+		t.Fatalf("expected shard with 0 replicas to be disabled")
+	}
+	for _, cache := range testCaches(10) {
+		if !s.Polls(cache) {
+			t.Errorf("expected disabled shard to poll %v", cache)
+		}
+	}
+}
+
+func TestShardOwners(t *testing.T) {
+	members := []tc.TrafficMonitorName{"tm0", "tm1", "tm2", "tm3"}
+	replicas := 2
+	shards := []Shard{}
+	for _, member := range members {
+		shards = append(shards, New(member, replicas, members))
+	}
+
+	polled := map[tc.TrafficMonitorName]int{}
+	for _, cache := range testCaches(1000) {
+		owners := shards[0].Owners(cache)
+		if len(owners) != replicas {
+			t.Fatalf("expected %v owners of %v, actual %v", replicas, cache, owners)
+		}
+		if owners[0] == owners[1] {
+			t.Fatalf("expected distinct owners of %v, actual %v", cache, owners)
+		}
+		pollers := 0
+		for _, s := range shards {
+			if s.Polls(cache) {
+				pollers++
+				polled[s.Self]++
+			}
+		}
+		if pollers != replicas {
+			t.Errorf("expected %v monitors to poll %v, actual %v", replicas, cache, pollers)
+		}
+	}
+
+	for _, member := range members {
+		if polled[member] < 300 || polled[member] > 700 {
+			t.Errorf("expected %v to poll roughly half the caches, actual %v", member, polled[member])
+		}
+	}
+}
+
+func TestShardRebalance(t *testing.T) {
+	members := []tc.TrafficMonitorName{"tm0", "tm1", "tm2"}
+	before := New("tm0", 1, members)
+	after := New("tm0", 1, members[:2])
+
+	for _, cache := range testCaches(1000) {
+		owner := before.Owners(cache)[0]
+		newOwner := after.Owners(cache)[0]
+		if owner != "tm2" && owner != newOwner {
+			t.Errorf("expected %v to stay with %v when tm2 left, actual %v", cache, owner, newOwner)
+		}
+		if newOwner == "tm2" {
+			t.Errorf("expected %v not to be owned by departed tm2", cache)
+		}
+	}
+}
+
+func TestShardSameMembers(t *testing.T) {
+	a := New("tm0", 2, []tc.TrafficMonitorName{"tm1", "tm0"})
+	b := New("tm0", 2, []tc.TrafficMonitorName{"tm0", "tm1"})
+	if !a.SameMembers(b) {
+		t.Errorf("expected member order not to matter")
+	}
+	if a.SameMembers(New("tm0", 2, []tc.TrafficMonitorName{"tm0"})) {
+		t.Errorf("expected different members to differ")
+	}
+}
@@ -20,6 +20,10 @@ package srvhttp
  */
 
 import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -76,10 +80,104 @@ func (s *Server) registerEndpoints(sm *http.ServeMux, endpoints map[string]http.
 	return nil
 }
 
+// Auth is the client authentication of a Server. The zero value allows all clients to make GET and HEAD requests, and only clients with a verified certificate to make other requests, which modify the monitor.
+type Auth struct {
+	// ClientCAFile is the PEM file of the CAs which sign client certificates. If set, TLS clients may present a certificate, and clients with a verified certificate, such as peer Traffic Monitors and Traffic Routers, are always allowed.
+	ClientCAFile string
+	// RequireClientCert is whether all TLS clients must present a verified certificate. It requires ClientCAFile.
+	RequireClientCert bool
+	// Tokens are the tokens of clients without a verified certificate. If any are set, such clients must send one, either as a bearer token or as the password of basic authentication, and may only make GET and HEAD requests.
+	Tokens []string
+	// AdminTokens are the tokens of clients without a verified certificate which may make any request, such as setting overrides.
+	AdminTokens []string
+}
+
+// tlsConfig returns the TLS server config to verify client certificates, or nil if client certificates aren't used.
+func (a Auth) tlsConfig() (*tls.Config, error) {
+	if a.ClientCAFile == "" {
+		if a.RequireClientCert {
+			return nil, errors.New("requiring client certificates requires a client CA file")
+		}
+		return nil, nil
+	}
+	pem, err := ioutil.ReadFile(a.ClientCAFile)
+	if err != nil {
+		return nil, errors.New("reading client CA file: " + err.Error())
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("client CA file '" + a.ClientCAFile + "' contains no certificates")
+	}
+	clientAuth := tls.VerifyClientCertIfGiven
+	if a.RequireClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	return &tls.Config{ClientCAs: pool, ClientAuth: clientAuth}, nil
+}
+
+// wrap returns a handler which serves requests with h if they are authorized.
+func (a Auth) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+		readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead
+		token := requestToken(r)
+		switch {
+		case validToken(a.AdminTokens, token):
+		case len(a.Tokens) > 0 && !validToken(a.Tokens, token), len(a.Tokens) == 0 && !readOnly:
+			w.Header().Set("WWW-Authenticate", `Basic realm="traffic_monitor"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		case !readOnly:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodHead)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func validToken(tokens []string, token string) bool {
+	if token == "" {
+		return false
+	}
+	valid := false
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			valid = true // don't return early, so the time doesn't depend on which token matched
+		}
+	}
+	return valid
+}
+
+// requestToken returns the bearer token of the request, or the basic authentication password, so browsers may use the web interface.
+func requestToken(r *http.Request) string {
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	authHdr := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(authHdr) > len(prefix) && strings.EqualFold(authHdr[:len(prefix)], prefix) {
+		return strings.TrimSpace(authHdr[len(prefix):])
+	}
+	return ""
+}
+
 // Run runs a new HTTP service at the given addr, making data requests to the given c.
 // Run may be called repeatedly, and each time, will shut down any existing service first.
 // Run is NOT threadsafe, and MUST NOT be called concurrently by multiple goroutines.
-func (s *Server) Run(endpoints map[string]http.HandlerFunc, addr string, readTimeout time.Duration, writeTimeout time.Duration, staticFileDir string, tls bool, certFile string, keyFile string) error {
+func (s *Server) Run(endpoints map[string]http.HandlerFunc, addr string, readTimeout time.Duration, writeTimeout time.Duration, staticFileDir string, useTLS bool, certFile string, keyFile string, auth Auth) error {
+	tlsConfig := (*tls.Config)(nil)
+	if useTLS {
+		cfg, err := auth.tlsConfig()
+		if err != nil {
+			return err
+		}
+		tlsConfig = cfg
+	}
+
 	if s.stoppableListener != nil {
 		log.Infof("Stopping Web Server\n")
 		s.stoppableListener.Stop()
@@ -103,17 +201,25 @@ func (s *Server) Run(endpoints map[string]http.HandlerFunc, addr string, readTim
 	}
 	server := &http.Server{
 		Addr:           addr,
-		Handler:        sm,
+		Handler:        auth.wrap(sm),
 		ReadTimeout:    readTimeout,
 		WriteTimeout:   writeTimeout,
 		MaxHeaderBytes: 1 << 20,
 	}
+	if useTLS {
+		server.TLSConfig = tlsConfig
+	} else if auth.ClientCAFile != "" || auth.RequireClientCert {
+		log.Warnf("Web server on %s is not TLS, ignoring client certificate configuration\n", addr)
+	}
+	if !useTLS && (len(auth.Tokens) > 0 || len(auth.AdminTokens) > 0) {
+		log.Warnf("Web server on %s requires tokens without TLS, tokens will be sent in plain text\n", addr)
+	}
 
 	s.stoppableListenerWaitGroup = sync.WaitGroup{}
 	s.stoppableListenerWaitGroup.Add(1)
 	go func() {
 		defer s.stoppableListenerWaitGroup.Done()
-		if tls {
+		if useTLS {
 			err = server.ServeTLS(s.stoppableListener, certFile, keyFile)
 			if err != stoppableListener.StoppedError {
 				log.Warnf("HTTP server stopped with error: %v\n", err)
@@ -0,0 +1,87 @@
+package srvhttp
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthWrap(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+
+	serve := func(auth Auth, method string, setup func(r *http.Request)) int {
+		r := httptest.NewRequest(method, "/publish/CrStates", nil)
+		if setup != nil {
+			setup(r)
+		}
+		w := httptest.NewRecorder()
+		auth.wrap(ok).ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := serve(Auth{}, http.MethodGet, nil); code != http.StatusOK {
+		t.Errorf("no tokens expected: %v, actual: %v", http.StatusOK, code)
+	}
+
+	auth := Auth{Tokens: []string{"foo", "bar"}}
+	if code := serve(auth, http.MethodGet, nil); code != http.StatusUnauthorized {
+		t.Errorf("missing token expected: %v, actual: %v", http.StatusUnauthorized, code)
+	}
+	if code := serve(auth, http.MethodGet, func(r *http.Request) { r.Header.Set("Authorization", "Bearer baz") }); code != http.StatusUnauthorized {
+		t.Errorf("wrong token expected: %v, actual: %v", http.StatusUnauthorized, code)
+	}
+	if code := serve(auth, http.MethodGet, func(r *http.Request) { r.Header.Set("Authorization", "Bearer bar") }); code != http.StatusOK {
+		t.Errorf("bearer token expected: %v, actual: %v", http.StatusOK, code)
+	}
+	if code := serve(auth, http.MethodGet, func(r *http.Request) { r.SetBasicAuth("anyone", "foo") }); code != http.StatusOK {
+		t.Errorf("basic auth token expected: %v, actual: %v", http.StatusOK, code)
+	}
+	if code := serve(auth, http.MethodPost, func(r *http.Request) { r.Header.Set("Authorization", "Bearer foo") }); code != http.StatusMethodNotAllowed {
+		t.Errorf("token POST expected: %v, actual: %v", http.StatusMethodNotAllowed, code)
+	}
+	verifiedCert := func(r *http.Request) {
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{&x509.Certificate{}}}}
+	}
+	if code := serve(auth, http.MethodGet, verifiedCert); code != http.StatusOK {
+		t.Errorf("verified client certificate expected: %v, actual: %v", http.StatusOK, code)
+	}
+	if code := serve(auth, http.MethodPost, verifiedCert); code != http.StatusOK {
+		t.Errorf("verified client certificate POST expected: %v, actual: %v", http.StatusOK, code)
+	}
+
+	if code := serve(Auth{}, http.MethodPost, nil); code != http.StatusUnauthorized {
+		t.Errorf("no tokens POST expected: %v, actual: %v", http.StatusUnauthorized, code)
+	}
+	adminAuth := Auth{AdminTokens: []string{"admin"}}
+	if code := serve(adminAuth, http.MethodPost, func(r *http.Request) { r.Header.Set("Authorization", "Bearer admin") }); code != http.StatusOK {
+		t.Errorf("admin token POST expected: %v, actual: %v", http.StatusOK, code)
+	}
+	if code := serve(adminAuth, http.MethodGet, nil); code != http.StatusOK {
+		t.Errorf("admin tokens without tokens GET expected: %v, actual: %v", http.StatusOK, code)
+	}
+	auth.AdminTokens = adminAuth.AdminTokens
+	if code := serve(auth, http.MethodGet, func(r *http.Request) { r.SetBasicAuth("admin", "admin") }); code != http.StatusOK {
+		t.Errorf("admin token GET expected: %v, actual: %v", http.StatusOK, code)
+	}
+}
@@ -0,0 +1,224 @@
+package statestream
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+)
+
+const (
+	// MessageTypeSnapshot is the type of a message containing the full CRStates. It is only sent to a subscriber when it starts, or can't resume from its last sequence number.
+	MessageTypeSnapshot = "snapshot"
+	// MessageTypeStates is the type of a message containing a CRStatesDiff.
+	MessageTypeStates = "states"
+	// MessageTypeEvent is the type of a message containing a health.Event.
+	MessageTypeEvent = "event"
+)
+
+// SubscriberBufferSize is the number of messages buffered for each subscriber. Subscribers which fall further behind are unsubscribed, and must resume.
+const SubscriberBufferSize = 256
+
+// Message is a single message of the stream. Data is the JSON of the message.
+type Message struct {
+	Seq  uint64
+	Type string
+	Data []byte
+}
+
+// CRStatesDiff is the changes between two CRStates.
+type CRStatesDiff struct {
+	Caches                  map[tc.CacheName]tc.IsAvailable                       `json:"caches,omitempty"`
+	DeliveryServices        map[tc.DeliveryServiceName]tc.CRStatesDeliveryService `json:"deliveryServices,omitempty"`
+	RemovedCaches           []tc.CacheName                                        `json:"removedCaches,omitempty"`
+	RemovedDeliveryServices []tc.DeliveryServiceName                              `json:"removedDeliveryServices,omitempty"`
+}
+
+// Empty returns whether the diff has no changes.
+func (d CRStatesDiff) Empty() bool {
+	return len(d.Caches) == 0 && len(d.DeliveryServices) == 0 && len(d.RemovedCaches) == 0 && len(d.RemovedDeliveryServices) == 0
+}
+
+// DiffCRStates returns the changes from the old CRStates to the new.
+func DiffCRStates(old tc.CRStates, new tc.CRStates) CRStatesDiff {
+	diff := CRStatesDiff{}
+	for name, available := range new.Caches {
+		if oldAvailable, ok := old.Caches[name]; ok && oldAvailable == available {
+			continue
+		}
+		if diff.Caches == nil {
+			diff.Caches = map[tc.CacheName]tc.IsAvailable{}
+		}
+		diff.Caches[name] = available
+	}
+	for name := range old.Caches {
+		if _, ok := new.Caches[name]; !ok {
+			diff.RemovedCaches = append(diff.RemovedCaches, name)
+		}
+	}
+	for name, ds := range new.DeliveryService {
+		if oldDS, ok := old.DeliveryService[name]; ok && dsStatesEqual(oldDS, ds) {
+			continue
+		}
+		if diff.DeliveryServices == nil {
+			diff.DeliveryServices = map[tc.DeliveryServiceName]tc.CRStatesDeliveryService{}
+		}
+		diff.DeliveryServices[name] = ds
+	}
+	for name := range old.DeliveryService {
+		if _, ok := new.DeliveryService[name]; !ok {
+			diff.RemovedDeliveryServices = append(diff.RemovedDeliveryServices, name)
+		}
+	}
+	return diff
+}
+
+func dsStatesEqual(a tc.CRStatesDeliveryService, b tc.CRStatesDeliveryService) bool {
+	if a.IsAvailable != b.IsAvailable || len(a.DisabledLocations) != len(b.DisabledLocations) {
+		return false
+	}
+	for i, loc := range a.DisabledLocations {
+		if b.DisabledLocations[i] != loc {
+			return false
+		}
+	}
+	return true
+}
+
+// Stream publishes CRStates changes and health events to subscribers, keeping a history of recent messages so subscribers may resume from a sequence number. It is safe for multiple goroutines.
+type Stream struct {
+	m           *sync.Mutex
+	seq         *uint64
+	states      *tc.CRStates
+	history     *[]Message
+	maxHistory  uint64
+	subscribers map[chan Message]struct{}
+}
+
+// New returns a new Stream, keeping at most maxHistory messages for resuming subscribers.
+func New(maxHistory uint64) Stream {
+	seq := uint64(0)
+	states := tc.NewCRStates()
+	return Stream{
+		m:           &sync.Mutex{},
+		seq:         &seq,
+		states:      &states,
+		history:     &[]Message{},
+		maxHistory:  maxHistory,
+		subscribers: map[chan Message]struct{}{},
+	}
+}
+
+// PublishStates publishes the changes from the last published CRStates to the given states, if any. The given states MUST NOT be modified after calling.
+func (s Stream) PublishStates(states tc.CRStates) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	diff := DiffCRStates(*s.states, states)
+	*s.states = states
+	if diff.Empty() {
+		return
+	}
+	s.publish(MessageTypeStates, diff)
+}
+
+// PublishEvent publishes the given health event.
+func (s Stream) PublishEvent(e health.Event) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.publish(MessageTypeEvent, e)
+}
+
+// publish adds a message with the given data to the history, and sends it to all subscribers. The caller MUST hold the lock.
+func (s Stream) publish(msgType string, data interface{}) {
+	bts, err := json.Marshal(data)
+	if err != nil {
+		log.Errorf("state stream marshalling %v message: %v\n", msgType, err)
+		return
+	}
+	*s.seq++
+	msg := Message{Seq: *s.seq, Type: msgType, Data: bts}
+
+	history := append(*s.history, msg)
+	if uint64(len(history)) > s.maxHistory {
+		history = history[uint64(len(history))-s.maxHistory:]
+	}
+	*s.history = history
+
+	for sub := range s.subscribers {
+		select {
+		case sub <- msg:
+		default:
+			log.Warnf("state stream subscriber fell behind at sequence %v, unsubscribing\n", msg.Seq)
+			delete(s.subscribers, sub)
+			close(sub)
+		}
+	}
+}
+
+// Subscribe subscribes to the stream. If resume is true, and the history contains every message after the sequence number since, the returned messages are those after since. Otherwise, the returned messages are a single snapshot of the current CRStates.
+// The returned channel receives all subsequent messages, and is closed if the subscriber falls behind, or is unsubscribed. The returned func unsubscribes, and MUST be called when the subscriber is finished.
+func (s Stream) Subscribe(since uint64, resume bool) ([]Message, <-chan Message, func()) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	msgs := []Message(nil)
+	if resume && s.canResume(since) {
+		for _, msg := range *s.history {
+			if msg.Seq > since {
+				msgs = append(msgs, msg)
+			}
+		}
+	} else {
+		bts, err := json.Marshal(*s.states)
+		if err != nil {
+			log.Errorf("state stream marshalling snapshot: %v\n", err)
+		} else {
+			msgs = []Message{{Seq: *s.seq, Type: MessageTypeSnapshot, Data: bts}}
+		}
+	}
+
+	sub := make(chan Message, SubscriberBufferSize)
+	s.subscribers[sub] = struct{}{}
+	unsubscribe := func() {
+		s.m.Lock()
+		defer s.m.Unlock()
+		if _, ok := s.subscribers[sub]; ok {
+			delete(s.subscribers, sub)
+			close(sub)
+		}
+	}
+	return msgs, sub, unsubscribe
+}
+
+// canResume returns whether the history contains every message after since. The caller MUST hold the lock.
+func (s Stream) canResume(since uint64) bool {
+	if since > *s.seq {
+		return false // a sequence from before a restart
+	}
+	if since == *s.seq {
+		return true
+	}
+	history := *s.history
+	return len(history) > 0 && history[0].Seq <= since+1
+}
@@ -0,0 +1,117 @@
+package statestream
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_monitor/health"
+)
+
+func TestDiffCRStates(t *testing.T) {
+	old := tc.CRStates{
+		Caches: map[tc.CacheName]tc.IsAvailable{
+			"unchanged": {IsAvailable: true},
+			"changed":   {IsAvailable: true},
+			"removed":   {IsAvailable: true},
+		},
+		DeliveryService: map[tc.DeliveryServiceName]tc.CRStatesDeliveryService{
+			"unchanged": {IsAvailable: true, DisabledLocations: []tc.CacheGroupName{"cg0"}},
+			"changed":   {IsAvailable: true, DisabledLocations: []tc.CacheGroupName{}},
+			"removed":   {IsAvailable: true, DisabledLocations: []tc.CacheGroupName{}},
+		},
+	}
+	new := tc.CRStates{
+		Caches: map[tc.CacheName]tc.IsAvailable{
+			"unchanged": {IsAvailable: true},
+			"changed":   {IsAvailable: false},
+			"added":     {IsAvailable: true},
+		},
+		DeliveryService: map[tc.DeliveryServiceName]tc.CRStatesDeliveryService{
+			"unchanged": {IsAvailable: true, DisabledLocations: []tc.CacheGroupName{"cg0"}},
+			"changed":   {IsAvailable: true, DisabledLocations: []tc.CacheGroupName{"cg1"}},
+		},
+	}
+
+	diff := DiffCRStates(old, new)
+	if len(diff.Caches) != 2 || diff.Caches["changed"].IsAvailable || !diff.Caches["added"].IsAvailable {
+		t.Errorf("expected changed and added caches, actual %+v", diff.Caches)
+	}
+	if len(diff.RemovedCaches) != 1 || diff.RemovedCaches[0] != "removed" {
+		t.Errorf("expected removed cache, actual %+v", diff.RemovedCaches)
+	}
+	if len(diff.DeliveryServices) != 1 || len(diff.DeliveryServices["changed"].DisabledLocations) != 1 {
+		t.Errorf("expected changed delivery service, actual %+v", diff.DeliveryServices)
+	}
+	if len(diff.RemovedDeliveryServices) != 1 || diff.RemovedDeliveryServices[0] != "removed" {
+		t.Errorf("expected removed delivery service, actual %+v", diff.RemovedDeliveryServices)
+	}
+	if !DiffCRStates(new, new).Empty() {
+		t.Errorf("expected diff of identical states to be empty, actual %+v", DiffCRStates(new, new))
+	}
+}
+
+func TestStreamSubscribe(t *testing.T) {
+	stream := New(2)
+
+	msgs, _, unsubscribe := stream.Subscribe(0, false)
+	unsubscribe()
+	if len(msgs) != 1 || msgs[0].Type != MessageTypeSnapshot || msgs[0].Seq != 0 {
+		t.Fatalf("expected initial snapshot, actual %+v", msgs)
+	}
+
+	_, sub, unsubscribe := stream.Subscribe(0, true)
+	states := tc.NewCRStates()
+	states.Caches["edge0"] = tc.IsAvailable{IsAvailable: true}
+	stream.PublishStates(states)
+	stream.PublishStates(states.Copy()) // no changes, not published
+	stream.PublishEvent(health.Event{Name: "edge0"})
+	stream.PublishEvent(health.Event{Name: "edge1"})
+
+	for i, expectedType := range []string{MessageTypeStates, MessageTypeEvent, MessageTypeEvent} {
+		msg := <-sub
+		if msg.Seq != uint64(i+1) || msg.Type != expectedType {
+			t.Errorf("expected message %v of type %v, actual %+v", i+1, expectedType, msg)
+		}
+	}
+	unsubscribe()
+	if _, ok := <-sub; ok {
+		t.Errorf("expected unsubscribed channel to be closed")
+	}
+
+	msgs, _, unsubscribe = stream.Subscribe(2, true)
+	unsubscribe()
+	if len(msgs) != 1 || msgs[0].Seq != 3 {
+		t.Errorf("expected resume from history, actual %+v", msgs)
+	}
+
+	msgs, _, unsubscribe = stream.Subscribe(0, true)
+	unsubscribe()
+	if len(msgs) != 1 || msgs[0].Type != MessageTypeSnapshot || msgs[0].Seq != 3 {
+		t.Errorf("expected snapshot when history doesn't reach the resume sequence, actual %+v", msgs)
+	}
+
+	msgs, _, unsubscribe = stream.Subscribe(100, true)
+	unsubscribe()
+	if len(msgs) != 1 || msgs[0].Type != MessageTypeSnapshot {
+		t.Errorf("expected snapshot when resuming from a future sequence, actual %+v", msgs)
+	}
+}
@@ -0,0 +1,52 @@
+package threadsafe
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"sync"
+
+	"github.com/apache/trafficcontrol/traffic_monitor/dsdata"
+)
+
+// DisabledCacheGroups wraps a dsdata.DisabledCacheGroups object to be safe for multiple reader goroutines and a single writer.
+type DisabledCacheGroups struct {
+	disabled *dsdata.DisabledCacheGroups
+	m        *sync.RWMutex
+}
+
+// NewDisabledCacheGroups returns a dsdata.DisabledCacheGroups object wrapped to be safe for multiple readers and a single writer.
+func NewDisabledCacheGroups() DisabledCacheGroups {
+	disabled := dsdata.DisabledCacheGroups{}
+	return DisabledCacheGroups{m: &sync.RWMutex{}, disabled: &disabled}
+}
+
+// Get returns the DisabledCacheGroups. Callers MUST NOT modify the returned object.
+func (o *DisabledCacheGroups) Get() dsdata.DisabledCacheGroups {
+	o.m.RLock()
+	defer o.m.RUnlock()
+	return *o.disabled
+}
+
+// Set sets the internal DisabledCacheGroups object. This MUST NOT be called by multiple goroutines.
+func (o *DisabledCacheGroups) Set(v dsdata.DisabledCacheGroups) {
+	o.m.Lock()
+	*o.disabled = v
+	o.m.Unlock()
+}
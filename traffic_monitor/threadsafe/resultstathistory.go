@@ -30,6 +30,7 @@ import (
 	"github.com/apache/trafficcontrol/lib/go-log"
 	"github.com/apache/trafficcontrol/lib/go-tc"
 	"github.com/apache/trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/trafficcontrol/traffic_monitor/shard"
 	"github.com/apache/trafficcontrol/traffic_monitor/srvhttp"
 
 	"github.com/json-iterator/go"
@@ -184,7 +185,8 @@ func newStatEqual(history []cache.ResultStatVal, stat interface{}) (bool, error)
 }
 
 // StatsMarshall encodes the stats in JSON, encoding up to historyCount of each stat. If statsToUse is empty, all stats are encoded; otherwise, only the given stats are encoded. If wildcard is true, stats which contain the text in each statsToUse are returned, instead of exact stat names. If cacheType is not CacheTypeInvalid, only stats for the given type are returned. If hosts is not empty, only the given hosts are returned.
-func StatsMarshall(statResultHistory ResultStatHistory, statInfo cache.ResultInfoHistory, combinedStates tc.CRStates, monitorConfig tc.TrafficMonitorConfigMap, statMaxKbpses cache.Kbpses, filter cache.Filter, params url.Values) ([]byte, error) {
+// If polling is sharded, caches this monitor doesn't poll are encoded from the given peerCaches stats, polled from the peers which poll them, and omitted if no peer stats exist.
+func StatsMarshall(statResultHistory ResultStatHistory, statInfo cache.ResultInfoHistory, combinedStates tc.CRStates, monitorConfig tc.TrafficMonitorConfigMap, statMaxKbpses cache.Kbpses, filter cache.Filter, params url.Values, sh shard.Shard, peerCaches map[tc.CacheName]map[string][]cache.ResultStatVal) ([]byte, error) {
 	stats := cache.Stats{
 		CommonAPIData: srvhttp.GetCommonAPIData(params, time.Now()),
 		Caches:        map[tc.CacheName]map[string][]cache.ResultStatVal{},
@@ -199,6 +201,14 @@ func StatsMarshall(statResultHistory ResultStatHistory, statInfo cache.ResultInf
 			continue
 		}
 
+		if peerStats, ok := peerCaches[id]; ok {
+			addPeerCacheStats(stats.Caches, id, peerStats, filter)
+			continue
+		}
+		if !sh.Polls(id) {
+			continue
+		}
+
 		cacheStatResultHistory := statResultHistory.LoadOrStore(id)
 		cacheStatResultHistory.Range(func(stat string, vals []cache.ResultStatVal) bool {
 			stat = "ats." + stat // TM1 prefixes ATS stats with 'ats.'
@@ -252,6 +262,26 @@ func StatsMarshall(statResultHistory ResultStatHistory, statInfo cache.ResultInf
 	return json.Marshal(stats)
 }
 
+// addPeerCacheStats adds the given stats of a cache polled by a peer to caches, filtered by the given filter. Peer stats are already prefixed and computed by the peer.
+func addPeerCacheStats(caches map[tc.CacheName]map[string][]cache.ResultStatVal, id tc.CacheName, peerStats map[string][]cache.ResultStatVal, filter cache.Filter) {
+	for stat, vals := range peerStats {
+		if !filter.UseStat(stat) {
+			continue
+		}
+		historyCount := 1
+		for _, val := range vals {
+			if !filter.WithinStatHistoryMax(historyCount) {
+				break
+			}
+			if _, ok := caches[id]; !ok {
+				caches[id] = map[string][]cache.ResultStatVal{}
+			}
+			caches[id][stat] = append(caches[id][stat], val)
+			historyCount += int(val.Span)
+		}
+	}
+}
+
 func pruneStats(history []cache.ResultStatVal, limit uint64) []cache.ResultStatVal {
 	if uint64(len(history)) > limit {
 		history = history[:limit-1]
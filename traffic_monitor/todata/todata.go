@@ -74,6 +74,7 @@ type TOData struct {
 	DeliveryServiceTypes   map[tc.DeliveryServiceName]tc.DSTypeCategory
 	DeliveryServiceRegexes Regexes
 	ServerCachegroups      map[tc.CacheName]tc.CacheGroupName
+	CDN                    tc.CDNName
 }
 
 // New returns a new empty TOData object, initializing pointer members.
@@ -148,7 +149,7 @@ func (d TODataThreadsafe) Update(to towrap.ITrafficOpsSession, cdn string) error
 		return fmt.Errorf("Error getting last CRConfig: %v", err)
 	}
 
-	newTOData := TOData{}
+	newTOData := TOData{CDN: tc.CDNName(cdn)}
 
 	var crConfig CRConfig
 	json := jsoniter.ConfigFastest
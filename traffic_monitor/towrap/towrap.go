@@ -51,6 +51,7 @@ type ITrafficOpsSession interface {
 	CacheGroups() ([]tc.CacheGroupNullable, error)
 	CRConfigHistory() []CRConfigStat
 	BackupFileExists() bool
+	Ping() error
 }
 
 const localHostIP = "127.0.0.1"
@@ -176,6 +177,11 @@ func NewTrafficOpsSessionThreadsafe(s *client.Session, crConfigHistoryLimit uint
 	return TrafficOpsSessionThreadsafe{session: &s, m: &sync.Mutex{}, lastCRConfig: NewByteMapCache(), crConfigHist: NewCRConfigHistoryThreadsafe(crConfigHistoryLimit), CRConfigBackupFile: cfg.CRConfigBackupFile, TMConfigBackupFile: cfg.TMConfigBackupFile}
 }
 
+// NewCDNSession returns a TrafficOpsSessionThreadsafe sharing the Traffic Ops session of s, with its own CRConfig history and the backup files of the given config, to monitor another CDN. Setting the session of either sets both.
+func (s TrafficOpsSessionThreadsafe) NewCDNSession(crConfigHistoryLimit uint64, cfg config.Config) TrafficOpsSessionThreadsafe {
+	return TrafficOpsSessionThreadsafe{session: s.session, m: s.m, lastCRConfig: NewByteMapCache(), crConfigHist: NewCRConfigHistoryThreadsafe(crConfigHistoryLimit), CRConfigBackupFile: cfg.CRConfigBackupFile, TMConfigBackupFile: cfg.TMConfigBackupFile}
+}
+
 // Set sets the internal Traffic Ops session. This is safe for multiple goroutines, being aware they will race.
 func (s TrafficOpsSessionThreadsafe) Set(session *client.Session) {
 	s.m.Lock()
@@ -551,6 +557,16 @@ func (s TrafficOpsSessionThreadsafe) Servers() ([]tc.Server, error) {
 	return servers, error
 }
 
+// Ping requests the Traffic Ops ping endpoint, returning an error if Traffic Ops didn't respond successfully.
+func (s TrafficOpsSessionThreadsafe) Ping() error {
+	ss := s.get()
+	if ss == nil {
+		return ErrNilSession
+	}
+	_, _, err := ss.Ping()
+	return err
+}
+
 func (s TrafficOpsSessionThreadsafe) Profiles() ([]tc.Profile, error) {
 	ss := s.get()
 	if ss == nil {
@@ -0,0 +1,39 @@
+/*
+
+    Licensed under the Apache License, Version 2.0 (the "License");
+    you may not use this file except in compliance with the License.
+    You may obtain a copy of the License at
+
+        http://www.apache.org/licenses/LICENSE-2.0
+
+    Unless required by applicable law or agreed to in writing, software
+    distributed under the License is distributed on an "AS IS" BASIS,
+    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+    See the License for the specific language governing permissions and
+    limitations under the License.
+*/
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- snapshot_history
+CREATE TABLE IF NOT EXISTS snapshot_history (
+    id bigserial PRIMARY KEY,
+    cdn text NOT NULL,
+    crconfig json NOT NULL,
+    monitoring json NOT NULL,
+    username text NOT NULL,
+    comment text,
+    rollback_of bigint,
+    created timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT snapshot_history_cdn_fkey FOREIGN KEY (cdn) REFERENCES cdn(name) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS snapshot_history_cdn_idx ON snapshot_history (cdn, id);
+
+INSERT INTO snapshot_history (cdn, crconfig, monitoring, username, created)
+SELECT cdn, crconfig, monitoring, '', last_updated FROM snapshot;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+DROP TABLE IF EXISTS snapshot_history;
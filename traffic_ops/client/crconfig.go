@@ -18,6 +18,10 @@ package client
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
 )
 
 // CRConfigRaw Deprecated: use GetCRConfig instead
@@ -51,3 +55,82 @@ func (to *Session) SnapshotCRConfig(cdn string) (ReqInf, error) {
 	reqInf := ReqInf{RemoteAddr: remoteAddr, CacheHitStatus: CacheHitStatusMiss}
 	return reqInf, err
 }
+
+// GetCRConfigDiff returns the difference between the current CRConfig snapshot of the given CDN and the CRConfig which would be created by snapshotting it now.
+func (to *Session) GetCRConfigDiff(cdn string) (tc.CRConfigDiff, ReqInf, error) {
+	uri := apiBase + `/cdns/` + cdn + `/snapshot/diff`
+	resp, remoteAddr, err := to.request(http.MethodGet, uri, nil)
+	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	if err != nil {
+		return tc.CRConfigDiff{}, reqInf, err
+	}
+	defer resp.Body.Close()
+
+	data := tc.CRConfigDiffResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return tc.CRConfigDiff{}, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetSnapshotHistory returns the CRConfig and monitoring snapshot history of the given CDN, newest first.
+func (to *Session) GetSnapshotHistory(cdn string) ([]tc.SnapshotHistory, ReqInf, error) {
+	uri := apiBase + `/cdns/` + cdn + `/snapshot/history`
+	resp, remoteAddr, err := to.request(http.MethodGet, uri, nil)
+	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	if err != nil {
+		return nil, reqInf, err
+	}
+	defer resp.Body.Close()
+
+	data := tc.SnapshotHistoryResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetSnapshotHistoryCRConfig returns the raw JSON bytes of the CRConfig of the given snapshot history entry.
+func (to *Session) GetSnapshotHistoryCRConfig(cdn string, id int) ([]byte, ReqInf, error) {
+	uri := apiBase + `/cdns/` + cdn + `/snapshot/history/` + strconv.Itoa(id)
+	resp, remoteAddr, err := to.request(http.MethodGet, uri, nil)
+	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	if err != nil {
+		return nil, reqInf, err
+	}
+	defer resp.Body.Close()
+
+	data := OuterResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, reqInf, err
+	}
+	return []byte(data.Response), reqInf, nil
+}
+
+// GetSnapshotHistoryDiff returns the difference between the CRConfigs of the given snapshot history entries.
+func (to *Session) GetSnapshotHistoryDiff(cdn string, id int, toID int) (tc.CRConfigDiff, ReqInf, error) {
+	uri := apiBase + `/cdns/` + cdn + `/snapshot/history/` + strconv.Itoa(id) + `/diff/` + strconv.Itoa(toID)
+	resp, remoteAddr, err := to.request(http.MethodGet, uri, nil)
+	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	if err != nil {
+		return tc.CRConfigDiff{}, reqInf, err
+	}
+	defer resp.Body.Close()
+
+	data := tc.CRConfigDiffResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return tc.CRConfigDiff{}, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// RollbackSnapshot re-publishes the given snapshot history entry as the current snapshot of the CDN. The comment is optional, and may be empty.
+func (to *Session) RollbackSnapshot(cdn string, id int, comment string) (ReqInf, error) {
+	uri := apiBase + `/cdns/` + cdn + `/snapshot/history/` + strconv.Itoa(id) + `/rollback`
+	if comment != "" {
+		uri += `?comment=` + url.QueryEscape(comment)
+	}
+	_, remoteAddr, err := to.request(http.MethodPut, uri, nil)
+	reqInf := ReqInf{RemoteAddr: remoteAddr, CacheHitStatus: CacheHitStatusMiss}
+	return reqInf, err
+}
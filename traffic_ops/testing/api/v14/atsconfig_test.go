@@ -17,6 +17,9 @@ package v14
 
 import (
 	"testing"
+
+	"github.com/apache/trafficcontrol/traffic_ops/testing/api/v14/config/cachecfg"
+	"github.com/apache/trafficcontrol/traffic_ops/testing/api/v14/config/ip_allow"
 )
 
 func TestATSConfigs(t *testing.T) {
@@ -70,6 +73,36 @@ func GetTestATSConfigs(t *testing.T) {
 		t.Fatalf("Getting profile by name '" + server.Profile + "' config storage.config: " + err.Error() + "\n")
 	}
 
+	ipAllow, _, err := TOSession.GetATSServerConfig(server.ID, "ip_allow.config")
+	if err != nil {
+		t.Fatalf("Getting server '" + server.HostName + "' config ip_allow.config: " + err.Error() + "\n")
+	}
+	if err := ip_allow.Parse(ipAllow); err != nil {
+		t.Errorf("Parsing server '" + server.HostName + "' config ip_allow.config: " + err.Error() + "\n")
+	}
+
+	cacheCfg, _, err := TOSession.GetATSProfileConfig(server.ProfileID, "cache.config")
+	if err != nil {
+		t.Fatalf("Getting profile '" + server.Profile + "' config cache.config: " + err.Error() + "\n")
+	}
+	if err := cachecfg.Parse(cacheCfg); err != nil {
+		t.Errorf("Parsing profile '" + server.Profile + "' config cache.config: " + err.Error() + "\n")
+	}
+
+	for _, cfgFile := range []string{"records.config", "volume.config"} {
+		if _, _, err := TOSession.GetATSProfileConfig(server.ProfileID, cfgFile); err != nil {
+			t.Errorf("Getting profile '" + server.Profile + "' config " + cfgFile + ": " + err.Error() + "\n")
+		}
+	}
+
+	if _, _, err := TOSession.GetATSServerConfig(server.ID, "hosting.config"); err != nil {
+		t.Errorf("Getting server '" + server.HostName + "' config hosting.config: " + err.Error() + "\n")
+	}
+
+	if _, _, err := TOSession.GetATSCDNConfig(server.CDNID, "ssl_multicert.config"); err != nil {
+		t.Errorf("Getting cdn '" + server.CDNName + "' config ssl_multicert.config: " + err.Error() + "\n")
+	}
+
 	_, _, err = TOSession.GetATSCDNConfig(server.CDNID, "bg_fetch.config")
 	if err != nil {
 		t.Fatalf("Getting cdn '" + server.CDNName + "' config bg_fetch.config: " + err.Error() + "\n")
@@ -57,6 +57,15 @@ func WriteResp(w http.ResponseWriter, r *http.Request, v interface{}) {
 	WriteRespRaw(w, r, resp)
 }
 
+// WriteRespWithSummary is like WriteResp, but also writes a "summary" object with the given count, which is the total number of objects matching the request, before any pagination.
+func WriteRespWithSummary(w http.ResponseWriter, r *http.Request, v interface{}, count uint64) {
+	resp := struct {
+		Response interface{} `json:"response"`
+		Summary  tc.Summary  `json:"summary"`
+	}{v, tc.Summary{Count: count}}
+	WriteRespRaw(w, r, resp)
+}
+
 // WriteRespRaw acts like WriteResp, but doesn't wrap the object in a `{"response":` object. This should be used to respond with endpoints which don't wrap their response in a "response" object.
 func WriteRespRaw(w http.ResponseWriter, r *http.Request, v interface{}) {
 	if respWritten(r) {
@@ -290,6 +299,8 @@ type APIInfo struct {
 	Version   *Version
 	Tx        *sqlx.Tx
 	Config    *config.Config
+	// Count is the total number of objects matching the request before the limit and offset were applied. It is set by Readers which paginate in the database, and is nil otherwise.
+	Count *uint64
 }
 
 // NewInfo get and returns the context info needed by handlers. It also returns any user error, any system error, and the status code which should be returned to the client if an error occurred.
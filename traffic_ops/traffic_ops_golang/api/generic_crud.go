@@ -85,13 +85,22 @@ func GenericCreate(val GenericCreator) (error, error, int) {
 	return nil, nil, http.StatusOK
 }
 
+// GenericRead does a Read (GET) for the given GenericReader object and type, filtering, ordering, and paginating by the request's query parameters. If the request is paginated, the total number of matching objects is set in the APIInfo Count.
 func GenericRead(val GenericReader) ([]interface{}, error, error, int) {
-	where, orderBy, queryValues, errs := dbhelpers.BuildWhereAndOrderBy(val.APIInfo().Params, val.ParamColumns())
+	where, orderBy, pagination, queryValues, errs := dbhelpers.BuildWhereAndOrderByAndPagination(val.APIInfo().Params, val.ParamColumns())
 	if len(errs) > 0 {
 		return nil, util.JoinErrs(errs), nil, http.StatusBadRequest
 	}
 
-	query := val.SelectQuery() + where + orderBy
+	if pagination != "" {
+		count, err := dbhelpers.GetCount(val.APIInfo().Tx, val.SelectQuery()+where, queryValues)
+		if err != nil {
+			return nil, nil, errors.New("counting " + val.GetType() + ": " + err.Error()), http.StatusInternalServerError
+		}
+		val.APIInfo().Count = &count
+	}
+
+	query := val.SelectQuery() + where + orderBy + pagination
 	rows, err := val.APIInfo().Tx.NamedQuery(query, queryValues)
 	if err != nil {
 		return nil, nil, errors.New("querying " + val.GetType() + ": " + err.Error()), http.StatusInternalServerError
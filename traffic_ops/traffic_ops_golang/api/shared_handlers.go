@@ -31,6 +31,8 @@ import (
 
 	"github.com/apache/trafficcontrol/lib/go-log"
 	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/dbhelpers"
 )
 
 const PathParamsKey = "pathParams"
@@ -116,7 +118,9 @@ func decodeAndValidateRequestBody(r *http.Request, v Validator) error {
 //      this handler retrieves the user from the context
 //      combines the path and query parameters
 //      produces the proper status code based on the error code returned
-//      marshals the structs returned into the proper response json
+//      paginates the results, if the Reader didn't paginate in the database
+//      selects the "fields" query parameter's fields
+//      marshals the structs returned into the proper response json, with a summary of the total count
 func ReadHandler(reader Reader) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		inf, userErr, sysErr, errCode := NewInfo(r, nil, nil)
@@ -140,8 +144,117 @@ func ReadHandler(reader Reader) http.HandlerFunc {
 			HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
 			return
 		}
-		WriteResp(w, r, results)
+
+		count := uint64(len(results))
+		if inf.Count != nil {
+			count = *inf.Count
+		} else {
+			limit, offset, errs := dbhelpers.GetPagination(inf.Params)
+			if len(errs) > 0 {
+				HandleErr(w, r, inf.Tx.Tx, http.StatusBadRequest, util.JoinErrs(errs), nil)
+				return
+			}
+			results = paginate(results, limit, offset)
+		}
+
+		if fields, ok := inf.Params["fields"]; ok {
+			selected, userErr, sysErr := selectFields(results, strings.Split(fields, ","), obj)
+			if userErr != nil || sysErr != nil {
+				errCode := http.StatusBadRequest
+				if sysErr != nil {
+					errCode = http.StatusInternalServerError
+					sysErr = errors.New("selecting fields: " + sysErr.Error())
+				}
+				HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+				return
+			}
+			results = selected
+		}
+		WriteRespWithSummary(w, r, results, count)
+	}
+}
+
+// paginate returns the page of vals at the given offset and of the given limit. A limit of 0 means no limit.
+func paginate(vals []interface{}, limit int, offset int) []interface{} {
+	if offset >= len(vals) {
+		return []interface{}{}
+	}
+	vals = vals[offset:]
+	if limit > 0 && limit < len(vals) {
+		vals = vals[:limit]
+	}
+	return vals
+}
+
+// selectFields returns vals as JSON objects with only the given fields, for the "fields" query parameter. Fields which the reader's or vals' types don't have are a user error. Fields which an object omits, because they're empty, are omitted.
+func selectFields(vals []interface{}, fields []string, reader interface{}) ([]interface{}, error, error) {
+	known := jsonFields(reflect.TypeOf(reader))
+	for _, val := range vals {
+		for field := range jsonFields(reflect.TypeOf(val)) {
+			known[field] = struct{}{}
+		}
+	}
+	for i, field := range fields {
+		fields[i] = strings.TrimSpace(field)
+		if _, ok := known[fields[i]]; !ok {
+			return nil, errors.New("unknown field '" + fields[i] + "'"), nil
+		}
+	}
+
+	selected := make([]interface{}, 0, len(vals))
+	for _, val := range vals {
+		bts, err := json.Marshal(val)
+		if err != nil {
+			return nil, nil, errors.New("marshalling: " + err.Error())
+		}
+		obj := map[string]json.RawMessage{}
+		if err := json.Unmarshal(bts, &obj); err != nil {
+			return nil, nil, fmt.Errorf("unmarshalling %T as object: %v", val, err)
+		}
+		selectedObj := map[string]json.RawMessage{}
+		for _, field := range fields {
+			if fieldVal, ok := obj[field]; ok {
+				selectedObj[field] = fieldVal
+			}
+		}
+		selected = append(selected, selectedObj)
+	}
+	return selected, nil, nil
+}
+
+// jsonFields returns the names of the JSON object fields of the given type, including those of embedded structs. It returns an empty map if the type isn't a struct, or a pointer to one.
+func jsonFields(t reflect.Type) map[string]struct{} {
+	fields := map[string]struct{}{}
+	if t == nil {
+		return fields
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			for embeddedField := range jsonFields(field.Type) {
+				fields[embeddedField] = struct{}{}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = struct{}{}
 	}
+	return fields
 }
 
 // UpdateHandler creates a handler function from the pointer to a struct implementing the Updater interface
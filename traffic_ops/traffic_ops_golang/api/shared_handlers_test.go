@@ -21,9 +21,11 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -181,12 +183,73 @@ func TestReadHandler(t *testing.T) {
 	readFunc(w, r)
 
 	//verifies the body is in the expected format
-	body := `{"response":[{"ID":1}]}`
+	body := `{"response":[{"ID":1}],"summary":{"count":1}}`
 	if w.Body.String() != body {
 		t.Error("Expected body", body, "got", w.Body.String())
 	}
 }
 
+func TestPaginate(t *testing.T) {
+	vals := []interface{}{1, 2, 3, 4, 5}
+	tests := []struct {
+		limit    int
+		offset   int
+		expected []interface{}
+	}{
+		{0, 0, []interface{}{1, 2, 3, 4, 5}},
+		{2, 0, []interface{}{1, 2}},
+		{2, 4, []interface{}{5}},
+		{0, 3, []interface{}{4, 5}},
+		{2, 5, []interface{}{}},
+	}
+	for _, test := range tests {
+		actual := paginate(vals, test.limit, test.offset)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("paginate limit %v offset %v expected %v, actual %v", test.limit, test.offset, test.expected, actual)
+		}
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	vals := []interface{}{
+		struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+			Desc string `json:"description"`
+		}{1, "foo", "a foo"},
+	}
+	selected, userErr, sysErr := selectFields(vals, []string{"name", " id"}, nil)
+	if userErr != nil || sysErr != nil {
+		t.Fatalf("selectFields expected no error, actual: %v %v", userErr, sysErr)
+	}
+	bts, err := json.Marshal(selected)
+	if err != nil {
+		t.Fatalf("marshalling selected fields: %v", err)
+	}
+	if expected := `[{"id":1,"name":"foo"}]`; string(bts) != expected {
+		t.Errorf("selectFields expected %v, actual %v", expected, string(bts))
+	}
+
+	if _, userErr, _ := selectFields(vals, []string{"name", "nonexistent"}, nil); userErr == nil {
+		t.Errorf("selectFields of unknown field expected user error, actual: nil")
+	}
+
+	// with no results, fields are checked against the reader's type, including embedded structs
+	type embedded struct {
+		Name string `json:"name"`
+	}
+	reader := &struct {
+		embedded
+		Secret string `json:"-"`
+	}{}
+	if _, userErr, sysErr := selectFields([]interface{}{}, []string{"name"}, reader); userErr != nil || sysErr != nil {
+		t.Errorf("selectFields of embedded field expected no error, actual: %v %v", userErr, sysErr)
+	}
+	if _, userErr, _ := selectFields([]interface{}{}, []string{"Secret"}, reader); userErr == nil {
+		t.Errorf("selectFields of ignored field expected user error, actual: nil")
+	}
+}
+
 func TestUpdateHandler(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	if err != nil {
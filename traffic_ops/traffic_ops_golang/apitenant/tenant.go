@@ -59,6 +59,7 @@ func (v *TOTenant) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 		"name":        dbhelpers.WhereColumnInfo{Column: "q.name", Checker: nil},
 		"parent_id":   dbhelpers.WhereColumnInfo{Column: "q.parent_id", Checker: api.IsInt},
 		"parent_name": dbhelpers.WhereColumnInfo{Column: "p.name", Checker: nil},
+		"lastUpdated": dbhelpers.WhereColumnInfo{Column: "q.last_updated", Checker: dbhelpers.IsTime},
 	}
 }
 func (v *TOTenant) UpdateQuery() string { return updateQuery() }
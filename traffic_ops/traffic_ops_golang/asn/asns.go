@@ -51,6 +51,7 @@ func (v *TOASNV11) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 		"cachegroup":     dbhelpers.WhereColumnInfo{"c.id", nil},
 		"id":             dbhelpers.WhereColumnInfo{"a.id", api.IsInt},
 		"cachegroupName": dbhelpers.WhereColumnInfo{"c.name", nil},
+		"lastUpdated":    dbhelpers.WhereColumnInfo{"a.last_updated", dbhelpers.IsTime},
 	}
 }
 func (v *TOASNV11) UpdateQuery() string { return updateQuery() }
@@ -0,0 +1,100 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+const CacheConfigFile = "cache.config"
+
+const RemapConfigDSQueryWhereProfile = `
+WHERE ds.id in (SELECT dss.deliveryservice FROM deliveryservice_server dss JOIN server s ON s.id = dss.server WHERE s.profile = $1)
+`
+
+// GetProfileCacheDotConfig serves the cache.config for a profile, which covers all delivery services assigned to servers with that profile.
+func GetProfileCacheDotConfig(w http.ResponseWriter, r *http.Request) {
+	serveProfileConfig(w, r, CacheConfigFile, getProfileCacheDotConfig)
+}
+
+func getProfileCacheDotConfig(tx *sql.Tx, profileID ProfileID, profileName string) (string, error) {
+	dses, err := getRemapConfigDSData(tx, RemapConfigDSQuerySelect()+RemapConfigDSQueryWhereProfile+RemapConfigDSQueryOrder, []interface{}{profileID})
+	if err != nil {
+		return "", errors.New("getting delivery services: " + err.Error())
+	}
+	return makeCacheDotConfig(dses), nil
+}
+
+// GetServerCacheDotConfig serves the cache.config for a mid server, which covers all delivery services in the server's CDN.
+// The cache.config of edges is profile-scoped, and must be requested from the profile route.
+func GetServerCacheDotConfig(w http.ResponseWriter, r *http.Request) {
+	serveServerConfig(w, r, CacheConfigFile, getServerCacheDotConfig)
+}
+
+func getServerCacheDotConfig(tx *sql.Tx, server *ServerInfo) (string, error) {
+	dses, err := getRemapConfigDSData(tx, RemapConfigDSQuerySelect()+RemapConfigDSQueryWhereMid+RemapConfigDSQueryOrder, []interface{}{server.CDN})
+	if err != nil {
+		return "", errors.New("getting delivery services: " + err.Error())
+	}
+	return makeCacheDotConfig(dses), nil
+}
+
+var originPortRe = regexp.MustCompile(`^(.*?):(\d+)`)
+
+// makeCacheDotConfig returns the cache.config text for the given delivery service regex rows, which marks the origins of all HTTP_NO_CACHE delivery services as never-cache.
+// Rows which aren't host regexes or have no origin are ignored. Duplicate lines are removed, and the lines are sorted.
+func makeCacheDotConfig(dses []RemapConfigDSData) string {
+	lines := map[string]struct{}{}
+	for _, ds := range dses {
+		if ds.RegexType != tc.DSMatchTypeHostRegex || ds.OriginFQDN == nil || ds.Type != tc.DSTypeHTTPNoCache {
+			continue
+		}
+		originFQDN := stripOriginScheme(*ds.OriginFQDN)
+		line := ""
+		if match := originPortRe.FindStringSubmatch(originFQDN); match != nil {
+			line = "dest_domain=" + match[1] + " port=" + match[2] + " scheme=http action=never-cache\n"
+		} else {
+			line = "dest_domain=" + originFQDN + " scheme=http action=never-cache\n"
+		}
+		lines[line] = struct{}{}
+	}
+
+	linesArr := []string{}
+	for line := range lines {
+		linesArr = append(linesArr, line)
+	}
+	sort.Strings(linesArr)
+	return strings.Join(linesArr, "")
+}
+
+// stripOriginScheme returns the given origin URI without its http:// or https:// scheme.
+func stripOriginScheme(origin string) string {
+	if strings.HasPrefix(origin, "http://") {
+		return strings.TrimPrefix(origin, "http://")
+	}
+	return strings.TrimPrefix(origin, "https://")
+}
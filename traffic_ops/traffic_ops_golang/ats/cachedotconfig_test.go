@@ -0,0 +1,43 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+)
+
+func TestMakeCacheDotConfig(t *testing.T) {
+	dses := []RemapConfigDSData{
+		{Name: "ds0", Type: tc.DSTypeHTTPNoCache, OriginFQDN: util.StrPtr("http://origin0.example:8080"), RegexType: tc.DSMatchTypeHostRegex},
+		{Name: "ds0", Type: tc.DSTypeHTTPNoCache, OriginFQDN: util.StrPtr("http://origin0.example:8080"), RegexType: tc.DSMatchTypePathRegex},
+		{Name: "ds1", Type: tc.DSTypeHTTPNoCache, OriginFQDN: util.StrPtr("https://origin1.example"), RegexType: tc.DSMatchTypeHostRegex},
+		{Name: "ds2", Type: tc.DSTypeHTTPNoCache, OriginFQDN: util.StrPtr("http://origin0.example:8080"), RegexType: tc.DSMatchTypeHostRegex},
+		{Name: "ds3", Type: tc.DSTypeHTTP, OriginFQDN: util.StrPtr("http://origin3.example"), RegexType: tc.DSMatchTypeHostRegex},
+		{Name: "ds4", Type: tc.DSTypeHTTPNoCache, RegexType: tc.DSMatchTypeHostRegex},
+	}
+	expected := "dest_domain=origin0.example port=8080 scheme=http action=never-cache\n" +
+		"dest_domain=origin1.example scheme=http action=never-cache\n"
+	if actual := makeCacheDotConfig(dses); actual != expected {
+		t.Errorf("makeCacheDotConfig expected '%v' actual '%v'", expected, actual)
+	}
+}
@@ -33,8 +33,11 @@ import (
 const configSuffix = ".config"
 
 const HeaderRewritePrefix = "hdr_rw_"
+const MidHeaderRewritePrefix = "hdr_rw_mid_"
 const RegexRemapPrefix = "regex_remap_"
 const CacheUrlPrefix = "cacheurl_"
+const URLSigPrefix = "url_sig_"
+const URISigningPrefix = "uri_signing_"
 
 const RemapFile = "remap.config"
 
@@ -122,6 +125,30 @@ func getServerNameFromNameOrID(tx *sql.Tx, serverNameOrID string) (string, error
 	return serverName, nil, nil, http.StatusOK
 }
 
+// getProfileFromNameOrID returns the profile ID and name from a parameter which may be the name or ID.
+// This also checks and verifies the existence of the given profile, and returns an appropriate user error if it doesn't exist.
+// Returns the ID, the name, any user error, any system error, and any error code.
+func getProfileFromNameOrID(tx *sql.Tx, profileNameOrID string) (ProfileID, string, error, error, int) {
+	if profileID, err := strconv.Atoi(profileNameOrID); err == nil {
+		profileName, ok, err := dbhelpers.GetProfileNameFromID(profileID, tx)
+		if err != nil {
+			return InvalidID, "", nil, fmt.Errorf("getting profile name from id %v: %v", profileID, err), http.StatusInternalServerError
+		} else if !ok {
+			return InvalidID, "", errors.New("profile not found"), nil, http.StatusNotFound
+		}
+		return ProfileID(profileID), profileName, nil, nil, http.StatusOK
+	}
+
+	profileName := profileNameOrID
+	profileID, ok, err := dbhelpers.GetProfileIDFromName(profileName, tx)
+	if err != nil {
+		return InvalidID, "", nil, fmt.Errorf("getting profile id from name '%v': %v", profileName, err), http.StatusInternalServerError
+	} else if !ok {
+		return InvalidID, "", errors.New("profile not found"), nil, http.StatusNotFound
+	}
+	return ProfileID(profileID), profileName, nil, nil, http.StatusOK
+}
+
 func headerComment(tx *sql.Tx, name string) (string, error) {
 	nameVersionStr, err := GetNameVersionString(tx)
 	if err != nil {
@@ -0,0 +1,91 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+const HostingConfigFile = "hosting.config"
+
+func GetHostingDotConfig(w http.ResponseWriter, r *http.Request) {
+	serveServerConfig(w, r, HostingConfigFile, getHostingDotConfig)
+}
+
+func getHostingDotConfig(tx *sql.Tx, server *ServerInfo) (string, error) {
+	storageParams, err := getProfileParamData(tx, server.ProfileID, StorageConfigFile)
+	if err != nil {
+		return "", errors.New("getting storage profile params: " + err.Error())
+	}
+
+	dses := []RemapConfigDSData{}
+	if _, ok := storageParams[StorageParamRAMDrivePrefix]; ok {
+		if strings.HasPrefix(server.Type, tc.MidTypePrefix) {
+			dses, err = getRemapConfigDSData(tx, RemapConfigDSQuerySelect()+RemapConfigDSQueryWhereMid+RemapConfigDSQueryOrder, []interface{}{server.CDN})
+		} else {
+			dses, err = getRemapConfigDSData(tx, RemapConfigDSQuerySelect()+RemapConfigDSQueryWhereEdge+RemapConfigDSQueryOrder, []interface{}{server.ID})
+		}
+		if err != nil {
+			return "", errors.New("getting delivery services: " + err.Error())
+		}
+	}
+	return makeHostingDotConfig(server, storageParams, dses), nil
+}
+
+// makeHostingDotConfig returns the hosting.config text for the given server.
+// If the server's profile has a RAM drive, the origins of live delivery services are put on the RAM volume. Edges do this for all live delivery services, mids only for national ones.
+// All other hosts use volume 1, which is the RAM volume if there is no disk drive.
+func makeHostingDotConfig(server *ServerInfo, storageParams map[string]string, dses []RemapConfigDSData) string {
+	text := ""
+	if _, ok := storageParams[StorageParamRAMDrivePrefix]; ok {
+		nextVolume := 1
+		if _, ok := storageParams[StorageParamDrivePrefix]; ok {
+			text += "# TRAFFIC OPS NOTE: volume " + strconv.Itoa(nextVolume) + " is the Disk volume\n"
+			nextVolume++
+		}
+		ramVolume := nextVolume
+		text += "# TRAFFIC OPS NOTE: volume " + strconv.Itoa(ramVolume) + " is the RAM volume\n"
+
+		isEdge := strings.HasPrefix(server.Type, tc.EdgeTypePrefix)
+		isMid := strings.HasPrefix(server.Type, tc.MidTypePrefix)
+		listed := map[string]struct{}{}
+		for _, ds := range dses {
+			if ds.RegexType != tc.DSMatchTypeHostRegex || ds.OriginFQDN == nil {
+				continue
+			}
+			if !((isEdge && ds.Type.IsLive()) || (isMid && ds.Type.IsNational())) {
+				continue
+			}
+			if _, ok := listed[*ds.OriginFQDN]; ok {
+				continue
+			}
+			text += "hostname=" + stripOriginScheme(*ds.OriginFQDN) + " volume=" + strconv.Itoa(ramVolume) + "\n"
+			listed[*ds.OriginFQDN] = struct{}{}
+		}
+	}
+	text += "hostname=*   volume=1\n"
+	return text
+}
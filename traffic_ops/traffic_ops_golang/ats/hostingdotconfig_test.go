@@ -0,0 +1,60 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+)
+
+func TestMakeHostingDotConfig(t *testing.T) {
+	dses := []RemapConfigDSData{
+		{Name: "ds0", Type: tc.DSTypeHTTPLive, OriginFQDN: util.StrPtr("http://origin0.example"), RegexType: tc.DSMatchTypeHostRegex},
+		{Name: "ds1", Type: tc.DSTypeDNSLiveNational, OriginFQDN: util.StrPtr("https://origin1.example"), RegexType: tc.DSMatchTypeHostRegex},
+		{Name: "ds2", Type: tc.DSTypeHTTP, OriginFQDN: util.StrPtr("http://origin2.example"), RegexType: tc.DSMatchTypeHostRegex},
+	}
+	storageParams := map[string]string{StorageParamDrivePrefix: "/dev/sd", StorageParamRAMDrivePrefix: "/dev/ram"}
+
+	edge := &ServerInfo{HostName: "edge0", Type: "EDGE"}
+	expected := "# TRAFFIC OPS NOTE: volume 1 is the Disk volume\n" +
+		"# TRAFFIC OPS NOTE: volume 2 is the RAM volume\n" +
+		"hostname=origin0.example volume=2\n" +
+		"hostname=origin1.example volume=2\n" +
+		"hostname=*   volume=1\n"
+	if actual := makeHostingDotConfig(edge, storageParams, dses); actual != expected {
+		t.Errorf("makeHostingDotConfig edge expected '%v' actual '%v'", expected, actual)
+	}
+
+	mid := &ServerInfo{HostName: "mid0", Type: "MID"}
+	expected = "# TRAFFIC OPS NOTE: volume 1 is the Disk volume\n" +
+		"# TRAFFIC OPS NOTE: volume 2 is the RAM volume\n" +
+		"hostname=origin1.example volume=2\n" +
+		"hostname=*   volume=1\n"
+	if actual := makeHostingDotConfig(mid, storageParams, dses); actual != expected {
+		t.Errorf("makeHostingDotConfig mid expected '%v' actual '%v'", expected, actual)
+	}
+
+	expected = "hostname=*   volume=1\n"
+	if actual := makeHostingDotConfig(edge, map[string]string{StorageParamDrivePrefix: "/dev/sd"}, dses); actual != expected {
+		t.Errorf("makeHostingDotConfig without RAM drive expected '%v' actual '%v'", expected, actual)
+	}
+}
@@ -0,0 +1,330 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+)
+
+const IPAllowConfigFile = "ip_allow.config"
+
+const ParamPurgeAllowIP = "purge_allow_ip"
+const ParamCoalesceMaskLenV4 = "coalesce_masklen_v4"
+const ParamCoalesceNumberV4 = "coalesce_number_v4"
+const ParamCoalesceMaskLenV6 = "coalesce_masklen_v6"
+const ParamCoalesceNumberV6 = "coalesce_number_v6"
+
+const DefaultCoalesceMaskLenV4 = 24
+const DefaultCoalesceNumberV4 = 5
+const DefaultCoalesceMaskLenV6 = 48
+const DefaultCoalesceNumberV6 = 5
+
+const IPAllowActionAllow = "ip_allow"
+const IPAllowActionDeny = "ip_deny"
+
+const IPAllowMethodAll = "ALL"
+const IPAllowMethodsPushPurgeDelete = "PUSH|PURGE|DELETE"
+
+const IPAllowAllIPv4 = "0.0.0.0-255.255.255.255"
+const IPAllowAllIPv6 = "::-ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"
+
+// IPAllowData is a single ip_allow.config rule.
+type IPAllowData struct {
+	SrcIP  string
+	Action string
+	Method string
+}
+
+// IPAllowServer is a server whose addresses a mid allows.
+type IPAllowServer struct {
+	HostName   string
+	IP         string
+	IPNetmask  string
+	IP6Address string
+}
+
+func GetIPAllowDotConfig(w http.ResponseWriter, r *http.Request) {
+	serveServerConfig(w, r, IPAllowConfigFile, getIPAllowDotConfig)
+}
+
+func getIPAllowDotConfig(tx *sql.Tx, server *ServerInfo) (string, error) {
+	params, err := getProfileParamData(tx, server.ProfileID, IPAllowConfigFile)
+	if err != nil {
+		return "", errors.New("getting profile params: " + err.Error())
+	}
+
+	allowServers := []IPAllowServer{}
+	if strings.HasPrefix(server.Type, tc.MidTypePrefix) {
+		if allowServers, err = getIPAllowChildServers(tx, server.CacheGroupID); err != nil {
+			return "", errors.New("getting child servers: " + err.Error())
+		}
+	}
+	return makeIPAllowDotConfig(makeIPAllowData(server, params, allowServers)), nil
+}
+
+// makeIPAllowDotConfig returns the ip_allow.config text of the given rules.
+func makeIPAllowDotConfig(data []IPAllowData) string {
+	text := ""
+	for _, al := range data {
+		text += fmt.Sprintf("src_ip=%-70s action=%-10s method=%-20s\n", al.SrcIP, al.Action, al.Method)
+	}
+	return text
+}
+
+// makeIPAllowData returns the ip_allow.config rules for the given server.
+// Localhost and the profile's purge_allow_ip parameters are always allowed.
+// Edges allow everything else except PUSH, PURGE and DELETE. Mids allow the given child servers, coalescing them into larger networks per the profile's coalesce parameters, and the RFC 1918 private networks, and deny everything else.
+func makeIPAllowData(server *ServerInfo, params map[string]string, allowServers []IPAllowServer) []IPAllowData {
+	data := []IPAllowData{
+		{SrcIP: "127.0.0.1", Action: IPAllowActionAllow, Method: IPAllowMethodAll},
+		{SrcIP: "::1", Action: IPAllowActionAllow, Method: IPAllowMethodAll},
+	}
+
+	coalesceMaskLenV4 := DefaultCoalesceMaskLenV4
+	coalesceNumberV4 := DefaultCoalesceNumberV4
+	coalesceMaskLenV6 := DefaultCoalesceMaskLenV6
+	coalesceNumberV6 := DefaultCoalesceNumberV6
+
+	keys := []string{}
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		val := params[key]
+		switch name := paramDuplicateSuffixRe.ReplaceAllString(key, ""); name {
+		case ParamPurgeAllowIP:
+			data = append(data, IPAllowData{SrcIP: val, Action: IPAllowActionAllow, Method: IPAllowMethodAll})
+		case ParamCoalesceMaskLenV4:
+			coalesceMaskLenV4 = getIPAllowIntParam(name, val, coalesceMaskLenV4)
+		case ParamCoalesceNumberV4:
+			coalesceNumberV4 = getIPAllowIntParam(name, val, coalesceNumberV4)
+		case ParamCoalesceMaskLenV6:
+			coalesceMaskLenV6 = getIPAllowIntParam(name, val, coalesceMaskLenV6)
+		case ParamCoalesceNumberV6:
+			coalesceNumberV6 = getIPAllowIntParam(name, val, coalesceNumberV6)
+		}
+	}
+
+	if !strings.HasPrefix(server.Type, tc.MidTypePrefix) {
+		return append(data,
+			IPAllowData{SrcIP: IPAllowAllIPv4, Action: IPAllowActionDeny, Method: IPAllowMethodsPushPurgeDelete},
+			IPAllowData{SrcIP: IPAllowAllIPv6, Action: IPAllowActionDeny, Method: IPAllowMethodsPushPurgeDelete},
+		)
+	}
+
+	ipv4Nets := []*net.IPNet{}
+	ipv6Nets := []*net.IPNet{}
+	for _, allowServer := range allowServers {
+		if ipv4Net := parseIPAllowIPv4(allowServer.IP, allowServer.IPNetmask); ipv4Net != nil {
+			ipv4Nets = append(ipv4Nets, ipv4Net)
+		} else {
+			log.Errorln(allowServer.HostName + " has an invalid IPv4 address; excluding from ip_allow data for " + server.HostName)
+		}
+		if allowServer.IP6Address == "" {
+			continue
+		}
+		if ipv6Net := parseIPAllowIPv6(allowServer.IP6Address); ipv6Net != nil {
+			ipv6Nets = append(ipv6Nets, ipv6Net)
+		} else {
+			log.Errorln(allowServer.HostName + " has an invalid IPv6 address; excluding from ip_allow data for " + server.HostName)
+		}
+	}
+
+	ipv4Nets = compactNets(append(ipv4Nets, coalesceNets(coalesceMaskLenV4, coalesceNumberV4, ipv4Nets)...))
+	ipv6Nets = compactNets(append(ipv6Nets, coalesceNets(coalesceMaskLenV6, coalesceNumberV6, ipv6Nets)...))
+	for _, ipNet := range append(ipv4Nets, ipv6Nets...) {
+		data = append(data, IPAllowData{SrcIP: netRange(ipNet), Action: IPAllowActionAllow, Method: IPAllowMethodAll})
+	}
+
+	return append(data,
+		IPAllowData{SrcIP: "10.0.0.0-10.255.255.255", Action: IPAllowActionAllow, Method: IPAllowMethodAll},
+		IPAllowData{SrcIP: "172.16.0.0-172.31.255.255", Action: IPAllowActionAllow, Method: IPAllowMethodAll},
+		IPAllowData{SrcIP: "192.168.0.0-192.168.255.255", Action: IPAllowActionAllow, Method: IPAllowMethodAll},
+		IPAllowData{SrcIP: IPAllowAllIPv4, Action: IPAllowActionDeny, Method: IPAllowMethodAll},
+		IPAllowData{SrcIP: IPAllowAllIPv6, Action: IPAllowActionDeny, Method: IPAllowMethodAll},
+	)
+}
+
+func getIPAllowIntParam(name string, val string, defaultVal int) int {
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		log.Errorln("ip_allow.config parameter " + name + " value '" + val + "' is not an integer, using " + strconv.Itoa(defaultVal))
+		return defaultVal
+	}
+	return i
+}
+
+// getIPAllowChildServers returns the edges in cachegroups whose parent or secondary parent is the given cachegroup, and all monitors.
+func getIPAllowChildServers(tx *sql.Tx, cacheGroupID int) ([]IPAllowServer, error) {
+	qry := `
+SELECT
+  s.host_name,
+  s.ip_address,
+  COALESCE(s.ip_netmask, ''),
+  COALESCE(s.ip6_address, '')
+FROM
+  server s
+  JOIN type t ON t.id = s.type
+  JOIN cachegroup cg ON cg.id = s.cachegroup
+WHERE
+  t.name = $1
+  OR (t.name LIKE '` + tc.EdgeTypePrefix + `%' AND (cg.parent_cachegroup_id = $2 OR cg.secondary_parent_cachegroup_id = $2))
+ORDER BY s.id
+`
+	rows, err := tx.Query(qry, tc.MonitorTypeName, cacheGroupID)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	servers := []IPAllowServer{}
+	for rows.Next() {
+		s := IPAllowServer{}
+		if err := rows.Scan(&s.HostName, &s.IP, &s.IPNetmask, &s.IP6Address); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		servers = append(servers, s)
+	}
+	return servers, nil
+}
+
+// parseIPAllowIPv4 returns the network of the given IPv4 address and netmask, or nil if either is invalid. An empty netmask is a single host.
+func parseIPAllowIPv4(ipStr string, netmaskStr string) *net.IPNet {
+	ip := net.ParseIP(ipStr).To4()
+	if ip == nil {
+		return nil
+	}
+	mask := net.CIDRMask(32, 32)
+	if netmaskStr != "" {
+		netmask := net.ParseIP(netmaskStr).To4()
+		if netmask == nil {
+			return nil
+		}
+		mask = net.IPMask(netmask)
+		if ones, bits := mask.Size(); ones == 0 && bits == 0 {
+			return nil // non-canonical mask
+		}
+	}
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
+
+// parseIPAllowIPv6 returns the network of the given IPv6 address, which may have a prefix length, or nil if it is invalid. An address without a prefix length is a single host.
+func parseIPAllowIPv6(ipStr string) *net.IPNet {
+	if !strings.Contains(ipStr, "/") {
+		ipStr += "/128"
+	}
+	_, ipNet, err := net.ParseCIDR(ipStr)
+	if err != nil || ipNet.IP.To4() != nil {
+		return nil
+	}
+	return ipNet
+}
+
+// coalesceNets returns the networks of the given prefix length which contain at least number of the given networks.
+// Networks with a shorter prefix than maskLen are returned unchanged.
+func coalesceNets(maskLen int, number int, nets []*net.IPNet) []*net.IPNet {
+	coalesced := []*net.IPNet{}
+	counts := map[string]int{}
+	supernets := map[string]*net.IPNet{}
+	for _, ipNet := range nets {
+		ones, bits := ipNet.Mask.Size()
+		if ones < maskLen {
+			coalesced = append(coalesced, ipNet)
+			continue
+		}
+		mask := net.CIDRMask(maskLen, bits)
+		supernet := &net.IPNet{IP: ipNet.IP.Mask(mask), Mask: mask}
+		key := supernet.String()
+		counts[key]++
+		supernets[key] = supernet
+	}
+	for key, count := range counts {
+		if count >= number {
+			coalesced = append(coalesced, supernets[key])
+		}
+	}
+	return coalesced
+}
+
+// compactNets returns the given networks sorted by address, with duplicates and networks contained in other networks removed, and adjacent networks merged into the network containing both.
+func compactNets(nets []*net.IPNet) []*net.IPNet {
+	sorted := make([]*net.IPNet, len(nets))
+	copy(sorted, nets)
+	sort.Slice(sorted, func(i, j int) bool {
+		if c := bytes.Compare(sorted[i].IP, sorted[j].IP); c != 0 {
+			return c < 0
+		}
+		iOnes, _ := sorted[i].Mask.Size()
+		jOnes, _ := sorted[j].Mask.Size()
+		return iOnes < jOnes
+	})
+
+	compacted := []*net.IPNet{}
+	for _, ipNet := range sorted {
+		if len(compacted) > 0 && compacted[len(compacted)-1].Contains(ipNet.IP) {
+			continue // networks are aligned, so a network containing this network's address contains the whole network
+		}
+		compacted = append(compacted, ipNet)
+		for len(compacted) > 1 {
+			merged := mergeSiblingNets(compacted[len(compacted)-2], compacted[len(compacted)-1])
+			if merged == nil {
+				break
+			}
+			compacted = append(compacted[:len(compacted)-2], merged)
+		}
+	}
+	return compacted
+}
+
+// mergeSiblingNets returns the network made of the two given networks, if they are the two halves of the same network; otherwise nil.
+func mergeSiblingNets(a *net.IPNet, b *net.IPNet) *net.IPNet {
+	aOnes, aBits := a.Mask.Size()
+	bOnes, bBits := b.Mask.Size()
+	if aOnes != bOnes || aBits != bBits || aOnes == 0 {
+		return nil
+	}
+	mask := net.CIDRMask(aOnes-1, aBits)
+	if !a.IP.Mask(mask).Equal(a.IP) || !b.IP.Mask(mask).Equal(a.IP) || a.IP.Equal(b.IP) {
+		return nil
+	}
+	return &net.IPNet{IP: a.IP, Mask: mask}
+}
+
+// netRange returns the ip_allow.config range of the given network, as the first and last addresses separated by a hyphen.
+func netRange(ipNet *net.IPNet) string {
+	last := make(net.IP, len(ipNet.IP))
+	for i := range ipNet.IP {
+		last[i] = ipNet.IP[i] | ^ipNet.Mask[i]
+	}
+	return ipNet.IP.String() + "-" + last.String()
+}
@@ -0,0 +1,125 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompactNets(t *testing.T) {
+	nets := []*net.IPNet{}
+	for _, cidr := range []string{"192.168.1.1/32", "192.168.1.0/32", "192.168.1.2/31", "10.0.0.0/8", "10.1.2.3/32", "192.168.1.0/32", "192.168.2.4/32"} {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("parsing cidr %v: %v", cidr, err)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	actual := []string{}
+	for _, ipNet := range compactNets(nets) {
+		actual = append(actual, ipNet.String())
+	}
+	expected := []string{"10.0.0.0/8", "192.168.1.0/30", "192.168.2.4/32"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("compactNets expected %v actual %v", expected, actual)
+	}
+}
+
+func TestCoalesceNets(t *testing.T) {
+	nets := []*net.IPNet{}
+	for i := 0; i < 5; i++ {
+		nets = append(nets, parseIPAllowIPv4(fmt.Sprintf("192.168.1.%d", i*10), ""))
+	}
+	nets = append(nets, parseIPAllowIPv4("192.168.2.1", ""), parseIPAllowIPv4("10.0.0.0", "255.0.0.0"))
+
+	actual := []string{}
+	for _, ipNet := range compactNets(coalesceNets(24, 5, nets)) {
+		actual = append(actual, ipNet.String())
+	}
+	expected := []string{"10.0.0.0/8", "192.168.1.0/24"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("coalesceNets expected %v actual %v", expected, actual)
+	}
+}
+
+func TestMakeIPAllowDotConfigEdge(t *testing.T) {
+	server := &ServerInfo{HostName: "edge0", Type: "EDGE"}
+	params := map[string]string{
+		ParamPurgeAllowIP:          "192.0.2.1",
+		ParamPurgeAllowIP + "__42": "192.0.2.2",
+		ParamCoalesceMaskLenV4:     "16",
+	}
+	txt := makeIPAllowDotConfig(makeIPAllowData(server, params, nil))
+
+	srcs := getIPAllowSrcs(txt)
+	expected := []string{"127.0.0.1", "::1", "192.0.2.1", "192.0.2.2", IPAllowAllIPv4, IPAllowAllIPv6}
+	if !reflect.DeepEqual(expected, srcs) {
+		t.Errorf("expected src_ips %v actual %v", expected, srcs)
+	}
+	if !strings.Contains(txt, "action=ip_deny    method=PUSH|PURGE|DELETE") {
+		t.Errorf("expected edge to deny push, purge, and delete, actual '%v'", txt)
+	}
+}
+
+func TestMakeIPAllowDotConfigMid(t *testing.T) {
+	server := &ServerInfo{HostName: "mid0", Type: "MID"}
+	params := map[string]string{ParamCoalesceNumberV4: "2"}
+	allowServers := []IPAllowServer{
+		{HostName: "edge0", IP: "192.0.2.10", IPNetmask: "255.255.255.255", IP6Address: "2001:db8::10/64"},
+		{HostName: "edge1", IP: "192.0.2.11", IPNetmask: "255.255.255.255", IP6Address: "2001:db8::11/64"},
+		{HostName: "edge2", IP: "198.51.100.5", IPNetmask: "255.255.255.0"},
+		{HostName: "invalid", IP: "not-an-ip", IP6Address: "not-an-ip"},
+	}
+	txt := makeIPAllowDotConfig(makeIPAllowData(server, params, allowServers))
+
+	srcs := getIPAllowSrcs(txt)
+	expected := []string{
+		"127.0.0.1",
+		"::1",
+		"192.0.2.0-192.0.2.255",
+		"198.51.100.0-198.51.100.255",
+		"2001:db8::-2001:db8::ffff:ffff:ffff:ffff",
+		"10.0.0.0-10.255.255.255",
+		"172.16.0.0-172.31.255.255",
+		"192.168.0.0-192.168.255.255",
+		IPAllowAllIPv4,
+		IPAllowAllIPv6,
+	}
+	if !reflect.DeepEqual(expected, srcs) {
+		t.Errorf("expected src_ips %v actual %v", expected, srcs)
+	}
+}
+
+func getIPAllowSrcs(txt string) []string {
+	srcs := []string{}
+	for _, line := range strings.Split(txt, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		srcs = append(srcs, strings.TrimPrefix(fields[0], "src_ip="))
+	}
+	return srcs
+}
@@ -49,21 +49,7 @@ func GetParentDotConfig(w http.ResponseWriter, r *http.Request) {
 	}
 	defer inf.Close()
 
-	idOrHost := strings.TrimSuffix(inf.Params["id-or-host"], ".json")
-	hostName := ""
-	isHost := false
-	id, err := strconv.Atoi(idOrHost)
-	if err != nil {
-		isHost = true
-		hostName = idOrHost
-	}
-
-	serverInfo, ok, err := &ServerInfo{}, false, error(nil)
-	if isHost {
-		serverInfo, ok, err = getServerInfoByHost(inf.Tx.Tx, hostName)
-	} else {
-		serverInfo, ok, err = getServerInfoByID(inf.Tx.Tx, id)
-	}
+	serverInfo, ok, err := getServerInfoByIDOrHost(inf.Tx.Tx, strings.TrimSuffix(inf.Params["id-or-host"], ".json"))
 	if err != nil {
 		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("Getting server info: "+err.Error()))
 		return
@@ -436,6 +422,15 @@ func getServerInfoByHost(tx *sql.Tx, host string) (*ServerInfo, bool, error) {
 	return getServerInfo(tx, ServerInfoQuery()+` WHERE s.host_name = $1 `, []interface{}{host})
 }
 
+// getServerInfoByIDOrHost returns the necessary info about the server, whether the server exists, and any error.
+// The idOrHost may be the numeric server ID, or else the server host name.
+func getServerInfoByIDOrHost(tx *sql.Tx, idOrHost string) (*ServerInfo, bool, error) {
+	if id, err := strconv.Atoi(idOrHost); err == nil {
+		return getServerInfoByID(tx, id)
+	}
+	return getServerInfoByHost(tx, idOrHost)
+}
+
 // getServerInfo returns the necessary info about the server, whether the server exists, and any error.
 func getServerInfo(tx *sql.Tx, qry string, qryParams []interface{}) (*ServerInfo, bool, error) {
 	s := ServerInfo{}
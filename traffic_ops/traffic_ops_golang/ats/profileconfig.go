@@ -0,0 +1,193 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/api"
+)
+
+// ProfileConfigFunc returns the text of a profile-scoped config file, without the header comment.
+type ProfileConfigFunc func(tx *sql.Tx, profileID ProfileID, profileName string) (string, error)
+
+// ServerConfigFunc returns the text of a server-scoped config file, without the header comment.
+type ServerConfigFunc func(tx *sql.Tx, server *ServerInfo) (string, error)
+
+// serveProfileConfig writes the given profile-scoped config file for the profile in the "profile-name-or-id" path parameter.
+func serveProfileConfig(w http.ResponseWriter, r *http.Request, cfgFile string, makeConfig ProfileConfigFunc) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"profile-name-or-id"}, nil)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	profileID, profileName, userErr, sysErr, errCode := getProfileFromNameOrID(inf.Tx.Tx, strings.TrimSuffix(inf.Params["profile-name-or-id"], ".json"))
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+
+	hdr, err := headerComment(inf.Tx.Tx, profileName)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting header comment: "+err.Error()))
+		return
+	}
+
+	text, err := makeConfig(inf.Tx.Tx, profileID, profileName)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting "+cfgFile+" text: "+err.Error()))
+		return
+	}
+
+	w.Header().Set(tc.ContentType, tc.ContentTypeTextPlain)
+	w.Write([]byte(hdr + text))
+}
+
+// serveServerConfig writes the given server-scoped config file for the server in the "id-or-host" path parameter.
+// If the file is not server-scoped for the requested server, as determined by getServerScope, a user error directing the client to the correct route is returned.
+func serveServerConfig(w http.ResponseWriter, r *http.Request, cfgFile string, makeConfig ServerConfigFunc) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"id-or-host"}, nil)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	server, ok, err := getServerInfoByIDOrHost(inf.Tx.Tx, strings.TrimSuffix(inf.Params["id-or-host"], ".json"))
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting server info: "+err.Error()))
+		return
+	} else if !ok {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("server not found"), nil)
+		return
+	}
+
+	scope, err := getServerScope(inf.Tx.Tx, cfgFile, server.Type)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting scope: "+err.Error()))
+		return
+	} else if scope != tc.ATSConfigMetaDataConfigFileScopeServers {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusBadRequest, errors.New("incorrect file scope for route used, please use the "+string(scope)+" route"), nil)
+		return
+	}
+
+	hdr, err := headerComment(inf.Tx.Tx, server.HostName)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting header comment: "+err.Error()))
+		return
+	}
+
+	text, err := makeConfig(inf.Tx.Tx, server)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting "+cfgFile+" text: "+err.Error()))
+		return
+	}
+
+	w.Header().Set(tc.ContentType, tc.ContentTypeTextPlain)
+	w.Write([]byte(hdr + text))
+}
+
+// ProfileParamHostNameValue is the records.config value which is replaced with the server's FQDN in profile-scoped config files.
+// Because profile-scoped files are shared by all servers, the value is replaced with the ORT placeholder rather than an actual host name.
+const ProfileParamHostNameValue = "STRING __HOSTNAME__"
+const ProfileParamFullHostNameValue = "STRING __FULL_HOSTNAME__"
+
+// getProfileParamData returns the parameters of the given profile with the given config file, excluding the "location" parameter.
+// Multiple parameters with the same name are given keys with their parameter ID appended after a double underscore, e.g. "name__42". The first parameter by ID keeps the plain name.
+func getProfileParamData(tx *sql.Tx, profileID ProfileID, configFile string) (map[string]string, error) {
+	qry := `
+SELECT
+  p.id,
+  p.name,
+  p.value
+FROM
+  parameter p
+  JOIN profile_parameter pp ON pp.parameter = p.id
+WHERE
+  pp.profile = $1
+  AND p.config_file = $2
+  AND p.name != 'location'
+ORDER BY p.id
+`
+	rows, err := tx.Query(qry, profileID, configFile)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	params := map[string]string{}
+	for rows.Next() {
+		id := 0
+		name := ""
+		val := ""
+		if err := rows.Scan(&id, &name, &val); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		if _, ok := params[name]; ok {
+			name += "__" + strconv.Itoa(id)
+		}
+		if val == ProfileParamHostNameValue {
+			val = ProfileParamFullHostNameValue
+		}
+		params[name] = val
+	}
+	return params, nil
+}
+
+var paramDuplicateSuffixRe = regexp.MustCompile(`__\d+$`)
+
+// makeGenericProfileConfig returns the text of a config file of sorted "name separator value" lines, one per parameter.
+func makeGenericProfileConfig(params map[string]string, separator string) string {
+	keys := []string{}
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	text := ""
+	for _, key := range keys {
+		name := paramDuplicateSuffixRe.ReplaceAllString(key, "")
+		text += name + separator + params[key] + "\n"
+	}
+	return text
+}
+
+const RecordsSeparator = " "
+
+func GetRecordsDotConfig(w http.ResponseWriter, r *http.Request) {
+	serveProfileConfig(w, r, RecordsConfigFile, getRecordsDotConfig)
+}
+
+func getRecordsDotConfig(tx *sql.Tx, profileID ProfileID, profileName string) (string, error) {
+	params, err := getProfileParamData(tx, profileID, RecordsConfigFile)
+	if err != nil {
+		return "", errors.New("getting profile params: " + err.Error())
+	}
+	return makeGenericProfileConfig(params, RecordsSeparator), nil
+}
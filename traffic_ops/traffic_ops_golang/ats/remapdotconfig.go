@@ -0,0 +1,521 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/api"
+
+	"github.com/lib/pq"
+)
+
+const RangeRequestHandlingDontCache = 0
+const RangeRequestHandlingBackgroundFetch = 1
+const RangeRequestHandlingCacheRangeRequest = 2
+
+const DSProtocolHTTP = 0
+const DSProtocolHTTPS = 1
+const DSProtocolHTTPAndHTTPS = 2
+const DSProtocolHTTPToHTTPS = 3
+
+const SigningAlgorithmURISigning = "uri_signing"
+
+const RemapConfigParamDSCPRemap = "dscp_remap"
+
+const CacheKeyConfigFile = "cachekey.config"
+const RecordsConfigFile = "records.config"
+
+// RemapHTTPHostPlaceholder is replaced in HTTP remap lines by the server's host name, mirroring the Perl "__http__" routing name.
+const RemapHTTPHostPlaceholder = "__http__"
+
+func GetRemapDotConfig(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"id-or-host"}, nil)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	serverInfo, ok, err := getServerInfoByIDOrHost(inf.Tx.Tx, strings.TrimSuffix(inf.Params["id-or-host"], ".json"))
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("Getting server info: "+err.Error()))
+		return
+	}
+	if !ok {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("server not found"), nil)
+		return
+	}
+
+	atsMajorVer, err := GetATSMajorVersion(inf.Tx.Tx, serverInfo.ProfileID)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("Getting ATS major version: "+err.Error()))
+		return
+	}
+
+	hdr, err := headerComment(inf.Tx.Tx, serverInfo.HostName)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("Getting header comment: "+err.Error()))
+		return
+	}
+
+	text := ""
+	if strings.HasPrefix(serverInfo.Type, tc.MidTypePrefix) {
+		dses, err := getRemapConfigDSDataForMid(inf.Tx.Tx, serverInfo.CDN)
+		if err != nil {
+			api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("Getting remap config mid DS data: "+err.Error()))
+			return
+		}
+		text = makeRemapDotConfigMid(atsMajorVer, dses)
+	} else {
+		dses, err := getRemapConfigDSDataForEdge(inf.Tx.Tx, serverInfo.ID)
+		if err != nil {
+			api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("Getting remap config edge DS data: "+err.Error()))
+			return
+		}
+		_, hasDSCPRemap, err := GetProfileParamValue(inf.Tx.Tx, serverInfo.ProfileID, "package", RemapConfigParamDSCPRemap)
+		if err != nil {
+			api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("Getting dscp_remap parameter: "+err.Error()))
+			return
+		}
+		globalCacheURL, _, err := GetProfileParamValue(inf.Tx.Tx, serverInfo.ProfileID, "cacheurl.config", "location")
+		if err != nil {
+			api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("Getting cacheurl.config location parameter: "+err.Error()))
+			return
+		}
+		// Perl only checks the truthiness of the parameter value, so an empty or "0" location is treated as missing.
+		hasGlobalCacheURL := globalCacheURL != "" && globalCacheURL != "0"
+		text = makeRemapDotConfigEdge(serverInfo, atsMajorVer, hasDSCPRemap, hasGlobalCacheURL, dses)
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(hdr + text))
+}
+
+// RemapConfigDSData is a single delivery service regex row, as used to build remap.config.
+// Delivery services with multiple regexes have one RemapConfigDSData per regex, in the order they must appear in the file.
+type RemapConfigDSData struct {
+	ID                   int
+	Name                 string
+	Type                 tc.DSType
+	OriginFQDN           *string
+	MidHeaderRewrite     *string
+	CacheURL             *string
+	RangeRequestHandling int
+	QStringIgnore        int
+	RegexRemap           *string
+	Pattern              string
+	RegexType            tc.DSMatchType
+	Domain               string
+	RoutingName          string
+	Protocol             int
+	SigningAlgorithm     *string
+	DSCP                 int
+	EdgeHeaderRewrite    *string
+	RemapText            *string
+	FQPacingRate         int
+	ProfileID            *int
+
+	// Params are the delivery service profile parameters which affect remap lines, by config file and then parameter name.
+	Params map[string]map[string]string
+}
+
+// RemapLine is a single "map from to" pair of a remap.config line, before any plugins are appended.
+type RemapLine struct {
+	From string
+	To   string
+}
+
+// makeRemapDotConfigEdge returns the remap.config text for an edge server, without the header comment.
+// Each regex row produces one block of lines, and the blocks are sorted, as Perl does.
+func makeRemapDotConfigEdge(server *ServerInfo, atsMajorVersion int, hasDSCPRemap bool, hasGlobalCacheURL bool, dses []RemapConfigDSData) string {
+	textLines := []string{}
+	for _, ds := range dses {
+		dsText := ""
+		if ds.Type == tc.DSTypeAnyMap {
+			if ds.RemapText != nil {
+				dsText = *ds.RemapText
+			}
+			dsText += "\n"
+		} else {
+			for _, line := range getRemapLines(server, ds) {
+				dsText += buildEdgeRemapLine(server, atsMajorVersion, hasDSCPRemap, hasGlobalCacheURL, ds, line)
+			}
+		}
+		textLines = append(textLines, dsText)
+	}
+	sort.Strings(textLines)
+	return strings.Join(textLines, "")
+}
+
+// makeRemapDotConfigMid returns the remap.config text for a mid server, without the header comment.
+// Mids have a single identity line per origin, and only for origins which need plugins.
+func makeRemapDotConfigMid(atsMajorVersion int, dses []RemapConfigDSData) string {
+	midRemaps := map[string]string{}
+	for _, ds := range dses {
+		if ds.Type.IsLive() && !ds.Type.IsNational() {
+			continue // Live local delivery services skip mids
+		}
+		if ds.OriginFQDN == nil || *ds.OriginFQDN == "" {
+			log.Warnf("remap.config generation: delivery service '%v' has no origin, skipping for mids\n", ds.Name)
+			continue
+		}
+		if _, ok := midRemaps[*ds.OriginFQDN]; ok {
+			continue // skip remap rules from extra HOST_REGEXP entries
+		}
+
+		midRemap := ""
+		if ds.MidHeaderRewrite != nil && *ds.MidHeaderRewrite != "" {
+			midRemap += " @plugin=header_rewrite.so @pparam=" + GetConfigFile(MidHeaderRewritePrefix, ds.Name)
+		}
+		if ds.QStringIgnore == int(tc.QStringIgnoreIgnoreInCacheKeyAndPassUp) {
+			midRemap += getQStringIgnoreRemap(atsMajorVersion, ds.RangeRequestHandling)
+		}
+		if ds.CacheURL != nil && *ds.CacheURL != "" {
+			midRemap += " @plugin=cacheurl.so @pparam=" + GetConfigFile(CacheUrlPrefix, ds.Name)
+		}
+		midRemap += getCacheKeyRemap(ds.Params[CacheKeyConfigFile])
+		if ds.RangeRequestHandling == RangeRequestHandlingCacheRangeRequest {
+			midRemap += " @plugin=cache_range_requests.so"
+		}
+		midRemap += getConfRemap(ds.Name, ds.Params[RecordsConfigFile])
+		// Like Perl, origins with nothing to add don't get a line at all, and a later DS with the same origin may still add one.
+		if midRemap != "" {
+			midRemaps[*ds.OriginFQDN] = midRemap
+		}
+	}
+
+	textLines := []string{}
+	for origin, midRemap := range midRemaps {
+		textLines = append(textLines, "map "+origin+" "+origin+midRemap+"\n")
+	}
+	sort.Strings(textLines)
+	return strings.Join(textLines, "")
+}
+
+// getRemapLines returns the map-from and map-to URLs for the given delivery service regex row.
+// Returns nothing for regexes which aren't host regexes or DSes without an origin, and an HTTP and HTTPS line for DSes serving both protocols.
+func getRemapLines(server *ServerInfo, ds RemapConfigDSData) []RemapLine {
+	if ds.RegexType != tc.DSMatchTypeHostRegex || ds.OriginFQDN == nil {
+		return nil
+	}
+	mapTo := *ds.OriginFQDN + "/"
+
+	httpFrom := ""
+	httpsFrom := ""
+	if strings.HasSuffix(ds.Pattern, `.*`) {
+		re := strings.Replace(ds.Pattern, `\`, "", -1)
+		re = strings.Replace(re, `.*`, "", -1)
+
+		hName := RemapHTTPHostPlaceholder
+		if ds.Type.IsDNS() {
+			hName = ds.RoutingName
+		}
+		portStr := ""
+		if hName == RemapHTTPHostPlaceholder && server.Port > 0 && server.Port != 80 {
+			portStr = ":" + strconv.Itoa(server.Port)
+		}
+		httpFrom = "http://" + hName + re + ds.Domain + portStr + "/"
+		httpsFrom = "https://" + hName + re + ds.Domain + "/"
+	} else {
+		httpFrom = "http://" + ds.Pattern + "/"
+		httpsFrom = "https://" + ds.Pattern + "/"
+	}
+
+	switch ds.Protocol {
+	case DSProtocolHTTP:
+		return []RemapLine{{From: httpFrom, To: mapTo}}
+	case DSProtocolHTTPS, DSProtocolHTTPToHTTPS:
+		return []RemapLine{{From: httpsFrom, To: mapTo}}
+	case DSProtocolHTTPAndHTTPS:
+		return []RemapLine{{From: httpFrom, To: mapTo}, {From: httpsFrom, To: mapTo}}
+	}
+	return nil
+}
+
+// buildEdgeRemapLine returns the remap.config line, including the trailing newline, for the given edge delivery service and map line.
+func buildEdgeRemapLine(server *ServerInfo, atsMajorVersion int, hasDSCPRemap bool, hasGlobalCacheURL bool, ds RemapConfigDSData, line RemapLine) string {
+	mapFrom := strings.Replace(line.From, RemapHTTPHostPlaceholder, server.HostName, 1)
+
+	text := "map\t" + mapFrom + "     " + line.To
+	if hasDSCPRemap {
+		text += " @plugin=dscp_remap.so @pparam=" + strconv.Itoa(ds.DSCP)
+	} else {
+		text += " @plugin=header_rewrite.so @pparam=dscp/set_dscp_" + strconv.Itoa(ds.DSCP) + ".config"
+	}
+	if ds.EdgeHeaderRewrite != nil {
+		text += " @plugin=header_rewrite.so @pparam=" + GetConfigFile(HeaderRewritePrefix, ds.Name)
+	}
+	if ds.SigningAlgorithm != nil {
+		if *ds.SigningAlgorithm == tc.SigningAlgorithmURLSig {
+			text += " @plugin=url_sig.so @pparam=" + GetConfigFile(URLSigPrefix, ds.Name)
+		} else if *ds.SigningAlgorithm == SigningAlgorithmURISigning {
+			text += " @plugin=uri_signing.so @pparam=" + GetConfigFile(URISigningPrefix, ds.Name)
+		}
+	}
+	if ds.QStringIgnore == int(tc.QStringIgnoreDrop) {
+		text += " @plugin=regex_remap.so @pparam=drop_qstring.config"
+	} else if ds.QStringIgnore == int(tc.QStringIgnoreIgnoreInCacheKeyAndPassUp) {
+		if hasGlobalCacheURL {
+			log.Debugln("qstring_ignore == 1, but global cacheurl.config param exists, so skipping remap rename config_file=cacheurl.config parameter if you want to change")
+		} else {
+			text += getQStringIgnoreRemap(atsMajorVersion, ds.RangeRequestHandling)
+		}
+	}
+	if ds.CacheURL != nil && *ds.CacheURL != "" {
+		text += " @plugin=cacheurl.so @pparam=" + GetConfigFile(CacheUrlPrefix, ds.Name)
+	}
+	text += getCacheKeyRemap(ds.Params[CacheKeyConfigFile])
+	text += getConfRemap(ds.Name, ds.Params[RecordsConfigFile])
+	if ds.RegexRemap != nil && *ds.RegexRemap != "" {
+		text += " @plugin=regex_remap.so @pparam=" + GetConfigFile(RegexRemapPrefix, ds.Name)
+	}
+	if ds.RangeRequestHandling == RangeRequestHandlingBackgroundFetch {
+		text += " @plugin=background_fetch.so @pparam=bg_fetch.config"
+	} else if ds.RangeRequestHandling == RangeRequestHandlingCacheRangeRequest {
+		text += " @plugin=cache_range_requests.so "
+	}
+	if ds.RemapText != nil {
+		text += " " + *ds.RemapText
+	}
+	if ds.FQPacingRate > 0 {
+		text += " @plugin=fq_pacing.so @pparam=--rate=" + strconv.Itoa(ds.FQPacingRate)
+	}
+	return text + "\n"
+}
+
+// getCacheKeyRemap returns the cachekey plugin text for the given delivery service cachekey.config parameters, or the empty string if there are none.
+func getCacheKeyRemap(params map[string]string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	names := []string{}
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	text := " @plugin=cachekey.so"
+	for _, name := range names {
+		text += " @pparam=--" + name + "=" + params[name]
+	}
+	return text
+}
+
+// getConfRemap returns conf_remap plugin text overriding ATS records.config settings for the given delivery service records.config parameters.
+// Parameters must be of the records.config form, e.g. name "CONFIG proxy.config.foo" and value "INT 1", and malformed parameters are skipped.
+func getConfRemap(dsName string, params map[string]string) string {
+	names := []string{}
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	text := ""
+	for _, name := range names {
+		nameFields := strings.Fields(name)
+		if len(nameFields) < 2 || nameFields[0] != "CONFIG" {
+			log.Debugf("remap.config generation: delivery service '%v' records.config parameter '%v' did not match the known syntax for parameter names\n", dsName, name)
+			continue
+		}
+		valType, val := splitRecordsConfigValue(params[name])
+		if valType != "INT" && valType != "FLOAT" && valType != "STRING" {
+			log.Debugf("remap.config generation: delivery service '%v' records.config parameter '%v' did not match the known syntax for values\n", dsName, name)
+			continue
+		}
+		text += " @plugin=conf_remap.so @pparam=" + nameFields[1] + "=" + val
+	}
+	return text
+}
+
+// splitRecordsConfigValue splits a records.config parameter value, e.g. "STRING foo bar", into its type and the remaining value.
+func splitRecordsConfigValue(v string) (string, string) {
+	v = strings.TrimLeft(v, " \t\n")
+	i := strings.IndexAny(v, " \t\n")
+	if i < 0 {
+		return v, ""
+	}
+	return v[:i], strings.TrimLeft(v[i:], " \t\n")
+}
+
+// getQStringIgnoreRemap returns the remap plugin text to ignore the query string in the cache key.
+// ATS 6 and later use the cachekey plugin, which also needs to include the Range header when caching range requests, because ATS only lets the cache key be set once per transaction. Earlier versions use cacheurl.
+func getQStringIgnoreRemap(atsMajorVersion int, rangeRequestHandling int) string {
+	if atsMajorVersion < 6 {
+		return " @plugin=cacheurl.so @pparam=cacheurl_qstring.config"
+	}
+	text := " @plugin=cachekey.so @pparam=--separator= @pparam=--remove-all-params=true @pparam=--remove-path=true @pparam=--capture-prefix-uri=/^([^?]*)/$1/"
+	if rangeRequestHandling == RangeRequestHandlingCacheRangeRequest {
+		text += " @pparam=--include-headers=Range"
+	}
+	return text
+}
+
+func RemapConfigDSQuerySelect() string {
+	return `
+SELECT
+  ds.id,
+  ds.xml_id,
+  dt.name AS ds_type,
+  (SELECT o.protocol::text || '://' || o.fqdn || rtrim(concat(':', o.port::text), ':')
+    FROM origin o
+    WHERE o.deliveryservice = ds.id
+    AND o.is_primary) as org_server_fqdn,
+  ds.mid_header_rewrite,
+  ds.cacheurl,
+  COALESCE(ds.range_request_handling, ` + strconv.Itoa(RangeRequestHandlingDontCache) + `),
+  COALESCE(ds.qstring_ignore, ` + tc.QStringIgnoreUseInCacheKeyAndPassUp.String() + `),
+  ds.regex_remap,
+  r.pattern,
+  rt.name AS re_type,
+  cdn.domain_name,
+  COALESCE(ds.routing_name, ''),
+  COALESCE(ds.protocol, ` + strconv.Itoa(DSProtocolHTTP) + `),
+  ds.signing_algorithm,
+  COALESCE(ds.dscp, 0),
+  ds.edge_header_rewrite,
+  ds.remap_text,
+  COALESCE(ds.fq_pacing_rate, 0),
+  ds.profile
+FROM
+  deliveryservice ds
+  JOIN deliveryservice_regex dsr ON dsr.deliveryservice = ds.id
+  JOIN regex r ON dsr.regex = r.id
+  JOIN type as rt ON r.type = rt.id
+  JOIN type as dt ON ds.type = dt.id
+  JOIN cdn ON cdn.id = ds.cdn_id
+`
+}
+
+const RemapConfigDSQueryWhereEdge = `
+WHERE ds.id in (SELECT dss.deliveryservice FROM deliveryservice_server dss WHERE dss.server = $1)
+`
+
+const RemapConfigDSQueryWhereMid = `
+WHERE
+  cdn.name = $1
+  AND ds.id in (SELECT deliveryservice_server.deliveryservice FROM deliveryservice_server)
+  AND ds.active = true
+`
+
+const RemapConfigDSQueryOrder = `
+ORDER BY ds.id, re_type, dsr.set_number
+`
+
+// getRemapConfigDSDataForEdge returns the regex rows of all delivery services assigned to the given edge server.
+func getRemapConfigDSDataForEdge(tx *sql.Tx, serverID int) ([]RemapConfigDSData, error) {
+	dses, err := getRemapConfigDSData(tx, RemapConfigDSQuerySelect()+RemapConfigDSQueryWhereEdge+RemapConfigDSQueryOrder, []interface{}{serverID})
+	if err != nil {
+		return nil, err
+	}
+	return getRemapConfigDSParams(tx, dses)
+}
+
+// getRemapConfigDSDataForMid returns the regex rows of all active, assigned delivery services in the given CDN. Mids serve every delivery service in their CDN.
+func getRemapConfigDSDataForMid(tx *sql.Tx, cdnName tc.CDNName) ([]RemapConfigDSData, error) {
+	dses, err := getRemapConfigDSData(tx, RemapConfigDSQuerySelect()+RemapConfigDSQueryWhereMid+RemapConfigDSQueryOrder, []interface{}{cdnName})
+	if err != nil {
+		return nil, err
+	}
+	return getRemapConfigDSParams(tx, dses)
+}
+
+func getRemapConfigDSData(tx *sql.Tx, qry string, qryParams []interface{}) ([]RemapConfigDSData, error) {
+	rows, err := tx.Query(qry, qryParams...)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+	dses := []RemapConfigDSData{}
+	for rows.Next() {
+		d := RemapConfigDSData{}
+		if err := rows.Scan(&d.ID, &d.Name, &d.Type, &d.OriginFQDN, &d.MidHeaderRewrite, &d.CacheURL, &d.RangeRequestHandling, &d.QStringIgnore, &d.RegexRemap, &d.Pattern, &d.RegexType, &d.Domain, &d.RoutingName, &d.Protocol, &d.SigningAlgorithm, &d.DSCP, &d.EdgeHeaderRewrite, &d.RemapText, &d.FQPacingRate, &d.ProfileID); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		d.Type = tc.DSTypeFromString(string(d.Type))
+		d.RegexType = tc.DSMatchTypeFromString(string(d.RegexType))
+		dses = append(dses, d)
+	}
+	return dses, nil
+}
+
+// getRemapConfigDSParams sets the Params of the given delivery services from their profiles' cachekey.config and records.config parameters.
+func getRemapConfigDSParams(tx *sql.Tx, dses []RemapConfigDSData) ([]RemapConfigDSData, error) {
+	profileIDs := []int64{}
+	for _, ds := range dses {
+		if ds.ProfileID != nil {
+			profileIDs = append(profileIDs, int64(*ds.ProfileID))
+		}
+	}
+	if len(profileIDs) == 0 {
+		return dses, nil
+	}
+
+	qry := `
+SELECT
+  pp.profile,
+  p.config_file,
+  p.name,
+  p.value
+FROM
+  parameter p
+  JOIN profile_parameter pp ON pp.parameter = p.id
+WHERE
+  pp.profile = ANY($1)
+  AND p.config_file IN ('` + CacheKeyConfigFile + `', '` + RecordsConfigFile + `')
+`
+	rows, err := tx.Query(qry, pq.Array(profileIDs))
+	if err != nil {
+		return nil, errors.New("querying ds params: " + err.Error())
+	}
+	defer rows.Close()
+
+	profileParams := map[int]map[string]map[string]string{}
+	for rows.Next() {
+		profileID := 0
+		configFile := ""
+		name := ""
+		val := ""
+		if err := rows.Scan(&profileID, &configFile, &name, &val); err != nil {
+			return nil, errors.New("scanning ds params: " + err.Error())
+		}
+		if _, ok := profileParams[profileID]; !ok {
+			profileParams[profileID] = map[string]map[string]string{}
+		}
+		if _, ok := profileParams[profileID][configFile]; !ok {
+			profileParams[profileID][configFile] = map[string]string{}
+		}
+		profileParams[profileID][configFile][name] = val
+	}
+
+	for i, ds := range dses {
+		if ds.ProfileID != nil {
+			dses[i].Params = profileParams[*ds.ProfileID]
+		}
+	}
+	return dses, nil
+}
@@ -0,0 +1,296 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+)
+
+func TestMakeRemapDotConfigEdge(t *testing.T) {
+	server := &ServerInfo{HostName: "edge0", Port: 8080}
+	dses := []RemapConfigDSData{
+		{
+			Name:                 "ds0",
+			Type:                 tc.DSTypeHTTP,
+			OriginFQDN:           util.StrPtr("http://origin0.example"),
+			Pattern:              `.*\.ds0\..*`,
+			RegexType:            tc.DSMatchTypeHostRegex,
+			Domain:               "cdn.example",
+			Protocol:             DSProtocolHTTPAndHTTPS,
+			DSCP:                 8,
+			EdgeHeaderRewrite:    util.StrPtr("set-header X-Foo bar"),
+			SigningAlgorithm:     util.StrPtr(tc.SigningAlgorithmURLSig),
+			QStringIgnore:        int(tc.QStringIgnoreIgnoreInCacheKeyAndPassUp),
+			RangeRequestHandling: RangeRequestHandlingCacheRangeRequest,
+		},
+		{
+			Name:        "ds0",
+			Type:        tc.DSTypeHTTP,
+			OriginFQDN:  util.StrPtr("http://origin0.example"),
+			Pattern:     "/path/.*",
+			RegexType:   tc.DSMatchTypePathRegex,
+			Domain:      "cdn.example",
+			Protocol:    DSProtocolHTTPAndHTTPS,
+			DSCP:        8,
+			RoutingName: "cdn",
+		},
+		{
+			Name:                 "ds1",
+			Type:                 tc.DSTypeDNS,
+			OriginFQDN:           util.StrPtr("https://origin1.example:8443"),
+			Pattern:              `.*\.ds1\..*`,
+			RegexType:            tc.DSMatchTypeHostRegex,
+			Domain:               "cdn.example",
+			RoutingName:          "ccr",
+			Protocol:             DSProtocolHTTPS,
+			SigningAlgorithm:     util.StrPtr(SigningAlgorithmURISigning),
+			QStringIgnore:        int(tc.QStringIgnoreDrop),
+			RegexRemap:           util.StrPtr("^/foo /bar"),
+			RangeRequestHandling: RangeRequestHandlingBackgroundFetch,
+			RemapText:            util.StrPtr("@plugin=tslua.so @pparam=ds1.lua"),
+			FQPacingRate:         1000,
+			Params: map[string]map[string]string{
+				CacheKeyConfigFile: {"remove-all-params": "true", "include-headers": "X-Foo"},
+				RecordsConfigFile: {
+					"CONFIG proxy.config.http.negative_caching_enabled": "INT 1",
+					"CONFIG proxy.config.http.insert_response_via_str":  "BAD 2",
+					"LOCAL proxy.local.foo":                             "INT 3",
+				},
+			},
+		},
+		{
+			Name:       "ds2",
+			Type:       tc.DSTypeHTTPNoCache,
+			OriginFQDN: util.StrPtr("http://origin2.example"),
+			Pattern:    "ds2.example.net",
+			RegexType:  tc.DSMatchTypeHostRegex,
+			Protocol:   DSProtocolHTTP,
+			CacheURL:   util.StrPtr("http://(.*) http://$1"),
+		},
+		{
+			Name:      "anymap",
+			Type:      tc.DSTypeAnyMap,
+			Pattern:   "anymap.example.net",
+			RegexType: tc.DSMatchTypeHostRegex,
+			Protocol:  DSProtocolHTTP,
+			RemapText: util.StrPtr("map http://anymap.example.net/ http://anymap-origin.example.net/"),
+		},
+	}
+
+	expected := "map\thttp://ds2.example.net/     http://origin2.example/ @plugin=header_rewrite.so @pparam=dscp/set_dscp_0.config @plugin=cacheurl.so @pparam=cacheurl_ds2.config\n" +
+		"map\thttp://edge0.ds0.cdn.example:8080/     http://origin0.example/ @plugin=header_rewrite.so @pparam=dscp/set_dscp_8.config @plugin=header_rewrite.so @pparam=hdr_rw_ds0.config @plugin=url_sig.so @pparam=url_sig_ds0.config @plugin=cachekey.so @pparam=--separator= @pparam=--remove-all-params=true @pparam=--remove-path=true @pparam=--capture-prefix-uri=/^([^?]*)/$1/ @pparam=--include-headers=Range @plugin=cache_range_requests.so \n" +
+		"map\thttps://edge0.ds0.cdn.example/     http://origin0.example/ @plugin=header_rewrite.so @pparam=dscp/set_dscp_8.config @plugin=header_rewrite.so @pparam=hdr_rw_ds0.config @plugin=url_sig.so @pparam=url_sig_ds0.config @plugin=cachekey.so @pparam=--separator= @pparam=--remove-all-params=true @pparam=--remove-path=true @pparam=--capture-prefix-uri=/^([^?]*)/$1/ @pparam=--include-headers=Range @plugin=cache_range_requests.so \n" +
+		"map\thttps://ccr.ds1.cdn.example/     https://origin1.example:8443/ @plugin=header_rewrite.so @pparam=dscp/set_dscp_0.config @plugin=uri_signing.so @pparam=uri_signing_ds1.config @plugin=regex_remap.so @pparam=drop_qstring.config @plugin=cachekey.so @pparam=--include-headers=X-Foo @pparam=--remove-all-params=true @plugin=conf_remap.so @pparam=proxy.config.http.negative_caching_enabled=1 @plugin=regex_remap.so @pparam=regex_remap_ds1.config @plugin=background_fetch.so @pparam=bg_fetch.config @plugin=tslua.so @pparam=ds1.lua @plugin=fq_pacing.so @pparam=--rate=1000\n" +
+		"map http://anymap.example.net/ http://anymap-origin.example.net/\n"
+
+	if actual := makeRemapDotConfigEdge(server, 7, false, false, dses); actual != expected {
+		t.Errorf("makeRemapDotConfigEdge expected:\n%v\nactual:\n%v", expected, actual)
+	}
+
+	expectedDSCPRemap := "map\thttp://ds2.example.net/     http://origin2.example/ @plugin=dscp_remap.so @pparam=0 @plugin=cacheurl.so @pparam=cacheurl_ds2.config\n"
+	if actual := makeRemapDotConfigEdge(server, 7, true, false, dses[3:4]); actual != expectedDSCPRemap {
+		t.Errorf("makeRemapDotConfigEdge with dscp_remap expected:\n%v\nactual:\n%v", expectedDSCPRemap, actual)
+	}
+
+	expectedGlobalCacheURL := "map\thttp://edge0.ds0.cdn.example:8080/     http://origin0.example/ @plugin=header_rewrite.so @pparam=dscp/set_dscp_8.config @plugin=header_rewrite.so @pparam=hdr_rw_ds0.config @plugin=url_sig.so @pparam=url_sig_ds0.config @plugin=cache_range_requests.so \n"
+	ds0HTTP := dses[0]
+	ds0HTTP.Protocol = DSProtocolHTTP
+	if actual := makeRemapDotConfigEdge(server, 7, false, true, []RemapConfigDSData{ds0HTTP}); actual != expectedGlobalCacheURL {
+		t.Errorf("makeRemapDotConfigEdge with global cacheurl expected:\n%v\nactual:\n%v", expectedGlobalCacheURL, actual)
+	}
+}
+
+func TestMakeRemapDotConfigMid(t *testing.T) {
+	dses := []RemapConfigDSData{
+		{
+			Name:                 "ds0",
+			Type:                 tc.DSTypeHTTP,
+			OriginFQDN:           util.StrPtr("http://origin0.example"),
+			MidHeaderRewrite:     util.StrPtr("set-header X-Foo bar"),
+			QStringIgnore:        int(tc.QStringIgnoreIgnoreInCacheKeyAndPassUp),
+			RangeRequestHandling: RangeRequestHandlingCacheRangeRequest,
+			Params: map[string]map[string]string{
+				RecordsConfigFile: {"CONFIG proxy.config.http.cache.required_headers": "INT 0"},
+			},
+		},
+		{
+			Name:             "ds0",
+			Type:             tc.DSTypeHTTP,
+			OriginFQDN:       util.StrPtr("http://origin0.example"),
+			MidHeaderRewrite: util.StrPtr("set-header X-Foo bar"),
+		},
+		{
+			Name:       "ds1",
+			Type:       tc.DSTypeHTTPLive,
+			OriginFQDN: util.StrPtr("http://origin1.example"),
+			CacheURL:   util.StrPtr("http://(.*) http://$1"),
+		},
+		{
+			Name:       "ds2",
+			Type:       tc.DSTypeDNSLiveNational,
+			OriginFQDN: util.StrPtr("http://origin2.example"),
+			CacheURL:   util.StrPtr("http://(.*) http://$1"),
+		},
+		{
+			Name:       "ds3",
+			Type:       tc.DSTypeHTTP,
+			OriginFQDN: util.StrPtr("http://origin3.example"),
+		},
+	}
+
+	expected := "map http://origin0.example http://origin0.example @plugin=header_rewrite.so @pparam=hdr_rw_mid_ds0.config @plugin=cacheurl.so @pparam=cacheurl_qstring.config @plugin=cache_range_requests.so @plugin=conf_remap.so @pparam=proxy.config.http.cache.required_headers=0\n" +
+		"map http://origin2.example http://origin2.example @plugin=cacheurl.so @pparam=cacheurl_ds2.config\n"
+
+	if actual := makeRemapDotConfigMid(5, dses); actual != expected {
+		t.Errorf("makeRemapDotConfigMid expected:\n%v\nactual:\n%v", expected, actual)
+	}
+}
+
+func TestSplitRecordsConfigValue(t *testing.T) {
+	type testCase struct {
+		value       string
+		expectedTyp string
+		expectedVal string
+	}
+	testCases := []testCase{
+		{"INT 1", "INT", "1"},
+		{"  STRING  foo bar", "STRING", "foo bar"},
+		{"FLOAT", "FLOAT", ""},
+		{"", "", ""},
+	}
+	for _, c := range testCases {
+		typ, val := splitRecordsConfigValue(c.value)
+		if typ != c.expectedTyp || val != c.expectedVal {
+			t.Errorf("splitRecordsConfigValue(%q) expected (%q, %q) actual (%q, %q)", c.value, c.expectedTyp, c.expectedVal, typ, val)
+		}
+	}
+}
+
+// remapPerlFixture is a test case of testdata/remap_dot_config.json, whose remap.config was generated by the Perl remap_dot_config with testdata/remap_dot_config.pl.
+// Delivery services use the Perl database column names.
+type remapPerlFixture struct {
+	Name   string `json:"name"`
+	Server struct {
+		HostName string `json:"host_name"`
+		Type     string `json:"type"`
+		TCPPort  int    `json:"tcp_port"`
+	} `json:"server"`
+	ATSMajorVersion   int  `json:"ats_major_version"`
+	DSCPRemap         bool `json:"dscp_remap"`
+	GlobalCacheURL    bool `json:"global_cacheurl"`
+	ProfileParameters map[int][]struct {
+		ConfigFile string `json:"config_file"`
+		Name       string `json:"name"`
+		Value      string `json:"value"`
+	} `json:"profile_parameters"`
+	DeliveryServices []struct {
+		XMLID                string  `json:"xml_id"`
+		Type                 string  `json:"ds_type"`
+		OrgServerFQDN        *string `json:"org_server_fqdn"`
+		MidHeaderRewrite     *string `json:"mid_header_rewrite"`
+		CacheURL             *string `json:"cacheurl"`
+		RangeRequestHandling int     `json:"range_request_handling"`
+		QStringIgnore        int     `json:"qstring_ignore"`
+		RegexRemap           *string `json:"regex_remap"`
+		Pattern              string  `json:"pattern"`
+		RegexType            string  `json:"re_type"`
+		DomainName           string  `json:"domain_name"`
+		RoutingName          string  `json:"routing_name"`
+		Protocol             int     `json:"protocol"`
+		SigningAlgorithm     *string `json:"signing_algorithm"`
+		DSCP                 int     `json:"dscp"`
+		EdgeHeaderRewrite    *string `json:"edge_header_rewrite"`
+		RemapText            *string `json:"remap_text"`
+		FQPacingRate         int     `json:"fq_pacing_rate"`
+		Profile              *int    `json:"profile"`
+	} `json:"deliveryservices"`
+}
+
+// TestRemapDotConfigPerl verifies the generated remap.config is byte-identical to the Perl remap.config, without the header comment.
+func TestRemapDotConfigPerl(t *testing.T) {
+	fixturesJSON, err := ioutil.ReadFile(filepath.Join("testdata", "remap_dot_config.json"))
+	if err != nil {
+		t.Fatalf("reading fixtures: %v", err)
+	}
+	fixtures := []remapPerlFixture{}
+	if err := json.Unmarshal(fixturesJSON, &fixtures); err != nil {
+		t.Fatalf("unmarshalling fixtures: %v", err)
+	}
+
+	for _, fixture := range fixtures {
+		expected, err := ioutil.ReadFile(filepath.Join("testdata", "remap_dot_config_"+fixture.Name+".config"))
+		if err != nil {
+			t.Fatalf("reading fixture '%v' Perl remap.config: %v", fixture.Name, err)
+		}
+
+		dses := []RemapConfigDSData{}
+		for _, fds := range fixture.DeliveryServices {
+			ds := RemapConfigDSData{
+				Name:                 fds.XMLID,
+				Type:                 tc.DSTypeFromString(fds.Type),
+				OriginFQDN:           fds.OrgServerFQDN,
+				MidHeaderRewrite:     fds.MidHeaderRewrite,
+				CacheURL:             fds.CacheURL,
+				RangeRequestHandling: fds.RangeRequestHandling,
+				QStringIgnore:        fds.QStringIgnore,
+				RegexRemap:           fds.RegexRemap,
+				Pattern:              fds.Pattern,
+				RegexType:            tc.DSMatchTypeFromString(fds.RegexType),
+				Domain:               fds.DomainName,
+				RoutingName:          fds.RoutingName,
+				Protocol:             fds.Protocol,
+				SigningAlgorithm:     fds.SigningAlgorithm,
+				DSCP:                 fds.DSCP,
+				EdgeHeaderRewrite:    fds.EdgeHeaderRewrite,
+				RemapText:            fds.RemapText,
+				FQPacingRate:         fds.FQPacingRate,
+				ProfileID:            fds.Profile,
+			}
+			if fds.Profile != nil {
+				ds.Params = map[string]map[string]string{}
+				for _, param := range fixture.ProfileParameters[*fds.Profile] {
+					if ds.Params[param.ConfigFile] == nil {
+						ds.Params[param.ConfigFile] = map[string]string{}
+					}
+					ds.Params[param.ConfigFile][param.Name] = param.Value
+				}
+			}
+			dses = append(dses, ds)
+		}
+
+		actual := ""
+		if strings.HasPrefix(fixture.Server.Type, tc.MidTypePrefix) {
+			actual = makeRemapDotConfigMid(fixture.ATSMajorVersion, dses)
+		} else {
+			server := &ServerInfo{HostName: fixture.Server.HostName, Port: fixture.Server.TCPPort, Type: fixture.Server.Type}
+			actual = makeRemapDotConfigEdge(server, fixture.ATSMajorVersion, fixture.DSCPRemap, fixture.GlobalCacheURL, dses)
+		}
+		if actual != string(expected) {
+			t.Errorf("remap.config fixture '%v' expected Perl output:\n%v\nactual:\n%v", fixture.Name, string(expected), actual)
+		}
+	}
+}
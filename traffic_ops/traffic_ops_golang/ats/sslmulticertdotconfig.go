@@ -0,0 +1,157 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/apache/trafficcontrol/lib/go-log"
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/api"
+)
+
+const SSLMultiCertConfigFile = "ssl_multicert.config"
+
+// SSLMultiCertDS is the data of a delivery service needed to build its ssl_multicert.config line.
+// The Pattern and SetNumber are of the delivery service's first host regex, if it has one.
+type SSLMultiCertDS struct {
+	Name        tc.DeliveryServiceName
+	Type        tc.DSType
+	Protocol    int
+	RoutingName string
+	Domain      string
+	Pattern     *string
+	SetNumber   int
+}
+
+func GetSSLMultiCertDotConfig(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"cdn-name-or-id"}, nil)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	cdnName, userErr, sysErr, errCode := getCDNNameFromNameOrID(inf.Tx.Tx, inf.Params["cdn-name-or-id"])
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+
+	text, err := headerComment(inf.Tx.Tx, "CDN "+cdnName)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting header comment: "+err.Error()))
+		return
+	}
+
+	dses, err := getSSLMultiCertDSes(inf.Tx.Tx, tc.CDNName(cdnName))
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting ssl_multicert.config delivery services: "+err.Error()))
+		return
+	}
+	text += makeSSLMultiCertDotConfig(dses)
+
+	w.Header().Set(tc.ContentType, tc.ContentTypeTextPlain)
+	w.Write([]byte(text))
+}
+
+// makeSSLMultiCertDotConfig returns the ssl_multicert.config text for the given delivery services.
+// The certificate and key of each delivery service are named after the host of its first example URL. Delivery services without a host regex are skipped.
+func makeSSLMultiCertDotConfig(dses []SSLMultiCertDS) string {
+	text := ""
+	for _, ds := range dses {
+		host, ok := getSSLMultiCertHost(ds)
+		if !ok {
+			log.Warnln("delivery service " + string(ds.Name) + " has no host regex, skipping ssl_multicert.config line")
+			continue
+		}
+		keyName := host + ".key"
+		certName := strings.Replace(host, ".", "_", -1) + "_cert.cer"
+		text += "ssl_cert_name=" + certName + "\t ssl_key_name=" + keyName + "\n"
+	}
+	return text
+}
+
+// getSSLMultiCertHost returns the host of the first example URL of the given delivery service, and whether it has one.
+func getSSLMultiCertHost(ds SSLMultiCertDS) (string, bool) {
+	if ds.Pattern == nil {
+		return "", false
+	}
+	if ds.SetNumber != 0 {
+		return *ds.Pattern, true
+	}
+	host := strings.Replace(*ds.Pattern, `\`, "", -1)
+	host = strings.Replace(host, `.*`, "", -1)
+	host = strings.Replace(host, `.`, "", -1)
+	return ds.RoutingName + "." + host + "." + ds.Domain, true
+}
+
+// getSSLMultiCertDSes returns the HTTPS delivery services in the given CDN, excluding steering delivery services, whose certificates are not on caches.
+func getSSLMultiCertDSes(tx *sql.Tx, cdn tc.CDNName) ([]SSLMultiCertDS, error) {
+	qry := `
+SELECT
+  ds.xml_id,
+  t.name,
+  ds.protocol,
+  COALESCE(ds.routing_name, ''),
+  cdn.domain_name,
+  hr.pattern,
+  COALESCE(hr.set_number, 0)
+FROM
+  deliveryservice ds
+  JOIN type t ON t.id = ds.type
+  JOIN cdn ON cdn.id = ds.cdn_id
+  LEFT JOIN LATERAL (
+    SELECT r.pattern, dsr.set_number
+    FROM deliveryservice_regex dsr
+    JOIN regex r ON r.id = dsr.regex
+    JOIN type rt ON rt.id = r.type
+    WHERE dsr.deliveryservice = ds.id
+    AND rt.name = $2
+    ORDER BY dsr.set_number
+    LIMIT 1
+  ) hr ON true
+WHERE
+  cdn.name = $1
+  AND ds.protocol > ` + strconv.Itoa(DSProtocolHTTP) + `
+  AND t.name NOT LIKE '%STEERING%'
+ORDER BY ds.xml_id
+`
+	rows, err := tx.Query(qry, cdn, tc.DSMatchTypeHostRegex.String())
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	dses := []SSLMultiCertDS{}
+	for rows.Next() {
+		ds := SSLMultiCertDS{}
+		if err := rows.Scan(&ds.Name, &ds.Type, &ds.Protocol, &ds.RoutingName, &ds.Domain, &ds.Pattern, &ds.SetNumber); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		ds.Type = tc.DSTypeFromString(string(ds.Type))
+		dses = append(dses, ds)
+	}
+	return dses, nil
+}
@@ -0,0 +1,112 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const StorageConfigFile = "storage.config"
+const VolumeConfigFile = "volume.config"
+
+const StorageParamDrivePrefix = "Drive_Prefix"
+const StorageParamDriveLetters = "Drive_Letters"
+const StorageParamRAMDrivePrefix = "RAM_Drive_Prefix"
+const StorageParamRAMDriveLetters = "RAM_Drive_Letters"
+const StorageParamSSDDrivePrefix = "SSD_Drive_Prefix"
+const StorageParamSSDDriveLetters = "SSD_Drive_Letters"
+
+// storageVolumePrefixes are the storage.config drive prefix and letter parameters, in the order their volumes are numbered.
+var storageVolumePrefixes = [][2]string{
+	{StorageParamDrivePrefix, StorageParamDriveLetters},
+	{StorageParamRAMDrivePrefix, StorageParamRAMDriveLetters},
+	{StorageParamSSDDrivePrefix, StorageParamSSDDriveLetters},
+}
+
+func GetStorageDotConfig(w http.ResponseWriter, r *http.Request) {
+	serveProfileConfig(w, r, StorageConfigFile, getStorageDotConfig)
+}
+
+func getStorageDotConfig(tx *sql.Tx, profileID ProfileID, profileName string) (string, error) {
+	params, err := getProfileParamData(tx, profileID, StorageConfigFile)
+	if err != nil {
+		return "", errors.New("getting profile params: " + err.Error())
+	}
+	return makeStorageDotConfig(params), nil
+}
+
+// makeStorageDotConfig returns the storage.config text for the given storage.config profile parameters.
+// Each drive type with a prefix parameter gets its own volume, numbered in the order disk, RAM, SSD.
+func makeStorageDotConfig(params map[string]string) string {
+	text := ""
+	nextVolume := 1
+	for _, prefix := range storageVolumePrefixes {
+		drivePrefix, ok := params[prefix[0]]
+		if !ok {
+			continue
+		}
+		letters := strings.Split(params[prefix[1]], ",")
+		sort.Strings(letters)
+		for _, letter := range letters {
+			text += drivePrefix + letter + " volume=" + strconv.Itoa(nextVolume) + "\n"
+		}
+		nextVolume++
+	}
+	return text
+}
+
+func GetVolumeDotConfig(w http.ResponseWriter, r *http.Request) {
+	serveProfileConfig(w, r, VolumeConfigFile, getVolumeDotConfig)
+}
+
+func getVolumeDotConfig(tx *sql.Tx, profileID ProfileID, profileName string) (string, error) {
+	params, err := getProfileParamData(tx, profileID, StorageConfigFile)
+	if err != nil {
+		return "", errors.New("getting profile params: " + err.Error())
+	}
+	return makeVolumeDotConfig(params), nil
+}
+
+// makeVolumeDotConfig returns the volume.config text for the given storage.config profile parameters.
+// Volumes are forced, and the space is divided equally between them.
+func makeVolumeDotConfig(params map[string]string) string {
+	numVolumes := 0
+	for _, prefix := range storageVolumePrefixes {
+		if _, ok := params[prefix[0]]; ok {
+			numVolumes++
+		}
+	}
+
+	text := "# TRAFFIC OPS NOTE: This is running with forced volumes - the size is irrelevant\n"
+	nextVolume := 1
+	for _, prefix := range storageVolumePrefixes {
+		if _, ok := params[prefix[0]]; !ok {
+			continue
+		}
+		text += "volume=" + strconv.Itoa(nextVolume) + " scheme=http size=" + strconv.Itoa(100/numVolumes) + "%\n"
+		nextVolume++
+	}
+	return text
+}
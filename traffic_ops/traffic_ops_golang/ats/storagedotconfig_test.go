@@ -0,0 +1,52 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"testing"
+)
+
+func TestMakeStorageDotConfig(t *testing.T) {
+	params := map[string]string{
+		StorageParamDrivePrefix:     "/dev/sd",
+		StorageParamDriveLetters:    "c,b,d",
+		StorageParamSSDDrivePrefix:  "/dev/nvme",
+		StorageParamSSDDriveLetters: "0n1",
+	}
+	expected := "/dev/sdb volume=1\n/dev/sdc volume=1\n/dev/sdd volume=1\n/dev/nvme0n1 volume=2\n"
+	if actual := makeStorageDotConfig(params); actual != expected {
+		t.Errorf("makeStorageDotConfig expected '%v' actual '%v'", expected, actual)
+	}
+}
+
+func TestMakeVolumeDotConfig(t *testing.T) {
+	params := map[string]string{
+		StorageParamDrivePrefix:    "/dev/sd",
+		StorageParamRAMDrivePrefix: "/dev/ram",
+		StorageParamSSDDrivePrefix: "/dev/nvme",
+	}
+	expected := "# TRAFFIC OPS NOTE: This is running with forced volumes - the size is irrelevant\n" +
+		"volume=1 scheme=http size=33%\n" +
+		"volume=2 scheme=http size=33%\n" +
+		"volume=3 scheme=http size=33%\n"
+	if actual := makeVolumeDotConfig(params); actual != expected {
+		t.Errorf("makeVolumeDotConfig expected '%v' actual '%v'", expected, actual)
+	}
+}
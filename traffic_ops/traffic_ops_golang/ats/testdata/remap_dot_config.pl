@@ -0,0 +1,192 @@
+#!/usr/bin/env perl
+#
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+
+# Generates the remap.config fixtures used by the Go remap.config tests, by running the Perl
+# API::Configs::ApacheTrafficServer::remap_dot_config against the delivery services in
+# remap_dot_config.json, in place of the database.
+#
+# Run from this directory, with only core Perl modules: perl remap_dot_config.pl
+#
+# The header comment is left out, because it contains the generation time.
+# Perl iterates hashes in a random order, so the fixtures must have at most one cachekey.config and one
+# valid records.config parameter per delivery service profile for the output to be reproducible.
+
+use strict;
+use warnings;
+use File::Basename;
+use File::Spec;
+use JSON::PP;
+
+my $dir = dirname( File::Spec->rel2abs(__FILE__) );
+my $lib = File::Spec->catdir( $dir, '..', '..', '..', 'app', 'lib' );
+
+# Stub the modules ApacheTrafficServer.pm uses, which remap_dot_config doesn't need.
+BEGIN {
+	for my $module (qw(UI/Utils.pm Mojo/Base.pm Date/Manip.pm NetAddr/IP.pm UI/DeliveryService.pm JSON.pm API/DeliveryService/KeysUrlSig.pm URI.pm)) {
+		$INC{$module} = __FILE__;
+	}
+}
+
+package Mojo::Base;
+
+sub import {
+	my $class = shift;
+	my $base  = shift;
+	my $caller = caller;
+	if ( defined($base) && $base !~ /^-/ ) {
+		no strict 'refs';
+		push @{"${caller}::ISA"}, $base;
+	}
+	strict->import;
+	warnings->import;
+}
+
+package Mojolicious::Controller;
+
+package API::DeliveryService::KeysUrlSig;
+
+sub import {
+	my $caller = caller;
+	no strict 'refs';
+	*{"${caller}::URL_SIG_KEYS_BUCKET"} = sub { 'url_sig_keys' };
+}
+
+# FakeObj is a database row, with an accessor for each key.
+package FakeObj;
+
+our $AUTOLOAD;
+
+sub new {
+	my ( $class, $fields ) = @_;
+	return bless { %{$fields} }, $class;
+}
+
+sub AUTOLOAD {
+	my $self = shift;
+	my $name = $AUTOLOAD;
+	$name =~ s/.*:://;
+	return if $name eq 'DESTROY';
+	die "no field '$name'" if !exists( $self->{$name} );
+	return $self->{$name};
+}
+
+# FakeResultSet returns the given rows.
+package FakeResultSet;
+
+sub new {
+	my ( $class, $rows ) = @_;
+	return bless { rows => [ @{$rows} ] }, $class;
+}
+
+sub next {
+	my $self = shift;
+	return shift @{ $self->{rows} };
+}
+
+# FakeDB serves the delivery service and delivery service profile parameter queries of remap_ds_data.
+package FakeDB;
+
+sub new {
+	my ( $class, $case ) = @_;
+	return bless { case => $case }, $class;
+}
+
+sub resultset {
+	my ( $self, $name ) = @_;
+	return bless { db => $self, name => $name }, 'FakeResultSource';
+}
+
+package FakeResultSource;
+
+sub search {
+	my ( $self, $cond ) = @_;
+	my $case = $self->{db}->{case};
+	if ( $self->{name} eq 'DeliveryServiceInfoForServerList' || $self->{name} eq 'DeliveryServiceInfoForDomainList' ) {
+		return FakeResultSet->new( [ map { FakeObj->new($_) } @{ $case->{deliveryservices} } ] );
+	}
+	if ( $self->{name} eq 'ProfileParameter' && defined( $cond->{profile} ) ) {
+		my $params = $case->{profile_parameters}->{ $cond->{profile} } || [];
+		return FakeResultSet->new( [ map { FakeObj->new( { parameter => FakeObj->new($_) } ) } @{$params} ] );
+	}
+	die "unexpected query of " . $self->{name};
+}
+
+package FakeLog;
+
+sub new { return bless {}, shift }
+sub debug { }
+sub error { }
+sub log { return shift }
+
+package main;
+
+# get_qstring_ignore_remap is the only UI::DeliveryService function used which doesn't need the database, so load it alone.
+my $ds_src = do {
+	local $/;
+	open( my $fh, '<', File::Spec->catfile( $lib, 'UI', 'DeliveryService.pm' ) ) or die $!;
+	<$fh>;
+};
+my ($ds_constants) = $ds_src =~ /^(use constant \{.*?^\};)/ms or die "UI::DeliveryService constants not found";
+my ($ds_qstring)   = $ds_src =~ /^(sub get_qstring_ignore_remap \{.*?^\})/ms or die "UI::DeliveryService::get_qstring_ignore_remap not found";
+eval "package UI::DeliveryService; $ds_constants $ds_qstring 1;" or die $@;
+
+require File::Spec->catfile( $lib, 'API', 'Configs', 'ApacheTrafficServer.pm' );
+
+my $json = do {
+	local $/;
+	open( my $fh, '<', File::Spec->catfile( $dir, 'remap_dot_config.json' ) ) or die $!;
+	<$fh>;
+};
+
+foreach my $case ( @{ JSON::PP->new->decode($json) } ) {
+	my $db = FakeDB->new($case);
+
+	no warnings qw(once redefine);
+	local *API::Configs::ApacheTrafficServer::db             = sub { return $db };
+	local *API::Configs::ApacheTrafficServer::app            = sub { return FakeLog->new };
+	local *API::Configs::ApacheTrafficServer::header_comment = sub { return "" };
+	local *API::Configs::ApacheTrafficServer::param_data     = sub {
+		my ( $self, $server_obj, $filename ) = @_;
+		die "unexpected param_data of $filename" if $filename ne 'package';
+		return $case->{dscp_remap} ? { dscp_remap => 1 } : undef;
+	};
+	local *API::Configs::ApacheTrafficServer::profile_param_value = sub {
+		my ( $self, $pid, $file, $param_name, $default ) = @_;
+		die "unexpected profile_param_value of $file $param_name" if $file ne 'cacheurl.config' || $param_name ne 'location';
+		return $case->{global_cacheurl} ? '/opt/trafficserver/etc/trafficserver/' : $default;
+	};
+	local *UI::DeliveryService::get_ats_major_version = sub { return $case->{ats_major_version} };
+
+	my $server_obj = FakeObj->new(
+		{
+			id          => 1,
+			host_name   => $case->{server}->{host_name},
+			domain_name => 'example',
+			tcp_port    => $case->{server}->{tcp_port},
+			type        => FakeObj->new( { name => $case->{server}->{type} } ),
+			profile     => FakeObj->new( { id => 1 } ),
+			cdn         => FakeObj->new( { name => 'cdn' } ),
+		}
+	);
+	my $self = bless {}, 'API::Configs::ApacheTrafficServer';
+	my $text = $self->remap_dot_config( $server_obj, 'remap.config' );
+
+	my $file = File::Spec->catfile( $dir, 'remap_dot_config_' . $case->{name} . '.config' );
+	open( my $fh, '>', $file ) or die $!;
+	print $fh $text;
+	close($fh);
+	print "wrote $file\n";
+}
@@ -383,17 +383,26 @@ func (cg *TOCacheGroup) Read() ([]interface{}, error, error, int) {
 	// Query Parameters to Database Query column mappings
 	// see the fields mapped in the SQL query
 	queryParamsToQueryCols := map[string]dbhelpers.WhereColumnInfo{
-		"id":        dbhelpers.WhereColumnInfo{"cachegroup.id", api.IsInt},
-		"name":      dbhelpers.WhereColumnInfo{"cachegroup.name", nil},
-		"shortName": dbhelpers.WhereColumnInfo{"short_name", nil},
-		"type":      dbhelpers.WhereColumnInfo{"cachegroup.type", nil},
+		"id":          dbhelpers.WhereColumnInfo{"cachegroup.id", api.IsInt},
+		"name":        dbhelpers.WhereColumnInfo{"cachegroup.name", nil},
+		"shortName":   dbhelpers.WhereColumnInfo{"short_name", nil},
+		"type":        dbhelpers.WhereColumnInfo{"cachegroup.type", nil},
+		"lastUpdated": dbhelpers.WhereColumnInfo{"cachegroup.last_updated", dbhelpers.IsTime},
 	}
-	where, orderBy, queryValues, errs := dbhelpers.BuildWhereAndOrderBy(cg.ReqInfo.Params, queryParamsToQueryCols)
+	where, orderBy, pagination, queryValues, errs := dbhelpers.BuildWhereAndOrderByAndPagination(cg.ReqInfo.Params, queryParamsToQueryCols)
 	if len(errs) > 0 {
 		return nil, util.JoinErrs(errs), nil, http.StatusBadRequest
 	}
 
-	query := SelectQuery() + where + orderBy
+	if pagination != "" {
+		count, err := dbhelpers.GetCount(cg.ReqInfo.Tx, SelectQuery()+where, queryValues)
+		if err != nil {
+			return nil, nil, errors.New("cachegroup read: " + err.Error()), http.StatusInternalServerError
+		}
+		cg.ReqInfo.Count = &count
+	}
+
+	query := SelectQuery() + where + orderBy + pagination
 	rows, err := cg.ReqInfo.Tx.NamedQuery(query, queryValues)
 	if err != nil {
 		return nil, nil, errors.New("cachegroup read: querying: " + err.Error()), http.StatusInternalServerError
@@ -49,6 +49,7 @@ func (v *TOCDN) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 		"dnssecEnabled": dbhelpers.WhereColumnInfo{"dnssec_enabled", nil},
 		"id":            dbhelpers.WhereColumnInfo{"id", api.IsInt},
 		"name":          dbhelpers.WhereColumnInfo{"name", nil},
+		"lastUpdated":   dbhelpers.WhereColumnInfo{"c.last_updated", dbhelpers.IsTime},
 	}
 }
 func (v *TOCDN) UpdateQuery() string { return updateQuery() }
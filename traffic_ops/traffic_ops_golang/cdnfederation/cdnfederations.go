@@ -54,7 +54,8 @@ func (v *TOCDNFederation) SelectQuery() string {
 }
 func (v *TOCDNFederation) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 	cols := map[string]dbhelpers.WhereColumnInfo{
-		"id": dbhelpers.WhereColumnInfo{Column: "federation.id", Checker: api.IsInt},
+		"id":          dbhelpers.WhereColumnInfo{Column: "federation.id", Checker: api.IsInt},
+		"lastUpdated": dbhelpers.WhereColumnInfo{Column: "federation.last_updated", Checker: dbhelpers.IsTime},
 	}
 	if v.ID == nil {
 		cols["name"] = dbhelpers.WhereColumnInfo{Column: "cdn.name", Checker: nil}
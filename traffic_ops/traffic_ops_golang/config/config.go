@@ -96,6 +96,9 @@ type ConfigTrafficOpsGolang struct {
 	// CRConfigEmulateOldPath is whether to emulate the legacy CRConfig request path when generating a new CRConfig. This primarily exists in the event a tool relies on the legacy path '/tools/write_crconfig'.
 	// Deprecated: will be removed in the next major version.
 	CRConfigEmulateOldPath bool `json:"crconfig_emulate_old_path"`
+
+	// SnapshotHistoryRetention is the number of CRConfig and monitoring snapshots kept per CDN, including the current one. If 0, DefaultSnapshotHistoryRetention is used. If negative, all snapshots are kept.
+	SnapshotHistoryRetention int `json:"snapshot_history_retention"`
 }
 
 // ConfigDatabase reflects the structure of the database.conf file
@@ -122,6 +125,7 @@ type ConfigLDAP struct {
 
 const DefaultLDAPTimeoutSecs = 60
 const DefaultDBQueryTimeoutSecs = 20
+const DefaultSnapshotHistoryRetention = 30
 
 // ErrorLog - critical messages
 func (c Config) ErrorLog() log.LogLocation {
@@ -282,6 +286,9 @@ func ParseConfig(cfg Config) (Config, error) {
 	if cfg.DBQueryTimeoutSeconds == 0 {
 		cfg.DBQueryTimeoutSeconds = DefaultDBQueryTimeoutSecs
 	}
+	if cfg.SnapshotHistoryRetention == 0 {
+		cfg.SnapshotHistoryRetention = DefaultSnapshotHistoryRetention
+	}
 
 	invalidTOURLStr := ""
 	var err error
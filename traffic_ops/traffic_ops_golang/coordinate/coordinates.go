@@ -44,8 +44,9 @@ func (v *TOCoordinate) NewReadObj() interface{}       { return &tc.CoordinateNul
 func (v *TOCoordinate) SelectQuery() string           { return selectQuery() }
 func (v *TOCoordinate) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 	return map[string]dbhelpers.WhereColumnInfo{
-		"id":   dbhelpers.WhereColumnInfo{"id", api.IsInt},
-		"name": dbhelpers.WhereColumnInfo{"name", nil},
+		"id":          dbhelpers.WhereColumnInfo{"id", api.IsInt},
+		"name":        dbhelpers.WhereColumnInfo{"name", nil},
+		"lastUpdated": dbhelpers.WhereColumnInfo{"c.last_updated", dbhelpers.IsTime},
 	}
 }
 func (v *TOCoordinate) UpdateQuery() string { return updateQuery() }
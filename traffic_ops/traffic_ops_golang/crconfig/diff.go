@@ -0,0 +1,160 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/api"
+)
+
+// DiffHandler serves the difference between the current CRConfig snapshot and the CRConfig which would be created by snapshotting now.
+func DiffHandler(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"cdn"}, nil)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	snapshot, cdnExists, err := GetSnapshot(inf.Tx.Tx, inf.Params["cdn"])
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("getting snapshot: "+err.Error()))
+		return
+	}
+	if !cdnExists {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("CDN not found"), nil)
+		return
+	}
+
+	crConfig, err := Make(inf.Tx.Tx, inf.Params["cdn"], inf.User.UserName, r.Host, r.URL.Path, inf.Config.Version, inf.Config.CRConfigUseRequestHost, inf.Config.CRConfigEmulateOldPath)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, err)
+		return
+	}
+
+	diff, err := Diff([]byte(snapshot), crConfig)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("diffing snapshot: "+err.Error()))
+		return
+	}
+	api.WriteResp(w, r, diff)
+}
+
+// Diff returns the difference between the given snapshotted CRConfig JSON and the given new CRConfig.
+// The CRConfigs are compared as JSON, so the difference is exactly what Traffic Router and Traffic Monitor would see.
+func Diff(oldCRConfig []byte, newCRConfig *tc.CRConfig) (tc.CRConfigDiff, error) {
+	newBts, err := json.Marshal(newCRConfig)
+	if err != nil {
+		return tc.CRConfigDiff{}, errors.New("marshalling new CRConfig: " + err.Error())
+	}
+	return DiffJSON(oldCRConfig, newBts)
+}
+
+// DiffJSON returns the difference between the given CRConfig JSON documents.
+func DiffJSON(oldCRConfig []byte, newCRConfig []byte) (tc.CRConfigDiff, error) {
+	oldSections := map[string]interface{}{}
+	if err := json.Unmarshal(oldCRConfig, &oldSections); err != nil {
+		return tc.CRConfigDiff{}, errors.New("unmarshalling old CRConfig: " + err.Error())
+	}
+	newSections := map[string]interface{}{}
+	if err := json.Unmarshal(newCRConfig, &newSections); err != nil {
+		return tc.CRConfigDiff{}, errors.New("unmarshalling new CRConfig: " + err.Error())
+	}
+
+	return tc.CRConfigDiff{
+		Config:           diffSection(oldSections["config"], newSections["config"]),
+		ContentServers:   diffSection(oldSections["contentServers"], newSections["contentServers"]),
+		ContentRouters:   diffSection(oldSections["contentRouters"], newSections["contentRouters"]),
+		DeliveryServices: diffSection(oldSections["deliveryServices"], newSections["deliveryServices"]),
+		EdgeLocations:    diffSection(oldSections["edgeLocations"], newSections["edgeLocations"]),
+		RouterLocations:  diffSection(oldSections["trafficRouterLocations"], newSections["trafficRouterLocations"]),
+		Monitors:         diffSection(oldSections["monitors"], newSections["monitors"]),
+	}, nil
+}
+
+// diffSection returns the difference between the given CRConfig sections, which are expected to be JSON objects. A missing section is treated as empty.
+func diffSection(oldSection interface{}, newSection interface{}) tc.CRConfigSectionDiff {
+	diff := tc.CRConfigSectionDiff{
+		Added:   map[string]interface{}{},
+		Removed: map[string]interface{}{},
+		Changed: map[string][]tc.CRConfigFieldDiff{},
+	}
+	oldObjs, _ := oldSection.(map[string]interface{})
+	newObjs, _ := newSection.(map[string]interface{})
+
+	for name, newObj := range newObjs {
+		oldObj, ok := oldObjs[name]
+		if !ok {
+			diff.Added[name] = newObj
+			continue
+		}
+		if fields := diffFields("", oldObj, newObj); len(fields) > 0 {
+			diff.Changed[name] = fields
+		}
+	}
+	for name, oldObj := range oldObjs {
+		if _, ok := newObjs[name]; !ok {
+			diff.Removed[name] = oldObj
+		}
+	}
+	return diff
+}
+
+// diffFields returns the changed fields between the given JSON values, recursing into objects. Arrays are compared as a single value.
+// The fields are sorted by path.
+func diffFields(path string, oldVal interface{}, newVal interface{}) []tc.CRConfigFieldDiff {
+	oldObj, oldIsObj := oldVal.(map[string]interface{})
+	newObj, newIsObj := newVal.(map[string]interface{})
+	if !oldIsObj || !newIsObj {
+		if reflect.DeepEqual(oldVal, newVal) {
+			return nil
+		}
+		return []tc.CRConfigFieldDiff{{Field: path, Old: oldVal, New: newVal}}
+	}
+
+	keys := map[string]struct{}{}
+	for key := range oldObj {
+		keys[key] = struct{}{}
+	}
+	for key := range newObj {
+		keys[key] = struct{}{}
+	}
+	sortedKeys := []string{}
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	fields := []tc.CRConfigFieldDiff{}
+	for _, key := range sortedKeys {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "/" + key
+		}
+		fields = append(fields, diffFields(fieldPath, oldObj[key], newObj[key])...)
+	}
+	return fields
+}
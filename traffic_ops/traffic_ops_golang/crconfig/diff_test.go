@@ -0,0 +1,125 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/lib/go-util"
+)
+
+func TestDiff(t *testing.T) {
+	oldCRC := &tc.CRConfig{
+		Config: map[string]interface{}{
+			"domain_name": "cdn.example",
+			"ttls":        map[string]interface{}{"A": "3600", "AAAA": "3600"},
+			"removed":     "true",
+		},
+		ContentServers: map[string]tc.CRConfigTrafficOpsServer{
+			"edge0": {Profile: util.StrPtr("EDGE0"), Port: util.IntPtr(80)},
+			"edge1": {Profile: util.StrPtr("EDGE0")},
+		},
+		DeliveryServices: map[string]tc.CRConfigDeliveryService{
+			"ds0": {Protocol: &tc.CRConfigDeliveryServiceProtocol{AcceptHTTPS: false}},
+		},
+		EdgeLocations: map[string]tc.CRConfigLatitudeLongitude{
+			"cg0": {Lat: 1, Lon: 2},
+		},
+		Stats: tc.CRConfigStats{TMUser: util.StrPtr("old")},
+	}
+	oldBts, err := json.Marshal(oldCRC)
+	if err != nil {
+		t.Fatalf("marshalling old CRConfig: %v", err)
+	}
+
+	newCRC := &tc.CRConfig{
+		Config: map[string]interface{}{
+			"domain_name": "cdn.example",
+			"ttls":        map[string]interface{}{"A": "60", "AAAA": "3600"},
+			"added":       "false",
+		},
+		ContentServers: map[string]tc.CRConfigTrafficOpsServer{
+			"edge0": {Profile: util.StrPtr("EDGE1"), Port: util.IntPtr(80)},
+			"edge2": {Profile: util.StrPtr("EDGE0")},
+		},
+		DeliveryServices: map[string]tc.CRConfigDeliveryService{
+			"ds0": {Protocol: &tc.CRConfigDeliveryServiceProtocol{AcceptHTTPS: true}},
+		},
+		EdgeLocations: map[string]tc.CRConfigLatitudeLongitude{
+			"cg0": {Lat: 1, Lon: 2},
+		},
+		Stats: tc.CRConfigStats{TMUser: util.StrPtr("new")},
+	}
+
+	diff, err := Diff(oldBts, newCRC)
+	if err != nil {
+		t.Fatalf("Diff expected nil error, actual: %v", err)
+	}
+
+	if _, ok := diff.Config.Added["added"]; !ok || len(diff.Config.Added) != 1 {
+		t.Errorf("expected config 'added' to be added, actual %+v", diff.Config.Added)
+	}
+	if _, ok := diff.Config.Removed["removed"]; !ok || len(diff.Config.Removed) != 1 {
+		t.Errorf("expected config 'removed' to be removed, actual %+v", diff.Config.Removed)
+	}
+	expectedTTLs := []tc.CRConfigFieldDiff{{Field: "A", Old: "3600", New: "60"}}
+	if len(diff.Config.Changed) != 1 || !reflect.DeepEqual(expectedTTLs, diff.Config.Changed["ttls"]) {
+		t.Errorf("expected config changed ttls %+v, actual %+v", expectedTTLs, diff.Config.Changed)
+	}
+
+	if _, ok := diff.ContentServers.Added["edge2"]; !ok || len(diff.ContentServers.Added) != 1 {
+		t.Errorf("expected server edge2 to be added, actual %+v", diff.ContentServers.Added)
+	}
+	if _, ok := diff.ContentServers.Removed["edge1"]; !ok || len(diff.ContentServers.Removed) != 1 {
+		t.Errorf("expected server edge1 to be removed, actual %+v", diff.ContentServers.Removed)
+	}
+	expectedServer := []tc.CRConfigFieldDiff{{Field: "profile", Old: "EDGE0", New: "EDGE1"}}
+	if len(diff.ContentServers.Changed) != 1 || !reflect.DeepEqual(expectedServer, diff.ContentServers.Changed["edge0"]) {
+		t.Errorf("expected server changed edge0 %+v, actual %+v", expectedServer, diff.ContentServers.Changed)
+	}
+
+	expectedDS := []tc.CRConfigFieldDiff{{Field: "protocol/acceptHttps", Old: "false", New: "true"}}
+	if len(diff.DeliveryServices.Changed) != 1 || !reflect.DeepEqual(expectedDS, diff.DeliveryServices.Changed["ds0"]) {
+		t.Errorf("expected delivery service changed ds0 %+v, actual %+v", expectedDS, diff.DeliveryServices.Changed)
+	}
+
+	if len(diff.EdgeLocations.Added) != 0 || len(diff.EdgeLocations.Removed) != 0 || len(diff.EdgeLocations.Changed) != 0 {
+		t.Errorf("expected no edge location changes, actual %+v", diff.EdgeLocations)
+	}
+}
+
+func TestDiffNoSnapshot(t *testing.T) {
+	newCRC := &tc.CRConfig{
+		DeliveryServices: map[string]tc.CRConfigDeliveryService{"ds0": {}},
+	}
+	diff, err := Diff([]byte(`{}`), newCRC)
+	if err != nil {
+		t.Fatalf("Diff expected nil error, actual: %v", err)
+	}
+	if _, ok := diff.DeliveryServices.Added["ds0"]; !ok {
+		t.Errorf("expected delivery service ds0 to be added, actual %+v", diff.DeliveryServices)
+	}
+	if diff.ContentServers.Added == nil || diff.ContentServers.Removed == nil || diff.ContentServers.Changed == nil {
+		t.Errorf("expected empty sections to have non-nil maps, actual %+v", diff.ContentServers)
+	}
+}
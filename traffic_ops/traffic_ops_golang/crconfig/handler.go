@@ -170,6 +170,11 @@ func SnapshotHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := AddSnapshotHistory(inf.Tx.Tx, cdn, inf.User.UserName, getSnapshotComment(inf.Params), nil, inf.Config.SnapshotHistoryRetention); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New(r.RemoteAddr+" snaphsotting CRConfig and Monitoring: "+err.Error()))
+		return
+	}
+
 	if err := deliveryservice.DeleteOldCerts(db.DB, inf.Tx.Tx, inf.Config, tc.CDNName(cdn)); err != nil {
 		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New(r.RemoteAddr+" snapshotting CRConfig and Monitoring: starting old certificate deletion job: "+err.Error()))
 		return
@@ -213,6 +218,11 @@ func SnapshotOldGUIHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := AddSnapshotHistory(inf.Tx.Tx, cdn, inf.User.UserName, nil, nil, inf.Config.SnapshotHistoryRetention); err != nil {
+		writePerlHTMLErr(w, r, inf.Tx.Tx, errors.New(r.RemoteAddr+" adding snapshot history: "+err.Error()), err)
+		return
+	}
+
 	if err := deliveryservice.DeleteOldCerts(db.DB, inf.Tx.Tx, inf.Config, tc.CDNName(cdn)); err != nil {
 		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New(r.RemoteAddr+" old snapshotting CRConfig and Monitoring: starting old certificate deletion job: "+err.Error()))
 		return
@@ -0,0 +1,256 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apache/trafficcontrol/lib/go-tc"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/api"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/dbhelpers"
+	"github.com/apache/trafficcontrol/traffic_ops/traffic_ops_golang/monitoring"
+)
+
+// AddSnapshotHistory adds the current CRConfig and monitoring snapshot of the given CDN to the snapshot history, and removes the oldest history beyond the retention count.
+// It must be called after Snapshot, in the same transaction. The rollbackOf is the ID of the history entry which was re-published, if this snapshot is a rollback. A negative retention keeps all history.
+func AddSnapshotHistory(tx *sql.Tx, cdn string, user string, comment *string, rollbackOf *int, retention int) error {
+	qry := `
+INSERT INTO snapshot_history (cdn, crconfig, monitoring, username, comment, rollback_of, created)
+SELECT s.cdn, s.crconfig, s.monitoring, $2, $3, $4, s.last_updated
+FROM snapshot s
+WHERE s.cdn = $1
+`
+	if _, err := tx.Exec(qry, cdn, user, comment, rollbackOf); err != nil {
+		return errors.New("inserting snapshot history: " + err.Error())
+	}
+	if retention < 0 {
+		return nil
+	}
+
+	delQry := `
+DELETE FROM snapshot_history
+WHERE cdn = $1
+AND id NOT IN (SELECT id FROM snapshot_history WHERE cdn = $1 ORDER BY id DESC LIMIT $2)
+`
+	if _, err := tx.Exec(delQry, cdn, retention); err != nil {
+		return errors.New("deleting old snapshot history: " + err.Error())
+	}
+	return nil
+}
+
+// GetSnapshotHistory returns the snapshot history of the given CDN, newest first.
+func GetSnapshotHistory(tx *sql.Tx, cdn string) ([]tc.SnapshotHistory, error) {
+	qry := `
+SELECT id, cdn, username, comment, rollback_of, created
+FROM snapshot_history
+WHERE cdn = $1
+ORDER BY id DESC
+`
+	rows, err := tx.Query(qry, cdn)
+	if err != nil {
+		return nil, errors.New("querying snapshot history: " + err.Error())
+	}
+	defer rows.Close()
+
+	history := []tc.SnapshotHistory{}
+	for rows.Next() {
+		h := tc.SnapshotHistory{}
+		if err := rows.Scan(&h.ID, &h.CDN, &h.User, &h.Comment, &h.RollbackOf, &h.Created); err != nil {
+			return nil, errors.New("scanning snapshot history: " + err.Error())
+		}
+		history = append(history, h)
+	}
+	return history, nil
+}
+
+// GetSnapshotHistoryByID returns the CRConfig and monitoring JSON of the given snapshot history entry of the given CDN, and whether it exists.
+func GetSnapshotHistoryByID(tx *sql.Tx, cdn string, id int) (string, string, bool, error) {
+	crConfig := ""
+	monitoringJSON := ""
+	qry := `SELECT crconfig, monitoring FROM snapshot_history WHERE cdn = $1 AND id = $2`
+	if err := tx.QueryRow(qry, cdn, id).Scan(&crConfig, &monitoringJSON); err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", false, nil
+		}
+		return "", "", false, errors.New("querying snapshot history: " + err.Error())
+	}
+	return crConfig, monitoringJSON, true, nil
+}
+
+// SnapshotHistoryHandler serves the snapshot history of a CDN, without the snapshot contents.
+func SnapshotHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"cdn"}, nil)
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	if ok, err := dbhelpers.CDNExists(inf.Params["cdn"], inf.Tx.Tx); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("checking CDN existence: "+err.Error()))
+		return
+	} else if !ok {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("CDN not found"), nil)
+		return
+	}
+
+	history, err := GetSnapshotHistory(inf.Tx.Tx, inf.Params["cdn"])
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, err)
+		return
+	}
+	api.WriteResp(w, r, history)
+}
+
+// SnapshotHistoryGetHandler serves the CRConfig of a snapshot history entry.
+func SnapshotHistoryGetHandler(w http.ResponseWriter, r *http.Request) {
+	serveSnapshotHistory(w, r, false)
+}
+
+// SnapshotHistoryGetMonitoringHandler serves the monitoring JSON of a snapshot history entry.
+func SnapshotHistoryGetMonitoringHandler(w http.ResponseWriter, r *http.Request) {
+	serveSnapshotHistory(w, r, true)
+}
+
+func serveSnapshotHistory(w http.ResponseWriter, r *http.Request, monitoring bool) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"cdn", "id"}, []string{"id"})
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	crConfig, monitoringJSON, ok, err := GetSnapshotHistoryByID(inf.Tx.Tx, inf.Params["cdn"], inf.IntParams["id"])
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, err)
+		return
+	} else if !ok {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("snapshot not found"), nil)
+		return
+	}
+
+	snapshot := crConfig
+	if monitoring {
+		snapshot = monitoringJSON
+	}
+	w.Header().Set(tc.ContentType, tc.ApplicationJson)
+	w.Write([]byte(`{"response":` + snapshot + `}`))
+}
+
+// SnapshotHistoryDiffHandler serves the difference between the CRConfigs of two snapshot history entries.
+func SnapshotHistoryDiffHandler(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"cdn", "id", "to-id"}, []string{"id", "to-id"})
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	oldCRConfig, _, ok, err := GetSnapshotHistoryByID(inf.Tx.Tx, inf.Params["cdn"], inf.IntParams["id"])
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, err)
+		return
+	} else if !ok {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("snapshot "+inf.Params["id"]+" not found"), nil)
+		return
+	}
+
+	newCRConfig, _, ok, err := GetSnapshotHistoryByID(inf.Tx.Tx, inf.Params["cdn"], inf.IntParams["to-id"])
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, err)
+		return
+	} else if !ok {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("snapshot "+inf.Params["to-id"]+" not found"), nil)
+		return
+	}
+
+	diff, err := DiffJSON([]byte(oldCRConfig), []byte(newCRConfig))
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("diffing snapshots: "+err.Error()))
+		return
+	}
+	api.WriteResp(w, r, diff)
+}
+
+// SnapshotRollbackHandler re-publishes a snapshot history entry as the current snapshot of its CDN.
+// The CRConfig date is set to the time of the rollback, because Traffic Router ignores CRConfigs older than the one it has.
+func SnapshotRollbackHandler(w http.ResponseWriter, r *http.Request) {
+	inf, userErr, sysErr, errCode := api.NewInfo(r, []string{"cdn", "id"}, []string{"id"})
+	if userErr != nil || sysErr != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, errCode, userErr, sysErr)
+		return
+	}
+	defer inf.Close()
+
+	cdn := inf.Params["cdn"]
+	id := inf.IntParams["id"]
+
+	crConfigJSON, monitoringJSON, ok, err := GetSnapshotHistoryByID(inf.Tx.Tx, cdn, id)
+	if err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, err)
+		return
+	} else if !ok {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusNotFound, errors.New("snapshot not found"), nil)
+		return
+	}
+
+	crConfig := tc.CRConfig{}
+	if err := json.Unmarshal([]byte(crConfigJSON), &crConfig); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("unmarshalling snapshot history CRConfig: "+err.Error()))
+		return
+	}
+	tmJSON := monitoring.Monitoring{}
+	if err := json.Unmarshal([]byte(monitoringJSON), &tmJSON); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New("unmarshalling snapshot history monitoring: "+err.Error()))
+		return
+	}
+
+	now := time.Now().Unix()
+	crConfig.Stats.DateUnixSeconds = &now
+	crConfig.Stats.TMUser = &inf.User.UserName
+	crConfig.Stats.CDNName = &cdn
+
+	if err := Snapshot(inf.Tx.Tx, &crConfig, &tmJSON); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New(r.RemoteAddr+" rolling back CRConfig and Monitoring: "+err.Error()))
+		return
+	}
+
+	if err := AddSnapshotHistory(inf.Tx.Tx, cdn, inf.User.UserName, getSnapshotComment(inf.Params), &id, inf.Config.SnapshotHistoryRetention); err != nil {
+		api.HandleErr(w, r, inf.Tx.Tx, http.StatusInternalServerError, nil, errors.New(r.RemoteAddr+" rolling back CRConfig and Monitoring: "+err.Error()))
+		return
+	}
+
+	api.CreateChangeLogRawTx(api.ApiChange, "Snapshot of CRConfig and Monitor for "+cdn+" rolled back to snapshot "+strconv.Itoa(id), inf.User, inf.Tx.Tx)
+	api.WriteResp(w, r, "SUCCESS")
+}
+
+// getSnapshotComment returns the optional snapshot comment query parameter, or nil if it wasn't given.
+func getSnapshotComment(params map[string]string) *string {
+	comment, ok := params["comment"]
+	if !ok || comment == "" {
+		return nil
+	}
+	return &comment
+}
@@ -0,0 +1,106 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestAddSnapshotHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	cdn := "mycdn"
+	comment := "my comment"
+	rollbackOf := 42
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO snapshot_history").WithArgs(cdn, "myuser", comment, rollbackOf).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE FROM snapshot_history").WithArgs(cdn, 10).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO snapshot_history").WithArgs(cdn, "myuser", nil, nil).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	dbCtx, cancel := context.WithTimeout(context.TODO(), time.Duration(10)*time.Second)
+	defer cancel()
+	tx, err := db.BeginTx(dbCtx, nil)
+	if err != nil {
+		t.Fatalf("creating transaction: %v", err)
+	}
+
+	if err := AddSnapshotHistory(tx, cdn, "myuser", &comment, &rollbackOf, 10); err != nil {
+		t.Errorf("AddSnapshotHistory err expected: nil, actual: %v", err)
+	}
+	// negative retention keeps all history, so nothing is deleted
+	if err := AddSnapshotHistory(tx, cdn, "myuser", nil, nil, -1); err != nil {
+		t.Errorf("AddSnapshotHistory unlimited retention err expected: nil, actual: %v", err)
+	}
+	tx.Commit()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expected queries not run: %v", err)
+	}
+}
+
+func TestGetSnapshotHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	cdn := "mycdn"
+	now := time.Now()
+
+	mock.ExpectBegin()
+	rows := sqlmock.NewRows([]string{"id", "cdn", "username", "comment", "rollback_of", "created"})
+	rows = rows.AddRow(2, cdn, "bob", nil, 1, now)
+	rows = rows.AddRow(1, cdn, "alice", "initial", nil, now)
+	mock.ExpectQuery("SELECT").WithArgs(cdn).WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	dbCtx, cancel := context.WithTimeout(context.TODO(), time.Duration(10)*time.Second)
+	defer cancel()
+	tx, err := db.BeginTx(dbCtx, nil)
+	if err != nil {
+		t.Fatalf("creating transaction: %v", err)
+	}
+	defer tx.Commit()
+
+	history, err := GetSnapshotHistory(tx, cdn)
+	if err != nil {
+		t.Fatalf("GetSnapshotHistory err expected: nil, actual: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("GetSnapshotHistory expected: 2 entries, actual: %+v", history)
+	}
+	if history[0].ID != 2 || history[0].Comment != nil || history[0].RollbackOf == nil || *history[0].RollbackOf != 1 {
+		t.Errorf("GetSnapshotHistory expected rollback entry 2 of 1, actual: %+v", history[0])
+	}
+	if history[1].User != "alice" || history[1].Comment == nil || *history[1].Comment != "initial" || history[1].RollbackOf != nil {
+		t.Errorf("GetSnapshotHistory expected commented entry 1, actual: %+v", history[1])
+	}
+}
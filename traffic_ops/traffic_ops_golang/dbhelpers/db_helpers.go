@@ -23,11 +23,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/apache/trafficcontrol/lib/go-log"
 	"github.com/apache/trafficcontrol/lib/go-tc"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 )
 
@@ -39,6 +43,15 @@ type WhereColumnInfo struct {
 const BaseWhere = "\nWHERE"
 const BaseOrderBy = "\nORDER BY"
 
+// LastUpdatedQueryParam is the query parameter of the lastUpdated column, which the newerThan and olderThan query parameters filter on.
+const LastUpdatedQueryParam = "lastUpdated"
+
+const SortOrderAsc = "asc"
+const SortOrderDesc = "desc"
+
+// BuildWhereAndOrderBy returns the WHERE and ORDER BY clauses for the given query parameters.
+//
+// Each parameter in queryParamsToSQLCols is an equality filter. The "orderby" parameter orders by the column of the given parameter, and "sortOrder" may be "asc" or "desc", either of which requires a valid "orderby". The "newerThan" and "olderThan" parameters filter on the column of the "lastUpdated" parameter, and are an error if the endpoint has no such column.
 func BuildWhereAndOrderBy(parameters map[string]string, queryParamsToSQLCols map[string]WhereColumnInfo) (string, string, map[string]interface{}, []error) {
 	whereClause := BaseWhere
 	orderBy := BaseOrderBy
@@ -47,7 +60,14 @@ func BuildWhereAndOrderBy(parameters map[string]string, queryParamsToSQLCols map
 	var errs []error
 	criteria, queryValues, errs = parseCriteriaAndQueryValues(queryParamsToSQLCols, parameters)
 
-	if len(queryValues) > 0 {
+	timeCriteria, timeErrs := parseTimeCriteria(queryParamsToSQLCols, parameters, queryValues)
+	errs = append(errs, timeErrs...)
+	if criteria != "" && timeCriteria != "" {
+		criteria += " AND "
+	}
+	criteria += timeCriteria
+
+	if criteria != "" {
 		whereClause += " " + criteria
 	}
 	if len(errs) > 0 {
@@ -63,6 +83,18 @@ func BuildWhereAndOrderBy(parameters map[string]string, queryParamsToSQLCols map
 			log.Debugln("Incorrect name for orderby: ", orderby)
 		}
 	}
+	if sortOrder, ok := parameters["sortOrder"]; ok {
+		sortOrder = strings.ToLower(sortOrder)
+		if sortOrder != SortOrderAsc && sortOrder != SortOrderDesc {
+			return "", "", queryValues, []error{errors.New("sortOrder must be '" + SortOrderAsc + "' or '" + SortOrderDesc + "'")}
+		}
+		if orderBy == BaseOrderBy {
+			return "", "", queryValues, []error{errors.New("sortOrder '" + sortOrder + "' requires a valid orderby")}
+		}
+		if sortOrder == SortOrderDesc {
+			orderBy += " DESC"
+		}
+	}
 	if whereClause == BaseWhere {
 		whereClause = ""
 	}
@@ -73,6 +105,153 @@ func BuildWhereAndOrderBy(parameters map[string]string, queryParamsToSQLCols map
 	return whereClause, orderBy, queryValues, errs
 }
 
+// BuildWhereAndOrderByAndPagination is like BuildWhereAndOrderBy, but also returns a LIMIT and OFFSET clause from the "limit", "offset", and "page" query parameters. The pagination clause is empty if the request isn't paginated, and must be appended after the ORDER BY clause.
+//
+// If the request is paginated without an "orderby", it's ordered by the "id" column, so pages are stable. Endpoints without an "id" column are ordered by all their columns. If it's paginated with an "orderby" of another column, the "id" column breaks ties.
+func BuildWhereAndOrderByAndPagination(parameters map[string]string, queryParamsToSQLCols map[string]WhereColumnInfo) (string, string, string, map[string]interface{}, []error) {
+	where, orderBy, queryValues, errs := BuildWhereAndOrderBy(parameters, queryParamsToSQLCols)
+	if len(errs) > 0 {
+		return "", "", "", queryValues, errs
+	}
+	limit, offset, errs := GetPagination(parameters)
+	if len(errs) > 0 {
+		return "", "", "", queryValues, errs
+	}
+	pagination := ""
+	if limit > 0 {
+		pagination += "\nLIMIT " + strconv.Itoa(limit)
+	}
+	if offset > 0 {
+		pagination += "\nOFFSET " + strconv.Itoa(offset)
+	}
+	if pagination != "" {
+		if orderBy == "" {
+			orderBy = BaseOrderBy + " " + keyOrderBy(queryParamsToSQLCols)
+		} else if colInfo, ok := queryParamsToSQLCols["id"]; ok && parameters["orderby"] != "id" {
+			orderBy += ", " + colInfo.Column
+		}
+	}
+	return where, orderBy, pagination, queryValues, nil
+}
+
+// keyOrderBy returns the columns to order paginated results by, when no order is requested: the "id" column if there is one, otherwise all columns, in the order of their query parameter names.
+func keyOrderBy(queryParamsToSQLCols map[string]WhereColumnInfo) string {
+	if colInfo, ok := queryParamsToSQLCols["id"]; ok {
+		return colInfo.Column
+	}
+	params := make([]string, 0, len(queryParamsToSQLCols))
+	for param := range queryParamsToSQLCols {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	cols := make([]string, 0, len(params))
+	for _, param := range params {
+		cols = append(cols, queryParamsToSQLCols[param].Column)
+	}
+	return strings.Join(cols, ", ")
+}
+
+// GetPagination returns the limit and offset of the "limit", "offset", and "page" query parameters. A limit of 0 means no limit.
+//
+// The page parameter is a 1-based page number of the limit's size, and may not be combined with offset.
+func GetPagination(parameters map[string]string) (int, int, []error) {
+	errs := []error{}
+	limit := 0
+	if limitStr, ok := parameters["limit"]; ok {
+		if l, err := strconv.Atoi(limitStr); err != nil || l < 1 {
+			errs = append(errs, errors.New("limit must be a positive integer"))
+		} else {
+			limit = l
+		}
+	}
+	offset := 0
+	if offsetStr, ok := parameters["offset"]; ok {
+		if o, err := strconv.Atoi(offsetStr); err != nil || o < 0 {
+			errs = append(errs, errors.New("offset must be a non-negative integer"))
+		} else {
+			offset = o
+		}
+	}
+	if pageStr, ok := parameters["page"]; ok {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			errs = append(errs, errors.New("page must be a positive integer"))
+		} else if _, ok := parameters["offset"]; ok {
+			errs = append(errs, errors.New("page and offset may not both be given"))
+		} else if _, ok := parameters["limit"]; !ok {
+			errs = append(errs, errors.New("page requires a limit"))
+		} else {
+			offset = (page - 1) * limit
+		}
+	}
+	if len(errs) > 0 {
+		return 0, 0, errs
+	}
+	return limit, offset, nil
+}
+
+// GetCount returns the number of rows the given query returns. It's used by paginated reads, to report the total number of objects matching the request.
+func GetCount(tx *sqlx.Tx, query string, queryValues map[string]interface{}) (uint64, error) {
+	rows, err := tx.NamedQuery(`SELECT COUNT(*) FROM (`+query+`) AS q`, queryValues)
+	if err != nil {
+		return 0, errors.New("querying count: " + err.Error())
+	}
+	defer rows.Close()
+	count := uint64(0)
+	if !rows.Next() {
+		return 0, errors.New("querying count: no rows returned")
+	}
+	if err := rows.Scan(&count); err != nil {
+		return 0, errors.New("scanning count: " + err.Error())
+	}
+	return count, nil
+}
+
+// parseTimeCriteria returns the criteria for the "newerThan" and "olderThan" query parameters, which filter on the column of the "lastUpdated" query parameter. The times are added to queryValues.
+func parseTimeCriteria(queryParamsToSQLCols map[string]WhereColumnInfo, parameters map[string]string, queryValues map[string]interface{}) (string, []error) {
+	criteriaArgs := []string{}
+	errs := []error{}
+	for _, param := range []struct {
+		name string
+		op   string
+	}{{"newerThan", ">"}, {"olderThan", "<"}} {
+		val, ok := parameters[param.name]
+		if !ok {
+			continue
+		}
+		colInfo, ok := queryParamsToSQLCols[LastUpdatedQueryParam]
+		if !ok {
+			errs = append(errs, errors.New(param.name+" is not supported for this endpoint"))
+			continue
+		}
+		t, err := ParseTime(val)
+		if err != nil {
+			errs = append(errs, errors.New(param.name+" "+err.Error()))
+			continue
+		}
+		criteriaArgs = append(criteriaArgs, colInfo.Column+" "+param.op+" :"+param.name)
+		queryValues[param.name] = t
+	}
+	return strings.Join(criteriaArgs, " AND "), errs
+}
+
+// ParseTime parses a time query parameter, which may be RFC3339 or the Traffic Ops lastUpdated format.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(tc.TimeLayout, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.New("must be an RFC3339 time or of the form '" + tc.TimeLayout + "'")
+}
+
+// IsTime is a WhereColumnInfo Checker for time query parameters.
+func IsTime(s string) error {
+	_, err := ParseTime(s)
+	return err
+}
+
 func parseCriteriaAndQueryValues(queryParamsToSQLCols map[string]WhereColumnInfo, parameters map[string]string) (string, map[string]interface{}, []error) {
 	m := make(map[string]interface{})
 	var criteria string
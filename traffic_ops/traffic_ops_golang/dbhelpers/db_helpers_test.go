@@ -22,6 +22,7 @@ package dbhelpers
 import (
 	"strings"
 	"testing"
+	"time"
 	"unicode"
 )
 
@@ -71,3 +72,86 @@ FROM table t
 	}
 
 }
+
+func TestBuildWhereAndOrderByAndPagination(t *testing.T) {
+	queryParamsToSQLCols := map[string]WhereColumnInfo{
+		"name":                WhereColumnInfo{"t.name", nil},
+		LastUpdatedQueryParam: WhereColumnInfo{"t.last_updated", IsTime},
+	}
+
+	params := map[string]string{
+		"name":      "foo",
+		"orderby":   "name",
+		"sortOrder": "desc",
+		"newerThan": "2018-01-02T03:04:05Z",
+		"limit":     "10",
+		"page":      "3",
+	}
+	where, orderBy, pagination, queryValues, errs := BuildWhereAndOrderByAndPagination(params, queryParamsToSQLCols)
+	if len(errs) > 0 {
+		t.Fatalf("expected no errors, actual: %v", errs)
+	}
+	if expected := "WHEREt.name=:nameANDt.last_updated>:newerThan"; stripAllWhitespace(where) != expected {
+		t.Errorf("expected where '%v', actual: '%v'", expected, stripAllWhitespace(where))
+	}
+	if expected := "ORDERBYt.nameDESC"; stripAllWhitespace(orderBy) != expected {
+		t.Errorf("expected order by '%v', actual: '%v'", expected, stripAllWhitespace(orderBy))
+	}
+	if expected := "LIMIT10OFFSET20"; stripAllWhitespace(pagination) != expected {
+		t.Errorf("expected pagination '%v', actual: '%v'", expected, stripAllWhitespace(pagination))
+	}
+	if newerThan, ok := queryValues["newerThan"].(time.Time); !ok || newerThan.Unix() != 1514862245 {
+		t.Errorf("expected newerThan query value 2018-01-02T03:04:05Z, actual: %v", queryValues["newerThan"])
+	}
+
+	_, _, pagination, _, errs = BuildWhereAndOrderByAndPagination(map[string]string{"name": "foo"}, queryParamsToSQLCols)
+	if len(errs) > 0 {
+		t.Fatalf("expected no errors, actual: %v", errs)
+	}
+	if pagination != "" {
+		t.Errorf("expected no pagination without limit or offset, actual: '%v'", pagination)
+	}
+
+	_, orderBy, _, _, errs = BuildWhereAndOrderByAndPagination(map[string]string{"limit": "10"}, map[string]WhereColumnInfo{"id": WhereColumnInfo{"t.id", nil}, "name": WhereColumnInfo{"t.name", nil}})
+	if len(errs) > 0 {
+		t.Fatalf("expected no errors, actual: %v", errs)
+	}
+	if expected := "ORDERBYt.id"; stripAllWhitespace(orderBy) != expected {
+		t.Errorf("expected paginated order by without orderby '%v', actual: '%v'", expected, stripAllWhitespace(orderBy))
+	}
+	_, orderBy, _, _, errs = BuildWhereAndOrderByAndPagination(map[string]string{"limit": "10", "orderby": "name", "sortOrder": "desc"}, map[string]WhereColumnInfo{"id": WhereColumnInfo{"t.id", nil}, "name": WhereColumnInfo{"t.name", nil}})
+	if len(errs) > 0 {
+		t.Fatalf("expected no errors, actual: %v", errs)
+	}
+	if expected := "ORDERBYt.nameDESC,t.id"; stripAllWhitespace(orderBy) != expected {
+		t.Errorf("expected paginated order by with orderby '%v', actual: '%v'", expected, stripAllWhitespace(orderBy))
+	}
+	_, orderBy, _, _, errs = BuildWhereAndOrderByAndPagination(map[string]string{"offset": "5"}, queryParamsToSQLCols)
+	if len(errs) > 0 {
+		t.Fatalf("expected no errors, actual: %v", errs)
+	}
+	if expected := "ORDERBYt.last_updated,t.name"; stripAllWhitespace(orderBy) != expected {
+		t.Errorf("expected paginated order by without orderby or id '%v', actual: '%v'", expected, stripAllWhitespace(orderBy))
+	}
+
+	for _, badParams := range []map[string]string{
+		{"limit": "0"},
+		{"offset": "-1"},
+		{"page": "2"},
+		{"limit": "10", "page": "2", "offset": "5"},
+		{"sortOrder": "sideways"},
+		{"sortOrder": "desc"},
+		{"orderby": "nonexistent", "sortOrder": "desc"},
+		{"sortOrder": "asc"},
+		{"orderby": "nonexistent", "sortOrder": "ASC"},
+		{"olderThan": "yesterday"},
+	} {
+		if _, _, _, _, errs := BuildWhereAndOrderByAndPagination(badParams, queryParamsToSQLCols); len(errs) == 0 {
+			t.Errorf("expected errors for params %v, actual: nil", badParams)
+		}
+	}
+
+	if _, _, _, _, errs := BuildWhereAndOrderByAndPagination(map[string]string{"newerThan": "2018-01-02T03:04:05Z"}, map[string]WhereColumnInfo{"name": WhereColumnInfo{"t.name", nil}}); len(errs) == 0 {
+		t.Errorf("expected error for newerThan without a lastUpdated column, actual: nil")
+	}
+}
@@ -78,14 +78,15 @@ func (req TODeliveryServiceRequest) GetType() string {
 // Read implements the api.Reader interface
 func (req *TODeliveryServiceRequest) Read() ([]interface{}, error, error, int) {
 	queryParamsToQueryCols := map[string]dbhelpers.WhereColumnInfo{
-		"assignee":   dbhelpers.WhereColumnInfo{Column: "s.username"},
-		"assigneeId": dbhelpers.WhereColumnInfo{Column: "r.assignee_id", Checker: api.IsInt},
-		"author":     dbhelpers.WhereColumnInfo{Column: "a.username"},
-		"authorId":   dbhelpers.WhereColumnInfo{Column: "r.author_id", Checker: api.IsInt},
-		"changeType": dbhelpers.WhereColumnInfo{Column: "r.change_type"},
-		"id":         dbhelpers.WhereColumnInfo{Column: "r.id", Checker: api.IsInt},
-		"status":     dbhelpers.WhereColumnInfo{Column: "r.status"},
-		"xmlId":      dbhelpers.WhereColumnInfo{Column: "r.deliveryservice->>'xmlId'"},
+		"assignee":    dbhelpers.WhereColumnInfo{Column: "s.username"},
+		"assigneeId":  dbhelpers.WhereColumnInfo{Column: "r.assignee_id", Checker: api.IsInt},
+		"author":      dbhelpers.WhereColumnInfo{Column: "a.username"},
+		"authorId":    dbhelpers.WhereColumnInfo{Column: "r.author_id", Checker: api.IsInt},
+		"changeType":  dbhelpers.WhereColumnInfo{Column: "r.change_type"},
+		"id":          dbhelpers.WhereColumnInfo{Column: "r.id", Checker: api.IsInt},
+		"status":      dbhelpers.WhereColumnInfo{Column: "r.status"},
+		"xmlId":       dbhelpers.WhereColumnInfo{Column: "r.deliveryservice->>'xmlId'"},
+		"lastUpdated": dbhelpers.WhereColumnInfo{Column: "r.last_updated", Checker: dbhelpers.IsTime},
 	}
 
 	p := req.APIInfo().Params
@@ -98,7 +99,7 @@ func (req *TODeliveryServiceRequest) Read() ([]interface{}, error, error, int) {
 		p["orderby"] = "xmlId"
 	}
 
-	where, orderBy, queryValues, errs := dbhelpers.BuildWhereAndOrderBy(p, queryParamsToQueryCols)
+	where, orderBy, pagination, queryValues, errs := dbhelpers.BuildWhereAndOrderByAndPagination(p, queryParamsToQueryCols)
 	if len(errs) > 0 {
 		return nil, util.JoinErrs(errs), nil, http.StatusBadRequest
 	}
@@ -108,7 +109,15 @@ func (req *TODeliveryServiceRequest) Read() ([]interface{}, error, error, int) {
 	}
 	where, queryValues = dbhelpers.AddTenancyCheck(where, queryValues, "CAST(r.deliveryservice->>'tenantId' AS bigint)", tenantIDs)
 
-	query := selectDeliveryServiceRequestsQuery() + where + orderBy
+	if pagination != "" {
+		count, err := dbhelpers.GetCount(req.APIInfo().Tx, selectDeliveryServiceRequestsQuery()+where, queryValues)
+		if err != nil {
+			return nil, nil, errors.New("dsr " + err.Error()), http.StatusInternalServerError
+		}
+		req.APIInfo().Count = &count
+	}
+
+	query := selectDeliveryServiceRequestsQuery() + where + orderBy + pagination
 	log.Debugln("Query is ", query)
 
 	rows, err := req.APIInfo().Tx.NamedQuery(query, queryValues)
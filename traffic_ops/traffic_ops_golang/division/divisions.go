@@ -44,8 +44,9 @@ func (v *TODivision) NewReadObj() interface{}       { return &tc.Division{} }
 func (v *TODivision) SelectQuery() string           { return selectQuery() }
 func (v *TODivision) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 	return map[string]dbhelpers.WhereColumnInfo{
-		"id":   dbhelpers.WhereColumnInfo{"id", api.IsInt},
-		"name": dbhelpers.WhereColumnInfo{"name", nil},
+		"id":          dbhelpers.WhereColumnInfo{"id", api.IsInt},
+		"name":        dbhelpers.WhereColumnInfo{"name", nil},
+		"lastUpdated": dbhelpers.WhereColumnInfo{"d.last_updated", dbhelpers.IsTime},
 	}
 }
 func (v *TODivision) UpdateQuery() string { return updateQuery() }
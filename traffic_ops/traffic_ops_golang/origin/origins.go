@@ -160,6 +160,7 @@ func getOrigins(params map[string]string, tx *sqlx.Tx, user *auth.CurrentUser) (
 		"primary":         dbhelpers.WhereColumnInfo{"o.is_primary", api.IsBool},
 		"profileId":       dbhelpers.WhereColumnInfo{"o.profile", api.IsInt},
 		"tenant":          dbhelpers.WhereColumnInfo{"o.tenant", api.IsInt},
+		"lastUpdated":     dbhelpers.WhereColumnInfo{"o.last_updated", dbhelpers.IsTime},
 	}
 
 	where, orderBy, queryValues, errs := dbhelpers.BuildWhereAndOrderBy(params, queryParamsToSQLCols)
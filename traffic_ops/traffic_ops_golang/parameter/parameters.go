@@ -35,11 +35,12 @@ import (
 )
 
 const (
-	NameQueryParam       = "name"
-	SecureQueryParam     = "secure"
-	ConfigFileQueryParam = "configFile"
-	IDQueryParam         = "id"
-	ValueQueryParam      = "value"
+	NameQueryParam        = "name"
+	SecureQueryParam      = "secure"
+	ConfigFileQueryParam  = "configFile"
+	IDQueryParam          = "id"
+	ValueQueryParam       = "value"
+	LastUpdatedQueryParam = "lastUpdated"
 )
 
 var (
@@ -58,10 +59,11 @@ func (v *TOParameter) NewReadObj() interface{}       { return &tc.ParameterNulla
 func (v *TOParameter) SelectQuery() string           { return selectQuery() }
 func (v *TOParameter) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 	return map[string]dbhelpers.WhereColumnInfo{
-		ConfigFileQueryParam: dbhelpers.WhereColumnInfo{"p.config_file", nil},
-		IDQueryParam:         dbhelpers.WhereColumnInfo{"p.id", api.IsInt},
-		NameQueryParam:       dbhelpers.WhereColumnInfo{"p.name", nil},
-		SecureQueryParam:     dbhelpers.WhereColumnInfo{"p.secure", api.IsBool}}
+		ConfigFileQueryParam:  dbhelpers.WhereColumnInfo{"p.config_file", nil},
+		IDQueryParam:          dbhelpers.WhereColumnInfo{"p.id", api.IsInt},
+		NameQueryParam:        dbhelpers.WhereColumnInfo{"p.name", nil},
+		SecureQueryParam:      dbhelpers.WhereColumnInfo{"p.secure", api.IsBool},
+		LastUpdatedQueryParam: dbhelpers.WhereColumnInfo{"p.last_updated", dbhelpers.IsTime}}
 }
 func (v *TOParameter) UpdateQuery() string { return updateQuery() }
 func (v *TOParameter) DeleteQuery() string { return deleteQuery() }
@@ -121,12 +123,20 @@ func (pa *TOParameter) Create() (error, error, int) {
 
 func (param *TOParameter) Read() ([]interface{}, error, error, int) {
 	queryParamsToQueryCols := param.ParamColumns()
-	where, orderBy, queryValues, errs := dbhelpers.BuildWhereAndOrderBy(param.APIInfo().Params, queryParamsToQueryCols)
+	where, orderBy, pagination, queryValues, errs := dbhelpers.BuildWhereAndOrderByAndPagination(param.APIInfo().Params, queryParamsToQueryCols)
 	if len(errs) > 0 {
 		return nil, util.JoinErrs(errs), nil, http.StatusBadRequest
 	}
 
-	query := selectQuery() + where + ParametersGroupBy() + orderBy
+	if pagination != "" {
+		count, err := dbhelpers.GetCount(param.ReqInfo.Tx, selectQuery()+where+ParametersGroupBy(), queryValues)
+		if err != nil {
+			return nil, nil, errors.New("counting " + param.GetType() + ": " + err.Error()), http.StatusInternalServerError
+		}
+		param.ReqInfo.Count = &count
+	}
+
+	query := selectQuery() + where + ParametersGroupBy() + orderBy + pagination
 	rows, err := param.ReqInfo.Tx.NamedQuery(query, queryValues)
 	if err != nil {
 		return nil, nil, errors.New("querying " + param.GetType() + ": " + err.Error()), http.StatusInternalServerError
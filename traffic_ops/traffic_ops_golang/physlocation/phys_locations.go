@@ -43,9 +43,10 @@ func (v *TOPhysLocation) NewReadObj() interface{}       { return &tc.PhysLocatio
 func (v *TOPhysLocation) SelectQuery() string           { return selectQuery() }
 func (v *TOPhysLocation) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 	return map[string]dbhelpers.WhereColumnInfo{
-		"name":   dbhelpers.WhereColumnInfo{"pl.name", nil},
-		"id":     dbhelpers.WhereColumnInfo{"pl.id", api.IsInt},
-		"region": dbhelpers.WhereColumnInfo{"pl.region", api.IsInt},
+		"name":        dbhelpers.WhereColumnInfo{"pl.name", nil},
+		"id":          dbhelpers.WhereColumnInfo{"pl.id", api.IsInt},
+		"region":      dbhelpers.WhereColumnInfo{"pl.region", api.IsInt},
+		"lastUpdated": dbhelpers.WhereColumnInfo{"pl.last_updated", dbhelpers.IsTime},
 	}
 }
 func (v *TOPhysLocation) UpdateQuery() string { return updateQuery() }
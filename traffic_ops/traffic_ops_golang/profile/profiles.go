@@ -108,6 +108,7 @@ func (prof *TOProfile) Read() ([]interface{}, error, error, int) {
 		CDNQueryParam:  dbhelpers.WhereColumnInfo{"c.id", nil},
 		NameQueryParam: dbhelpers.WhereColumnInfo{"prof.name", nil},
 		IDQueryParam:   dbhelpers.WhereColumnInfo{"prof.id", api.IsInt},
+		"lastUpdated":  dbhelpers.WhereColumnInfo{"prof.last_updated", dbhelpers.IsTime},
 	}
 	where, orderBy, queryValues, errs := dbhelpers.BuildWhereAndOrderBy(prof.APIInfo().Params, queryParamsToQueryCols)
 
@@ -39,9 +39,10 @@ func (v *TORegion) NewReadObj() interface{}       { return &tc.Region{} }
 func (v *TORegion) SelectQuery() string           { return selectQuery() }
 func (v *TORegion) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 	return map[string]dbhelpers.WhereColumnInfo{
-		"name":     dbhelpers.WhereColumnInfo{"r.name", nil},
-		"division": dbhelpers.WhereColumnInfo{"r.division", nil},
-		"id":       dbhelpers.WhereColumnInfo{"r.id", api.IsInt},
+		"name":        dbhelpers.WhereColumnInfo{"r.name", nil},
+		"division":    dbhelpers.WhereColumnInfo{"r.division", nil},
+		"id":          dbhelpers.WhereColumnInfo{"r.id", api.IsInt},
+		"lastUpdated": dbhelpers.WhereColumnInfo{"r.last_updated", dbhelpers.IsTime},
 	}
 }
 func (v *TORegion) UpdateQuery() string { return updateQuery() }
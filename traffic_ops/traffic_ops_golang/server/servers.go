@@ -174,7 +174,7 @@ func (server TOServer) ChangeLogMessage(action string) (string, error) {
 func (server *TOServer) Read() ([]interface{}, error, error, int) {
 	returnable := []interface{}{}
 
-	servers, errs, errType := getServers(server.ReqInfo.Params, server.ReqInfo.Tx, server.ReqInfo.User)
+	servers, count, errs, errType := getServers(server.ReqInfo.Params, server.ReqInfo.Tx, server.ReqInfo.User)
 	if len(errs) > 0 {
 		for _, err := range errs {
 			if err.Error() == `id cannot parse to integer` {
@@ -188,11 +188,13 @@ func (server *TOServer) Read() ([]interface{}, error, error, int) {
 	for _, server := range servers {
 		returnable = append(returnable, server)
 	}
+	server.ReqInfo.Count = count
 
 	return returnable, nil, nil, http.StatusOK
 }
 
-func getServers(params map[string]string, tx *sqlx.Tx, user *auth.CurrentUser) ([]tc.ServerNullable, []error, tc.ApiErrorType) {
+// getServers returns the servers matching the given query parameters. If the request is paginated in the database, it also returns the total number of servers matching the request; otherwise the count is nil.
+func getServers(params map[string]string, tx *sqlx.Tx, user *auth.CurrentUser) ([]tc.ServerNullable, *uint64, []error, tc.ApiErrorType) {
 	// Query Parameters to Database Query column mappings
 	// see the fields mapped in the SQL query
 	queryParamsToSQLCols := map[string]dbhelpers.WhereColumnInfo{
@@ -206,6 +208,7 @@ func getServers(params map[string]string, tx *sqlx.Tx, user *auth.CurrentUser) (
 		"status":           dbhelpers.WhereColumnInfo{"st.name", nil},
 		"type":             dbhelpers.WhereColumnInfo{"t.name", nil},
 		"dsId":             dbhelpers.WhereColumnInfo{"dss.deliveryservice", nil},
+		"lastUpdated":      dbhelpers.WhereColumnInfo{"s.last_updated", dbhelpers.IsTime},
 	}
 
 	usesMids := false
@@ -214,11 +217,11 @@ func getServers(params map[string]string, tx *sqlx.Tx, user *auth.CurrentUser) (
 		// don't allow query on ds outside user's tenant
 		dsID, err := strconv.Atoi(dsIDStr)
 		if err != nil {
-			return nil, []error{errors.New("dsId must be an integer")}, tc.DataMissingError
+			return nil, nil, []error{errors.New("dsId must be an integer")}, tc.DataMissingError
 		}
 		userErr, sysErr, _ := tenant.CheckID(tx.Tx, user, dsID)
 		if userErr != nil || sysErr != nil {
-			return nil, []error{errors.New("Forbidden")}, tc.ForbiddenError
+			return nil, nil, []error{errors.New("Forbidden")}, tc.ForbiddenError
 		}
 		// only if dsId is part of params: add join on deliveryservice_server table
 		queryAddition = `
@@ -227,23 +230,36 @@ FULL OUTER JOIN deliveryservice_server dss ON dss.server = s.id
 		// depending on ds type, also need to add mids
 		dsType, err := deliveryservice.GetDeliveryServiceType(dsID, tx.Tx)
 		if err != nil {
-			return nil, []error{err}, tc.DataConflictError
+			return nil, nil, []error{err}, tc.DataConflictError
 		}
 		usesMids = dsType.UsesMidCache()
 		log.Debugf("Servers for ds %d; uses mids? %v\n", dsID, usesMids)
 	}
 
-	where, orderBy, queryValues, errs := dbhelpers.BuildWhereAndOrderBy(params, queryParamsToSQLCols)
+	where, orderBy, pagination, queryValues, errs := dbhelpers.BuildWhereAndOrderByAndPagination(params, queryParamsToSQLCols)
 	if len(errs) > 0 {
-		return nil, errs, tc.DataConflictError
+		return nil, nil, errs, tc.DataConflictError
+	}
+	if usesMids {
+		// mids are added after the query, so the handler has to paginate the combined list
+		pagination = ""
+	}
+
+	var count *uint64
+	if pagination != "" {
+		c, err := dbhelpers.GetCount(tx, selectQuery()+queryAddition+where, queryValues)
+		if err != nil {
+			return nil, nil, []error{fmt.Errorf("getting server count: %v", err)}, tc.SystemError
+		}
+		count = &c
 	}
 
-	query := selectQuery() + queryAddition + where + orderBy
+	query := selectQuery() + queryAddition + where + orderBy + pagination
 	log.Debugln("Query is ", query)
 
 	rows, err := tx.NamedQuery(query, queryValues)
 	if err != nil {
-		return nil, []error{fmt.Errorf("querying: %v", err)}, tc.SystemError
+		return nil, nil, []error{fmt.Errorf("querying: %v", err)}, tc.SystemError
 	}
 	defer rows.Close()
 
@@ -254,7 +270,7 @@ FULL OUTER JOIN deliveryservice_server dss ON dss.server = s.id
 	for rows.Next() {
 		var s tc.ServerNullable
 		if err = rows.StructScan(&s); err != nil {
-			return nil, []error{fmt.Errorf("getting servers: %v", err)}, tc.SystemError
+			return nil, nil, []error{fmt.Errorf("getting servers: %v", err)}, tc.SystemError
 		}
 		if user.PrivLevel < auth.PrivLevelOperations {
 			s.ILOPassword = &HiddenField
@@ -271,17 +287,17 @@ FULL OUTER JOIN deliveryservice_server dss ON dss.server = s.id
 		if len(errs) > 0 {
 			for _, err := range errs {
 				if err.Error() == `id cannot parse to integer` {
-					return nil, []error{errors.New("Resource not found.")}, tc.DataMissingError //matches perl response
+					return nil, nil, []error{errors.New("Resource not found.")}, tc.DataMissingError //matches perl response
 				}
 			}
-			return nil, errs, errType
+			return nil, nil, errs, errType
 		}
 		for _, server := range mids {
 			servers = append(servers, server)
 		}
 	}
 
-	return servers, nil, tc.NoError
+	return servers, count, nil, tc.NoError
 }
 
 // getMidServers gets mids used by the servers in this ds
@@ -162,7 +162,7 @@ func TestGetServersByCachegroup(t *testing.T) {
 
 	user := auth.CurrentUser{}
 
-	servers, errs, errType := getServers(v, db.MustBegin(), &user)
+	servers, _, errs, errType := getServers(v, db.MustBegin(), &user)
 	if len(errs) > 0 {
 		t.Errorf("getServers expected: no errors, actual: %v with error type: %s", errs, errType.String())
 	}
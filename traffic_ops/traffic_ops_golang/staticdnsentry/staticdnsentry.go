@@ -52,6 +52,7 @@ func (v *TOStaticDNSEntry) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 		"ttl":               dbhelpers.WhereColumnInfo{"sde.ttl", nil},
 		"type":              dbhelpers.WhereColumnInfo{"tp.name", nil},
 		"typeId":            dbhelpers.WhereColumnInfo{"tp.id", nil},
+		"lastUpdated":       dbhelpers.WhereColumnInfo{"sde.last_updated", dbhelpers.IsTime},
 	}
 }
 func (v *TOStaticDNSEntry) UpdateQuery() string { return updateQuery() }
@@ -50,6 +50,7 @@ func (v *TOStatus) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 		"id":          dbhelpers.WhereColumnInfo{"id", api.IsInt},
 		"description": dbhelpers.WhereColumnInfo{"description", nil},
 		"name":        dbhelpers.WhereColumnInfo{"name", nil},
+		"lastUpdated": dbhelpers.WhereColumnInfo{"s.last_updated", dbhelpers.IsTime},
 	}
 }
 func (v *TOStatus) UpdateQuery() string { return updateQuery() }
@@ -43,9 +43,10 @@ func (v *TOType) NewReadObj() interface{}       { return &tc.TypeNullable{} }
 func (v *TOType) SelectQuery() string           { return selectQuery() }
 func (v *TOType) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 	return map[string]dbhelpers.WhereColumnInfo{
-		"name":       dbhelpers.WhereColumnInfo{"typ.name", nil},
-		"id":         dbhelpers.WhereColumnInfo{"typ.id", api.IsInt},
-		"useInTable": dbhelpers.WhereColumnInfo{"typ.use_in_table", nil},
+		"name":        dbhelpers.WhereColumnInfo{"typ.name", nil},
+		"id":          dbhelpers.WhereColumnInfo{"typ.id", api.IsInt},
+		"useInTable":  dbhelpers.WhereColumnInfo{"typ.use_in_table", nil},
+		"lastUpdated": dbhelpers.WhereColumnInfo{"typ.last_updated", dbhelpers.IsTime},
 	}
 }
 func (v *TOType) UpdateQuery() string { return updateQuery() }
@@ -82,9 +82,10 @@ func (user *TOUser) NewReadObj() interface{} {
 
 func (user *TOUser) ParamColumns() map[string]dbhelpers.WhereColumnInfo {
 	return map[string]dbhelpers.WhereColumnInfo{
-		"id":       dbhelpers.WhereColumnInfo{"u.id", api.IsInt},
-		"tenant":   dbhelpers.WhereColumnInfo{"t.name", nil},
-		"username": dbhelpers.WhereColumnInfo{"u.username", nil},
+		"id":          dbhelpers.WhereColumnInfo{"u.id", api.IsInt},
+		"tenant":      dbhelpers.WhereColumnInfo{"t.name", nil},
+		"username":    dbhelpers.WhereColumnInfo{"u.username", nil},
+		"lastUpdated": dbhelpers.WhereColumnInfo{"u.last_updated", dbhelpers.IsTime},
 	}
 }
 
@@ -185,7 +186,7 @@ func checkTenancy(tenantID *int, tenantIDs []int) bool {
 func (this *TOUser) Read() ([]interface{}, error, error, int) {
 
 	inf := this.APIInfo()
-	where, orderBy, queryValues, errs := dbhelpers.BuildWhereAndOrderBy(inf.Params, this.ParamColumns())
+	where, orderBy, pagination, queryValues, errs := dbhelpers.BuildWhereAndOrderByAndPagination(inf.Params, this.ParamColumns())
 	if len(errs) > 0 {
 		return nil, util.JoinErrs(errs), nil, http.StatusBadRequest
 	}
@@ -196,7 +197,15 @@ func (this *TOUser) Read() ([]interface{}, error, error, int) {
 	}
 	where, queryValues = dbhelpers.AddTenancyCheck(where, queryValues, "u.tenant_id", tenantIDs)
 
-	query := this.SelectQuery() + where + orderBy
+	if pagination != "" {
+		count, err := dbhelpers.GetCount(inf.Tx, this.SelectQuery()+where, queryValues)
+		if err != nil {
+			return nil, nil, fmt.Errorf("counting users: %v", err), http.StatusInternalServerError
+		}
+		inf.Count = &count
+	}
+
+	query := this.SelectQuery() + where + orderBy + pagination
 	rows, err := inf.Tx.NamedQuery(query, queryValues)
 	if err != nil {
 		return nil, nil, fmt.Errorf("querying users : %v", err), http.StatusInternalServerError